@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"io/fs"
 	"log"
 	"os"
+	"sort"
 
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/api/routes"
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/config"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/audit"
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/grammar"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/links"
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/spelling"
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
 	"github.com/gin-gonic/gin"
 )
@@ -19,13 +26,83 @@ func main() {
 	// Initialize services
 	storageService := storage.NewFileStorage(cfg.NotesDir)
 	markdownService := markdown.NewService()
+	lintConfig := markdown.LintConfig{}
+	if cfg.LintConfig != "" {
+		loaded, err := markdown.LoadLintConfig(cfg.LintConfig)
+		if err != nil {
+			log.Fatalf("Failed to load lint config: %v", err)
+		}
+		lintConfig = loaded
+	}
+	lintConfig.OnSave = lintConfig.OnSave || cfg.LintOnSave
+	if err := markdownService.SetLintConfig(lintConfig); err != nil {
+		log.Fatalf("Invalid lint config: %v", err)
+	}
+	if err := markdownService.SetFormatOptions(markdown.FormatOptions{Width: cfg.FormatWidth}); err != nil {
+		log.Fatalf("Invalid format width: %v", err)
+	}
 	grammarService := grammar.NewService()
+	languages := make([]string, 0, len(cfg.Dictionaries))
+	for language := range cfg.Dictionaries {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	for _, language := range languages {
+		name := cfg.Dictionaries[language]
+		dictionary, err := spelling.Load(cfg.DictionaryDir, name)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			log.Printf("Dictionary %s not found in %s, spell checking is disabled for %s", name, cfg.DictionaryDir, language)
+		case err != nil:
+			log.Fatalf("Failed to load dictionary: %v", err)
+		default:
+			grammarService.SetDictionary(language, dictionary)
+		}
+	}
+	customDictionary, err := spelling.LoadCustomDictionary(cfg.CustomDictionary)
+	if err != nil {
+		log.Fatalf("Failed to load custom dictionary: %v", err)
+	}
+	grammarService.SetCustomDictionary(customDictionary)
+	styles, err := grammar.LoadStyles(cfg.StylesDir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		log.Printf("Style directory %s not found, style rules are disabled", cfg.StylesDir)
+	case err != nil:
+		log.Fatalf("Failed to load style rules: %v", err)
+	default:
+		if err := grammarService.SetStyles(styles); err != nil {
+			log.Fatalf("Invalid style rules: %v", err)
+		}
+	}
+	if cfg.LanguageToolURL != "" {
+		checker, err := grammar.NewLanguageToolChecker(grammar.LanguageToolOptions{
+			URL:     cfg.LanguageToolURL,
+			Timeout: cfg.LanguageToolTimeout,
+			Retries: cfg.LanguageToolRetries,
+		}, grammarService.Local())
+		if err != nil {
+			log.Fatalf("Invalid LanguageTool config: %v", err)
+		}
+		grammarService.SetChecker(checker)
+		log.Printf("Checking grammar with LanguageTool at %s", cfg.LanguageToolURL)
+	}
+	storageService.OnChange(grammarService.InvalidateNote)
+	grammarHistory := grammar.NewHistory(grammarService, storageService)
+	storageService.OnChange(grammarHistory.Enqueue)
+	go grammarHistory.Run(context.Background())
+	linkChecker := links.NewChecker(storageService, markdownService)
+	if cfg.LinkCheckInterval > 0 {
+		go linkChecker.Run(context.Background(), cfg.LinkCheckInterval)
+	}
+
+	audits := audit.NewService(storageService, grammarService, cfg.AuditConcurrency)
 
 	// Initialize Gin router
 	router := gin.Default()
 
 	// Setup routes
-	routes.Setup(router, storageService, markdownService, grammarService)
+	routes.Setup(router, storageService, markdownService, grammarService, linkChecker, audits)
 
 	// Start server
 	port := os.Getenv("PORT")
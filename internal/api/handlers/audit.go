@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/audit"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler handles grammar audit requests
+type AuditHandler struct {
+	audits *audit.Service
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(audits *audit.Service) *AuditHandler {
+	return &AuditHandler{
+		audits: audits,
+	}
+}
+
+// StartAudit handles starting a grammar audit of all notes, or of the
+// notes of a folder or with a tag. The audit runs in the background; its
+// status is polled until its report can be downloaded.
+func (h *AuditHandler) StartAudit(c *gin.Context) {
+	var req models.GrammarAuditRequest
+	// An empty body audits every note
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	status, err := h.audits.Start(req)
+	if err != nil {
+		respondGrammarError(c, err)
+		return
+	}
+	c.Header("Location", "/api/v1/grammar/audits/"+status.ID)
+	c.JSON(http.StatusAccepted, status)
+}
+
+// ListAudits handles listing the audits, newest first
+func (h *AuditHandler) ListAudits(c *gin.Context) {
+	c.JSON(http.StatusOK, h.audits.List())
+}
+
+// GetAudit handles getting the status and progress of an audit
+func (h *AuditHandler) GetAudit(c *gin.Context) {
+	status, ok := h.audits.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Audit not found"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// GetAuditReport handles downloading the report of a finished audit as
+// JSON, CSV or SARIF
+func (h *AuditHandler) GetAuditReport(c *gin.Context) {
+	format := c.DefaultQuery("format", audit.FormatJSON)
+	supported := false
+	for _, f := range audit.Formats {
+		supported = supported || f == format
+	}
+	if !supported {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Unsupported format; use " + strings.Join(audit.Formats, ", ")})
+		return
+	}
+
+	id := c.Param("id")
+	report, err := h.audits.Report(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Audit not found"})
+			return
+		}
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "No report: " + err.Error()})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.audits.WriteReport(&buf, report, format); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to write report"})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="grammar-audit-`+id+`.`+format+`"`)
+	c.Data(http.StatusOK, audit.ContentType(format), buf.Bytes())
+}
+
+// DeleteAudit handles cancelling an audit that is still running and
+// deleting an audit with its report
+func (h *AuditHandler) DeleteAudit(c *gin.Context) {
+	if !h.audits.Delete(c.Param("id")) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Audit not found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
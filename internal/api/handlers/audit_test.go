@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/audit"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/grammar"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/utils/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrammarAudits(t *testing.T) {
+	store := storage.NewFileStorage(t.TempDir())
+	require.NoError(t, store.Save(&models.Note{Title: "Bad", Content: "---\ntags: [docs]\n---\nThe the cat sat.\n"}))
+	require.NoError(t, store.Save(&models.Note{Title: "Good", Content: "---\ntags: [docs]\n---\nThe cat sat.\n"}))
+	require.NoError(t, store.Save(&models.Note{Title: "Other", Content: "It it sat.\n"}))
+
+	handler := NewAuditHandler(audit.NewService(store, &grammar.Service{}, 2))
+	router := testutils.SetupRouter()
+	router.POST("/api/v1/grammar/audits", handler.StartAudit)
+	router.GET("/api/v1/grammar/audits", handler.ListAudits)
+	router.GET("/api/v1/grammar/audits/:id", handler.GetAudit)
+	router.GET("/api/v1/grammar/audits/:id/report", handler.GetAuditReport)
+	router.DELETE("/api/v1/grammar/audits/:id", handler.DeleteAudit)
+
+	w := testutils.PerformRequest(router, http.MethodPost, "/api/v1/grammar/audits", testutils.CreateJSONRequest(t, models.GrammarAuditRequest{Tag: "docs"}))
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var status models.GrammarAudit
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	path := "/api/v1/grammar/audits/" + status.ID
+	assert.Equal(t, path, w.Header().Get("Location"))
+	assert.Equal(t, "docs", status.Tag)
+
+	require.Eventually(t, func() bool {
+		w := testutils.PerformRequest(router, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+		return status.Status == audit.StatusDone
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, status.Total)
+	assert.Equal(t, 1.0, status.Progress)
+
+	w = testutils.PerformRequest(router, http.MethodGet, path+"/report", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var report models.GrammarAuditReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	require.Len(t, report.Notes, 2)
+	assert.Equal(t, "Bad", report.Notes[0].Title)
+
+	w = testutils.PerformRequest(router, http.MethodGet, path+"/report?format=csv", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="grammar-audit-`+status.ID+`.csv"`, w.Header().Get("Content-Disposition"))
+	assert.True(t, strings.HasPrefix(w.Body.String(), "note_id,title,"))
+
+	w = testutils.PerformRequest(router, http.MethodGet, path+"/report?format=sarif", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/sarif+json", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"ruleId": "repeated-words"`)
+
+	w = testutils.PerformRequest(router, http.MethodGet, path+"/report?format=xml", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Without a body every note is audited
+	w = testutils.PerformRequest(router, http.MethodPost, "/api/v1/grammar/audits", nil)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	w = testutils.PerformRequest(router, http.MethodGet, "/api/v1/grammar/audits", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var audits []models.GrammarAudit
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &audits))
+	require.Len(t, audits, 2)
+	assert.Equal(t, status.ID, audits[1].ID)
+
+	w = testutils.PerformRequest(router, http.MethodPost, "/api/v1/grammar/audits", testutils.CreateJSONRequest(t, models.GrammarAuditRequest{Rules: map[string]bool{"missing": true}}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = testutils.PerformRequest(router, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	for _, p := range []string{path, path + "/report"} {
+		w = testutils.PerformRequest(router, http.MethodGet, p, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	}
+	w = testutils.PerformRequest(router, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/export"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler handles exporting notes to document formats
+type ExportHandler struct {
+	storage  storage.Storage
+	markdown *markdown.Service
+	pdf      export.PDFOptions
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(storage storage.Storage, markdown *markdown.Service) *ExportHandler {
+	return &ExportHandler{
+		storage:  storage,
+		markdown: markdown,
+		pdf:      export.DefaultPDFOptions(),
+	}
+}
+
+// GetNotePDF handles exporting a note as a PDF document
+func (h *ExportHandler) GetNotePDF(c *gin.Context) {
+	id := c.Param("id")
+
+	note, err := h.storage.Get(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Note not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get note"})
+		return
+	}
+
+	options, err := h.pdfOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := export.NewPDFExporter(h.markdown, options).Export(&buf, []*models.Note{note}); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to export note"})
+		return
+	}
+
+	c.Header("Content-Disposition", attachmentDisposition(note.Title, ".pdf"))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// ExportNote handles exporting a note in the format given by the format
+// query parameter
+func (h *ExportHandler) ExportNote(c *gin.Context) {
+	id := c.Param("id")
+
+	note, err := h.storage.Get(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Note not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get note"})
+		return
+	}
+
+	exporter, err := h.exporter(c, c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	h.writeExport(c, exporter, note.Title, []*models.Note{note})
+}
+
+// ExportNotes handles exporting several notes, in the order given, as a
+// single document
+func (h *ExportHandler) ExportNotes(c *gin.Context) {
+	var req models.ExportNotesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	exporter, err := h.exporter(c, req.Format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	notes := make([]*models.Note, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		note, err := h.storage.Get(id)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				c.JSON(http.StatusNotFound, models.ErrorResponse{Error: fmt.Sprintf("Note %s not found", id)})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get note"})
+			return
+		}
+		notes = append(notes, note)
+	}
+
+	title := notes[0].Title
+	if len(notes) > 1 {
+		title = "notes"
+	}
+	h.writeExport(c, exporter, title, notes)
+}
+
+// exporter returns the exporter for a format name. PDF exports honour the
+// same layout query parameters as the pdf endpoint.
+func (h *ExportHandler) exporter(c *gin.Context, format string) (export.Exporter, error) {
+	if format == "" {
+		return nil, fmt.Errorf("format is required, expected one of %s", strings.Join(export.Formats(), ", "))
+	}
+	if strings.EqualFold(format, "pdf") {
+		options, err := h.pdfOptions(c)
+		if err != nil {
+			return nil, err
+		}
+		return export.NewPDFExporter(h.markdown, options), nil
+	}
+	return export.New(format, h.markdown, h.loadImage)
+}
+
+// writeExport renders the notes and sends the document as a download
+func (h *ExportHandler) writeExport(c *gin.Context, exporter export.Exporter, title string, notes []*models.Note) {
+	var buf bytes.Buffer
+	if err := exporter.Export(&buf, notes); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to export note"})
+		return
+	}
+
+	c.Header("Content-Disposition", attachmentDisposition(title, exporter.FileExtension()))
+	c.Data(http.StatusOK, exporter.ContentType(), buf.Bytes())
+}
+
+// pdfOptions applies the page_size, margin, toc, header and footer query
+// parameters to the default PDF options
+func (h *ExportHandler) pdfOptions(c *gin.Context) (export.PDFOptions, error) {
+	options := h.pdf
+	options.Images = h.loadImage
+
+	if size := c.Query("page_size"); size != "" {
+		options.PageSize = size
+	}
+	if margin := c.Query("margin"); margin != "" {
+		margins, err := parseMargins(margin)
+		if err != nil {
+			return options, err
+		}
+		options.Margins = margins
+	}
+	if toc := c.Query("toc"); toc != "" {
+		enabled, err := strconv.ParseBool(toc)
+		if err != nil {
+			return options, fmt.Errorf("toc must be true or false")
+		}
+		options.TableOfContents = enabled
+	}
+	// An empty header or footer parameter turns it off
+	if header, ok := c.GetQuery("header"); ok {
+		options.Header = header
+	}
+	if footer, ok := c.GetQuery("footer"); ok {
+		options.Footer = footer
+	}
+
+	return options, options.Validate()
+}
+
+// parseMargins parses margins in millimetres given either as one value for
+// all sides or as "top,right,bottom,left"
+func parseMargins(value string) (export.Margins, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 1 && len(parts) != 4 {
+		return export.Margins{}, fmt.Errorf("margin must be one value or top,right,bottom,left in millimetres")
+	}
+	values := make([]float64, len(parts))
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return export.Margins{}, fmt.Errorf("invalid margin %q", part)
+		}
+		values[i] = v
+	}
+	if len(values) == 1 {
+		return export.Margins{Top: values[0], Right: values[0], Bottom: values[0], Left: values[0]}, nil
+	}
+	return export.Margins{Top: values[0], Right: values[1], Bottom: values[2], Left: values[3]}, nil
+}
+
+// loadImage resolves images referenced by notes against the attachments
+// folder. Remote images are not fetched.
+func (h *ExportHandler) loadImage(src string) ([]byte, error) {
+	fileStorage, ok := h.storage.(*storage.FileStorage)
+	if !ok {
+		return nil, fmt.Errorf("storage type not supported")
+	}
+	u, err := url.Parse(src)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return nil, fmt.Errorf("only local attachments can be embedded")
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(u.Path, "/"), "attachments/")
+	return fileStorage.ReadAttachment(name)
+}
+
+// attachmentDisposition builds a Content-Disposition header that downloads
+// the export under the note's title
+func attachmentDisposition(title, ext string) string {
+	name := utils.SanitizeFilename(title)
+	return mime.FormatMediaType("attachment", map[string]string{"filename": name + ext})
+}
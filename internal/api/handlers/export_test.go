@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/utils/testutils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupExportTest(t *testing.T) (*gin.Engine, *storage.FileStorage, string) {
+	tempDir := t.TempDir()
+	storageService := storage.NewFileStorage(tempDir)
+	markdownService := markdown.Service{}
+	handler := NewExportHandler(storageService, &markdownService)
+
+	router := testutils.SetupRouter()
+	notes := router.Group("/api/v1/notes")
+	notes.GET("/:id/pdf", handler.GetNotePDF)
+	notes.GET("/:id/export", handler.ExportNote)
+	notes.POST("/export", handler.ExportNotes)
+
+	return router, storageService, tempDir
+}
+
+func TestGetNotePDF(t *testing.T) {
+	router, storageService, tempDir := setupExportTest(t)
+
+	// Store an attachment the note refers to
+	var img bytes.Buffer
+	require.NoError(t, png.Encode(&img, image.NewGray(image.Rect(0, 0, 2, 2))))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "attachments"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "attachments", "chart.png"), img.Bytes(), 0644))
+
+	note := &models.Note{
+		Title:   "Quarterly Report",
+		Content: "# Summary\n\nNumbers went up.\n\n![chart](attachments/chart.png)\n\n![secret](../../etc/passwd)",
+	}
+	require.NoError(t, storageService.Save(note))
+
+	w := testutils.PerformRequest(router, http.MethodGet, "/api/v1/notes/"+note.ID+"/pdf?page_size=letter&margin=15&toc=true", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="Quarterly Report.pdf"`, w.Header().Get("Content-Disposition"))
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, "%PDF-1.4"))
+	assert.Contains(t, body, "/MediaBox [0 0 612.00 792.00]")
+	assert.Contains(t, body, "/Subtype /Image", "attachment is embedded")
+	assert.Equal(t, 1, strings.Count(body, "/Subtype /Image"), "paths outside attachments are not read")
+}
+
+func TestGetNotePDF_Errors(t *testing.T) {
+	router, storageService, _ := setupExportTest(t)
+
+	note := &models.Note{Title: "Note", Content: "text"}
+	require.NoError(t, storageService.Save(note))
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{name: "missing note", path: "/api/v1/notes/missing/pdf", status: http.StatusNotFound},
+		{name: "unknown page size", path: "/api/v1/notes/" + note.ID + "/pdf?page_size=tabloid", status: http.StatusBadRequest},
+		{name: "bad margin", path: "/api/v1/notes/" + note.ID + "/pdf?margin=1,2", status: http.StatusBadRequest},
+		{name: "oversized margin", path: "/api/v1/notes/" + note.ID + "/pdf?margin=200", status: http.StatusBadRequest},
+		{name: "bad toc flag", path: "/api/v1/notes/" + note.ID + "/pdf?toc=maybe", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutils.PerformRequest(router, http.MethodGet, tt.path, nil)
+			assert.Equal(t, tt.status, w.Code)
+
+			var response models.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.NotEmpty(t, response.Error)
+		})
+	}
+}
+
+func TestExportNote(t *testing.T) {
+	router, storageService, _ := setupExportTest(t)
+
+	note := &models.Note{Title: "Field Guide", Content: "# Birds\n\nMany.\n\n# Trees\n\nTall."}
+	require.NoError(t, storageService.Save(note))
+
+	tests := []struct {
+		format      string
+		contentType string
+		filename    string
+		prefix      string
+	}{
+		{format: "epub", contentType: "application/epub+zip", filename: "Field Guide.epub", prefix: "PK"},
+		{format: "docx", contentType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", filename: "Field Guide.docx", prefix: "PK"},
+		{format: "pdf", contentType: "application/pdf", filename: "Field Guide.pdf", prefix: "%PDF-1.4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			w := testutils.PerformRequest(router, http.MethodGet, "/api/v1/notes/"+note.ID+"/export?format="+tt.format, nil)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, `attachment; filename="`+tt.filename+`"`, w.Header().Get("Content-Disposition"))
+			assert.True(t, strings.HasPrefix(w.Body.String(), tt.prefix))
+		})
+	}
+}
+
+func TestExportNotes(t *testing.T) {
+	router, storageService, _ := setupExportTest(t)
+
+	first := &models.Note{Title: "First", Content: "# One\n\nText."}
+	second := &models.Note{Title: "Second", Content: "# Two\n\nText."}
+	require.NoError(t, storageService.Save(first))
+	require.NoError(t, storageService.Save(second))
+
+	req := models.ExportNotesRequest{IDs: []string{second.ID, first.ID}, Format: "epub"}
+	w := testutils.PerformRequest(router, http.MethodPost, "/api/v1/notes/export", testutils.CreateJSONRequest(t, req))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/epub+zip", w.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=notes.epub", w.Header().Get("Content-Disposition"))
+
+	body := w.Body.Bytes()
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+	var chapters []string
+	for _, f := range archive.File {
+		if strings.HasPrefix(f.Name, "OEBPS/chapter-") {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			content, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			rc.Close()
+			chapters = append(chapters, string(content))
+		}
+	}
+	require.Len(t, chapters, 2)
+	assert.Contains(t, chapters[0], "Two", "notes keep the requested order")
+	assert.Contains(t, chapters[1], "One")
+}
+
+func TestExportNotes_Errors(t *testing.T) {
+	router, storageService, _ := setupExportTest(t)
+
+	note := &models.Note{Title: "Note", Content: "text"}
+	require.NoError(t, storageService.Save(note))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{name: "missing format", method: http.MethodGet, path: "/api/v1/notes/" + note.ID + "/export", status: http.StatusBadRequest},
+		{name: "unknown format", method: http.MethodGet, path: "/api/v1/notes/" + note.ID + "/export?format=rtf", status: http.StatusBadRequest},
+		{name: "missing note", method: http.MethodGet, path: "/api/v1/notes/missing/export?format=epub", status: http.StatusNotFound},
+		{name: "bad pdf options", method: http.MethodGet, path: "/api/v1/notes/" + note.ID + "/export?format=pdf&page_size=tabloid", status: http.StatusBadRequest},
+		{name: "bulk without ids", method: http.MethodPost, path: "/api/v1/notes/export", body: models.ExportNotesRequest{Format: "docx"}, status: http.StatusBadRequest},
+		{name: "bulk unknown format", method: http.MethodPost, path: "/api/v1/notes/export", body: models.ExportNotesRequest{IDs: []string{note.ID}, Format: "rtf"}, status: http.StatusBadRequest},
+		{name: "bulk missing note", method: http.MethodPost, path: "/api/v1/notes/export", body: models.ExportNotesRequest{IDs: []string{note.ID, "missing"}, Format: "docx"}, status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != nil {
+				body = testutils.CreateJSONRequest(t, tt.body)
+			}
+			w := testutils.PerformRequest(router, tt.method, tt.path, body)
+			assert.Equal(t, tt.status, w.Code)
+
+			var response models.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.NotEmpty(t, response.Error)
+		})
+	}
+}
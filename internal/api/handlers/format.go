@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// FormatHandler handles markdown formatting requests
+type FormatHandler struct {
+	storage  storage.Storage
+	markdown *markdown.Service
+}
+
+// NewFormatHandler creates a new format handler
+func NewFormatHandler(storage storage.Storage, markdown *markdown.Service) *FormatHandler {
+	return &FormatHandler{
+		storage:  storage,
+		markdown: markdown,
+	}
+}
+
+// Format handles formatting markdown content
+func (h *FormatHandler) Format(c *gin.Context) {
+	var req models.FormatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	options := h.markdown.FormatOptions()
+	if req.Width != nil {
+		options.Width = *req.Width
+	}
+	if err := options.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	formatted := h.markdown.Format(req.Content, options)
+	c.JSON(http.StatusOK, models.FormatResult{Content: formatted, Changed: formatted != req.Content})
+}
+
+// FormatNote handles formatting a stored note, saving it when its content
+// changes. The width query parameter overrides the configured width.
+func (h *FormatHandler) FormatNote(c *gin.Context) {
+	options := h.markdown.FormatOptions()
+	if width := c.Query("width"); width != "" {
+		n, err := strconv.Atoi(width)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid width"})
+			return
+		}
+		options.Width = n
+	}
+	if err := options.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	note, err := h.storage.Get(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Note not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get note"})
+		return
+	}
+
+	formatted := h.markdown.Format(note.Content, options)
+	changed := formatted != note.Content
+	if changed {
+		note.Content = formatted
+		if err := h.storage.Save(note); err != nil {
+			if strings.Contains(err.Error(), "changed") {
+				c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Note changed while it was being formatted; try again"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to save note"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, models.FormattedNote{Note: note, Changed: changed})
+}
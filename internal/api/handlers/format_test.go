@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/utils/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	markdownService := markdown.Service{}
+	require.NoError(t, markdownService.SetFormatOptions(markdown.FormatOptions{Width: 20}))
+	handler := NewFormatHandler(storage.NewFileStorage(t.TempDir()), &markdownService)
+
+	router := testutils.SetupRouter()
+	router.POST("/api/v1/format", handler.Format)
+
+	zero := 0
+	negative := -1
+	narrow := 10
+	tests := []struct {
+		name           string
+		request        models.FormatRequest
+		expectedStatus int
+		expected       models.FormatResult
+	}{
+		{
+			name:           "configured width",
+			request:        models.FormatRequest{Content: "Title\n=====\n\n* one two three four five six"},
+			expectedStatus: http.StatusOK,
+			expected:       models.FormatResult{Content: "# Title\n\n- one two three four\n    five six\n", Changed: true},
+		},
+		{
+			name:           "width override",
+			request:        models.FormatRequest{Content: "one\ntwo", Width: &zero},
+			expectedStatus: http.StatusOK,
+			expected:       models.FormatResult{Content: "one two\n", Changed: true},
+		},
+		{
+			name:           "already formatted",
+			request:        models.FormatRequest{Content: "# Title\n"},
+			expectedStatus: http.StatusOK,
+			expected:       models.FormatResult{Content: "# Title\n"},
+		},
+		{
+			name:           "negative width",
+			request:        models.FormatRequest{Content: "text", Width: &negative},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "width too small",
+			request:        models.FormatRequest{Content: "text", Width: &narrow},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing content",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutils.PerformRequest(router, http.MethodPost, "/api/v1/format", testutils.CreateJSONRequest(t, tt.request))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var result models.FormatResult
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestFormatNote(t *testing.T) {
+	storageService := storage.NewFileStorage(t.TempDir())
+	handler := NewFormatHandler(storageService, markdown.NewService())
+
+	router := testutils.SetupRouter()
+	router.POST("/api/v1/notes/:id/format", handler.FormatNote)
+
+	note := &models.Note{Title: "Messy", Content: "Heading\n-------\n\n+ a\n+ b\n\n__strong__\n"}
+	require.NoError(t, storageService.Save(note))
+
+	w := testutils.PerformRequest(router, http.MethodPost, "/api/v1/notes/"+note.ID+"/format", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		models.Note
+		Changed bool `json:"changed"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Changed)
+	assert.Equal(t, "## Heading\n\n- a\n- b\n\n**strong**\n", response.Content)
+
+	saved, err := storageService.Get(note.ID)
+	require.NoError(t, err)
+	assert.Equal(t, response.Content, saved.Content)
+
+	// Formatting again changes nothing
+	w = testutils.PerformRequest(router, http.MethodPost, "/api/v1/notes/"+note.ID+"/format", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.False(t, response.Changed)
+
+	w = testutils.PerformRequest(router, http.MethodPost, "/api/v1/notes/"+note.ID+"/format?width=abc", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = testutils.PerformRequest(router, http.MethodPost, "/api/v1/notes/missing/format", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// racingStorage saves a note again each time it is read, as another
+// request would between the read and the save of a handler
+type racingStorage struct {
+	*storage.FileStorage
+}
+
+func (s racingStorage) Get(id string) (*models.Note, error) {
+	note, err := s.FileStorage.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	other := *note
+	other.Content += "\nEdited meanwhile.\n"
+	if err := s.FileStorage.Save(&other); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+func TestFormatNote_Conflict(t *testing.T) {
+	storageService := storage.NewFileStorage(t.TempDir())
+	handler := NewFormatHandler(racingStorage{storageService}, markdown.NewService())
+
+	router := testutils.SetupRouter()
+	router.POST("/api/v1/notes/:id/format", handler.FormatNote)
+
+	note := &models.Note{Title: "Messy", Content: "+ a\n+ b\n"}
+	require.NoError(t, storageService.Save(note))
+
+	w := testutils.PerformRequest(router, http.MethodPost, "/api/v1/notes/"+note.ID+"/format", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	saved, err := storageService.Get(note.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "+ a\n+ b\n\nEdited meanwhile.\n", saved.Content)
+}
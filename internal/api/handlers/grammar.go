@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/grammar"
+	"github.com/gin-gonic/gin"
+)
+
+// GrammarHandler handles grammar requests that are not about one note
+type GrammarHandler struct {
+	grammar *grammar.Service
+}
+
+// NewGrammarHandler creates a new grammar handler
+func NewGrammarHandler(grammar *grammar.Service) *GrammarHandler {
+	return &GrammarHandler{
+		grammar: grammar,
+	}
+}
+
+// ListRules handles listing the grammar rules with the languages they
+// check. The language query parameter lists the rules of one language.
+func (h *GrammarHandler) ListRules(c *gin.Context) {
+	languages := h.grammar.Languages()
+	if language := c.Query("language"); language != "" {
+		found := false
+		for _, l := range languages {
+			found = found || l == language
+		}
+		if !found {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Unsupported language " + language})
+			return
+		}
+		languages = []string{language}
+	}
+
+	rules := []models.GrammarRule{}
+	index := map[string]int{}
+	for _, language := range languages {
+		for _, rule := range h.grammar.Rules(language) {
+			if i, ok := index[rule.ID()]; ok {
+				rules[i].Languages = append(rules[i].Languages, language)
+				continue
+			}
+			index[rule.ID()] = len(rules)
+			rules = append(rules, models.GrammarRule{
+				ID:          rule.ID(),
+				Category:    rule.Category(),
+				Severity:    rule.Severity(),
+				Description: rule.Description(),
+				Languages:   []string{language},
+			})
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// GetDictionary handles listing the words of the custom dictionary
+func (h *GrammarHandler) GetDictionary(c *gin.Context) {
+	dictionary := h.grammar.CustomDictionary()
+	if dictionary == nil {
+		c.JSON(http.StatusOK, models.DictionaryWords{Words: []string{}})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.DictionaryWords{Words: dictionary.Words()})
+}
+
+// AddDictionaryWords handles adding words to the custom dictionary
+func (h *GrammarHandler) AddDictionaryWords(c *gin.Context) {
+	var req models.AddDictionaryWordsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	dictionary := h.grammar.CustomDictionary()
+	if dictionary == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Custom dictionary is not configured"})
+		return
+	}
+	if err := dictionary.Add(req.Words...); err != nil {
+		if strings.Contains(err.Error(), "invalid word") {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to save dictionary"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.DictionaryWords{Words: dictionary.Words()})
+}
+
+// DeleteDictionaryWord handles removing a word from the custom dictionary
+func (h *GrammarHandler) DeleteDictionaryWord(c *gin.Context) {
+	dictionary := h.grammar.CustomDictionary()
+	if dictionary == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Word not found"})
+		return
+	}
+	if err := dictionary.Remove(c.Param("word")); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Word not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to save dictionary"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
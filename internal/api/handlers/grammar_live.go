@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/grammar"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// maxLiveMessageBytes limits the messages of live grammar sessions, which
+// hold the whole note when opening one
+const maxLiveMessageBytes = 8 << 20
+
+// LiveGrammar handles live grammar sessions over WebSocket. The client
+// opens a session with the content and then sends edits; the session
+// sends the issues of the paragraphs the edits changed once the client
+// stops typing.
+func (h *GrammarHandler) LiveGrammar(c *gin.Context) {
+	server := websocket.Server{
+		Handler: h.serveLiveGrammar,
+		// Any origin may connect, as with CORS for the rest of the API
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *GrammarHandler) serveLiveGrammar(ws *websocket.Conn) {
+	defer ws.Close()
+	ws.MaxPayloadBytes = maxLiveMessageBytes
+
+	var mu sync.Mutex
+	send := func(event models.LiveGrammarEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		websocket.JSON.Send(ws, event)
+	}
+	sendError := func(version int, err error) {
+		send(models.LiveGrammarEvent{Type: "error", Version: version, Error: err.Error()})
+	}
+
+	var open models.LiveGrammarMessage
+	if err := websocket.JSON.Receive(ws, &open); err != nil {
+		return
+	}
+	if open.Type != "open" {
+		sendError(0, fmt.Errorf("expected an open message, got %q", open.Type))
+		return
+	}
+	options := grammar.SessionOptions{
+		Options: grammar.Options{Language: open.Language, Rules: open.Rules},
+		Unit:    open.Unit,
+	}
+	if open.DebounceMS != nil {
+		options.Debounce = time.Duration(*open.DebounceMS) * time.Millisecond
+		if *open.DebounceMS == 0 {
+			options.Debounce = -1
+		}
+	}
+	session, err := h.grammar.NewSession(ws.Request().Context(), open.Content, options, send)
+	if err != nil {
+		sendError(0, err)
+		return
+	}
+	defer session.Close()
+
+	for {
+		var message models.LiveGrammarMessage
+		if err := websocket.JSON.Receive(ws, &message); err != nil {
+			return
+		}
+		if message.Type != "edit" {
+			sendError(0, fmt.Errorf("unknown message type %q", message.Type))
+			continue
+		}
+		if version, err := session.Edit(message.Edits); err != nil {
+			sendError(version, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/grammar"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/utils/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func TestLiveGrammar(t *testing.T) {
+	handler := NewGrammarHandler(&grammar.Service{})
+	router := testutils.SetupRouter()
+	router.GET("/api/v1/grammar/live", handler.LiveGrammar)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	dial := func(t *testing.T) *websocket.Conn {
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/grammar/live"
+		ws, err := websocket.Dial(url, "", server.URL)
+		require.NoError(t, err)
+		t.Cleanup(func() { ws.Close() })
+		ws.SetDeadline(time.Now().Add(5 * time.Second))
+		return ws
+	}
+	receive := func(t *testing.T, ws *websocket.Conn) models.LiveGrammarEvent {
+		var event models.LiveGrammarEvent
+		require.NoError(t, websocket.JSON.Receive(ws, &event))
+		return event
+	}
+
+	t.Run("session", func(t *testing.T) {
+		ws := dial(t)
+		debounce := 0
+		require.NoError(t, websocket.JSON.Send(ws, models.LiveGrammarMessage{
+			Type:       "open",
+			Content:    "The the cat 😀.\n\nA dog.\n",
+			Unit:       grammar.UnitUTF16,
+			DebounceMS: &debounce,
+		}))
+		event := receive(t, ws)
+		assert.Equal(t, "result", event.Type)
+		assert.True(t, event.Full)
+		require.Len(t, event.Issues, 1)
+
+		require.NoError(t, websocket.JSON.Send(ws, models.LiveGrammarMessage{
+			Type:  "edit",
+			Edits: []models.TextEdit{{Offset: 19, Delete: 3, Insert: "dog  ran"}},
+		}))
+		event = receive(t, ws)
+		assert.Equal(t, "result", event.Type)
+		assert.Equal(t, 1, event.Version)
+		require.Len(t, event.Checked, 1)
+		assert.Equal(t, 17, event.Checked[0].UTF16Offset)
+		require.Len(t, event.Issues, 1)
+		assert.Equal(t, "multiple-spaces", event.Issues[0].Rule)
+		assert.Equal(t, 22, event.Issues[0].UTF16Offset)
+
+		require.NoError(t, websocket.JSON.Send(ws, models.LiveGrammarMessage{
+			Type:  "edit",
+			Edits: []models.TextEdit{{Offset: 100, Insert: "x"}},
+		}))
+		event = receive(t, ws)
+		assert.Equal(t, "error", event.Type)
+		assert.Equal(t, 1, event.Version)
+		assert.Contains(t, event.Error, "out of range")
+
+		require.NoError(t, websocket.JSON.Send(ws, models.LiveGrammarMessage{Type: "close"}))
+		event = receive(t, ws)
+		assert.Equal(t, "error", event.Type)
+	})
+
+	for _, tt := range []struct {
+		name    string
+		message models.LiveGrammarMessage
+	}{
+		{"edit before open", models.LiveGrammarMessage{Type: "edit"}},
+		{"unknown rule", models.LiveGrammarMessage{Type: "open", Rules: map[string]bool{"nope": false}}},
+		{"unknown unit", models.LiveGrammarMessage{Type: "open", Unit: "lines"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := dial(t)
+			require.NoError(t, websocket.JSON.Send(ws, tt.message))
+			event := receive(t, ws)
+			assert.Equal(t, "error", event.Type)
+			assert.NotEmpty(t, event.Error)
+
+			// The session is closed after a failed open
+			var next models.LiveGrammarEvent
+			assert.Error(t, websocket.JSON.Receive(ws, &next))
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/grammar"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/spelling"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/utils/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListGrammarRules(t *testing.T) {
+	handler := NewGrammarHandler(grammar.NewService())
+	router := testutils.SetupRouter()
+	router.GET("/api/v1/grammar/rules", handler.ListRules)
+
+	w := testutils.PerformRequest(router, http.MethodGet, "/api/v1/grammar/rules", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var rules []models.GrammarRule
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rules))
+	assert.Len(t, rules, len(grammar.Rules()))
+	assert.Contains(t, rules, models.GrammarRule{
+		ID:          "repeated-words",
+		Category:    grammar.CategoryGrammar,
+		Severity:    grammar.SeverityError,
+		Description: `A word should not be repeated by mistake, as in "the the"`,
+		Languages:   []string{grammar.LanguageGerman, grammar.LanguageEnglish, grammar.LanguageSpanish},
+	})
+
+	w = testutils.PerformRequest(router, http.MethodGet, "/api/v1/grammar/rules?language=es", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rules))
+	ids := map[string]bool{}
+	for _, rule := range rules {
+		ids[rule.ID] = true
+		assert.Equal(t, []string{grammar.LanguageSpanish}, rule.Languages)
+	}
+	assert.True(t, ids["inverted-punctuation"])
+	assert.False(t, ids["a-an"])
+
+	w = testutils.PerformRequest(router, http.MethodGet, "/api/v1/grammar/rules?language=xx", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDictionary(t *testing.T) {
+	custom, err := spelling.LoadCustomDictionary(filepath.Join(t.TempDir(), "dictionary.txt"))
+	require.NoError(t, err)
+	service := grammar.NewService()
+	service.SetCustomDictionary(custom)
+
+	handler := NewGrammarHandler(service)
+	router := testutils.SetupRouter()
+	router.GET("/api/v1/dictionary", handler.GetDictionary)
+	router.POST("/api/v1/dictionary", handler.AddDictionaryWords)
+	router.DELETE("/api/v1/dictionary/:word", handler.DeleteDictionaryWord)
+
+	words := func(w *httptest.ResponseRecorder) []string {
+		var response models.DictionaryWords
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Words
+	}
+
+	w := testutils.PerformRequest(router, http.MethodGet, "/api/v1/dictionary", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{}, words(w))
+
+	body := testutils.CreateJSONRequest(t, models.AddDictionaryWordsRequest{Words: []string{"Zentrix", "kubectl"}})
+	w = testutils.PerformRequest(router, http.MethodPost, "/api/v1/dictionary", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"Zentrix", "kubectl"}, words(w))
+	assert.True(t, custom.Check("Zentrix"))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{"no words", http.MethodPost, "/api/v1/dictionary", models.AddDictionaryWordsRequest{}, http.StatusBadRequest},
+		{"invalid word", http.MethodPost, "/api/v1/dictionary", models.AddDictionaryWordsRequest{Words: []string{"two words"}}, http.StatusBadRequest},
+		{"delete", http.MethodDelete, "/api/v1/dictionary/kubectl", nil, http.StatusNoContent},
+		{"delete again", http.MethodDelete, "/api/v1/dictionary/kubectl", nil, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != nil {
+				body = testutils.CreateJSONRequest(t, tt.body)
+			}
+			w := testutils.PerformRequest(router, tt.method, tt.path, body)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+
+	w = testutils.PerformRequest(router, http.MethodGet, "/api/v1/dictionary", nil)
+	assert.Equal(t, []string{"Zentrix"}, words(w))
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/gin-gonic/gin"
+)
+
+// LintHandler handles markdown linting requests
+type LintHandler struct {
+	markdown *markdown.Service
+}
+
+// NewLintHandler creates a new lint handler
+func NewLintHandler(markdown *markdown.Service) *LintHandler {
+	return &LintHandler{
+		markdown: markdown,
+	}
+}
+
+// Lint handles linting markdown content with the configured rules
+func (h *LintHandler) Lint(c *gin.Context) {
+	var req models.LintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.markdown.Lint(req.Content))
+}
+
+// ListRules handles listing the lint rules and whether they are enabled
+func (h *LintHandler) ListRules(c *gin.Context) {
+	config := h.markdown.LintConfig()
+	rules := []gin.H{}
+	for _, rule := range markdown.LintRules() {
+		severity := rule.Severity
+		if override, ok := config.Severity[rule.ID]; ok {
+			severity = override
+		}
+		rules = append(rules, gin.H{
+			"id":          rule.ID,
+			"description": rule.Description,
+			"severity":    severity,
+			"enabled":     config.Enabled(rule.ID),
+		})
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
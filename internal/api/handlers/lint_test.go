@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/grammar"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/utils/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	markdownService := markdown.Service{}
+	require.NoError(t, markdownService.SetLintConfig(markdown.LintConfig{Rules: map[string]bool{"no-bare-urls": false}}))
+	handler := NewLintHandler(&markdownService)
+
+	router := testutils.SetupRouter()
+	router.POST("/api/v1/lint", handler.Lint)
+	router.GET("/api/v1/lint/rules", handler.ListRules)
+
+	body := testutils.CreateJSONRequest(t, models.LintRequest{Content: "# Title\n\n### Jump\n\n```\nopen https://example.com"})
+	w := testutils.PerformRequest(router, http.MethodPost, "/api/v1/lint", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result models.LintResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, []models.LintIssue{
+		{Rule: "heading-increment", Severity: "warning", Message: "Heading level jumps from h1 to h3", Line: 3, Column: 1},
+		{Rule: "fenced-code-closed", Severity: "error", Message: "Code fence ``` is never closed", Line: 5, Column: 1},
+	}, result.Issues)
+	assert.Equal(t, 1, result.Errors)
+	assert.Equal(t, 1, result.Warnings)
+
+	w = testutils.PerformRequest(router, http.MethodPost, "/api/v1/lint", testutils.CreateJSONRequest(t, map[string]string{}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = testutils.PerformRequest(router, http.MethodGet, "/api/v1/lint/rules", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var rules []map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rules))
+	assert.Len(t, rules, len(markdown.LintRules()))
+	for _, rule := range rules {
+		assert.Equal(t, rule["id"] != "no-bare-urls", rule["enabled"], rule["id"])
+	}
+}
+
+func TestCreateNote_LintOnSave(t *testing.T) {
+	storageService := storage.NewFileStorage(t.TempDir())
+	markdownService := markdown.Service{}
+	require.NoError(t, markdownService.SetLintConfig(markdown.LintConfig{OnSave: true}))
+	handler := NewNotesHandler(storageService, &markdownService, &grammar.Service{})
+
+	router := testutils.SetupRouter()
+	router.POST("/api/v1/notes", handler.CreateNote)
+
+	// Lint errors reject the note
+	body := testutils.CreateJSONRequest(t, models.CreateNoteRequest{Title: "Broken", Content: "```\nnever closed"})
+	w := testutils.PerformRequest(router, http.MethodPost, "/api/v1/notes", body)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var result models.LintResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 1, result.Errors)
+	notes, err := storageService.List()
+	require.NoError(t, err)
+	assert.Empty(t, notes)
+
+	// Warnings are returned with the saved note
+	body = testutils.CreateJSONRequest(t, models.CreateNoteRequest{Title: "Warned", Content: "# A\n\n### B"})
+	w = testutils.PerformRequest(router, http.MethodPost, "/api/v1/notes", body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response struct {
+		models.Note
+		LintIssues []models.LintIssue `json:"lint_issues"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotEmpty(t, response.ID)
+	assert.Equal(t, "Warned", response.Title)
+	require.Len(t, response.LintIssues, 1)
+	assert.Equal(t, "heading-increment", response.LintIssues[0].Rule)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/links"
+	"github.com/gin-gonic/gin"
+)
+
+// MaintenanceHandler handles maintenance requests
+type MaintenanceHandler struct {
+	links *links.Checker
+}
+
+// NewMaintenanceHandler creates a new maintenance handler
+func NewMaintenanceHandler(links *links.Checker) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		links: links,
+	}
+}
+
+// BrokenLinks handles listing links to missing notes, anchors and
+// attachments. The latest background check is returned unless refresh=true
+// asks for a new check.
+func (h *MaintenanceHandler) BrokenLinks(c *gin.Context) {
+	report := h.links.Report()
+	if report == nil || c.Query("refresh") == "true" {
+		var err error
+		report, err = h.links.Check()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to check links"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/links"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/utils/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrokenLinks(t *testing.T) {
+	storageService := storage.NewFileStorage(t.TempDir())
+	handler := NewMaintenanceHandler(links.NewChecker(storageService, markdown.NewService()))
+
+	router := testutils.SetupRouter()
+	router.GET("/api/v1/maintenance/broken-links", handler.BrokenLinks)
+
+	note := &models.Note{Title: "Note", Content: "# Title\n\n[missing](/api/v1/notes/gone)"}
+	require.NoError(t, storageService.Save(note))
+
+	var report models.BrokenLinksReport
+	w := testutils.PerformRequest(router, http.MethodGet, "/api/v1/maintenance/broken-links", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, []models.BrokenLink{
+		{NoteID: note.ID, NoteTitle: "Note", Line: 3, Target: "/api/v1/notes/gone", Reason: links.ReasonMissingNote},
+	}, report.BrokenLinks)
+
+	// The latest report is returned until a refresh is asked for
+	note.Content = "# Title"
+	require.NoError(t, storageService.Save(note))
+
+	w = testutils.PerformRequest(router, http.MethodGet, "/api/v1/maintenance/broken-links", nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Len(t, report.BrokenLinks, 1)
+
+	w = testutils.PerformRequest(router, http.MethodGet, "/api/v1/maintenance/broken-links?refresh=true", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Empty(t, report.BrokenLinks)
+	assert.Equal(t, 1, report.NotesChecked)
+}
@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/grammar"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// NoteGrammarHandler handles grammar requests on stored notes
+type NoteGrammarHandler struct {
+	storage storage.Storage
+	grammar *grammar.Service
+}
+
+// NewNoteGrammarHandler creates a new note grammar handler
+func NewNoteGrammarHandler(storage storage.Storage, grammar *grammar.Service) *NoteGrammarHandler {
+	return &NoteGrammarHandler{
+		storage: storage,
+		grammar: grammar,
+	}
+}
+
+// GetGrammar handles checking the grammar of a stored note. The language
+// query parameter selects the language as in CheckGrammar, and disable
+// lists rule IDs, style packs or categories to skip, separated by commas.
+// With since, only the paragraphs changed since that revision are checked.
+func (h *NoteGrammarHandler) GetGrammar(c *gin.Context) {
+	options := queryGrammarOptions(c)
+	note, ok := h.getNote(c)
+	if !ok {
+		return
+	}
+
+	var since *int
+	if value := c.Query("since"); value != "" {
+		revision, err := strconv.Atoi(value)
+		if err != nil || revision < 1 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid since revision"})
+			return
+		}
+		fileStorage, ok := h.storage.(*storage.FileStorage)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Storage type not supported"})
+			return
+		}
+		previous, err := fileStorage.GetRevision(note.ID, revision)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				c.JSON(http.StatusNotFound, models.ErrorResponse{Error: fmt.Sprintf("Revision %d not found", revision)})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get revision"})
+			return
+		}
+		options.Previous = &previous.Content
+		since = &revision
+	}
+
+	if options.Ignored, ok = ignoredIssues(c, h.storage, note.ID); !ok {
+		return
+	}
+	result, cached, err := h.grammar.CheckNoteContext(c.Request.Context(), note.ID, note.Content, options)
+	if err != nil {
+		respondGrammarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NoteGrammarResult{
+		GrammarCheckResult: *result,
+		NoteID:             note.ID,
+		Revision:           note.Revision,
+		Since:              since,
+		RuleSetVersion:     h.grammar.RuleSetVersion(),
+		Cached:             cached,
+	})
+}
+
+// FixGrammar handles applying the replacements of grammar issues to a
+// stored note. The issues are those of a check of the current content;
+// IDs of an older check are rejected, as the note changed since.
+func (h *NoteGrammarHandler) FixGrammar(c *gin.Context) {
+	var req models.FixGrammarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.All == (len(req.Issues) > 0) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Either issues or all is required"})
+		return
+	}
+
+	note, ok := h.getNote(c)
+	if !ok {
+		return
+	}
+	ignored, ok := ignoredIssues(c, h.storage, note.ID)
+	if !ok {
+		return
+	}
+	result, _, err := h.grammar.CheckNoteContext(c.Request.Context(), note.ID, note.Content, grammar.Options{Rules: req.Rules, Language: req.Language, Ignored: ignored})
+	if err != nil {
+		respondGrammarError(c, err)
+		return
+	}
+
+	var selected []models.GrammarIssue
+	if req.All {
+		for _, issue := range result.Issues {
+			if issue.Fixable {
+				selected = append(selected, issue)
+			}
+		}
+	} else {
+		byID := map[string]models.GrammarIssue{}
+		for _, issue := range result.Issues {
+			byID[issue.ID] = issue
+		}
+		seen := map[string]bool{}
+		for _, id := range req.Issues {
+			issue, ok := byID[id]
+			if !ok {
+				c.JSON(http.StatusConflict, models.ErrorResponse{Error: fmt.Sprintf("Issue %s not found; check the note again", id)})
+				return
+			}
+			if !seen[id] {
+				seen[id] = true
+				selected = append(selected, issue)
+			}
+		}
+	}
+
+	fixed, applied, skipped := grammar.ApplyFixes(note.Content, selected)
+	response := models.GrammarFixResult{
+		Applied: applied,
+		Skipped: skipped,
+		Diff:    utils.UnifiedDiff("a/"+note.ID+".md", "b/"+note.ID+".md", note.Content, fixed),
+		Changed: fixed != note.Content,
+		DryRun:  req.DryRun,
+	}
+	if response.Applied == nil {
+		response.Applied = []models.GrammarIssue{}
+	}
+	if response.Skipped == nil {
+		response.Skipped = []models.SkippedFix{}
+	}
+	note.Content = fixed
+	if response.Changed && !req.DryRun {
+		if err := h.storage.Save(note); err != nil {
+			if strings.Contains(err.Error(), "changed") {
+				c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Note changed while it was being fixed; check the note again"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to save note"})
+			return
+		}
+	}
+	response.Note = note
+	c.JSON(http.StatusOK, response)
+}
+
+// GetGrammarHistory handles getting the grammar score history of a note,
+// one score per revision, to tell whether it improves
+func (h *NoteGrammarHandler) GetGrammarHistory(c *gin.Context) {
+	fileStorage, ok := h.fileStorage(c)
+	if !ok {
+		return
+	}
+	note, ok := h.getNote(c)
+	if !ok {
+		return
+	}
+	history, err := grammar.NewHistory(h.grammar, fileStorage).Get(note.ID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Note not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get grammar history"})
+		return
+	}
+
+	response := models.GrammarHistory{
+		NoteID:   note.ID,
+		Revision: note.Revision,
+		History:  history,
+	}
+	if response.History == nil {
+		response.History = []models.GrammarScoreEntry{}
+	}
+	if len(history) > 0 {
+		response.Trend = math.Round((history[len(history)-1].Score-history[0].Score)*10) / 10
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ListIgnores handles listing the issues ignored on a note
+func (h *NoteGrammarHandler) ListIgnores(c *gin.Context) {
+	fileStorage, ok := h.fileStorage(c)
+	if !ok {
+		return
+	}
+	note, ok := h.getNote(c)
+	if !ok {
+		return
+	}
+	ignores, err := grammar.Ignores(fileStorage, note.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get ignored issues"})
+		return
+	}
+	if ignores == nil {
+		ignores = []models.GrammarIgnore{}
+	}
+	c.JSON(http.StatusOK, ignores)
+}
+
+// IgnoreIssue handles ignoring an issue of a note. The issue is looked up
+// by ID or fingerprint in a check of the current content with the
+// request's rules and language; checks of the note then no longer report
+// issues with its fingerprint, even after the text around it changes.
+func (h *NoteGrammarHandler) IgnoreIssue(c *gin.Context) {
+	var req models.IgnoreGrammarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if (req.Issue == "") == (req.Fingerprint == "") {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Either issue or fingerprint is required"})
+		return
+	}
+	fileStorage, ok := h.fileStorage(c)
+	if !ok {
+		return
+	}
+	note, ok := h.getNote(c)
+	if !ok {
+		return
+	}
+	ignores, err := grammar.Ignores(fileStorage, note.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get ignored issues"})
+		return
+	}
+	for _, ignore := range ignores {
+		if req.Fingerprint != "" && ignore.Fingerprint == req.Fingerprint {
+			c.JSON(http.StatusOK, ignore)
+			return
+		}
+	}
+
+	result, _, err := h.grammar.CheckNoteContext(c.Request.Context(), note.ID, note.Content, grammar.Options{Rules: req.Rules, Language: req.Language})
+	if err != nil {
+		respondGrammarError(c, err)
+		return
+	}
+	for _, issue := range result.Issues {
+		if issue.ID != req.Issue && issue.Fingerprint != req.Fingerprint {
+			continue
+		}
+		ignore, err := grammar.Ignore(fileStorage, note.ID, issue, note.Content[issue.Offset:issue.Offset+issue.Length])
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to ignore issue"})
+			return
+		}
+		c.JSON(http.StatusCreated, ignore)
+		return
+	}
+	c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Issue not found; check the note again"})
+}
+
+// UnignoreIssue handles reporting the issues of a fingerprint again
+func (h *NoteGrammarHandler) UnignoreIssue(c *gin.Context) {
+	fileStorage, ok := h.fileStorage(c)
+	if !ok {
+		return
+	}
+	note, ok := h.getNote(c)
+	if !ok {
+		return
+	}
+	found, err := grammar.Unignore(fileStorage, note.ID, c.Param("fingerprint"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to unignore issue"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Ignored issue not found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// fileStorage returns the storage of the handler for requests that need
+// note metadata, responding with an error when it has none
+func (h *NoteGrammarHandler) fileStorage(c *gin.Context) (*storage.FileStorage, bool) {
+	fileStorage, ok := h.storage.(*storage.FileStorage)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Storage type not supported"})
+	}
+	return fileStorage, ok
+}
+
+// queryGrammarOptions returns the options of a check from the language
+// and disable query parameters
+func queryGrammarOptions(c *gin.Context) grammar.Options {
+	options := grammar.Options{Language: c.Query("language")}
+	for _, id := range strings.Split(c.Query("disable"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			if options.Rules == nil {
+				options.Rules = map[string]bool{}
+			}
+			options.Rules[id] = false
+		}
+	}
+	return options
+}
+
+// ignoredIssues returns the fingerprints of the issues ignored on a note;
+// notes of storage without metadata have none
+func ignoredIssues(c *gin.Context, store storage.Storage, id string) ([]string, bool) {
+	fileStorage, ok := store.(*storage.FileStorage)
+	if !ok {
+		return nil, true
+	}
+	fingerprints, err := grammar.IgnoredFingerprints(fileStorage, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get ignored issues"})
+		return nil, false
+	}
+	return fingerprints, true
+}
+
+// getNote gets the note of the id parameter, responding with an error when
+// it cannot
+func (h *NoteGrammarHandler) getNote(c *gin.Context) (*models.Note, bool) {
+	note, err := h.storage.Get(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Note not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get note"})
+		return nil, false
+	}
+	return note, true
+}
+
+// respondGrammarError responds to an error of a grammar check, with status
+// 400 for invalid options
+func respondGrammarError(c *gin.Context, err error) {
+	if strings.Contains(err.Error(), "unknown grammar rule") || strings.Contains(err.Error(), "unsupported language") {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to check grammar"})
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/grammar"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/utils/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetNoteGrammar(t *testing.T) {
+	store := storage.NewFileStorage(t.TempDir())
+	service := grammar.Service{}
+	store.OnChange(service.InvalidateNote)
+	note := &models.Note{Title: "Cat", Content: "The the cat sat.\n\nIt left.\n"}
+	require.NoError(t, store.Save(note))
+
+	handler := NewNoteGrammarHandler(store, &service)
+	router := testutils.SetupRouter()
+	router.GET("/api/v1/notes/:id/grammar", handler.GetGrammar)
+	path := "/api/v1/notes/" + note.ID + "/grammar"
+
+	get := func(t *testing.T, query string) models.NoteGrammarResult {
+		w := testutils.PerformRequest(router, http.MethodGet, path+query, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var result models.NoteGrammarResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		return result
+	}
+
+	result := get(t, "")
+	assert.Equal(t, note.ID, result.NoteID)
+	assert.Equal(t, 1, result.Revision)
+	assert.False(t, result.Cached)
+	assert.Equal(t, service.RuleSetVersion(), result.RuleSetVersion)
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, "repeated-words", result.Issues[0].Rule)
+	assert.True(t, get(t, "").Cached)
+	assert.Empty(t, get(t, "?disable=grammar").Issues)
+
+	// Saving the note invalidates its results
+	note.Content = "The the cat sat.\n\nit left  again.\n"
+	require.NoError(t, store.Save(note))
+	result = get(t, "")
+	assert.False(t, result.Cached)
+	assert.Equal(t, 2, result.Revision)
+	assert.Len(t, result.Issues, 3)
+
+	result = get(t, "?since=1")
+	require.NotNil(t, result.Since)
+	assert.Equal(t, 1, *result.Since)
+	assert.False(t, result.Cached)
+	require.Len(t, result.Issues, 2)
+	for _, issue := range result.Issues {
+		assert.NotEqual(t, "repeated-words", issue.Rule)
+	}
+	assert.Empty(t, get(t, "?since=2").Issues)
+
+	for _, tt := range []struct {
+		path   string
+		status int
+	}{
+		{path + "?since=0", http.StatusBadRequest},
+		{path + "?since=x", http.StatusBadRequest},
+		{path + "?since=3", http.StatusNotFound},
+		{path + "?disable=nope", http.StatusBadRequest},
+		{path + "?language=xx", http.StatusBadRequest},
+		{"/api/v1/notes/missing/grammar", http.StatusNotFound},
+	} {
+		t.Run(tt.path, func(t *testing.T) {
+			w := testutils.PerformRequest(router, http.MethodGet, tt.path, nil)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestGetNoteGrammarHistory(t *testing.T) {
+	store := storage.NewFileStorage(t.TempDir())
+	service := grammar.Service{}
+	history := grammar.NewHistory(&service, store)
+	store.OnChange(service.InvalidateNote)
+	store.OnChange(func(id string) { require.NoError(t, history.Record(id)) })
+
+	note := &models.Note{Title: "Cat", Content: "The the cat sat  on the mat."}
+	require.NoError(t, store.Save(note))
+	note.Content = "The cat sat on the mat."
+	require.NoError(t, store.Save(note))
+
+	handler := NewNoteGrammarHandler(store, &service)
+	router := testutils.SetupRouter()
+	router.GET("/api/v1/notes/:id/grammar/history", handler.GetGrammarHistory)
+
+	w := testutils.PerformRequest(router, http.MethodGet, "/api/v1/notes/"+note.ID+"/grammar/history", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var result models.GrammarHistory
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, note.ID, result.NoteID)
+	assert.Equal(t, 2, result.Revision)
+	require.Len(t, result.History, 2)
+	assert.Equal(t, 2, result.History[0].Issues)
+	assert.Equal(t, 100.0, result.History[1].Score)
+	assert.Greater(t, result.Trend, 0.0)
+	assert.InDelta(t, result.History[1].Score-result.History[0].Score, result.Trend, 0.01)
+	assert.Len(t, result.History[0].Breakdown, len(grammar.Categories))
+
+	w = testutils.PerformRequest(router, http.MethodGet, "/api/v1/notes/missing/grammar/history", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestFixGrammar(t *testing.T) {
+	const content = "The the cat sat , then left.  It was (happy.\n"
+	store := storage.NewFileStorage(t.TempDir())
+	note := &models.Note{Title: "Cat", Content: content}
+	require.NoError(t, store.Save(note))
+
+	service := grammar.Service{}
+	handler := NewNoteGrammarHandler(store, &service)
+	router := testutils.SetupRouter()
+	router.POST("/api/v1/notes/:id/grammar/fix", handler.FixGrammar)
+	path := "/api/v1/notes/" + note.ID + "/grammar/fix"
+
+	check, err := service.Check(content)
+	require.NoError(t, err)
+	ids := map[string]string{}
+	for _, issue := range check.Issues {
+		ids[issue.Rule] = issue.ID
+	}
+	require.Contains(t, ids, "repeated-words")
+	require.Contains(t, ids, "unbalanced-parentheses")
+
+	fix := func(t *testing.T, req models.FixGrammarRequest) models.GrammarFixResult {
+		w := testutils.PerformRequest(router, http.MethodPost, path, testutils.CreateJSONRequest(t, req))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var result models.GrammarFixResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		return result
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		result := fix(t, models.FixGrammarRequest{All: true, DryRun: true})
+		assert.True(t, result.Changed)
+		assert.True(t, result.DryRun)
+		assert.Equal(t, "The cat sat, then left. It was (happy.\n", result.Content)
+		assert.Equal(t, "--- a/"+note.ID+".md\n+++ b/"+note.ID+".md\n@@ -1 +1 @@\n-"+content+"+The cat sat, then left. It was (happy.\n", result.Diff)
+
+		stored, err := store.Get(note.ID)
+		require.NoError(t, err)
+		assert.Equal(t, content, stored.Content)
+	})
+
+	for _, tt := range []struct {
+		name   string
+		req    models.FixGrammarRequest
+		status int
+	}{
+		{"neither issues nor all", models.FixGrammarRequest{}, http.StatusBadRequest},
+		{"both issues and all", models.FixGrammarRequest{All: true, Issues: []string{ids["repeated-words"]}}, http.StatusBadRequest},
+		{"unknown issue", models.FixGrammarRequest{Issues: []string{"0123456789ab"}}, http.StatusConflict},
+		{"unknown rule", models.FixGrammarRequest{All: true, Rules: map[string]bool{"nope": false}}, http.StatusBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutils.PerformRequest(router, http.MethodPost, path, testutils.CreateJSONRequest(t, tt.req))
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+
+	t.Run("selected issues", func(t *testing.T) {
+		result := fix(t, models.FixGrammarRequest{Issues: []string{ids["repeated-words"], ids["unbalanced-parentheses"]}})
+		assert.True(t, result.Changed)
+		assert.False(t, result.DryRun)
+		require.Len(t, result.Applied, 1)
+		assert.Equal(t, "repeated-words", result.Applied[0].Rule)
+		require.Len(t, result.Skipped, 1)
+		assert.Equal(t, grammar.SkipNotFixable, result.Skipped[0].Reason)
+		assert.True(t, strings.HasPrefix(result.Content, "The cat sat ,"))
+
+		stored, err := store.Get(note.ID)
+		require.NoError(t, err)
+		assert.Equal(t, result.Content, stored.Content)
+
+		// The IDs were those of the previous content
+		w := testutils.PerformRequest(router, http.MethodPost, path, testutils.CreateJSONRequest(t, models.FixGrammarRequest{Issues: []string{ids["repeated-words"]}}))
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	w := testutils.PerformRequest(router, http.MethodPost, "/api/v1/notes/missing/grammar/fix", testutils.CreateJSONRequest(t, models.FixGrammarRequest{All: true}))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGrammarIgnores(t *testing.T) {
+	store := storage.NewFileStorage(t.TempDir())
+	service := grammar.Service{}
+	store.OnChange(service.InvalidateNote)
+	note := &models.Note{Title: "Cat", Content: "The the cat sat  on the mat.\n"}
+	require.NoError(t, store.Save(note))
+
+	handler := NewNoteGrammarHandler(store, &service)
+	router := testutils.SetupRouter()
+	router.GET("/api/v1/notes/:id/grammar", handler.GetGrammar)
+	router.GET("/api/v1/notes/:id/grammar/ignores", handler.ListIgnores)
+	router.POST("/api/v1/notes/:id/grammar/ignores", handler.IgnoreIssue)
+	router.DELETE("/api/v1/notes/:id/grammar/ignores/:fingerprint", handler.UnignoreIssue)
+	path := "/api/v1/notes/" + note.ID + "/grammar"
+
+	check := func(t *testing.T) models.NoteGrammarResult {
+		w := testutils.PerformRequest(router, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var result models.NoteGrammarResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		return result
+	}
+	result := check(t)
+	require.Len(t, result.Issues, 2)
+	issue := result.Issues[0]
+	require.Equal(t, "repeated-words", issue.Rule)
+
+	w := testutils.PerformRequest(router, http.MethodPost, path+"/ignores", testutils.CreateJSONRequest(t, models.IgnoreGrammarRequest{Issue: issue.ID}))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var ignore models.GrammarIgnore
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &ignore))
+	assert.Equal(t, issue.Fingerprint, ignore.Fingerprint)
+	assert.Equal(t, "repeated-words", ignore.Rule)
+	assert.Equal(t, "The the", ignore.Text)
+
+	// Ignoring it again returns the existing ignore
+	w = testutils.PerformRequest(router, http.MethodPost, path+"/ignores", testutils.CreateJSONRequest(t, models.IgnoreGrammarRequest{Fingerprint: issue.Fingerprint}))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	result = check(t)
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, "multiple-spaces", result.Issues[0].Rule)
+
+	// The issue stays ignored when text before it changes
+	note.Content = "# Cats\n\nThe the cat sat  on the mat.\n"
+	require.NoError(t, store.Save(note))
+	result = check(t)
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, "multiple-spaces", result.Issues[0].Rule)
+
+	w = testutils.PerformRequest(router, http.MethodGet, path+"/ignores", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var ignores []models.GrammarIgnore
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &ignores))
+	assert.Len(t, ignores, 1)
+
+	for _, tt := range []struct {
+		name string
+		req  models.IgnoreGrammarRequest
+		code int
+	}{
+		{"neither issue nor fingerprint", models.IgnoreGrammarRequest{}, http.StatusBadRequest},
+		{"both issue and fingerprint", models.IgnoreGrammarRequest{Issue: "a", Fingerprint: "b"}, http.StatusBadRequest},
+		{"unknown issue", models.IgnoreGrammarRequest{Issue: "missing"}, http.StatusConflict},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutils.PerformRequest(router, http.MethodPost, path+"/ignores", testutils.CreateJSONRequest(t, tt.req))
+			assert.Equal(t, tt.code, w.Code, w.Body.String())
+		})
+	}
+
+	w = testutils.PerformRequest(router, http.MethodDelete, path+"/ignores/"+issue.Fingerprint, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = testutils.PerformRequest(router, http.MethodDelete, path+"/ignores/"+issue.Fingerprint, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Len(t, check(t).Issues, 2)
+
+	w = testutils.PerformRequest(router, http.MethodGet, "/api/v1/notes/missing/grammar/ignores", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"bytes"
+	"io"
 	"net/http"
 	"strings"
 
@@ -35,6 +37,11 @@ func (h *NotesHandler) CreateNote(c *gin.Context) {
 		return
 	}
 
+	lint, ok := h.lintOnSave(c, req.Content)
+	if !ok {
+		return
+	}
+
 	note := &models.Note{
 		Title:   req.Title,
 		Content: req.Content,
@@ -45,7 +52,32 @@ func (h *NotesHandler) CreateNote(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, note)
+	h.respondSaved(c, note, lint)
+}
+
+// lintOnSave lints content about to be saved when lint on save is enabled.
+// It responds with the findings and returns false when the content has
+// lint errors.
+func (h *NotesHandler) lintOnSave(c *gin.Context, content string) (*models.LintResult, bool) {
+	if !h.markdown.LintConfig().OnSave {
+		return nil, true
+	}
+	result := h.markdown.Lint(content)
+	if result.Errors > 0 {
+		c.JSON(http.StatusUnprocessableEntity, result)
+		return nil, false
+	}
+	return result, true
+}
+
+// respondSaved responds with a newly saved note, including its lint
+// warnings when it was linted
+func (h *NotesHandler) respondSaved(c *gin.Context, note *models.Note, lint *models.LintResult) {
+	if lint == nil {
+		c.JSON(http.StatusCreated, note)
+		return
+	}
+	c.JSON(http.StatusCreated, models.LintedNote{Note: note, LintIssues: lint.Issues})
 }
 
 // ListNotes handles listing all notes
@@ -90,7 +122,27 @@ func (h *NotesHandler) GetNoteHTML(c *gin.Context) {
 		return
 	}
 
-	html := h.markdown.ToHTML(note.Content)
+	var html string
+	switch c.Query("annotate") {
+	case "":
+		html = h.markdown.ToHTML(note.Content)
+	case "grammar":
+		// Highlight the grammar issues of the note, with a summary above it
+		options := queryGrammarOptions(c)
+		var ok bool
+		if options.Ignored, ok = ignoredIssues(c, h.storage, note.ID); !ok {
+			return
+		}
+		result, _, err := h.grammar.CheckNoteContext(c.Request.Context(), note.ID, note.Content, options)
+		if err != nil {
+			respondGrammarError(c, err)
+			return
+		}
+		html = grammar.Summary(result) + h.markdown.ToAnnotatedHTML(note.Content, grammar.Marks(result.Issues))
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "annotate must be grammar"})
+		return
+	}
 	
 	// Return as HTML content
 	c.Header("Content-Type", "text/html; charset=utf-8")
@@ -133,6 +185,102 @@ func (h *NotesHandler) GetNoteHTML(c *gin.Context) {
             padding-left: 20px;
             color: #666;
         }
+        math[display="block"] {
+            display: block;
+            margin: 1em 0;
+            overflow-x: auto;
+        }
+        .math-fallback code {
+            color: #a33;
+        }
+        figure.diagram {
+            margin: 1em 0;
+            overflow-x: auto;
+            text-align: center;
+        }
+        .diagram-error {
+            border: 1px solid #e0b4b4;
+            background-color: #fff6f6;
+            color: #9f3a38;
+            padding: 10px 15px;
+            border-radius: 4px;
+            margin: 1em 0;
+        }
+        .diagram-error pre {
+            background-color: #fff;
+        }
+        .callout {
+            display: block;
+            border-left: 4px solid #0969da;
+            background-color: #f0f6ff;
+            border-radius: 4px;
+            margin: 1em 0;
+            padding: 10px 15px;
+        }
+        .callout-title {
+            font-weight: bold;
+            margin: 0 0 5px 0;
+        }
+        details.callout > summary {
+            cursor: pointer;
+        }
+        .callout-content > :last-child {
+            margin-bottom: 0;
+        }
+        .callout-tip, .callout-success {
+            border-left-color: #1a7f37;
+            background-color: #effaf1;
+        }
+        .callout-important, .callout-question, .callout-example {
+            border-left-color: #8250df;
+            background-color: #f6f0ff;
+        }
+        .callout-warning, .callout-todo {
+            border-left-color: #9a6700;
+            background-color: #fff8e5;
+        }
+        .callout-caution, .callout-failure, .callout-bug {
+            border-left-color: #cf222e;
+            background-color: #fff0f0;
+        }
+        .callout-quote, .callout-abstract {
+            border-left-color: #888;
+            background-color: #f6f6f6;
+        }
+        mark.grammar-issue {
+            background-color: transparent;
+            color: inherit;
+            text-decoration: underline wavy #cf222e;
+            text-underline-offset: 3px;
+            cursor: help;
+        }
+        mark.grammar-warning {
+            text-decoration-color: #9a6700;
+        }
+        mark.grammar-info {
+            text-decoration-color: #0969da;
+        }
+        mark.grammar-issue:target {
+            background-color: #fff8c5;
+        }
+        .grammar-summary {
+            border: 1px solid #ddd;
+            border-radius: 4px;
+            padding: 10px 15px;
+            margin-bottom: 1em;
+            font-size: 0.9em;
+        }
+        .grammar-summary h2 {
+            font-size: 1.1em;
+            margin: 0 0 5px 0;
+        }
+        .grammar-categories {
+            display: flex;
+            flex-wrap: wrap;
+            gap: 0 1.5em;
+            list-style: none;
+            padding: 0;
+        }
     </style>
 </head>
 <body>
@@ -145,6 +293,23 @@ func (h *NotesHandler) GetNoteHTML(c *gin.Context) {
 `, note.Title, note.Title, html)
 }
 
+// GetNoteStats handles getting reading statistics for a note
+func (h *NotesHandler) GetNoteStats(c *gin.Context) {
+	id := c.Param("id")
+
+	note, err := h.storage.Get(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Note not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get note"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.markdown.Stats(note.Content))
+}
+
 // DeleteNote handles deleting a note
 func (h *NotesHandler) DeleteNote(c *gin.Context) {
 	id := c.Param("id")
@@ -165,9 +330,9 @@ func (h *NotesHandler) CheckGrammar(c *gin.Context) {
 		return
 	}
 
-	result, err := h.grammar.Check(req.Content)
+	result, err := h.grammar.CheckContext(c.Request.Context(), req.Content, grammar.Options{Rules: req.Rules, Language: req.Language})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to check grammar"})
+		respondGrammarError(c, err)
 		return
 	}
 
@@ -196,11 +361,22 @@ func (h *NotesHandler) UploadNote(c *gin.Context) {
 		return
 	}
 
-	note, err := fileStorage.SaveUploadedFile(file, header.Filename)
+	content, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Failed to read file"})
+		return
+	}
+
+	lint, ok := h.lintOnSave(c, string(content))
+	if !ok {
+		return
+	}
+
+	note, err := fileStorage.SaveUploadedFile(bytes.NewReader(content), header.Filename)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to save uploaded file"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, note)
+	h.respondSaved(c, note, lint)
 }
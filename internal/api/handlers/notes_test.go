@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
@@ -46,6 +47,7 @@ func setupTest(t *testing.T) (
 	notes.GET("", handler.ListNotes)
 	notes.GET("/:id", handler.GetNote)
 	notes.GET("/:id/html", handler.GetNoteHTML)
+	notes.GET("/:id/stats", handler.GetNoteStats)
 	notes.DELETE("/:id", handler.DeleteNote)
 	notes.POST("/check-grammar", handler.CheckGrammar)
 
@@ -113,3 +115,112 @@ func TestCheckGrammar(t *testing.T) {
 	assert.GreaterOrEqual(t, response.Score, 0.0)
 	assert.LessOrEqual(t, response.Score, 100.0)
 }
+
+func TestCheckGrammar_Rules(t *testing.T) {
+	_, router, _, _, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	payload := models.CheckGrammarRequest{
+		Content: "this needs grammar checking.",
+		Rules:   map[string]bool{"capitalization": false},
+	}
+	w := testutils.PerformRequest(router, http.MethodPost, "/api/v1/notes/check-grammar", testutils.CreateJSONRequest(t, payload))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.GrammarCheckResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Empty(t, response.Issues)
+
+	payload.Rules = map[string]bool{"no-such-rule": true}
+	w = testutils.PerformRequest(router, http.MethodPost, "/api/v1/notes/check-grammar", testutils.CreateJSONRequest(t, payload))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCheckGrammar_Language(t *testing.T) {
+	_, router, _, _, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	payload := models.CheckGrammarRequest{
+		Content:  "Necesito una hora para terminar esto, qué dices?",
+		Language: "auto",
+	}
+	w := testutils.PerformRequest(router, http.MethodPost, "/api/v1/notes/check-grammar", testutils.CreateJSONRequest(t, payload))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.GrammarCheckResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "es", response.Language)
+	require.Len(t, response.Issues, 1)
+	assert.Equal(t, "inverted-punctuation", response.Issues[0].Rule)
+
+	payload.Language = ""
+	w = testutils.PerformRequest(router, http.MethodPost, "/api/v1/notes/check-grammar", testutils.CreateJSONRequest(t, payload))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "en", response.Language)
+
+	payload.Language = "xx"
+	w = testutils.PerformRequest(router, http.MethodPost, "/api/v1/notes/check-grammar", testutils.CreateJSONRequest(t, payload))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetNoteStats(t *testing.T) {
+	_, router, storageService, _, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	note := &models.Note{
+		Title:   "Stats",
+		Content: "# Heading\n\nOne sentence here. Another [link](https://example.com).\n\n```\ncode\n```",
+	}
+	require.NoError(t, storageService.Save(note))
+
+	w := testutils.PerformRequest(router, http.MethodGet, "/api/v1/notes/"+note.ID+"/stats", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.NoteStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 6, response.Words)
+	assert.Equal(t, 3, response.Sentences)
+	assert.Equal(t, 1, response.Headings)
+	assert.Equal(t, 1, response.Links)
+	assert.Equal(t, 1, response.CodeBlocks)
+	assert.Equal(t, 1, response.ReadingTimeMinutes)
+
+	w = testutils.PerformRequest(router, http.MethodGet, "/api/v1/notes/missing/stats", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetNoteHTML_AnnotateGrammar(t *testing.T) {
+	_, router, storageService, _, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	note := &models.Note{Title: "Cat", Content: "# Cats\n\nThe *the* cat sat on the [mat](https://example.com).\n\n```\nthe the\n```\n"}
+	require.NoError(t, storageService.Save(note))
+	path := "/api/v1/notes/" + note.ID + "/html"
+
+	w := testutils.PerformRequest(router, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "<mark")
+	assert.NotContains(t, w.Body.String(), "grammar-summary\"")
+
+	w = testutils.PerformRequest(router, http.MethodGet, path+"?annotate=grammar", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `<aside class="grammar-summary"`)
+	assert.Contains(t, body, `<p><mark id="grammar-issue-`)
+	assert.Contains(t, body, `data-rule="repeated-words"`)
+	// The issue spans the emphasis, so it is marked on both sides of it
+	assert.Contains(t, body, `>The </mark><em><mark class="grammar-issue grammar-error"`)
+	assert.Contains(t, body, ">the</mark></em> cat")
+	assert.Contains(t, body, "<pre><code>the the\n</code></pre>")
+	assert.Equal(t, 2, strings.Count(body, "<mark "))
+	assert.Equal(t, 1, strings.Count(body, `<mark id=`))
+
+	w = testutils.PerformRequest(router, http.MethodGet, path+"?annotate=grammar&disable=grammar", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "<mark")
+
+	w = testutils.PerformRequest(router, http.MethodGet, path+"?annotate=spelling", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = testutils.PerformRequest(router, http.MethodGet, path+"?annotate=grammar&disable=missing", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
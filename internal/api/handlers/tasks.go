@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/tasks"
+	"github.com/gin-gonic/gin"
+)
+
+// TasksHandler handles task list requests
+type TasksHandler struct {
+	tasks *tasks.Service
+}
+
+// NewTasksHandler creates a new tasks handler
+func NewTasksHandler(tasks *tasks.Service) *TasksHandler {
+	return &TasksHandler{
+		tasks: tasks,
+	}
+}
+
+// ListTasks handles listing the tasks of all notes. The status, owner,
+// note_id, due_before, due_after, overdue and q query parameters filter
+// the tasks.
+func (h *TasksHandler) ListTasks(c *gin.Context) {
+	filter := tasks.Filter{
+		Status:  c.Query("status"),
+		Owner:   strings.TrimPrefix(c.Query("owner"), "@"),
+		NoteID:  c.Query("note_id"),
+		Overdue: c.Query("overdue") == "true",
+		Query:   c.Query("q"),
+	}
+	if filter.Status != "" && filter.Status != tasks.StatusOpen && filter.Status != tasks.StatusDone {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid status, expected open or done"})
+		return
+	}
+	for param, date := range map[string]**time.Time{"due_before": &filter.DueBefore, "due_after": &filter.DueAfter} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(tasks.DateLayout, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid " + param + ", expected YYYY-MM-DD"})
+			return
+		}
+		*date = &t
+	}
+
+	result, err := h.tasks.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to list tasks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// UpdateTask handles checking or unchecking a task, rewriting its checkbox
+// in the note
+func (h *TasksHandler) UpdateTask(c *gin.Context) {
+	var req models.UpdateTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	task, err := h.tasks.SetDone(c.Param("id"), *req.Done)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid task id"):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid task ID"})
+		case strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Task not found"})
+		case strings.Contains(err.Error(), "changed"):
+			c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Task changed since it was listed; list the tasks again"})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update task"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/tasks"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/utils/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTasks(t *testing.T) {
+	storageService := storage.NewFileStorage(t.TempDir())
+	handler := NewTasksHandler(tasks.NewService(storageService, markdown.NewService()))
+
+	router := testutils.SetupRouter()
+	router.GET("/api/v1/tasks", handler.ListTasks)
+	router.PATCH("/api/v1/tasks/:id", handler.UpdateTask)
+
+	note := &models.Note{Title: "Plan", Content: "# Plan\n\n- [ ] Write @due(2026-11-01) @alice\n- [x] Read\n"}
+	require.NoError(t, storageService.Save(note))
+
+	var list []models.Task
+	w := testutils.PerformRequest(router, http.MethodGet, "/api/v1/tasks?status=open&owner=@alice", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list, 1)
+	assert.Equal(t, models.Task{ID: tasks.ID(note.ID, 3, "Write"), NoteID: note.ID, NoteTitle: "Plan", Line: 3, Text: "Write", Due: "2026-11-01", Owner: "alice"}, list[0])
+
+	var task models.Task
+	w = testutils.PerformRequest(router, http.MethodPatch, "/api/v1/tasks/"+list[0].ID, testutils.CreateJSONRequest(t, map[string]bool{"done": true}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &task))
+	assert.True(t, task.Done)
+
+	saved, err := storageService.Get(note.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "# Plan\n\n- [x] Write @due(2026-11-01) @alice\n- [x] Read\n", saved.Content)
+
+	w = testutils.PerformRequest(router, http.MethodGet, "/api/v1/tasks?status=open", nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Empty(t, list)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		code   int
+	}{
+		{"invalid status", http.MethodGet, "/api/v1/tasks?status=maybe", nil, http.StatusBadRequest},
+		{"invalid date", http.MethodGet, "/api/v1/tasks?due_before=tomorrow", nil, http.StatusBadRequest},
+		{"missing done", http.MethodPatch, "/api/v1/tasks/" + tasks.ID(note.ID, 3, "Write"), map[string]string{}, http.StatusBadRequest},
+		{"invalid id", http.MethodPatch, "/api/v1/tasks/nope", map[string]bool{"done": true}, http.StatusBadRequest},
+		{"not a task", http.MethodPatch, "/api/v1/tasks/" + tasks.ID(note.ID, 1, "Plan"), map[string]bool{"done": true}, http.StatusNotFound},
+		{"changed task", http.MethodPatch, "/api/v1/tasks/" + tasks.ID(note.ID, 4, "Write"), map[string]bool{"done": true}, http.StatusConflict},
+		{"missing note", http.MethodPatch, "/api/v1/tasks/" + tasks.ID("missing", 3, "Write"), map[string]bool{"done": true}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w = testutils.PerformRequest(router, tt.method, tt.path, nil)
+			if tt.body != nil {
+				w = testutils.PerformRequest(router, tt.method, tt.path, testutils.CreateJSONRequest(t, tt.body))
+			}
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
@@ -3,20 +3,31 @@ package routes
 import (
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/api/handlers"
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/api/middleware"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/audit"
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/grammar"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/links"
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/tasks"
 	"github.com/gin-gonic/gin"
 )
 
 // Setup configures all routes
-func Setup(router *gin.Engine, storage storage.Storage, markdown *markdown.Service, grammar *grammar.Service) {
+func Setup(router *gin.Engine, storage storage.Storage, markdown *markdown.Service, grammar *grammar.Service, links *links.Checker, audits *audit.Service) {
 	// Apply global middleware
 	router.Use(middleware.Logger())
 	router.Use(middleware.CORS())
 	
 	// Create handlers
 	notesHandler := handlers.NewNotesHandler(storage, markdown, grammar)
+	exportHandler := handlers.NewExportHandler(storage, markdown)
+	lintHandler := handlers.NewLintHandler(markdown)
+	formatHandler := handlers.NewFormatHandler(storage, markdown)
+	maintenanceHandler := handlers.NewMaintenanceHandler(links)
+	tasksHandler := handlers.NewTasksHandler(tasks.NewService(storage, markdown))
+	grammarHandler := handlers.NewGrammarHandler(grammar)
+	noteGrammarHandler := handlers.NewNoteGrammarHandler(storage, grammar)
+	auditHandler := handlers.NewAuditHandler(audits)
 
 	// API v1 routes
 	v1 := router.Group("/api/v1")
@@ -28,11 +39,50 @@ func Setup(router *gin.Engine, storage storage.Storage, markdown *markdown.Servi
 			notes.GET("", notesHandler.ListNotes)
 			notes.GET("/:id", notesHandler.GetNote)
 			notes.GET("/:id/html", notesHandler.GetNoteHTML)
+			notes.GET("/:id/stats", notesHandler.GetNoteStats)
+			notes.GET("/:id/pdf", exportHandler.GetNotePDF)
+			notes.GET("/:id/export", exportHandler.ExportNote)
+			notes.POST("/:id/format", formatHandler.FormatNote)
+			notes.GET("/:id/grammar", noteGrammarHandler.GetGrammar)
+			notes.GET("/:id/grammar/history", noteGrammarHandler.GetGrammarHistory)
+			notes.GET("/:id/grammar/ignores", noteGrammarHandler.ListIgnores)
+			notes.POST("/:id/grammar/ignores", noteGrammarHandler.IgnoreIssue)
+			notes.DELETE("/:id/grammar/ignores/:fingerprint", noteGrammarHandler.UnignoreIssue)
+			notes.POST("/:id/grammar/fix", noteGrammarHandler.FixGrammar)
+			notes.POST("/export", exportHandler.ExportNotes)
 			notes.DELETE("/:id", notesHandler.DeleteNote)
 			notes.POST("/upload", notesHandler.UploadNote)
 			notes.POST("/check-grammar", notesHandler.CheckGrammar)
 		}
 
+		// Grammar routes
+		v1.GET("/grammar/rules", grammarHandler.ListRules)
+		v1.GET("/grammar/live", grammarHandler.LiveGrammar)
+		v1.POST("/grammar/audits", auditHandler.StartAudit)
+		v1.GET("/grammar/audits", auditHandler.ListAudits)
+		v1.GET("/grammar/audits/:id", auditHandler.GetAudit)
+		v1.GET("/grammar/audits/:id/report", auditHandler.GetAuditReport)
+		v1.DELETE("/grammar/audits/:id", auditHandler.DeleteAudit)
+
+		// Custom dictionary routes
+		v1.GET("/dictionary", grammarHandler.GetDictionary)
+		v1.POST("/dictionary", grammarHandler.AddDictionaryWords)
+		v1.DELETE("/dictionary/:word", grammarHandler.DeleteDictionaryWord)
+
+		// Lint routes
+		v1.POST("/lint", lintHandler.Lint)
+		v1.GET("/lint/rules", lintHandler.ListRules)
+
+		// Format routes
+		v1.POST("/format", formatHandler.Format)
+
+		// Task routes
+		v1.GET("/tasks", tasksHandler.ListTasks)
+		v1.PATCH("/tasks/:id", tasksHandler.UpdateTask)
+
+		// Maintenance routes
+		v1.GET("/maintenance/broken-links", maintenanceHandler.BrokenLinks)
+
 		// Documentation routes
 		v1.GET("/docs", serveSwaggerUI)
 		v1.GET("/docs/openapi.yaml", serveOpenAPISpec)
@@ -2,6 +2,10 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds the application configuration
@@ -9,15 +13,60 @@ type Config struct {
 	Port     string
 	NotesDir string
 	LogLevel string
+	// LintConfig is the path of a JSON file selecting lint rules
+	LintConfig string
+	// LintOnSave lints notes when they are saved
+	LintOnSave bool
+	// FormatWidth is the column formatted paragraphs are wrapped at
+	FormatWidth int
+	// LinkCheckInterval is how often links between notes are checked in
+	// the background; 0 disables background checks
+	LinkCheckInterval time.Duration
+	// DictionaryDir is the directory of Hunspell dictionaries
+	DictionaryDir string
+	// Dictionaries maps languages to the names of the Hunspell
+	// dictionaries to check their spelling with, such as en_US for
+	// en_US.aff and en_US.dic
+	Dictionaries map[string]string
+	// CustomDictionary is the file of extra correct words; it defaults to
+	// dictionary.txt in the notes directory
+	CustomDictionary string
+	// StylesDir is the directory of style rule packs, one subdirectory of
+	// YAML rules per pack
+	StylesDir string
+	// LanguageToolURL is the base URL of a LanguageTool server to check
+	// grammar with, falling back to the built-in rules; empty disables it
+	LanguageToolURL string
+	// LanguageToolTimeout limits each request to the LanguageTool server
+	LanguageToolTimeout time.Duration
+	// LanguageToolRetries is how often failed requests are retried
+	LanguageToolRetries int
+	// AuditConcurrency is how many notes a grammar audit checks at once
+	AuditConcurrency int
 }
 
 // Load loads configuration from environment variables
 func Load() *Config {
-	return &Config{
-		Port:     getEnv("PORT", "8080"),
-		NotesDir: getEnv("NOTES_DIR", "./notes"),
-		LogLevel: getEnv("LOG_LEVEL", "info"),
+	cfg := &Config{
+		Port:              getEnv("PORT", "8080"),
+		NotesDir:          getEnv("NOTES_DIR", "./notes"),
+		LogLevel:          getEnv("LOG_LEVEL", "info"),
+		LintConfig:        getEnv("LINT_CONFIG", ""),
+		LintOnSave:        getEnv("LINT_ON_SAVE", "false") == "true",
+		FormatWidth:       getEnvInt("FORMAT_WIDTH", 80),
+		LinkCheckInterval: getEnvDuration("LINK_CHECK_INTERVAL", time.Hour),
+		DictionaryDir:     getEnv("DICTIONARY_DIR", "./dictionaries"),
+		Dictionaries:      getEnvMap("DICTIONARIES", "en:en_US,de:de_DE,es:es_ES"),
+		StylesDir:         getEnv("STYLES_DIR", "./styles"),
+
+		LanguageToolURL:     getEnv("LANGUAGETOOL_URL", ""),
+		LanguageToolTimeout: getEnvDuration("LANGUAGETOOL_TIMEOUT", 10*time.Second),
+		LanguageToolRetries: getEnvInt("LANGUAGETOOL_RETRIES", 2),
+
+		AuditConcurrency: getEnvInt("AUDIT_CONCURRENCY", 4),
 	}
+	cfg.CustomDictionary = getEnv("CUSTOM_DICTIONARY", filepath.Join(cfg.NotesDir, "dictionary.txt"))
+	return cfg
 }
 
 // getEnv gets an environment variable with a fallback value
@@ -27,3 +76,41 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getEnvInt gets an integer environment variable with a fallback value
+func getEnvInt(key string, fallback int) int {
+	if value, ok := os.LookupEnv(key); ok {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+// getEnvDuration gets a duration environment variable, such as "30m", with
+// a fallback value
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value, ok := os.LookupEnv(key); ok {
+		if value == "0" {
+			return 0
+		}
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
+
+// getEnvMap gets an environment variable of comma-separated key:value
+// pairs, such as "en:en_US,de:de_DE", with a fallback value. Pairs without
+// a colon are ignored.
+func getEnvMap(key, fallback string) map[string]string {
+	result := map[string]string{}
+	for _, pair := range strings.Split(getEnv(key, fallback), ",") {
+		k, v, ok := strings.Cut(pair, ":")
+		if k, v = strings.TrimSpace(k), strings.TrimSpace(v); ok && k != "" && v != "" {
+			result[k] = v
+		}
+	}
+	return result
+}
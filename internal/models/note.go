@@ -9,6 +9,8 @@ type Note struct {
 	Content   string    `json:"content"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// Revision counts the saves of the note
+	Revision int `json:"revision"`
 }
 
 // NoteMetadata represents note metadata without content
@@ -17,6 +19,7 @@ type NoteMetadata struct {
 	Title     string    `json:"title"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Revision  int       `json:"revision"`
 }
 
 // CreateNoteRequest represents a request to create a new note
@@ -25,27 +28,442 @@ type CreateNoteRequest struct {
 	Content string `json:"content" binding:"required"`
 }
 
+// ExportNotesRequest represents a request to export several notes as one document
+type ExportNotesRequest struct {
+	IDs    []string `json:"ids" binding:"required,min=1"`
+	Format string   `json:"format" binding:"required"`
+}
+
 // CheckGrammarRequest represents a request to check grammar
 type CheckGrammarRequest struct {
 	Content string `json:"content" binding:"required"`
+	// Rules enables or disables rules by rule ID or category
+	Rules map[string]bool `json:"rules,omitempty"`
+	// Language is an ISO 639-1 code such as "de", or "auto" to detect it;
+	// it defaults to English
+	Language string `json:"language,omitempty"`
 }
 
 // GrammarCheckResult represents the result of a grammar check
 type GrammarCheckResult struct {
 	Issues []GrammarIssue `json:"issues"`
-	Score  float64        `json:"score"`
+	// Score is 100 for text without issues, and decays with the weighted
+	// issues per 100 words
+	Score float64 `json:"score"`
+	// Words is the number of words of the prose checked
+	Words int `json:"words"`
+	// Breakdown scores the issues of each category
+	Breakdown []GrammarCategoryScore `json:"breakdown"`
+	// Language is the language the text was checked in, as requested or
+	// detected
+	Language string `json:"language"`
+	// Checker is the checker that found the issues, such as local or
+	// languagetool
+	Checker string `json:"checker"`
+	// Suppressed is the number of issues not reported because of
+	// suppression comments or ignored issues
+	Suppressed int `json:"suppressed"`
+	// Disabled is set when the front matter turns grammar checking off
+	Disabled bool `json:"disabled,omitempty"`
+	// Checked holds the ranges of the paragraphs, headings, list items and
+	// table cells checked when only changed ones were
+	Checked []TextRange `json:"checked,omitempty"`
+}
+
+// GrammarCategoryScore is the score of the issues of one category
+type GrammarCategoryScore struct {
+	Category string `json:"category"`
+	Issues   int    `json:"issues"`
+	// Weight is the weight of the category in the score
+	Weight float64 `json:"weight"`
+	// Penalty is the sum of the weighted severities of the issues
+	Penalty float64 `json:"penalty"`
+	Score   float64 `json:"score"`
+}
+
+// GrammarIgnore is an issue ignored on a note. Issues with the same
+// fingerprint are not reported by checks of the note.
+type GrammarIgnore struct {
+	Fingerprint string `json:"fingerprint"`
+	Rule        string `json:"rule"`
+	// Text is the text of the issue when it was ignored
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
-// GrammarIssue represents a single grammar issue
+// IgnoreGrammarRequest represents a request to ignore an issue of a note,
+// by the ID or the fingerprint it has in a check of the current content
+type IgnoreGrammarRequest struct {
+	Issue       string          `json:"issue"`
+	Fingerprint string          `json:"fingerprint"`
+	Rules       map[string]bool `json:"rules"`
+	Language    string          `json:"language"`
+}
+
+// GrammarScoreEntry is the grammar score of a revision of a note
+type GrammarScoreEntry struct {
+	Revision  int                    `json:"revision"`
+	Score     float64                `json:"score"`
+	Issues    int                    `json:"issues"`
+	Words     int                    `json:"words"`
+	Breakdown []GrammarCategoryScore `json:"breakdown"`
+	Language  string                 `json:"language"`
+	// RuleSetVersion is the version of the rules and dictionaries the
+	// revision was scored with; scores of other versions may differ for
+	// the same content
+	RuleSetVersion string    `json:"rule_set_version"`
+	CheckedAt      time.Time `json:"checked_at"`
+}
+
+// GrammarHistory is the grammar score history of a note
+type GrammarHistory struct {
+	NoteID string `json:"note_id"`
+	// Revision is the current revision of the note
+	Revision int                 `json:"revision"`
+	History  []GrammarScoreEntry `json:"history"`
+	// Trend is the score of the current revision minus that of the first
+	// revision in the history
+	Trend float64 `json:"trend"`
+}
+
+// TextRange is a range of markdown, counted like the offsets of issues
+type TextRange struct {
+	Offset      int `json:"offset"`
+	Length      int `json:"length"`
+	RuneOffset  int `json:"rune_offset"`
+	RuneLength  int `json:"rune_length"`
+	UTF16Offset int `json:"utf16_offset"`
+	UTF16Length int `json:"utf16_length"`
+}
+
+// GrammarIssue represents a single grammar issue. Offset and Length count
+// bytes of the UTF-8 markdown; the rune and UTF-16 variants count code
+// points and UTF-16 code units, as used by browser editors.
 type GrammarIssue struct {
+	// ID identifies the issue in the checked content. It is derived from
+	// the rule, the offset and the text of the issue, so it changes when
+	// the issue or the text before it does.
+	ID string `json:"id"`
+	// Fingerprint identifies the issue by its rule, its text and its
+	// sentence, so that it stays the same when other text changes
+	Fingerprint string `json:"fingerprint"`
+	Rule        string `json:"rule"`
+	Severity    string `json:"severity"`
 	Message     string `json:"message"`
 	Offset      int    `json:"offset"`
 	Length      int    `json:"length"`
+	RuneOffset  int    `json:"rune_offset"`
+	RuneLength  int    `json:"rune_length"`
+	UTF16Offset int    `json:"utf16_offset"`
+	UTF16Length int    `json:"utf16_length"`
 	Replacement string `json:"replacement,omitempty"`
-	Type        string `json:"type"`
+	// Suggestions lists the ranked replacements of spelling issues
+	Suggestions []string `json:"suggestions,omitempty"`
+	// Fixable is set when Replacement fixes the issue; an empty
+	// Replacement then deletes the text
+	Fixable bool   `json:"fixable"`
+	Type    string `json:"type"`
+	// Sentence is the 0-based index of the sentence the issue is in,
+	// counting the sentences of the whole note
+	Sentence int `json:"sentence"`
+}
+
+// TextEdit replaces Delete characters at Offset with Insert. Offset and
+// Delete count in the unit of the live grammar session.
+type TextEdit struct {
+	Offset int    `json:"offset"`
+	Delete int    `json:"delete"`
+	Insert string `json:"insert"`
+}
+
+// LiveGrammarMessage is a message of the client of a live grammar session.
+// The first message opens the session with the content; edit messages
+// then change it.
+type LiveGrammarMessage struct {
+	// Type is open or edit
+	Type     string          `json:"type"`
+	Content  string          `json:"content,omitempty"`
+	Language string          `json:"language,omitempty"`
+	Rules    map[string]bool `json:"rules,omitempty"`
+	// Unit is what offsets of edits count: byte, rune or utf16
+	Unit string `json:"unit,omitempty"`
+	// DebounceMS is how long the session waits for more edits before
+	// checking
+	DebounceMS *int       `json:"debounce_ms,omitempty"`
+	Edits      []TextEdit `json:"edits,omitempty"`
+}
+
+// LiveGrammarEvent is a message of a live grammar session to its client
+type LiveGrammarEvent struct {
+	// Type is result or error
+	Type string `json:"type"`
+	// Version is the number of edit messages applied to the content that
+	// was checked, or that failed
+	Version int `json:"version"`
+	// Full is set when the whole content was checked rather than the
+	// ranges in checked
+	Full bool `json:"full,omitempty"`
+	*GrammarCheckResult
+	Error string `json:"error,omitempty"`
+}
+
+// NoteGrammarResult is the result of a grammar check of a stored note
+type NoteGrammarResult struct {
+	GrammarCheckResult
+	NoteID string `json:"note_id"`
+	// Revision is the revision of the note that was checked
+	Revision int `json:"revision"`
+	// Since is the revision the note was compared with when only changed
+	// paragraphs were checked
+	Since *int `json:"since,omitempty"`
+	// RuleSetVersion is the version of the rules and dictionaries used
+	RuleSetVersion string `json:"rule_set_version"`
+	// Cached is set when the result was computed before
+	Cached bool `json:"cached"`
+}
+
+// FixGrammarRequest selects the grammar issues of a note to fix, by the
+// IDs of a check of its current content or with All. Rules and Language
+// must be those of the check, so that it finds the same issues.
+type FixGrammarRequest struct {
+	Issues   []string        `json:"issues,omitempty"`
+	All      bool            `json:"all,omitempty"`
+	Rules    map[string]bool `json:"rules,omitempty"`
+	Language string          `json:"language,omitempty"`
+	// DryRun returns the fixed note without saving it
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// SkippedFix is a grammar issue whose fix was not applied. Reason is
+// not-fixable for issues without a replacement and overlap for issues
+// overlapping a fix applied instead.
+type SkippedFix struct {
+	Issue  GrammarIssue `json:"issue"`
+	Reason string       `json:"reason"`
+	Detail string       `json:"detail,omitempty"`
+}
+
+// GrammarFixResult is a note after fixing grammar issues, with the fixes
+// applied and skipped and a unified diff of its content
+type GrammarFixResult struct {
+	*Note
+	Applied []GrammarIssue `json:"applied"`
+	Skipped []SkippedFix   `json:"skipped"`
+	Diff    string         `json:"diff"`
+	Changed bool           `json:"changed"`
+	DryRun  bool           `json:"dry_run"`
+}
+
+// GrammarRule describes a grammar rule
+type GrammarRule struct {
+	ID          string `json:"id"`
+	Category    string `json:"category"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+	// Languages lists the languages the rule checks
+	Languages []string `json:"languages"`
+}
+
+// DictionaryWords lists the words of the custom dictionary
+type DictionaryWords struct {
+	Words []string `json:"words"`
+}
+
+// AddDictionaryWordsRequest represents a request to add words to the
+// custom dictionary
+type AddDictionaryWordsRequest struct {
+	Words []string `json:"words" binding:"required,min=1"`
+}
+
+// LintRequest represents a request to lint markdown
+type LintRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// LintResult represents the result of linting markdown
+type LintResult struct {
+	Issues   []LintIssue `json:"issues"`
+	Errors   int         `json:"errors"`
+	Warnings int         `json:"warnings"`
+}
+
+// LintIssue represents a single lint finding. Line and column are 1-based
+// and columns count characters.
+type LintIssue struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// LintedNote is a saved note with the lint findings of its content, returned
+// when notes are linted on save
+type LintedNote struct {
+	*Note
+	LintIssues []LintIssue `json:"lint_issues"`
+}
+
+// FormatRequest represents a request to format markdown. Width overrides
+// the configured wrap width; 0 unwraps paragraphs.
+type FormatRequest struct {
+	Content string `json:"content" binding:"required"`
+	Width   *int   `json:"width"`
+}
+
+// FormatResult represents formatted markdown
+type FormatResult struct {
+	Content string `json:"content"`
+	Changed bool   `json:"changed"`
+}
+
+// FormattedNote is a note after formatting, with whether its content changed
+type FormattedNote struct {
+	*Note
+	Changed bool `json:"changed"`
+}
+
+// BrokenLinksReport represents the result of checking the links of all
+// notes. Notes whose markdown could not be parsed are listed apart, and
+// their links are not checked.
+type BrokenLinksReport struct {
+	CheckedAt        time.Time         `json:"checked_at"`
+	NotesChecked     int               `json:"notes_checked"`
+	LinksChecked     int               `json:"links_checked"`
+	BrokenLinks      []BrokenLink      `json:"broken_links"`
+	UnparseableNotes []UnparseableNote `json:"unparseable_notes"`
+}
+
+// UnparseableNote represents a note the markdown parser failed on
+type UnparseableNote struct {
+	NoteID    string `json:"note_id"`
+	NoteTitle string `json:"note_title"`
+	Error     string `json:"error"`
+}
+
+// BrokenLink represents a link in a note whose target does not exist.
+// Reason is one of missing-note, missing-anchor or missing-attachment.
+type BrokenLink struct {
+	NoteID    string `json:"note_id"`
+	NoteTitle string `json:"note_title"`
+	Line      int    `json:"line"`
+	Target    string `json:"target"`
+	Reason    string `json:"reason"`
+}
+
+// Task represents a task list item in a note. The ID is the note ID, line
+// and a fingerprint of the text, and changes when lines are added above
+// the task or its text changes.
+type Task struct {
+	ID        string `json:"id"`
+	NoteID    string `json:"note_id"`
+	NoteTitle string `json:"note_title"`
+	Line      int    `json:"line"`
+	Text      string `json:"text"`
+	Done      bool   `json:"done"`
+	Due       string `json:"due,omitempty"`
+	Owner     string `json:"owner,omitempty"`
+}
+
+// UpdateTaskRequest represents a request to check or uncheck a task
+type UpdateTaskRequest struct {
+	Done *bool `json:"done" binding:"required"`
+}
+
+// NoteStats represents reading statistics of a note. Counts other than
+// headings, links, images and code blocks cover prose only; code blocks
+// are left out.
+type NoteStats struct {
+	Words              int     `json:"words"`
+	Characters         int     `json:"characters"`
+	CharactersNoSpaces int     `json:"characters_no_spaces"`
+	Sentences          int     `json:"sentences"`
+	Headings           int     `json:"headings"`
+	Links              int     `json:"links"`
+	Images             int     `json:"images"`
+	CodeBlocks         int     `json:"code_blocks"`
+	ReadingTimeSeconds int     `json:"reading_time_seconds"`
+	ReadingTimeMinutes int     `json:"reading_time_minutes"`
+	FleschReadingEase  float64 `json:"flesch_reading_ease"`
+	FleschKincaidGrade float64 `json:"flesch_kincaid_grade"`
 }
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
+
+// GrammarAuditRequest represents a request to audit the grammar of all
+// notes, or of the notes of a folder or with a tag
+type GrammarAuditRequest struct {
+	// Folder selects the notes whose front matter folder is it or one of
+	// its subfolders
+	Folder string `json:"folder,omitempty"`
+	// Tag selects the notes with the tag in their front matter
+	Tag string `json:"tag,omitempty"`
+	// Rules and Language are those of CheckGrammarRequest
+	Rules    map[string]bool `json:"rules,omitempty"`
+	Language string          `json:"language,omitempty"`
+}
+
+// GrammarAudit is the status of a grammar audit. Status is queued,
+// running, done, failed or cancelled.
+type GrammarAudit struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Folder string `json:"folder,omitempty"`
+	Tag    string `json:"tag,omitempty"`
+	// Total is the number of notes selected, known once the audit runs
+	Total int `json:"total"`
+	// Checked counts the notes checked so far, including those that
+	// failed
+	Checked int `json:"checked"`
+	Failed  int `json:"failed"`
+	// Progress is the share of the notes checked, from 0 to 1
+	Progress   float64    `json:"progress"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// GrammarAuditReport is the report of a finished grammar audit, with the
+// worst notes first
+type GrammarAuditReport struct {
+	AuditID        string             `json:"audit_id"`
+	Folder         string             `json:"folder,omitempty"`
+	Tag            string             `json:"tag,omitempty"`
+	RuleSetVersion string             `json:"rule_set_version"`
+	CreatedAt      time.Time          `json:"created_at"`
+	FinishedAt     time.Time          `json:"finished_at"`
+	Notes          []GrammarAuditNote `json:"notes"`
+	// Issues counts the issues of all notes, and Score is the mean score
+	// of the notes weighted by their words
+	Issues int     `json:"issues"`
+	Score  float64 `json:"score"`
+}
+
+// GrammarAuditNote is the result of checking a note in an audit. Notes
+// that could not be checked have an error instead.
+type GrammarAuditNote struct {
+	NoteID   string              `json:"note_id"`
+	Title    string              `json:"title"`
+	Revision int                 `json:"revision"`
+	Folder   string              `json:"folder,omitempty"`
+	Tags     []string            `json:"tags,omitempty"`
+	Score    float64             `json:"score"`
+	Words    int                 `json:"words"`
+	Language string              `json:"language,omitempty"`
+	Issues   []GrammarAuditIssue `json:"issues"`
+	Error    string              `json:"error,omitempty"`
+}
+
+// GrammarAuditIssue is an issue of an audited note with its 1-based line
+// and column, in code points
+type GrammarAuditIssue struct {
+	GrammarIssue
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	// Text is the text of the issue
+	Text string `json:"text"`
+}
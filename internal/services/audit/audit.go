@@ -0,0 +1,484 @@
+// Package audit checks the grammar of many notes in the background and
+// reports the issues found, worst notes first.
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/grammar"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Audit statuses
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusDone      = "done"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+const (
+	// DefaultConcurrency is how many notes an audit checks at once when
+	// the service is given no other
+	DefaultConcurrency = 4
+	// MaxAudits is the number of audits kept; the oldest finished audits
+	// are dropped first
+	MaxAudits = 20
+)
+
+// Service runs grammar audits of the stored notes. Audits run one at a
+// time, in the order they are started, and are kept in memory.
+type Service struct {
+	storage     storage.Storage
+	grammar     *grammar.Service
+	concurrency int
+	// now returns the current time, replaced in tests
+	now func() time.Time
+
+	// running serializes audits
+	running sync.Mutex
+	mu      sync.Mutex
+	audits  map[string]*audit
+	order   []string
+}
+
+// audit is an audit with its request and, once done, its report
+type audit struct {
+	status  models.GrammarAudit
+	request models.GrammarAuditRequest
+	report  *models.GrammarAuditReport
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewService creates a new audit service checking up to concurrency notes
+// at once
+func NewService(storage storage.Storage, grammar *grammar.Service, concurrency int) *Service {
+	if concurrency < 1 {
+		concurrency = DefaultConcurrency
+	}
+	return &Service{
+		storage:     storage,
+		grammar:     grammar,
+		concurrency: concurrency,
+		now:         time.Now,
+		audits:      map[string]*audit{},
+	}
+}
+
+// Start queues an audit and returns its status. The rules and language of
+// the request are validated first, so that an audit does not fail on
+// every note.
+func (s *Service) Start(req models.GrammarAuditRequest) (models.GrammarAudit, error) {
+	if _, err := s.grammar.CheckWithOptions("", grammar.Options{Rules: req.Rules, Language: req.Language}); err != nil {
+		return models.GrammarAudit{}, err
+	}
+	req.Folder = cleanFolder(req.Folder)
+	req.Tag = cleanTag(req.Tag)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &audit{
+		status: models.GrammarAudit{
+			ID:        uuid.New().String(),
+			Status:    StatusQueued,
+			Folder:    req.Folder,
+			Tag:       req.Tag,
+			CreatedAt: s.now(),
+		},
+		request: req,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	s.audits[a.status.ID] = a
+	s.order = append(s.order, a.status.ID)
+	s.prune()
+	status := a.status
+	s.mu.Unlock()
+
+	go s.run(ctx, a)
+	return status, nil
+}
+
+// prune drops the oldest finished audits beyond MaxAudits. It is called
+// with mu held.
+func (s *Service) prune() {
+	excess := len(s.order) - MaxAudits
+	kept := s.order[:0]
+	for _, id := range s.order {
+		a := s.audits[id]
+		if excess > 0 && finished(a.status.Status) {
+			delete(s.audits, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.order = kept
+}
+
+// finished reports whether an audit with a status has stopped
+func finished(status string) bool {
+	return status == StatusDone || status == StatusFailed || status == StatusCancelled
+}
+
+// Get returns the status of an audit
+func (s *Service) Get(id string) (models.GrammarAudit, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.audits[id]
+	if !ok {
+		return models.GrammarAudit{}, false
+	}
+	return a.status, true
+}
+
+// List returns the status of the audits kept, newest first
+func (s *Service) List() []models.GrammarAudit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	audits := make([]models.GrammarAudit, 0, len(s.order))
+	for i := len(s.order) - 1; i >= 0; i-- {
+		audits = append(audits, s.audits[s.order[i]].status)
+	}
+	return audits
+}
+
+// Report returns the report of a finished audit
+func (s *Service) Report(id string) (*models.GrammarAuditReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.audits[id]
+	if !ok {
+		return nil, errors.New("audit not found")
+	}
+	if a.report == nil {
+		return nil, fmt.Errorf("audit is %s", a.status.Status)
+	}
+	return a.report, nil
+}
+
+// Delete cancels an audit that has not finished and forgets it. It
+// reports whether the audit existed.
+func (s *Service) Delete(id string) bool {
+	s.mu.Lock()
+	a, ok := s.audits[id]
+	if ok {
+		delete(s.audits, id)
+		for i, other := range s.order {
+			if other == id {
+				s.order = append(s.order[:i], s.order[i+1:]...)
+				break
+			}
+		}
+	}
+	s.mu.Unlock()
+	if ok {
+		a.cancel()
+	}
+	return ok
+}
+
+// Wait waits until an audit finishes or the context is done
+func (s *Service) Wait(ctx context.Context, id string) error {
+	s.mu.Lock()
+	a, ok := s.audits[id]
+	s.mu.Unlock()
+	if !ok {
+		return errors.New("audit not found")
+	}
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// update changes the status of an audit
+func (s *Service) update(a *audit, change func(status *models.GrammarAudit)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	change(&a.status)
+	if a.status.Total > 0 {
+		a.status.Progress = float64(a.status.Checked) / float64(a.status.Total)
+	}
+}
+
+// run runs an audit once the audits before it are done
+func (s *Service) run(ctx context.Context, a *audit) {
+	defer close(a.done)
+	defer a.cancel()
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	started := s.now()
+	s.update(a, func(status *models.GrammarAudit) {
+		status.Status = StatusRunning
+		status.StartedAt = &started
+	})
+	report, err := s.audit(ctx, a)
+	finishedAt := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a.status.FinishedAt = &finishedAt
+	switch {
+	case ctx.Err() != nil:
+		a.status.Status = StatusCancelled
+	case err != nil:
+		a.status.Status = StatusFailed
+		a.status.Error = err.Error()
+	default:
+		a.status.Status = StatusDone
+		a.status.Progress = 1
+		report.FinishedAt = finishedAt
+		a.report = report
+	}
+}
+
+// audit checks the notes selected by an audit, concurrency at a time
+func (s *Service) audit(ctx context.Context, a *audit) (*models.GrammarAuditReport, error) {
+	notes, err := s.selectNotes(ctx, a.request)
+	if err != nil {
+		return nil, err
+	}
+	s.update(a, func(status *models.GrammarAudit) {
+		status.Total = len(notes)
+		if status.Total == 0 {
+			status.Progress = 1
+		}
+	})
+
+	results := make([]models.GrammarAuditNote, len(notes))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < min(s.concurrency, len(notes)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = s.check(ctx, notes[i], a.request)
+				s.update(a, func(status *models.GrammarAudit) {
+					status.Checked++
+					if results[i].Error != "" {
+						status.Failed++
+					}
+				})
+			}
+		}()
+	}
+send:
+	for i := range notes {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(next)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sortWorstFirst(results)
+	report := &models.GrammarAuditReport{
+		AuditID:        a.status.ID,
+		Folder:         a.request.Folder,
+		Tag:            a.request.Tag,
+		RuleSetVersion: s.grammar.RuleSetVersion(),
+		CreatedAt:      a.status.CreatedAt,
+		Notes:          results,
+		Score:          100,
+	}
+	weighted, words := 0.0, 0
+	for _, note := range results {
+		report.Issues += len(note.Issues)
+		if note.Error == "" {
+			weighted += note.Score * float64(max(note.Words, 1))
+			words += max(note.Words, 1)
+		}
+	}
+	if words > 0 {
+		report.Score = math.Round(weighted/float64(words)*10) / 10
+	}
+	return report, nil
+}
+
+// selectedNote is a note selected by an audit, with its folder and tags
+type selectedNote struct {
+	*models.Note
+	folder string
+	tags   []string
+}
+
+// selectNotes reads the notes an audit checks, in the order of the storage
+func (s *Service) selectNotes(ctx context.Context, req models.GrammarAuditRequest) ([]selectedNote, error) {
+	list, err := s.storage.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+	var notes []selectedNote
+	for _, meta := range list {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		note, err := s.storage.Get(meta.ID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get note %s: %w", meta.ID, err)
+		}
+		folder, tags := frontMatter(note.Content)
+		if req.Folder != "" && folder != req.Folder && !strings.HasPrefix(folder, req.Folder+"/") {
+			continue
+		}
+		if req.Tag != "" && !hasTag(tags, req.Tag) {
+			continue
+		}
+		notes = append(notes, selectedNote{Note: note, folder: folder, tags: tags})
+	}
+	return notes, nil
+}
+
+// check checks a note with the options of an audit, leaving out the
+// issues ignored on it. A panic while checking, such as one raised by the
+// markdown parser, fails the note rather than the audit.
+func (s *Service) check(ctx context.Context, note selectedNote, req models.GrammarAuditRequest) (result models.GrammarAuditNote) {
+	result = models.GrammarAuditNote{
+		NoteID:   note.ID,
+		Title:    note.Title,
+		Revision: note.Revision,
+		Folder:   note.folder,
+		Tags:     note.tags,
+		Issues:   []models.GrammarAuditIssue{},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			result.Score, result.Words, result.Language = 0, 0, ""
+			result.Issues = []models.GrammarAuditIssue{}
+			result.Error = fmt.Sprintf("failed to check note: %v", r)
+		}
+	}()
+	options := grammar.Options{Rules: req.Rules, Language: req.Language}
+	if fileStorage, ok := s.storage.(*storage.FileStorage); ok {
+		ignored, err := grammar.IgnoredFingerprints(fileStorage, note.ID)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		options.Ignored = ignored
+	}
+	checked, _, err := s.grammar.CheckNoteContext(ctx, note.ID, note.Content, options)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Score = checked.Score
+	result.Words = checked.Words
+	result.Language = checked.Language
+	for _, issue := range checked.Issues {
+		line, column := position(note.Content, issue.Offset)
+		result.Issues = append(result.Issues, models.GrammarAuditIssue{
+			GrammarIssue: issue,
+			Line:         line,
+			Column:       column,
+			Text:         note.Content[issue.Offset : issue.Offset+issue.Length],
+		})
+	}
+	return result
+}
+
+// position returns the 1-based line and column, in code points, of a byte
+// offset of text
+func position(text string, offset int) (line, column int) {
+	before := text[:offset]
+	start := strings.LastIndexByte(before, '\n') + 1
+	return strings.Count(before, "\n") + 1, utf8.RuneCountInString(before[start:]) + 1
+}
+
+// sortWorstFirst sorts the notes of a report by score, then by their
+// number of issues and title. Notes that could not be checked come last.
+func sortWorstFirst(notes []models.GrammarAuditNote) {
+	sort.SliceStable(notes, func(i, j int) bool {
+		a, b := notes[i], notes[j]
+		if (a.Error == "") != (b.Error == "") {
+			return a.Error == ""
+		}
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		if len(a.Issues) != len(b.Issues) {
+			return len(a.Issues) > len(b.Issues)
+		}
+		return a.Title < b.Title
+	})
+}
+
+// frontMatter returns the folder and tags of a note from its front
+// matter. Tags may be a list or separated by commas.
+func frontMatter(content string) (folder string, tags []string) {
+	front := markdown.FrontMatter(content)
+	if front == "" {
+		return "", nil
+	}
+	var settings struct {
+		Folder string      `yaml:"folder"`
+		Tags   interface{} `yaml:"tags"`
+	}
+	if err := yaml.Unmarshal([]byte(front), &settings); err != nil {
+		return "", nil
+	}
+	var names []string
+	switch value := settings.Tags.(type) {
+	case string:
+		names = strings.Split(value, ",")
+	case []interface{}:
+		for _, tag := range value {
+			names = append(names, fmt.Sprint(tag))
+		}
+	}
+	for _, name := range names {
+		if tag := cleanTag(name); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return cleanFolder(settings.Folder), tags
+}
+
+// cleanFolder normalizes a folder to its path without surrounding slashes
+func cleanFolder(folder string) string {
+	return strings.Trim(strings.TrimSpace(folder), "/")
+}
+
+// cleanTag normalizes a tag, dropping a leading #
+func cleanTag(tag string) string {
+	return strings.TrimPrefix(strings.TrimSpace(tag), "#")
+}
+
+// hasTag reports whether tags have a tag, ignoring case
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,305 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/grammar"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowChecker runs the local rules slowly, recording how many checks run
+// at once, until it is released
+type slowChecker struct {
+	grammar.Checker
+	release chan struct{}
+
+	mu      sync.Mutex
+	running int
+	most    int
+}
+
+func (c *slowChecker) Check(ctx context.Context, request grammar.CheckRequest) (*grammar.CheckResponse, error) {
+	if len(request.Blocks) == 0 {
+		return c.Checker.Check(ctx, request)
+	}
+	c.mu.Lock()
+	c.running++
+	c.most = max(c.most, c.running)
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.running--
+		c.mu.Unlock()
+	}()
+	select {
+	case <-c.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	time.Sleep(5 * time.Millisecond)
+	return c.Checker.Check(ctx, request)
+}
+
+func wait(t *testing.T, service *Service, id string) models.GrammarAudit {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, service.Wait(ctx, id))
+	status, ok := service.Get(id)
+	require.True(t, ok)
+	return status
+}
+
+func saveNotes(t *testing.T, store *storage.FileStorage) map[string]*models.Note {
+	t.Helper()
+	notes := map[string]*models.Note{
+		"clean":   {Title: "Clean", Content: "---\nfolder: docs/guides\ntags: [docs, api]\n---\nThe cat sat on the mat.\n"},
+		"bad":     {Title: "Bad", Content: "---\nfolder: docs\ntags: docs, draft\n---\nThe the cat sat  on the mat.\n"},
+		"worse":   {Title: "Worse", Content: "---\nfolder: blog\ntags: \"#draft\"\n---\nThe the cat.\n\nIt it sat  here.\n"},
+		"nothing": {Title: "Nothing", Content: "The dog sat on the mat.\n"},
+	}
+	for _, note := range notes {
+		require.NoError(t, store.Save(note))
+	}
+	return notes
+}
+
+func TestService_Audit(t *testing.T) {
+	store := storage.NewFileStorage(t.TempDir())
+	notes := saveNotes(t, store)
+	service := NewService(store, &grammar.Service{}, 2)
+
+	status, err := service.Start(models.GrammarAuditRequest{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, status.ID)
+
+	status = wait(t, service, status.ID)
+	assert.Equal(t, StatusDone, status.Status)
+	assert.Equal(t, 4, status.Total)
+	assert.Equal(t, 4, status.Checked)
+	assert.Equal(t, 1.0, status.Progress)
+	require.NotNil(t, status.FinishedAt)
+
+	report, err := service.Report(status.ID)
+	require.NoError(t, err)
+	require.Len(t, report.Notes, 4)
+	assert.Equal(t, "Worse", report.Notes[0].Title)
+	assert.Equal(t, "Bad", report.Notes[1].Title)
+	assert.Equal(t, 100.0, report.Notes[2].Score)
+	assert.Equal(t, 5, report.Issues)
+	assert.Less(t, report.Score, 100.0)
+
+	bad := report.Notes[1]
+	assert.Equal(t, notes["bad"].ID, bad.NoteID)
+	assert.Equal(t, "docs", bad.Folder)
+	assert.Equal(t, []string{"docs", "draft"}, bad.Tags)
+	require.Len(t, bad.Issues, 2)
+	assert.Equal(t, "repeated-words", bad.Issues[0].Rule)
+	assert.Equal(t, 5, bad.Issues[0].Line)
+	assert.Equal(t, 1, bad.Issues[0].Column)
+	assert.Equal(t, "The the", bad.Issues[0].Text)
+	assert.Equal(t, 16, bad.Issues[1].Column)
+
+	// Ignored issues are left out
+	_, err = grammar.Ignore(store, notes["bad"].ID, bad.Issues[0].GrammarIssue, bad.Issues[0].Text)
+	require.NoError(t, err)
+	status, err = service.Start(models.GrammarAuditRequest{Rules: map[string]bool{"spacing": false}})
+	require.NoError(t, err)
+	wait(t, service, status.ID)
+	report, err = service.Report(status.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Issues)
+
+	assert.Len(t, service.List(), 2)
+	assert.Equal(t, status.ID, service.List()[0].ID)
+}
+
+func TestService_Audit_UnparseableNote(t *testing.T) {
+	store := storage.NewFileStorage(t.TempDir())
+	saveNotes(t, store)
+	broken := &models.Note{Title: "Broken", Content: "Term\n\n:\nab"}
+	require.NoError(t, store.Save(broken))
+	service := NewService(store, &grammar.Service{}, 2)
+
+	status, err := service.Start(models.GrammarAuditRequest{})
+	require.NoError(t, err)
+	status = wait(t, service, status.ID)
+	assert.Equal(t, StatusDone, status.Status)
+	assert.Equal(t, 5, status.Checked)
+	assert.Equal(t, 1, status.Failed)
+
+	report, err := service.Report(status.ID)
+	require.NoError(t, err)
+	require.Len(t, report.Notes, 5)
+	last := report.Notes[4]
+	assert.Equal(t, broken.ID, last.NoteID)
+	assert.Contains(t, last.Error, "failed to check note")
+	assert.Empty(t, last.Issues)
+	assert.Equal(t, 5, report.Issues)
+}
+
+func TestService_Audit_Filter(t *testing.T) {
+	store := storage.NewFileStorage(t.TempDir())
+	saveNotes(t, store)
+	service := NewService(store, &grammar.Service{}, 2)
+
+	for _, tt := range []struct {
+		name   string
+		req    models.GrammarAuditRequest
+		titles []string
+	}{
+		{"folder and subfolders", models.GrammarAuditRequest{Folder: "/docs/"}, []string{"Bad", "Clean"}},
+		{"subfolder", models.GrammarAuditRequest{Folder: "docs/guides"}, []string{"Clean"}},
+		{"tag", models.GrammarAuditRequest{Tag: "#Draft"}, []string{"Worse", "Bad"}},
+		{"folder and tag", models.GrammarAuditRequest{Folder: "docs", Tag: "api"}, []string{"Clean"}},
+		{"nothing", models.GrammarAuditRequest{Folder: "missing"}, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := service.Start(tt.req)
+			require.NoError(t, err)
+			status = wait(t, service, status.ID)
+			assert.Equal(t, StatusDone, status.Status)
+			assert.Equal(t, len(tt.titles), status.Total)
+			report, err := service.Report(status.ID)
+			require.NoError(t, err)
+			var titles []string
+			for _, note := range report.Notes {
+				titles = append(titles, note.Title)
+			}
+			assert.Equal(t, tt.titles, titles)
+		})
+	}
+
+	_, err := service.Start(models.GrammarAuditRequest{Rules: map[string]bool{"missing": true}})
+	assert.ErrorContains(t, err, "unknown grammar rule")
+	_, err = service.Start(models.GrammarAuditRequest{Language: "xx"})
+	assert.Error(t, err)
+}
+
+func TestService_Audit_Concurrency(t *testing.T) {
+	store := storage.NewFileStorage(t.TempDir())
+	for i := 0; i < 8; i++ {
+		require.NoError(t, store.Save(&models.Note{Title: "Note", Content: "The the cat sat."}))
+	}
+	checks := &grammar.Service{}
+	checker := &slowChecker{Checker: checks.Local(), release: make(chan struct{})}
+	checks.SetChecker(checker)
+	service := NewService(store, checks, 3)
+
+	status, err := service.Start(models.GrammarAuditRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, StatusQueued, status.Status)
+
+	// The report is not ready while the audit runs
+	require.Eventually(t, func() bool {
+		checker.mu.Lock()
+		defer checker.mu.Unlock()
+		return checker.running == 3
+	}, 5*time.Second, time.Millisecond)
+	running, _ := service.Get(status.ID)
+	assert.Equal(t, StatusRunning, running.Status)
+	assert.Equal(t, 8, running.Total)
+	_, err = service.Report(status.ID)
+	assert.ErrorContains(t, err, "audit is running")
+
+	close(checker.release)
+	status = wait(t, service, status.ID)
+	assert.Equal(t, StatusDone, status.Status)
+	assert.Equal(t, 8, status.Checked)
+	assert.Equal(t, 3, checker.most)
+}
+
+func TestService_Delete(t *testing.T) {
+	store := storage.NewFileStorage(t.TempDir())
+	require.NoError(t, store.Save(&models.Note{Title: "Note", Content: "The the cat sat."}))
+	checks := &grammar.Service{}
+	checker := &slowChecker{Checker: checks.Local(), release: make(chan struct{})}
+	checks.SetChecker(checker)
+	service := NewService(store, checks, 1)
+
+	status, err := service.Start(models.GrammarAuditRequest{})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.Eventually(t, func() bool {
+		checker.mu.Lock()
+		defer checker.mu.Unlock()
+		return checker.running == 1
+	}, 5*time.Second, time.Millisecond)
+
+	done := service.audits[status.ID].done
+	assert.True(t, service.Delete(status.ID))
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("audit was not cancelled")
+	}
+	_, ok := service.Get(status.ID)
+	assert.False(t, ok)
+	assert.False(t, service.Delete(status.ID))
+	_, err = service.Report(status.ID)
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestService_WriteReport(t *testing.T) {
+	store := storage.NewFileStorage(t.TempDir())
+	notes := saveNotes(t, store)
+	service := NewService(store, &grammar.Service{}, 2)
+	status, err := service.Start(models.GrammarAuditRequest{Folder: "docs"})
+	require.NoError(t, err)
+	wait(t, service, status.ID)
+	report, err := service.Report(status.ID)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, service.WriteReport(&buf, report, FormatJSON))
+	var decoded models.GrammarAuditReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, report.AuditID, decoded.AuditID)
+	assert.Len(t, decoded.Notes, 2)
+
+	buf.Reset()
+	require.NoError(t, service.WriteReport(&buf, report, FormatCSV))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	assert.Equal(t, csvHeader, rows[0])
+	assert.Equal(t, []string{notes["bad"].ID, "Bad", "docs", "docs,draft"}, rows[1][:4])
+	assert.Equal(t, []string{"repeated-words", "grammar", "error", "5", "1", "The the"}, rows[1][6:12])
+	assert.Equal(t, "Clean", rows[3][1])
+	assert.Equal(t, "100.0", rows[3][4])
+	assert.Equal(t, "", rows[3][6])
+
+	buf.Reset()
+	require.NoError(t, service.WriteReport(&buf, report, FormatSARIF))
+	var sarif sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &sarif))
+	assert.Equal(t, "2.1.0", sarif.Version)
+	require.Len(t, sarif.Runs, 1)
+	run := sarif.Runs[0]
+	assert.Equal(t, "unicodeCodePoints", run.ColumnKind)
+	require.Len(t, run.Results, 2)
+	result := run.Results[0]
+	assert.Equal(t, "repeated-words", result.RuleID)
+	assert.Equal(t, "error", result.Level)
+	location := result.Locations[0].PhysicalLocation
+	assert.Equal(t, "/api/v1/notes/"+notes["bad"].ID, location.ArtifactLocation.URI)
+	assert.Equal(t, sarifRegion{StartLine: 5, StartColumn: 1, EndLine: 5, EndColumn: 8, Snippet: &sarifMessage{Text: "The the"}}, location.Region)
+	assert.Equal(t, report.Notes[0].Issues[0].Fingerprint, result.PartialFingerprints["grammarFingerprint/v1"])
+	require.Len(t, result.Fixes, 1)
+	assert.Equal(t, "The", result.Fixes[0].ArtifactChanges[0].Replacements[0].InsertedContent.Text)
+	require.Len(t, run.Tool.Driver.Rules, 2)
+	assert.Equal(t, "multiple-spaces", run.Tool.Driver.Rules[0].ID)
+	require.NotNil(t, run.Tool.Driver.Rules[1].ShortDescription)
+
+	assert.Error(t, service.WriteReport(&buf, report, "xml"))
+}
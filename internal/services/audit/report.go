@@ -0,0 +1,329 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/grammar"
+)
+
+// Report formats
+const (
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+	FormatSARIF = "sarif"
+)
+
+// Formats lists the report formats
+var Formats = []string{FormatJSON, FormatCSV, FormatSARIF}
+
+// ContentType returns the media type of a report format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatSARIF:
+		return "application/sarif+json"
+	}
+	return "application/json"
+}
+
+// WriteReport writes a report in a format
+func (s *Service) WriteReport(w io.Writer, report *models.GrammarAuditReport, format string) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case FormatCSV:
+		return writeCSV(w, report)
+	case FormatSARIF:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s.sarif(report))
+	}
+	return fmt.Errorf("unsupported report format %q", format)
+}
+
+// csvHeader names the columns of CSV reports
+var csvHeader = []string{
+	"note_id", "title", "folder", "tags", "score", "words",
+	"rule", "category", "severity", "line", "column", "text", "message", "replacement", "fingerprint",
+	"error",
+}
+
+// writeCSV writes a report with a row per issue, worst notes first. Notes
+// without issues have a row with empty issue columns.
+func writeCSV(w io.Writer, report *models.GrammarAuditReport) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, note := range report.Notes {
+		prefix := []string{
+			note.NoteID,
+			note.Title,
+			note.Folder,
+			strings.Join(note.Tags, ","),
+			strconv.FormatFloat(note.Score, 'f', 1, 64),
+			strconv.Itoa(note.Words),
+		}
+		if len(note.Issues) == 0 {
+			row := append(append([]string{}, prefix...), make([]string, len(csvHeader)-len(prefix))...)
+			row[len(row)-1] = note.Error
+			if err := out.Write(row); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, issue := range note.Issues {
+			replacement := ""
+			if issue.Fixable {
+				replacement = issue.Replacement
+			}
+			row := append(append([]string{}, prefix...),
+				issue.Rule,
+				issue.Type,
+				issue.Severity,
+				strconv.Itoa(issue.Line),
+				strconv.Itoa(issue.Column),
+				issue.Text,
+				issue.Message,
+				replacement,
+				issue.Fingerprint,
+				"",
+			)
+			if err := out.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// SARIF 2.1.0 log, with the parts reports use
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	ColumnKind  string            `json:"columnKind"`
+	Results     []sarifResult     `json:"results"`
+	Invocations []sarifInvocation `json:"invocations"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version"`
+	Rules   []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string            `json:"id"`
+	ShortDescription     *sarifMessage     `json:"shortDescription,omitempty"`
+	DefaultConfiguration sarifRuleConfig   `json:"defaultConfiguration"`
+	Properties           map[string]string `json:"properties,omitempty"`
+}
+
+type sarifRuleConfig struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+	Fixes               []sarifFix        `json:"fixes,omitempty"`
+	Properties          map[string]any    `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int           `json:"startLine"`
+	StartColumn int           `json:"startColumn"`
+	EndLine     int           `json:"endLine"`
+	EndColumn   int           `json:"endColumn"`
+	Snippet     *sarifMessage `json:"snippet,omitempty"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion  `json:"deletedRegion"`
+	InsertedContent sarifMessage `json:"insertedContent"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+// sarifLevels maps severities to SARIF levels
+var sarifLevels = map[string]string{
+	grammar.SeverityError:   "error",
+	grammar.SeverityWarning: "warning",
+	grammar.SeverityInfo:    "note",
+}
+
+// sarifLevel returns the SARIF level of a severity
+func sarifLevel(severity string) string {
+	if level, ok := sarifLevels[severity]; ok {
+		return level
+	}
+	return "warning"
+}
+
+// noteURI is the artifact location of a note in SARIF reports: its path
+// in the API
+func noteURI(id string) string {
+	return "/api/v1/notes/" + id
+}
+
+// sarif converts a report to a SARIF log with a result per issue, worst
+// notes first, and the rules of the issues. Columns count code points.
+// Notes that could not be checked are reported as notifications.
+func (s *Service) sarif(report *models.GrammarAuditReport) sarifLog {
+	descriptions := map[string]grammar.Rule{}
+	for _, language := range s.grammar.Languages() {
+		for _, rule := range s.grammar.Rules(language) {
+			if _, ok := descriptions[rule.ID()]; !ok {
+				descriptions[rule.ID()] = rule
+			}
+		}
+	}
+
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:    "go-markdown-note-taking-app grammar",
+			Version: report.RuleSetVersion,
+			Rules:   []sarifRule{},
+		}},
+		ColumnKind: "unicodeCodePoints",
+		Results:    []sarifResult{},
+	}
+	rules := map[string]sarifRule{}
+	invocation := sarifInvocation{ExecutionSuccessful: true}
+	for _, note := range report.Notes {
+		artifact := sarifArtifactLocation{URI: noteURI(note.NoteID)}
+		if note.Error != "" {
+			invocation.ToolExecutionNotifications = append(invocation.ToolExecutionNotifications, sarifNotification{
+				Level:     "error",
+				Message:   sarifMessage{Text: fmt.Sprintf("Failed to check note %q: %s", note.Title, note.Error)},
+				Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: artifact, Region: sarifRegion{StartLine: 1, StartColumn: 1, EndLine: 1, EndColumn: 1}}}},
+			})
+			continue
+		}
+		for _, issue := range note.Issues {
+			if _, ok := rules[issue.Rule]; !ok {
+				rule := sarifRule{
+					ID:                   issue.Rule,
+					DefaultConfiguration: sarifRuleConfig{Level: sarifLevel(issue.Severity)},
+					Properties:           map[string]string{"category": issue.Type},
+				}
+				if known, ok := descriptions[issue.Rule]; ok {
+					rule.ShortDescription = &sarifMessage{Text: known.Description()}
+					rule.DefaultConfiguration.Level = sarifLevel(known.Severity())
+				}
+				rules[issue.Rule] = rule
+			}
+
+			region := issueRegion(issue)
+			result := sarifResult{
+				RuleID:              issue.Rule,
+				Level:               sarifLevel(issue.Severity),
+				Message:             sarifMessage{Text: issue.Message},
+				PartialFingerprints: map[string]string{"grammarFingerprint/v1": issue.Fingerprint},
+				Properties:          map[string]any{"category": issue.Type, "noteTitle": note.Title, "noteScore": note.Score},
+			}
+			withSnippet := region
+			withSnippet.Snippet = &sarifMessage{Text: issue.Text}
+			result.Locations = []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: artifact, Region: withSnippet}}}
+			if issue.Fixable {
+				description := fmt.Sprintf("Replace with %q", issue.Replacement)
+				if issue.Replacement == "" {
+					description = "Remove the text"
+				}
+				result.Fixes = []sarifFix{{
+					Description: sarifMessage{Text: description},
+					ArtifactChanges: []sarifArtifactChange{{
+						ArtifactLocation: artifact,
+						Replacements:     []sarifReplacement{{DeletedRegion: region, InsertedContent: sarifMessage{Text: issue.Replacement}}},
+					}},
+				}}
+			}
+			run.Results = append(run.Results, result)
+		}
+	}
+	for _, rule := range rules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+	}
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool { return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID })
+	run.Invocations = []sarifInvocation{invocation}
+
+	return sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	}
+}
+
+// issueRegion returns the region of an issue, ending after its last code
+// point
+func issueRegion(issue models.GrammarAuditIssue) sarifRegion {
+	endLine, endColumn := issue.Line, issue.Column
+	for _, r := range issue.Text {
+		if r == '\n' {
+			endLine++
+			endColumn = 1
+			continue
+		}
+		endColumn++
+	}
+	return sarifRegion{StartLine: issue.Line, StartColumn: issue.Column, EndLine: endLine, EndColumn: endColumn}
+}
@@ -0,0 +1,66 @@
+// Package diagram renders text diagram languages to standalone SVG.
+//
+// Everything is implemented in pure Go so diagrams can be rendered on the
+// server without Graphviz or a JavaScript runtime. Two languages are
+// supported: a practical subset of Graphviz DOT, and the line-based
+// sequence diagram syntax popularised by js-sequence-diagrams.
+package diagram
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error describes why a diagram could not be rendered
+type Error struct {
+	Language string
+	Line     int
+	Message  string
+}
+
+func (e *Error) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s diagram, line %d: %s", e.Language, e.Line, e.Message)
+	}
+	return fmt.Sprintf("%s diagram: %s", e.Language, e.Message)
+}
+
+// languages maps fenced code block info strings to renderers
+var languages = map[string]func(src string) (string, error){
+	"dot":      RenderDOT,
+	"graphviz": RenderDOT,
+	"sequence": RenderSequence,
+}
+
+// unsupported lists diagram languages that are recognised but cannot be
+// rendered on the server. Blocks in these languages get an error box rather
+// than silently showing up as code.
+var unsupported = map[string]bool{
+	"mermaid":   true,
+	"plantuml":  true,
+	"puml":      true,
+	"ditaa":     true,
+	"flowchart": true,
+}
+
+// IsDiagram reports whether a fenced code block language is a diagram
+// language, supported or not
+func IsDiagram(lang string) bool {
+	lang = normalizeLanguage(lang)
+	_, ok := languages[lang]
+	return ok || unsupported[lang]
+}
+
+// Render renders the diagram source in the given language to SVG
+func Render(lang, src string) (string, error) {
+	lang = normalizeLanguage(lang)
+	render, ok := languages[lang]
+	if !ok {
+		return "", &Error{Language: lang, Message: "diagram language is not supported on the server"}
+	}
+	return render(src)
+}
+
+func normalizeLanguage(lang string) string {
+	return strings.ToLower(strings.TrimSpace(lang))
+}
@@ -0,0 +1,146 @@
+package diagram
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertWellFormed checks that the SVG parses as XML
+func assertWellFormed(t *testing.T, svg string) {
+	t.Helper()
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestRenderDOT(t *testing.T) {
+	src := `digraph G {
+		// services
+		node [shape=box];
+		api [label="API\nGateway"];
+		api -> auth [label="verify"];
+		api -> notes -> storage;
+		storage -> api; /* cycle */
+		db [shape=cylinder, style=filled, fillcolor="#eef"];
+		storage -> db
+	}`
+
+	svg, err := RenderDOT(src)
+	require.NoError(t, err)
+	assertWellFormed(t, svg)
+
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" class="diagram diagram-dot"`))
+	assert.Contains(t, svg, ">API</text>")
+	assert.Contains(t, svg, ">Gateway</text>")
+	assert.Contains(t, svg, ">verify</text>")
+	assert.Contains(t, svg, `fill="#eef"`)
+	assert.Equal(t, 5, strings.Count(svg, "<line"), "one line per edge")
+}
+
+func TestRenderDOT_Layout(t *testing.T) {
+	g, err := parseDOT("digraph { a -> b -> c; a -> c }")
+	require.NoError(t, err)
+	layoutGraph(g)
+
+	a, b, c := g.index["a"], g.index["b"], g.index["c"]
+	assert.Equal(t, []int{0, 1, 2}, []int{a.rank, b.rank, c.rank})
+	assert.Less(t, a.y, b.y)
+	assert.Less(t, b.y, c.y)
+
+	g, err = parseDOT("digraph { rankdir=LR; a -> b }")
+	require.NoError(t, err)
+	layoutGraph(g)
+	assert.Less(t, g.index["a"].x, g.index["b"].x)
+	assert.Equal(t, g.index["a"].y, g.index["b"].y)
+}
+
+func TestRenderDOT_Undirected(t *testing.T) {
+	svg, err := RenderDOT("graph { a -- b }")
+	require.NoError(t, err)
+	assert.NotContains(t, svg, "marker-end")
+
+	_, err = RenderDOT("graph { a -> b }")
+	assert.Error(t, err)
+}
+
+func TestRenderDOT_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		line int
+	}{
+		{name: "missing header", src: "a -> b", line: 1},
+		{name: "dangling edge", src: "digraph {\n  a ->\n}", line: 3},
+		{name: "unterminated string", src: "digraph {\n a [label=\"oops]\n}", line: 2},
+		{name: "missing brace", src: "digraph { a -> b", line: 1},
+		{name: "empty graph", src: "digraph { }", line: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := RenderDOT(tt.src)
+			require.Error(t, err)
+
+			var diagramErr *Error
+			require.True(t, errors.As(err, &diagramErr))
+			assert.Equal(t, "dot", diagramErr.Language)
+			assert.Equal(t, tt.line, diagramErr.Line)
+		})
+	}
+}
+
+func TestRenderSequence(t *testing.T) {
+	src := `Title: Login
+participant Browser as B
+B->Server: POST /login
+Note right of Server: checks password
+Server-->>B: 200 OK
+Note over B,Server: session established`
+
+	svg, err := RenderSequence(src)
+	require.NoError(t, err)
+	assertWellFormed(t, svg)
+
+	assert.Contains(t, svg, `class="diagram diagram-sequence"`)
+	assert.Contains(t, svg, ">Login</text>")
+	assert.Contains(t, svg, ">Browser</text>")
+	assert.Contains(t, svg, ">POST /login</text>")
+	assert.Contains(t, svg, "arrow-open")
+	assert.Contains(t, svg, `stroke-dasharray="6,4"`)
+	assert.Equal(t, 2, strings.Count(svg, `fill="#fff8c4"`), "one box per note")
+}
+
+func TestRenderSequence_Errors(t *testing.T) {
+	_, err := RenderSequence("A->B: hi\nthis is not a message")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+
+	_, err = RenderSequence("Note left of A,B: too many")
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	assert.True(t, IsDiagram("dot"))
+	assert.True(t, IsDiagram("Graphviz"))
+	assert.True(t, IsDiagram("sequence"))
+	assert.True(t, IsDiagram("mermaid"))
+	assert.False(t, IsDiagram("go"))
+
+	_, err := Render("graphviz", "digraph { a -> b }")
+	assert.NoError(t, err)
+
+	_, err = Render("mermaid", "graph TD; A-->B")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported")
+}
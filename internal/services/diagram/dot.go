@@ -0,0 +1,451 @@
+package diagram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// graph is a parsed DOT graph
+type graph struct {
+	directed bool
+	attrs    map[string]string
+	nodes    []*node
+	index    map[string]*node
+	edges    []*edge
+}
+
+type node struct {
+	id    string
+	attrs map[string]string
+
+	// Layout results
+	rank  int
+	order float64
+	x, y  float64
+	w, h  float64
+}
+
+type edge struct {
+	from, to *node
+	attrs    map[string]string
+}
+
+func (n *node) label() string {
+	if label, ok := n.attrs["label"]; ok {
+		return unescapeLabel(label)
+	}
+	return n.id
+}
+
+// unescapeLabel turns DOT's \n, \l and \r line breaks into newlines
+func unescapeLabel(s string) string {
+	r := strings.NewReplacer(`\n`, "\n", `\l`, "\n", `\r`, "\n")
+	return strings.TrimRight(r.Replace(s), "\n")
+}
+
+// RenderDOT renders a Graphviz DOT graph to SVG using a layered layout
+func RenderDOT(src string) (string, error) {
+	g, err := parseDOT(src)
+	if err != nil {
+		return "", err
+	}
+	if len(g.nodes) == 0 {
+		return "", &Error{Language: "dot", Message: "graph has no nodes"}
+	}
+	layoutGraph(g)
+	return drawGraph(g, newSVGWriter(src)), nil
+}
+
+type dotTokenKind int
+
+const (
+	dotID dotTokenKind = iota
+	dotPunct
+	dotEOF
+)
+
+type dotToken struct {
+	kind dotTokenKind
+	text string
+	line int
+}
+
+// lexDOT splits DOT source into identifiers, quoted strings and punctuation,
+// dropping comments
+func lexDOT(src string) ([]dotToken, error) {
+	var tokens []dotToken
+	runes := []rune(src)
+	line := 1
+	atLineStart := true
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			line++
+			atLineStart = true
+			continue
+		case unicode.IsSpace(r):
+			continue
+		case r == '#' && atLineStart:
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			i--
+			continue
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			i--
+			continue
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			j := i + 2
+			for j+1 < len(runes) && !(runes[j] == '*' && runes[j+1] == '/') {
+				if runes[j] == '\n' {
+					line++
+				}
+				j++
+			}
+			if j+1 >= len(runes) {
+				return nil, &Error{Language: "dot", Line: line, Message: "unterminated comment"}
+			}
+			i = j + 1
+			continue
+		}
+		atLineStart = false
+
+		switch {
+		case r == '"':
+			var b strings.Builder
+			start := line
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '"'; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) && runes[j+1] == '"' {
+					j++
+				}
+				if runes[j] == '\n' {
+					line++
+				}
+				b.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, &Error{Language: "dot", Line: start, Message: "unterminated string"}
+			}
+			tokens = append(tokens, dotToken{kind: dotID, text: b.String(), line: start})
+			i = j
+		case r == '<':
+			// HTML-like labels are shown as their text content
+			depth, j := 1, i+1
+			for ; j < len(runes) && depth > 0; j++ {
+				switch runes[j] {
+				case '<':
+					depth++
+				case '>':
+					depth--
+				case '\n':
+					line++
+				}
+			}
+			if depth > 0 {
+				return nil, &Error{Language: "dot", Line: line, Message: "unterminated HTML label"}
+			}
+			tokens = append(tokens, dotToken{kind: dotID, text: stripTags(string(runes[i+1 : j-1])), line: line})
+			i = j - 1
+		case r == '-' && i+1 < len(runes) && (runes[i+1] == '>' || runes[i+1] == '-'):
+			tokens = append(tokens, dotToken{kind: dotPunct, text: string(runes[i : i+2]), line: line})
+			i++
+		case strings.ContainsRune("{}[];,=:", r):
+			tokens = append(tokens, dotToken{kind: dotPunct, text: string(r), line: line})
+		case r == '_' || r == '.' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && (runes[j] == '_' || runes[j] == '.' || unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) ||
+				(j == i && runes[j] == '-')) {
+				j++
+			}
+			tokens = append(tokens, dotToken{kind: dotID, text: string(runes[i:j]), line: line})
+			i = j - 1
+		default:
+			return nil, &Error{Language: "dot", Line: line, Message: "unexpected character " + string(r)}
+		}
+	}
+	return append(tokens, dotToken{kind: dotEOF, line: line}), nil
+}
+
+func stripTags(s string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>':
+			inTag = false
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// dotParser builds a graph from DOT tokens. Subgraphs are flattened into the
+// parent graph, but their attribute defaults stay scoped to them.
+type dotParser struct {
+	tokens []dotToken
+	pos    int
+	g      *graph
+}
+
+type dotScope struct {
+	node map[string]string
+	edge map[string]string
+}
+
+func (s dotScope) clone() dotScope {
+	return dotScope{node: copyAttrs(s.node), edge: copyAttrs(s.edge)}
+}
+
+func copyAttrs(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func parseDOT(src string) (*graph, error) {
+	tokens, err := lexDOT(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &dotParser{tokens: tokens, g: &graph{attrs: map[string]string{}, index: map[string]*node{}}}
+
+	if p.keyword("strict") {
+		p.pos++
+	}
+	switch {
+	case p.keyword("digraph"):
+		p.g.directed = true
+	case p.keyword("graph"):
+	default:
+		return nil, p.errorf("expected graph or digraph")
+	}
+	p.pos++
+	if p.peek().kind == dotID {
+		p.pos++
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	if _, err := p.parseStatements(dotScope{node: map[string]string{}, edge: map[string]string{}}); err != nil {
+		return nil, err
+	}
+	if p.peek().kind != dotEOF {
+		return nil, p.errorf("unexpected content after closing brace")
+	}
+	return p.g, nil
+}
+
+func (p *dotParser) peek() dotToken {
+	return p.tokens[p.pos]
+}
+
+func (p *dotParser) keyword(word string) bool {
+	t := p.peek()
+	return t.kind == dotID && strings.EqualFold(t.text, word)
+}
+
+func (p *dotParser) punct(text string) bool {
+	t := p.peek()
+	return t.kind == dotPunct && t.text == text
+}
+
+func (p *dotParser) expect(text string) error {
+	if !p.punct(text) {
+		return p.errorf("expected %q", text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *dotParser) errorf(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if t := p.peek(); t.kind != dotEOF {
+		msg += " near " + strconv.Quote(t.text)
+	} else {
+		msg += " at end of input"
+	}
+	return &Error{Language: "dot", Line: p.peek().line, Message: msg}
+}
+
+// parseStatements parses statements up to and including the closing brace
+// and returns the nodes declared in this block
+func (p *dotParser) parseStatements(scope dotScope) ([]*node, error) {
+	var members []*node
+	for {
+		switch {
+		case p.peek().kind == dotEOF:
+			return nil, p.errorf("missing closing brace")
+		case p.punct("}"):
+			p.pos++
+			return members, nil
+		case p.punct(";") || p.punct(","):
+			p.pos++
+			continue
+		}
+
+		nodes, err := p.parseStatement(&scope)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, nodes...)
+	}
+}
+
+func (p *dotParser) parseStatement(scope *dotScope) ([]*node, error) {
+	switch {
+	case p.keyword("node") || p.keyword("edge") || p.keyword("graph"):
+		kind := strings.ToLower(p.peek().text)
+		p.pos++
+		attrs, err := p.parseAttrList()
+		if err != nil {
+			return nil, err
+		}
+		target := p.g.attrs
+		if kind == "node" {
+			target = scope.node
+		} else if kind == "edge" {
+			target = scope.edge
+		}
+		for k, v := range attrs {
+			target[k] = v
+		}
+		return nil, nil
+	}
+
+	if p.peek().kind == dotID && p.tokens[p.pos+1].kind == dotPunct && p.tokens[p.pos+1].text == "=" {
+		key := p.peek().text
+		p.pos += 2
+		if p.peek().kind != dotID {
+			return nil, p.errorf("expected value for %s", key)
+		}
+		p.g.attrs[key] = p.peek().text
+		p.pos++
+		return nil, nil
+	}
+
+	operand, err := p.parseOperand(*scope)
+	if err != nil {
+		return nil, err
+	}
+	all := append([]*node(nil), operand...)
+	chain := [][]*node{operand}
+	for p.punct("->") || p.punct("--") {
+		if p.punct("->") != p.g.directed {
+			return nil, p.errorf("edge operator does not match graph type")
+		}
+		p.pos++
+		next, err := p.parseOperand(*scope)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, next)
+		all = append(all, next...)
+	}
+
+	attrs, err := p.parseAttrList()
+	if err != nil {
+		return nil, err
+	}
+	if len(chain) == 1 {
+		// A plain node statement applies the attributes to the node
+		for _, n := range operand {
+			for k, v := range attrs {
+				n.attrs[k] = v
+			}
+		}
+		return all, nil
+	}
+	for i := 0; i+1 < len(chain); i++ {
+		for _, from := range chain[i] {
+			for _, to := range chain[i+1] {
+				e := &edge{from: from, to: to, attrs: copyAttrs(scope.edge)}
+				for k, v := range attrs {
+					e.attrs[k] = v
+				}
+				p.g.edges = append(p.g.edges, e)
+			}
+		}
+	}
+	return all, nil
+}
+
+// parseOperand parses a node ID or a subgraph used as an edge endpoint
+func (p *dotParser) parseOperand(scope dotScope) ([]*node, error) {
+	if p.keyword("subgraph") || p.punct("{") {
+		if p.keyword("subgraph") {
+			p.pos++
+			if p.peek().kind == dotID {
+				p.pos++
+			}
+		}
+		if err := p.expect("{"); err != nil {
+			return nil, err
+		}
+		return p.parseStatements(scope.clone())
+	}
+
+	if p.peek().kind != dotID {
+		return nil, p.errorf("expected node name")
+	}
+	id := p.peek().text
+	p.pos++
+	// Ports (node:port:compass) do not affect this layout
+	for p.punct(":") {
+		p.pos += 2
+	}
+	return []*node{p.node(id, scope)}, nil
+}
+
+// node returns the node with the given ID, creating it with the scope's
+// default attributes on first use
+func (p *dotParser) node(id string, scope dotScope) *node {
+	if n, ok := p.g.index[id]; ok {
+		return n
+	}
+	n := &node{id: id, attrs: copyAttrs(scope.node)}
+	p.g.index[id] = n
+	p.g.nodes = append(p.g.nodes, n)
+	return n
+}
+
+// parseAttrList parses zero or more [k=v, ...] lists
+func (p *dotParser) parseAttrList() (map[string]string, error) {
+	attrs := map[string]string{}
+	for p.punct("[") {
+		p.pos++
+		for !p.punct("]") {
+			if p.peek().kind != dotID {
+				return nil, p.errorf("expected attribute name")
+			}
+			key := strings.ToLower(p.peek().text)
+			p.pos++
+			value := "true"
+			if p.punct("=") {
+				p.pos++
+				if p.peek().kind != dotID {
+					return nil, p.errorf("expected value for %s", key)
+				}
+				value = p.peek().text
+				p.pos++
+			}
+			attrs[key] = value
+			if p.punct(",") || p.punct(";") {
+				p.pos++
+			}
+		}
+		p.pos++
+	}
+	return attrs, nil
+}
@@ -0,0 +1,352 @@
+package diagram
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	nodePaddingX = 16.0
+	nodePaddingY = 10.0
+	minNodeWidth = 54.0
+	rankGap      = 56.0
+	nodeGap      = 28.0
+	margin       = 16.0
+)
+
+// layoutGraph assigns positions to the nodes of g with a simplified
+// Sugiyama-style layered layout: cycles are broken, nodes are ranked by
+// longest path, ordered within ranks by barycenter sweeps to reduce
+// crossings, and finally centred rank by rank.
+func layoutGraph(g *graph) {
+	for _, n := range g.nodes {
+		label := n.label()
+		n.w = math.Max(minNodeWidth, textWidth(label)+2*nodePaddingX)
+		n.h = textHeight(label) + 2*nodePaddingY
+		switch shape(n) {
+		case "circle", "doublecircle":
+			d := math.Max(n.w, n.h)
+			n.w, n.h = d, d
+		case "diamond":
+			n.w *= 1.5
+			n.h *= 1.5
+		case "ellipse", "oval":
+			n.w *= 1.2
+		}
+	}
+
+	horizontal := isHorizontal(g)
+	if horizontal {
+		// Lay out top-to-bottom with swapped extents, then rotate
+		for _, n := range g.nodes {
+			n.w, n.h = n.h, n.w
+		}
+	}
+
+	assignRanks(g)
+	ranks := orderRanks(g)
+	positionRanks(ranks)
+
+	if horizontal {
+		for _, n := range g.nodes {
+			n.x, n.y = n.y, n.x
+			n.w, n.h = n.h, n.w
+		}
+	}
+}
+
+func isHorizontal(g *graph) bool {
+	dir := strings.ToUpper(g.attrs["rankdir"])
+	return dir == "LR" || dir == "RL"
+}
+
+func shape(n *node) string {
+	if s, ok := n.attrs["shape"]; ok {
+		return strings.ToLower(s)
+	}
+	return "ellipse"
+}
+
+// assignRanks gives every node a rank equal to the longest path reaching it,
+// ignoring edges that close a cycle
+func assignRanks(g *graph) {
+	index := make(map[*node]int, len(g.nodes))
+	for i, n := range g.nodes {
+		index[n] = i
+	}
+	out := make([][]*node, len(g.nodes))
+	for _, e := range g.edges {
+		if e.from != e.to {
+			out[index[e.from]] = append(out[index[e.from]], e.to)
+		}
+	}
+
+	// Depth-first search marks back edges so the remaining graph is acyclic
+	const (
+		unvisited = iota
+		active
+		finished
+	)
+	state := make([]int, len(g.nodes))
+	back := map[[2]int]bool{}
+	var visit func(i int)
+	visit = func(i int) {
+		state[i] = active
+		for _, to := range out[i] {
+			j := index[to]
+			switch state[j] {
+			case unvisited:
+				visit(j)
+			case active:
+				back[[2]int{i, j}] = true
+			}
+		}
+		state[i] = finished
+	}
+	for i := range g.nodes {
+		if state[i] == unvisited {
+			visit(i)
+		}
+	}
+
+	// Longest path ranking over the acyclic edges, relaxing until stable
+	for _, n := range g.nodes {
+		n.rank = 0
+	}
+	for changed, rounds := true, 0; changed && rounds <= len(g.nodes); rounds++ {
+		changed = false
+		for i, n := range g.nodes {
+			for _, to := range out[i] {
+				if back[[2]int{i, index[to]}] {
+					continue
+				}
+				if to.rank < n.rank+1 {
+					to.rank = n.rank + 1
+					changed = true
+				}
+			}
+		}
+	}
+}
+
+// orderRanks groups nodes by rank and reorders each rank by the barycenter
+// of its neighbours, sweeping down and up a few times
+func orderRanks(g *graph) [][]*node {
+	maxRank := 0
+	for _, n := range g.nodes {
+		if n.rank > maxRank {
+			maxRank = n.rank
+		}
+	}
+	ranks := make([][]*node, maxRank+1)
+	for _, n := range g.nodes {
+		n.order = float64(len(ranks[n.rank]))
+		ranks[n.rank] = append(ranks[n.rank], n)
+	}
+
+	neighbours := map[*node][]*node{}
+	for _, e := range g.edges {
+		neighbours[e.from] = append(neighbours[e.from], e.to)
+		neighbours[e.to] = append(neighbours[e.to], e.from)
+	}
+
+	reorder := func(rank []*node, adjacentRank int) {
+		for _, n := range rank {
+			sum, count := 0.0, 0
+			for _, m := range neighbours[n] {
+				if m.rank == adjacentRank {
+					sum += m.order
+					count++
+				}
+			}
+			if count > 0 {
+				n.order = sum / float64(count)
+			}
+		}
+		sort.SliceStable(rank, func(i, j int) bool { return rank[i].order < rank[j].order })
+		for i, n := range rank {
+			n.order = float64(i)
+		}
+	}
+
+	for sweep := 0; sweep < 4; sweep++ {
+		for r := 1; r <= maxRank; r++ {
+			reorder(ranks[r], r-1)
+		}
+		for r := maxRank - 1; r >= 0; r-- {
+			reorder(ranks[r], r+1)
+		}
+	}
+	return ranks
+}
+
+// positionRanks places ranks top to bottom and centres each one
+// horizontally against the widest rank
+func positionRanks(ranks [][]*node) {
+	widths := make([]float64, len(ranks))
+	widest := 0.0
+	for r, rank := range ranks {
+		for i, n := range rank {
+			widths[r] += n.w
+			if i > 0 {
+				widths[r] += nodeGap
+			}
+		}
+		widest = math.Max(widest, widths[r])
+	}
+
+	y := margin
+	for r, rank := range ranks {
+		tallest := 0.0
+		for _, n := range rank {
+			tallest = math.Max(tallest, n.h)
+		}
+		x := margin + (widest-widths[r])/2
+		for _, n := range rank {
+			n.x = x + n.w/2
+			n.y = y + tallest/2
+			x += n.w + nodeGap
+		}
+		y += tallest + rankGap
+	}
+}
+
+// drawGraph writes the laid out graph as SVG
+func drawGraph(g *graph, w *svgWriter) string {
+	width, height := 0.0, 0.0
+	for _, n := range g.nodes {
+		width = math.Max(width, n.x+n.w/2+margin)
+		height = math.Max(height, n.y+n.h/2+margin)
+	}
+
+	title := g.attrs["label"]
+	if title != "" {
+		height += lineHeight + 8
+		width = math.Max(width, textWidth(title)+2*margin)
+	}
+
+	for _, e := range g.edges {
+		drawEdge(w, g, e)
+	}
+	for _, n := range g.nodes {
+		drawNode(w, n)
+	}
+	if title != "" {
+		w.text(width/2, height-margin-lineHeight/2, unescapeLabel(title), ` font-weight="bold"`)
+	}
+	return w.document("diagram-dot", width, height)
+}
+
+func drawNode(w *svgWriter, n *node) {
+	stroke := attr(n.attrs["color"], "#333")
+	fill := "#fff"
+	if strings.Contains(n.attrs["style"], "filled") {
+		fill = attr(n.attrs["fillcolor"], attr(n.attrs["color"], "#ddd"))
+		if _, hasFill := n.attrs["fillcolor"]; hasFill || n.attrs["color"] == "" {
+			stroke = "#333"
+		}
+	}
+	dash := ""
+	if strings.Contains(n.attrs["style"], "dashed") {
+		dash = ` stroke-dasharray="5,3"`
+	}
+	common := fmt.Sprintf(`fill="%s" stroke="%s"%s`, fill, stroke, dash)
+
+	switch shape(n) {
+	case "box", "rect", "rectangle", "square", "component", "note", "tab", "folder":
+		rx := 0.0
+		if strings.Contains(n.attrs["style"], "rounded") {
+			rx = 6
+		}
+		w.printf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="%.0f" %s/>`, n.x-n.w/2, n.y-n.h/2, n.w, n.h, rx, common)
+	case "circle", "doublecircle":
+		w.printf(`<circle cx="%.1f" cy="%.1f" r="%.1f" %s/>`, n.x, n.y, n.w/2, common)
+		if shape(n) == "doublecircle" {
+			w.printf(`<circle cx="%.1f" cy="%.1f" r="%.1f" fill="none" stroke="%s"/>`, n.x, n.y, n.w/2-4, stroke)
+		}
+	case "diamond":
+		w.printf(`<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f" %s/>`,
+			n.x, n.y-n.h/2, n.x+n.w/2, n.y, n.x, n.y+n.h/2, n.x-n.w/2, n.y, common)
+	case "cylinder":
+		ry := 6.0
+		w.printf(`<path d="M%.1f,%.1f a%.1f,%.1f 0 0,0 %.1f,0 v%.1f a%.1f,%.1f 0 0,1 -%.1f,0 z" %s/>`,
+			n.x-n.w/2, n.y-n.h/2+ry, n.w/2, ry, n.w, n.h-2*ry, n.w/2, ry, n.w, common)
+		w.printf(`<path d="M%.1f,%.1f a%.1f,%.1f 0 0,1 %.1f,0" fill="none" stroke="%s"/>`, n.x-n.w/2, n.y-n.h/2+ry, n.w/2, ry, n.w, stroke)
+	case "plaintext", "plain", "none":
+	default:
+		w.printf(`<ellipse cx="%.1f" cy="%.1f" rx="%.1f" ry="%.1f" %s/>`, n.x, n.y, n.w/2, n.h/2, common)
+	}
+
+	fontColor := ""
+	if c := n.attrs["fontcolor"]; c != "" {
+		fontColor = ` fill="` + attr(c, "") + `"`
+	}
+	w.text(n.x, n.y, n.label(), fontColor)
+}
+
+func drawEdge(w *svgWriter, g *graph, e *edge) {
+	stroke := attr(e.attrs["color"], "#333")
+	dash := ""
+	switch {
+	case strings.Contains(e.attrs["style"], "dashed"):
+		dash = ` stroke-dasharray="5,3"`
+	case strings.Contains(e.attrs["style"], "dotted"):
+		dash = ` stroke-dasharray="2,3"`
+	}
+
+	markers := ""
+	dir := e.attrs["dir"]
+	if dir == "" && g.directed {
+		dir = "forward"
+	}
+	if dir == "forward" || dir == "both" {
+		markers += ` marker-end="` + w.marker("arrow") + `"`
+	}
+	if dir == "back" || dir == "both" {
+		markers += ` marker-start="` + w.marker("arrow") + `"`
+	}
+
+	var lx, ly float64
+	if e.from == e.to {
+		// Self loops are drawn as a small arc on the right of the node
+		n := e.from
+		x, y := n.x+n.w/2, n.y
+		w.printf(`<path d="M%.1f,%.1f C%.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="none" stroke="%s"%s%s/>`,
+			x, y-6, x+30, y-24, x+30, y+24, x, y+6, stroke, dash, markers)
+		lx, ly = x+36, y
+	} else {
+		x1, y1 := boundaryPoint(e.from, e.to.x, e.to.y)
+		x2, y2 := boundaryPoint(e.to, e.from.x, e.from.y)
+		w.printf(`<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s"%s%s/>`, x1, y1, x2, y2, stroke, dash, markers)
+		lx, ly = (x1+x2)/2, (y1+y2)/2
+	}
+
+	if label := e.attrs["label"]; label != "" {
+		label = unescapeLabel(label)
+		tw, th := textWidth(label)+6, textHeight(label)
+		w.printf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="#fff" fill-opacity="0.85"/>`, lx-tw/2, ly-th/2, tw, th)
+		w.text(lx, ly, label, ` font-size="12"`)
+	}
+}
+
+// boundaryPoint returns where the line from the centre of n towards (tx, ty)
+// leaves the node's outline
+func boundaryPoint(n *node, tx, ty float64) (float64, float64) {
+	dx, dy := tx-n.x, ty-n.y
+	if dx == 0 && dy == 0 {
+		return n.x, n.y
+	}
+	hw, hh := n.w/2, n.h/2
+	var t float64
+	switch shape(n) {
+	case "box", "rect", "rectangle", "square", "component", "note", "tab", "folder", "cylinder", "plaintext", "plain", "none":
+		t = math.Min(hw/math.Abs(dx), hh/math.Abs(dy))
+	case "diamond":
+		t = 1 / (math.Abs(dx)/hw + math.Abs(dy)/hh)
+	default:
+		t = 1 / math.Sqrt(dx*dx/(hw*hw)+dy*dy/(hh*hh))
+	}
+	return n.x + dx*t, n.y + dy*t
+}
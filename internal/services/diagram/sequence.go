@@ -0,0 +1,273 @@
+package diagram
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+const (
+	participantGap    = 40.0
+	participantHeight = 36.0
+	messageGap        = 38.0
+	noteGap           = 10.0
+)
+
+type participant struct {
+	name  string
+	label string
+	x     float64
+	w     float64
+}
+
+type sequenceStep struct {
+	// Messages have a source and target; notes have a placement
+	from, to *participant
+	text     string
+	dashed   bool
+	open     bool
+
+	note      bool
+	placement string
+	over      []*participant
+}
+
+type sequence struct {
+	title        string
+	participants []*participant
+	index        map[string]*participant
+	steps        []sequenceStep
+}
+
+var (
+	seqTitle       = regexp.MustCompile(`^(?i)title\s*:\s*(.+)$`)
+	seqParticipant = regexp.MustCompile(`^(?i)participant\s+(.+?)(?:\s+as\s+(\S+))?$`)
+	seqNote        = regexp.MustCompile(`^(?i)note\s+(left of|right of|over)\s+([^:]+):\s*(.*)$`)
+	seqMessage     = regexp.MustCompile(`^(.+?)\s*(-->>|->>|-->|->)\s*(.+?)\s*:\s*(.*)$`)
+)
+
+// RenderSequence renders a sequence diagram written in the
+// js-sequence-diagrams syntax:
+//
+//	Title: Login
+//	participant Browser as B
+//	B->Server: POST /login
+//	Server-->B: 200 OK
+//	Note right of Server: checks password
+func RenderSequence(src string) (string, error) {
+	seq, err := parseSequence(src)
+	if err != nil {
+		return "", err
+	}
+	if len(seq.participants) == 0 {
+		return "", &Error{Language: "sequence", Message: "diagram has no participants"}
+	}
+	return drawSequence(seq, newSVGWriter(src)), nil
+}
+
+func parseSequence(src string) (*sequence, error) {
+	seq := &sequence{index: map[string]*participant{}}
+	for i, raw := range strings.Split(src, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if m := seqTitle.FindStringSubmatch(line); m != nil {
+			seq.title = unescapeLabel(m[1])
+			continue
+		}
+		if m := seqParticipant.FindStringSubmatch(line); m != nil {
+			label, alias := strings.Trim(m[1], `"`), m[2]
+			if alias == "" {
+				alias = label
+			}
+			seq.participant(alias).label = unescapeLabel(label)
+			continue
+		}
+		if m := seqNote.FindStringSubmatch(line); m != nil {
+			var over []*participant
+			for _, name := range strings.Split(m[2], ",") {
+				over = append(over, seq.participant(strings.TrimSpace(name)))
+			}
+			placement := strings.ToLower(m[1])
+			if placement != "over" && len(over) != 1 {
+				return nil, &Error{Language: "sequence", Line: i + 1, Message: "a note " + placement + " takes a single participant"}
+			}
+			seq.steps = append(seq.steps, sequenceStep{note: true, placement: placement, over: over, text: unescapeLabel(m[3])})
+			continue
+		}
+		if m := seqMessage.FindStringSubmatch(line); m != nil {
+			seq.steps = append(seq.steps, sequenceStep{
+				from:   seq.participant(m[1]),
+				to:     seq.participant(m[3]),
+				text:   unescapeLabel(m[4]),
+				dashed: strings.HasPrefix(m[2], "--"),
+				open:   strings.HasSuffix(m[2], ">>"),
+			})
+			continue
+		}
+		return nil, &Error{Language: "sequence", Line: i + 1, Message: "cannot parse " + `"` + line + `"`}
+	}
+	return seq, nil
+}
+
+// participant returns the participant with the given name, adding it in
+// order of first appearance
+func (s *sequence) participant(name string) *participant {
+	if p, ok := s.index[name]; ok {
+		return p
+	}
+	p := &participant{name: name, label: name}
+	s.index[name] = p
+	s.participants = append(s.participants, p)
+	return p
+}
+
+func drawSequence(seq *sequence, w *svgWriter) string {
+	// Size participant boxes, then spread them so every message label fits
+	// between its endpoints
+	for _, p := range seq.participants {
+		p.w = math.Max(minNodeWidth, textWidth(p.label)+2*nodePaddingX)
+	}
+	gaps := make([]float64, len(seq.participants))
+	position := map[*participant]int{}
+	for i, p := range seq.participants {
+		position[p] = i
+	}
+	for _, step := range seq.steps {
+		if step.note {
+			continue
+		}
+		a, b := position[step.from], position[step.to]
+		if a > b {
+			a, b = b, a
+		}
+		if a == b {
+			continue
+		}
+		need := (textWidth(step.text) + 2*nodePaddingX) / float64(b-a)
+		for i := a; i < b; i++ {
+			gaps[i] = math.Max(gaps[i], need)
+		}
+	}
+
+	// Notes to the left of the first participant, and notes or self messages
+	// to the right of the last one, need extra room at the edges
+	first, last := seq.participants[0], seq.participants[len(seq.participants)-1]
+	extraLeft, extraRight := 0.0, 0.0
+	for _, step := range seq.steps {
+		if !step.note {
+			if step.from == last && step.to == last {
+				extraRight = math.Max(extraRight, 36+textWidth(step.text)-last.w/2)
+			}
+			continue
+		}
+		need := textWidth(step.text) + 2*nodePaddingX + noteGap
+		if step.placement == "left of" && step.over[0] == first {
+			extraLeft = math.Max(extraLeft, need-first.w/2)
+		}
+		if step.placement == "right of" && step.over[0] == last {
+			extraRight = math.Max(extraRight, need-last.w/2)
+		}
+	}
+
+	for i, p := range seq.participants {
+		if i == 0 {
+			p.x = margin + extraLeft + p.w/2
+			continue
+		}
+		prev := seq.participants[i-1]
+		p.x = prev.x + math.Max(prev.w/2+participantGap+p.w/2, gaps[i-1])
+	}
+	width := last.x + last.w/2 + extraRight + margin
+
+	top := margin
+	if seq.title != "" {
+		top += lineHeight + 12
+	}
+
+	// Messages and notes are drawn after the lifelines so they sit on top
+	body := &svgWriter{id: w.id}
+	y := top + participantHeight + messageGap/2
+	for _, step := range seq.steps {
+		if step.note {
+			y += drawNote(body, step, y)
+			continue
+		}
+		y += drawMessage(body, step, y)
+	}
+	bottom := y + messageGap/2
+	height := bottom + participantHeight + margin
+
+	if seq.title != "" {
+		width = math.Max(width, textWidth(seq.title)+2*margin)
+		w.text(width/2, margin+lineHeight/2, seq.title, ` font-weight="bold"`)
+	}
+	for _, p := range seq.participants {
+		w.printf(`<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#999" stroke-dasharray="4,4"/>`, p.x, top+participantHeight, p.x, bottom)
+		for _, boxY := range []float64{top, bottom} {
+			w.printf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="4" fill="#fff" stroke="#333"/>`, p.x-p.w/2, boxY, p.w, participantHeight)
+			w.text(p.x, boxY+participantHeight/2, p.label, "")
+		}
+	}
+	w.b.WriteString(body.b.String())
+	return w.document("diagram-sequence", width, height)
+}
+
+// drawMessage draws an arrow between two lifelines and returns the vertical
+// space it used
+func drawMessage(w *svgWriter, step sequenceStep, y float64) float64 {
+	dash := ""
+	if step.dashed {
+		dash = ` stroke-dasharray="6,4"`
+	}
+	marker := w.marker("arrow")
+	if step.open {
+		marker = w.marker("arrow-open")
+	}
+
+	labelHeight := textHeight(step.text)
+	lineY := y + labelHeight
+	if step.from == step.to {
+		x := step.from.x
+		w.printf(`<path d="M%.1f,%.1f h30 v16 h-30" fill="none" stroke="#333"%s marker-end="%s"/>`, x, lineY, dash, marker)
+		w.printf(`<text x="%.1f" y="%.1f" dominant-baseline="central">%s</text>`, x+36, lineY+8, escapeText(step.text))
+		return labelHeight + 16 + messageGap
+	}
+
+	w.text((step.from.x+step.to.x)/2, y+labelHeight/2-2, step.text, ` font-size="13"`)
+	w.printf(`<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#333"%s marker-end="%s"/>`, step.from.x, lineY, step.to.x, lineY, dash, marker)
+	return labelHeight + messageGap
+}
+
+// drawNote draws a note box and returns the vertical space it used
+func drawNote(w *svgWriter, step sequenceStep, y float64) float64 {
+	width := textWidth(step.text) + 2*nodePaddingX
+	height := textHeight(step.text) + 2*noteGap
+
+	var left float64
+	anchor := step.over[0]
+	switch step.placement {
+	case "left of":
+		left = anchor.x - width - noteGap
+	case "right of":
+		left = anchor.x + noteGap
+	default:
+		minX, maxX := anchor.x, anchor.x
+		for _, p := range step.over {
+			minX, maxX = math.Min(minX, p.x), math.Max(maxX, p.x)
+		}
+		span := maxX - minX + 2*noteGap
+		width = math.Max(width, span)
+		left = (minX+maxX)/2 - width/2
+	}
+
+	w.printf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="#fff8c4" stroke="#c9b458"/>`, left, y, width, height)
+	w.text(left+width/2, y+height/2, step.text, "")
+	return height + messageGap/2
+}
+
+func escapeText(s string) string {
+	return attr(strings.ReplaceAll(s, "\n", " "), "")
+}
@@ -0,0 +1,85 @@
+package diagram
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	fontSize   = 14.0
+	fontFamily = "-apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif"
+	lineHeight = 18.0
+)
+
+// textWidth estimates the rendered width of a label. There is no font
+// metrics engine on the server, so an average glyph width is good enough for
+// sizing boxes around short labels.
+func textWidth(s string) float64 {
+	widest := 0
+	for _, line := range strings.Split(s, "\n") {
+		if n := utf8.RuneCountInString(line); n > widest {
+			widest = n
+		}
+	}
+	return float64(widest) * fontSize * 0.6
+}
+
+// textHeight returns the height of a possibly multi-line label
+func textHeight(s string) float64 {
+	return float64(strings.Count(s, "\n")+1) * lineHeight
+}
+
+// svgWriter accumulates SVG elements. Several diagrams can be inlined in the
+// same page, so element IDs are prefixed with a value derived from the source.
+type svgWriter struct {
+	b  strings.Builder
+	id string
+}
+
+func newSVGWriter(src string) *svgWriter {
+	sum := sha256.Sum256([]byte(src))
+	return &svgWriter{id: "dg" + hex.EncodeToString(sum[:4])}
+}
+
+// marker returns the url() reference for one of the arrowhead markers
+func (w *svgWriter) marker(name string) string {
+	return "url(#" + w.id + "-" + name + ")"
+}
+
+func (w *svgWriter) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&w.b, format, args...)
+}
+
+// text writes a centred, possibly multi-line label at (x, y)
+func (w *svgWriter) text(x, y float64, label, extra string) {
+	lines := strings.Split(label, "\n")
+	top := y - float64(len(lines)-1)*lineHeight/2
+	for i, line := range lines {
+		w.printf(`<text x="%.1f" y="%.1f" text-anchor="middle" dominant-baseline="central"%s>%s</text>`,
+			x, top+float64(i)*lineHeight, extra, html.EscapeString(line))
+	}
+}
+
+// document wraps the accumulated elements in an <svg> root of the given size
+func (w *svgWriter) document(class string, width, height float64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" class="diagram %s" role="img" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" font-family="%s" font-size="%.0f">`,
+		class, width, height, width, height, html.EscapeString(fontFamily), fontSize)
+	fmt.Fprintf(&b, `<defs><marker id="%s-arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10 z" fill="#333"/></marker>`, w.id)
+	fmt.Fprintf(&b, `<marker id="%s-arrow-open" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10" fill="none" stroke="#333"/></marker></defs>`, w.id)
+	b.WriteString(w.b.String())
+	b.WriteString("</svg>")
+	return b.String()
+}
+
+// attr returns an escaped attribute value, or the fallback when empty
+func attr(value, fallback string) string {
+	if value == "" {
+		value = fallback
+	}
+	return html.EscapeString(value)
+}
@@ -0,0 +1,606 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/russross/blackfriday/v2"
+)
+
+// DOCXExporter writes notes as an Office Open XML word processing document.
+// Each chapter-level heading starts a new page.
+type DOCXExporter struct {
+	markdown *markdown.Service
+	images   ImageLoader
+}
+
+// NewDOCXExporter creates a DOCX exporter. Images referenced by notes are
+// embedded using the loader, which may be nil.
+func NewDOCXExporter(markdown *markdown.Service, images ImageLoader) *DOCXExporter {
+	return &DOCXExporter{
+		markdown: markdown,
+		images:   images,
+	}
+}
+
+// ContentType returns the MIME type of DOCX files
+func (e *DOCXExporter) ContentType() string {
+	return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+}
+
+// FileExtension returns the extension of DOCX files
+func (e *DOCXExporter) FileExtension() string {
+	return ".docx"
+}
+
+// Page geometry: A4 with one inch margins, in twentieths of a point
+const (
+	docxPageWidth    = 11906
+	docxPageHeight   = 16838
+	docxMargin       = 1440
+	docxContentWidth = docxPageWidth - 2*docxMargin
+	// emuPerTwip converts twips to the EMUs DrawingML uses
+	emuPerTwip = 635
+	// emuPerPixel assumes images are 96 dpi
+	emuPerPixel = 9525
+)
+
+// docxRel is a relationship from the document part to a hyperlink or image
+type docxRel struct {
+	id       string
+	relType  string
+	target   string
+	external bool
+}
+
+// docxMedia is an image embedded in the package
+type docxMedia struct {
+	rel           string
+	file          string
+	data          []byte
+	width, height int
+}
+
+// docxNumbering is a list numbering instance; each ordered list gets its
+// own so numbering restarts at 1
+type docxNumbering struct {
+	id      int
+	ordered bool
+}
+
+// docxContext is the list and quote nesting a block is written in
+type docxContext struct {
+	// listLevel is -1 outside lists
+	listLevel int
+	numID     int
+	// numbered is set for the first paragraph of a list item
+	numbered bool
+	quote    int
+	callout  bool
+}
+
+// docxBuilder writes the body of document.xml while collecting the
+// relationships, media and numbering instances it refers to
+type docxBuilder struct {
+	doc       *markdown.Document
+	images    ImageLoader
+	body      strings.Builder
+	rels      []docxRel
+	media     []*docxMedia
+	mediaSrc  map[string]*docxMedia
+	numbering []docxNumbering
+	links     map[string]string
+
+	noteIndex    int
+	chapterLevel int
+	// pageStart is set until something is written on a new page
+	pageStart  bool
+	bookmarkID int
+	drawingID  int
+}
+
+var bookmarkUnsafe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// Export writes the notes to w as a single DOCX document
+func (e *DOCXExporter) Export(w io.Writer, notes []*models.Note) error {
+	if len(notes) == 0 {
+		return fmt.Errorf("no notes to export")
+	}
+
+	b := &docxBuilder{
+		images:   e.images,
+		mediaSrc: map[string]*docxMedia{},
+		links:    map[string]string{},
+		// The bullet list numbering instance is shared by all bullet lists
+		numbering: []docxNumbering{{id: 1}},
+	}
+	for i, note := range notes {
+		b.note(i, note, e.markdown.Parse(note.Content))
+	}
+	return b.write(w, documentTitle(notes))
+}
+
+func (b *docxBuilder) note(index int, note *models.Note, doc *markdown.Document) {
+	b.doc = doc
+	b.noteIndex = index
+	b.chapterLevel = 0
+	for node := doc.Root.FirstChild; node != nil; node = node.Next {
+		if node.Type == blackfriday.Heading && (b.chapterLevel == 0 || node.HeadingData.Level < b.chapterLevel) {
+			b.chapterLevel = node.HeadingData.Level
+		}
+	}
+
+	props := `<w:pStyle w:val="Title"/>`
+	if index > 0 {
+		props += `<w:pageBreakBefore/>`
+	}
+	b.paragraph(props, b.bookmark("")+docxRun("", note.Title))
+	b.pageStart = true
+
+	ctx := docxContext{listLevel: -1}
+	for node := doc.Root.FirstChild; node != nil; node = node.Next {
+		b.block(node, ctx)
+	}
+}
+
+func (b *docxBuilder) block(node *blackfriday.Node, ctx docxContext) {
+	switch node.Type {
+	case blackfriday.Paragraph:
+		b.paragraph(b.paragraphProps("", ctx), b.inline(node, ""))
+	case blackfriday.Heading:
+		level := node.HeadingData.Level
+		props := fmt.Sprintf(`<w:pStyle w:val="Heading%d"/>`, level)
+		if level == b.chapterLevel && !b.pageStart {
+			props += `<w:pageBreakBefore/>`
+		}
+		b.paragraph(props, b.bookmark(node.HeadingData.HeadingID)+b.inline(node, ""))
+	case blackfriday.List:
+		b.list(node, ctx)
+	case blackfriday.BlockQuote:
+		ctx.quote++
+		if callout, ok := b.doc.Callout(node); ok {
+			ctx.callout = true
+			b.paragraph(b.paragraphProps("CalloutTitle", ctx), docxRun("<w:b/>", callout.Title))
+		}
+		for child := node.FirstChild; child != nil; child = child.Next {
+			b.block(child, ctx)
+		}
+	case blackfriday.CodeBlock:
+		var runs strings.Builder
+		lines := strings.Split(strings.TrimSuffix(b.doc.Text(node.Literal), "\n"), "\n")
+		for i, line := range lines {
+			if i > 0 {
+				runs.WriteString("<w:r><w:br/></w:r>")
+			}
+			runs.WriteString(docxRun("", strings.ReplaceAll(line, "\t", "    ")))
+		}
+		b.paragraph(b.paragraphProps("Code", ctx), runs.String())
+	case blackfriday.Table:
+		b.table(node)
+	case blackfriday.HorizontalRule:
+		b.paragraph(`<w:pStyle w:val="HorizontalRule"/>`, "")
+	case blackfriday.HTMLBlock:
+		// Raw HTML has no Word equivalent and is left out
+		return
+	default:
+		for child := node.FirstChild; child != nil; child = child.Next {
+			b.block(child, ctx)
+		}
+	}
+	b.pageStart = false
+}
+
+// paragraphProps returns paragraph properties for a block in the given
+// list and quote context
+func (b *docxBuilder) paragraphProps(style string, ctx docxContext) string {
+	if style == "" && ctx.quote > 0 {
+		style = "Quote"
+		if ctx.callout {
+			style = "Callout"
+		}
+	}
+	props := ""
+	if style != "" {
+		props = `<w:pStyle w:val="` + style + `"/>`
+	}
+	if ctx.listLevel < 0 && ctx.quote <= 1 {
+		return props
+	}
+	if ctx.numbered {
+		props += fmt.Sprintf(`<w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`, ctx.listLevel, ctx.numID)
+	}
+	// Indent list content and nested quotes past their markers
+	indent := 360 * max(ctx.quote, 1)
+	if ctx.listLevel >= 0 {
+		indent += 720 * ctx.listLevel
+	}
+	if ctx.numbered {
+		props += fmt.Sprintf(`<w:ind w:left="%d" w:hanging="360"/>`, indent+360)
+	} else {
+		props += fmt.Sprintf(`<w:ind w:left="%d"/>`, indent+360)
+	}
+	return props
+}
+
+func (b *docxBuilder) list(node *blackfriday.Node, ctx docxContext) {
+	ctx.listLevel++
+	ctx.numID = 1
+	if node.ListData.ListFlags&blackfriday.ListTypeOrdered != 0 {
+		ctx.numID = len(b.numbering) + 1
+		b.numbering = append(b.numbering, docxNumbering{id: ctx.numID, ordered: true})
+	}
+	for item := node.FirstChild; item != nil; item = item.Next {
+		flags := item.ListData.ListFlags
+		if flags&blackfriday.ListTypeTerm != 0 {
+			b.paragraph("", b.inline(item, "<w:b/>"))
+			continue
+		}
+		itemCtx := ctx
+		itemCtx.numbered = flags&blackfriday.ListTypeDefinition == 0
+		for child := item.FirstChild; child != nil; child = child.Next {
+			if child.Type == blackfriday.List {
+				b.list(child, ctx)
+				continue
+			}
+			b.block(child, itemCtx)
+			itemCtx.numbered = false
+		}
+	}
+}
+
+func (b *docxBuilder) table(node *blackfriday.Node) {
+	var rows []*blackfriday.Node
+	columns := 0
+	node.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering && n.Type == blackfriday.TableRow {
+			rows = append(rows, n)
+			cells := 0
+			for cell := n.FirstChild; cell != nil; cell = cell.Next {
+				cells++
+			}
+			columns = max(columns, cells)
+			return blackfriday.SkipChildren
+		}
+		return blackfriday.GoToNext
+	})
+	if columns == 0 {
+		return
+	}
+
+	width := docxContentWidth / columns
+	b.body.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="5000" w:type="pct"/></w:tblPr><w:tblGrid>`)
+	for i := 0; i < columns; i++ {
+		fmt.Fprintf(&b.body, `<w:gridCol w:w="%d"/>`, width)
+	}
+	b.body.WriteString(`</w:tblGrid>`)
+	for _, row := range rows {
+		header := row.Parent.Type == blackfriday.TableHead
+		b.body.WriteString("<w:tr>")
+		if header {
+			b.body.WriteString(`<w:trPr><w:tblHeader/></w:trPr>`)
+		}
+		cells := 0
+		for cell := row.FirstChild; cell != nil; cell = cell.Next {
+			cells++
+			fmt.Fprintf(&b.body, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/>`, width)
+			if header {
+				b.body.WriteString(`<w:shd w:val="clear" w:color="auto" w:fill="EEEEEE"/>`)
+			}
+			b.body.WriteString("</w:tcPr>")
+			props := ""
+			switch cell.TableCellData.Align {
+			case blackfriday.TableAlignmentCenter:
+				props = `<w:jc w:val="center"/>`
+			case blackfriday.TableAlignmentRight:
+				props = `<w:jc w:val="right"/>`
+			}
+			runProps := ""
+			if header {
+				runProps = "<w:b/>"
+			}
+			b.paragraph(props, b.inline(cell, runProps))
+			b.body.WriteString("</w:tc>")
+		}
+		// Every row needs the same number of cells
+		for ; cells < columns; cells++ {
+			fmt.Fprintf(&b.body, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/></w:tcPr><w:p/></w:tc>`, width)
+		}
+		b.body.WriteString("</w:tr>")
+	}
+	b.body.WriteString("</w:tbl>")
+	// Keep a paragraph between consecutive tables so Word does not merge them
+	b.paragraph("", "")
+}
+
+// inline converts the inline children of a node to runs
+func (b *docxBuilder) inline(parent *blackfriday.Node, runProps string) string {
+	var out strings.Builder
+	for node := parent.FirstChild; node != nil; node = node.Next {
+		switch node.Type {
+		case blackfriday.Text:
+			out.WriteString(docxRun(runProps, strings.ReplaceAll(b.doc.Text(node.Literal), "\n", " ")))
+		case blackfriday.Code:
+			out.WriteString(docxRun(runProps+`<w:rStyle w:val="CodeChar"/>`, b.doc.Text(node.Literal)))
+		case blackfriday.Softbreak:
+			out.WriteString(docxRun(runProps, " "))
+		case blackfriday.Hardbreak:
+			out.WriteString("<w:r><w:br/></w:r>")
+		case blackfriday.Emph:
+			out.WriteString(b.inline(node, runProps+"<w:i/>"))
+		case blackfriday.Strong:
+			out.WriteString(b.inline(node, runProps+"<w:b/>"))
+		case blackfriday.Del:
+			out.WriteString(b.inline(node, runProps+"<w:strike/>"))
+		case blackfriday.Link:
+			dest := string(node.LinkData.Destination)
+			children := b.inline(node, `<w:rStyle w:val="Hyperlink"/>`+runProps)
+			if strings.HasPrefix(dest, "#") {
+				fmt.Fprintf(&out, `<w:hyperlink w:anchor="%s">%s</w:hyperlink>`, b.bookmarkName(dest[1:]), children)
+			} else {
+				fmt.Fprintf(&out, `<w:hyperlink r:id="%s">%s</w:hyperlink>`, b.hyperlinkRel(dest), children)
+			}
+		case blackfriday.Image:
+			out.WriteString(b.image(node))
+		case blackfriday.HTMLSpan:
+		default:
+			out.WriteString(b.inline(node, runProps))
+		}
+	}
+	return out.String()
+}
+
+// image embeds an image as an inline drawing scaled to the text width, or
+// falls back to its alt text
+func (b *docxBuilder) image(node *blackfriday.Node) string {
+	src := string(node.LinkData.Destination)
+	alt := b.doc.NodeText(node)
+	media, ok := b.mediaSrc[src]
+	if !ok {
+		media = b.loadMedia(src)
+		b.mediaSrc[src] = media
+	}
+	if media == nil {
+		return docxRun("<w:i/>", "["+alt+"]")
+	}
+
+	cx := int64(media.width) * emuPerPixel
+	cy := int64(media.height) * emuPerPixel
+	if maxWidth := int64(docxContentWidth) * emuPerTwip; cx > maxWidth {
+		cy = cy * maxWidth / cx
+		cx = maxWidth
+	}
+	b.drawingID++
+	return fmt.Sprintf(`<w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0"><wp:extent cx="%d" cy="%d"/>`+
+		`<wp:docPr id="%d" name="Picture %d" descr="%s"/>`+
+		`<a:graphic xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture">`+
+		`<pic:pic xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture"><pic:nvPicPr><pic:cNvPr id="%d" name="%s"/><pic:cNvPicPr/></pic:nvPicPr>`+
+		`<pic:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`+
+		`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr>`+
+		`</pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`,
+		cx, cy, b.drawingID, b.drawingID, html.EscapeString(alt), b.drawingID, media.file, media.rel, cx, cy)
+}
+
+// loadMedia loads an image Word can display, or returns nil
+func (b *docxBuilder) loadMedia(src string) *docxMedia {
+	data, err := loadImage(b.images, src)
+	if err != nil {
+		return nil
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || epubMedia[format] == "" {
+		return nil
+	}
+	media := &docxMedia{
+		rel:    b.addRel("http://schemas.openxmlformats.org/officeDocument/2006/relationships/image", "", false),
+		data:   data,
+		width:  config.Width,
+		height: config.Height,
+	}
+	media.file = fmt.Sprintf("image%d.%s", len(b.media)+1, format)
+	b.rels[len(b.rels)-1].target = "media/" + media.file
+	b.media = append(b.media, media)
+	return media
+}
+
+func (b *docxBuilder) hyperlinkRel(target string) string {
+	if id, ok := b.links[target]; ok {
+		return id
+	}
+	id := b.addRel("http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink", target, true)
+	b.links[target] = id
+	return id
+}
+
+func (b *docxBuilder) addRel(relType, target string, external bool) string {
+	// rId1 and rId2 are reserved for the styles and numbering parts
+	id := fmt.Sprintf("rId%d", len(b.rels)+3)
+	b.rels = append(b.rels, docxRel{id: id, relType: relType, target: target, external: external})
+	return id
+}
+
+// bookmark returns an empty bookmark marking a heading as a link target
+func (b *docxBuilder) bookmark(id string) string {
+	b.bookmarkID++
+	return fmt.Sprintf(`<w:bookmarkStart w:id="%d" w:name="%s"/><w:bookmarkEnd w:id="%d"/>`, b.bookmarkID, b.bookmarkName(id), b.bookmarkID)
+}
+
+// bookmarkName maps a heading anchor to a valid Word bookmark name, which
+// must be at most 40 characters of letters, digits and underscores.
+// Anchors are namespaced by note so several notes can share heading names.
+func (b *docxBuilder) bookmarkName(id string) string {
+	name := fmt.Sprintf("_n%d_%s", b.noteIndex, bookmarkUnsafe.ReplaceAllString(id, "_"))
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	return name
+}
+
+func (b *docxBuilder) paragraph(props, runs string) {
+	b.body.WriteString("<w:p>")
+	if props != "" {
+		b.body.WriteString("<w:pPr>" + props + "</w:pPr>")
+	}
+	b.body.WriteString(runs)
+	b.body.WriteString("</w:p>")
+}
+
+// docxRun returns a run of text with the given run properties
+func docxRun(props, text string) string {
+	if text == "" {
+		return ""
+	}
+	run := "<w:r>"
+	if props != "" {
+		run += "<w:rPr>" + props + "</w:rPr>"
+	}
+	return run + `<w:t xml:space="preserve">` + xmlText(text) + "</w:t></w:r>"
+}
+
+// xmlText escapes text for XML, dropping characters XML cannot represent
+func xmlText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, s)
+	return html.EscapeString(s)
+}
+
+func (b *docxBuilder) write(w io.Writer, title string) error {
+	document := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+		`xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"><w:body>` +
+		b.body.String() +
+		fmt.Sprintf(`<w:sectPr><w:pgSz w:w="%d" w:h="%d"/><w:pgMar w:top="%d" w:right="%d" w:bottom="%d" w:left="%d" w:header="708" w:footer="708" w:gutter="0"/></w:sectPr>`,
+			docxPageWidth, docxPageHeight, docxMargin, docxMargin, docxMargin, docxMargin) +
+		"</w:body></w:document>\n"
+
+	var rels strings.Builder
+	rels.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>
+`)
+	for _, rel := range b.rels {
+		mode := ""
+		if rel.external {
+			mode = ` TargetMode="External"`
+		}
+		fmt.Fprintf(&rels, "<Relationship Id=\"%s\" Type=\"%s\" Target=\"%s\"%s/>\n", rel.id, rel.relType, html.EscapeString(rel.target), mode)
+	}
+	rels.WriteString("</Relationships>\n")
+
+	core := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+<dc:title>` + xmlText(title) + `</dc:title>
+<dcterms:created xsi:type="dcterms:W3CDTF">` + time.Now().UTC().Format("2006-01-02T15:04:05Z") + `</dcterms:created>
+</cp:coreProperties>
+`
+
+	entries := []zipEntry{
+		{name: "[Content_Types].xml", data: []byte(docxContentTypes)},
+		{name: "_rels/.rels", data: []byte(docxPackageRels)},
+		{name: "docProps/core.xml", data: []byte(core)},
+		{name: "word/document.xml", data: []byte(document)},
+		{name: "word/_rels/document.xml.rels", data: []byte(rels.String())},
+		{name: "word/styles.xml", data: []byte(docxStyles)},
+		{name: "word/numbering.xml", data: []byte(b.numberingXML())},
+	}
+	for _, media := range b.media {
+		entries = append(entries, zipEntry{name: "word/media/" + media.file, data: media.data, store: true})
+	}
+	return writeZip(w, entries)
+}
+
+// numberingXML defines bullet and decimal list formats and one numbering
+// instance per list
+func (b *docxBuilder) numberingXML() string {
+	var out strings.Builder
+	out.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+`)
+	bullets := []string{"•", "◦", "▪"}
+	for abstract, ordered := range []bool{false, true} {
+		fmt.Fprintf(&out, `<w:abstractNum w:abstractNumId="%d"><w:multiLevelType w:val="hybridMultilevel"/>`, abstract)
+		for level := 0; level < 9; level++ {
+			format, text := "bullet", bullets[level%len(bullets)]
+			if ordered {
+				format, text = "decimal", fmt.Sprintf("%%%d.", level+1)
+			}
+			fmt.Fprintf(&out, `<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="%s"/><w:lvlText w:val="%s"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`,
+				level, format, text, 720*(level+1))
+		}
+		out.WriteString("</w:abstractNum>\n")
+	}
+	for _, num := range b.numbering {
+		abstract := 0
+		if num.ordered {
+			abstract = 1
+		}
+		fmt.Fprintf(&out, `<w:num w:numId="%d"><w:abstractNumId w:val="%d"/>`, num.id, abstract)
+		if num.ordered {
+			for level := 0; level < 9; level++ {
+				fmt.Fprintf(&out, `<w:lvlOverride w:ilvl="%d"><w:startOverride w:val="1"/></w:lvlOverride>`, level)
+			}
+		}
+		out.WriteString("</w:num>\n")
+	}
+	out.WriteString("</w:numbering>\n")
+	return out.String()
+}
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Default Extension="png" ContentType="image/png"/>
+<Default Extension="jpeg" ContentType="image/jpeg"/>
+<Default Extension="gif" ContentType="image/gif"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>
+<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+</Types>
+`
+
+const docxPackageRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+</Relationships>
+`
+
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults>
+<w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="Calibri" w:cs="Calibri"/><w:sz w:val="22"/><w:szCs w:val="22"/><w:lang w:val="en-US"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="160" w:line="264" w:lineRule="auto"/></w:pPr></w:pPrDefault>
+</w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>
+<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="4" w:color="CCCCCC"/></w:pBdr><w:spacing w:after="240"/></w:pPr><w:rPr><w:b/><w:sz w:val="48"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="360" w:after="120"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="36"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="300" w:after="100"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="30"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="80"/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:b/><w:sz w:val="26"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading4"><w:name w:val="heading 4"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="200" w:after="80"/><w:outlineLvl w:val="3"/></w:pPr><w:rPr><w:b/><w:sz w:val="24"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading5"><w:name w:val="heading 5"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="200" w:after="80"/><w:outlineLvl w:val="4"/></w:pPr><w:rPr><w:b/><w:sz w:val="22"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading6"><w:name w:val="heading 6"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="200" w:after="80"/><w:outlineLvl w:val="5"/></w:pPr><w:rPr><w:b/><w:i/><w:sz w:val="22"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:pBdr><w:left w:val="single" w:sz="18" w:space="8" w:color="CCCCCC"/></w:pBdr><w:ind w:left="360"/></w:pPr><w:rPr><w:color w:val="555555"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Callout"><w:name w:val="Callout"/><w:basedOn w:val="Normal"/><w:pPr><w:pBdr><w:left w:val="single" w:sz="24" w:space="8" w:color="0969DA"/></w:pBdr><w:ind w:left="360"/></w:pPr></w:style>
+<w:style w:type="paragraph" w:styleId="CalloutTitle"><w:name w:val="Callout Title"/><w:basedOn w:val="Callout"/><w:next w:val="Callout"/><w:pPr><w:keepNext/><w:spacing w:after="60"/></w:pPr><w:rPr><w:color w:val="0969DA"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="Code"/><w:basedOn w:val="Normal"/><w:pPr><w:shd w:val="clear" w:color="auto" w:fill="F4F4F4"/><w:spacing w:after="160" w:line="240" w:lineRule="auto"/></w:pPr><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:sz w:val="19"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="HorizontalRule"><w:name w:val="Horizontal Rule"/><w:basedOn w:val="Normal"/><w:pPr><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="CCCCCC"/></w:pBdr></w:pPr></w:style>
+<w:style w:type="character" w:styleId="CodeChar"><w:name w:val="Code Char"/><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:sz w:val="20"/><w:shd w:val="clear" w:color="auto" w:fill="F4F4F4"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="0563C1"/><w:u w:val="single"/></w:rPr></w:style>
+<w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:tblPr><w:tblBorders><w:top w:val="single" w:sz="4" w:color="BFBFBF"/><w:left w:val="single" w:sz="4" w:color="BFBFBF"/><w:bottom w:val="single" w:sz="4" w:color="BFBFBF"/><w:right w:val="single" w:sz="4" w:color="BFBFBF"/><w:insideH w:val="single" w:sz="4" w:color="BFBFBF"/><w:insideV w:val="single" w:sz="4" w:color="BFBFBF"/></w:tblBorders><w:tblCellMar><w:left w:w="108" w:type="dxa"/><w:right w:w="108" w:type="dxa"/></w:tblCellMar></w:tblPr><w:pPr><w:spacing w:after="0"/></w:pPr></w:style>
+</w:styles>
+`
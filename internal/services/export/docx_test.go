@@ -0,0 +1,108 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDOCXExporter_Export(t *testing.T) {
+	content := `# Overview
+
+Some **bold**, *italic*, ~~struck~~ and ` + "`code`" + ` text with a [link](https://example.com?a=1&b=2)
+and a [jump](#details).
+
+- first
+  1. nested
+  2. ordered
+
+1. one
+2. two
+
+> [!WARNING] Careful
+> Callout body.
+
+| Name | Value |
+|------|------:|
+| a    | 1     |
+
+` + "```\nline one\n\tline two\n```" + `
+
+![chart](chart.png)
+
+![missing](missing.png)
+
+---
+
+# Details
+
+Final <b>paragraph</b>.
+`
+	images := func(src string) ([]byte, error) {
+		if src == "chart.png" {
+			return testPNG(t, 2000, 1000), nil
+		}
+		return nil, fmt.Errorf("not found")
+	}
+	exporter := NewDOCXExporter(&markdown.Service{}, images)
+	assert.Equal(t, ".docx", exporter.FileExtension())
+
+	var buf bytes.Buffer
+	notes := []*models.Note{
+		{Title: "My Note", Content: content},
+		{Title: "Second Note", Content: "# Overview\n\nMore."},
+	}
+	require.NoError(t, exporter.Export(&buf, notes))
+	_, files := readZip(t, buf.Bytes())
+
+	for _, part := range []string{
+		"[Content_Types].xml", "_rels/.rels", "docProps/core.xml", "word/document.xml",
+		"word/_rels/document.xml.rels", "word/styles.xml", "word/numbering.xml", "word/media/image1.png",
+	} {
+		assert.Contains(t, files, part)
+	}
+
+	doc := files["word/document.xml"]
+	assert.Contains(t, doc, `<w:pStyle w:val="Title"/>`)
+	assert.Contains(t, doc, `<w:t xml:space="preserve">My Note</w:t>`)
+	assert.Contains(t, doc, `<w:rPr><w:b/></w:rPr><w:t xml:space="preserve">bold</w:t>`)
+	assert.Contains(t, doc, `<w:rPr><w:i/></w:rPr><w:t xml:space="preserve">italic</w:t>`)
+	assert.Contains(t, doc, `<w:strike/>`)
+	assert.Contains(t, doc, `<w:rStyle w:val="CodeChar"/>`)
+	assert.Contains(t, doc, `<w:hyperlink w:anchor="_n0_details">`)
+	assert.Contains(t, doc, `w:name="_n0_details"`)
+	assert.Contains(t, doc, `w:name="_n1_overview"`, "bookmarks are unique per note")
+	assert.Contains(t, doc, `<w:tblHeader/>`)
+	assert.Contains(t, doc, `<w:jc w:val="right"/>`)
+	assert.Contains(t, doc, `line one</w:t></w:r><w:r><w:br/></w:r>`)
+	assert.Contains(t, doc, "    line two", "tabs are expanded")
+	assert.Contains(t, doc, `<w:pStyle w:val="CalloutTitle"/>`)
+	assert.Contains(t, doc, `<w:pStyle w:val="HorizontalRule"/>`)
+	assert.Contains(t, doc, "[missing]", "unavailable images fall back to alt text")
+	assert.NotContains(t, doc, "<b>")
+	assert.Contains(t, doc, `<wp:extent cx="5731510" cy="2865755"/>`, "wide images are scaled to the text width")
+
+	// Only the second chapter of the first note and the second note start new pages
+	assert.Equal(t, 2, strings.Count(doc, "<w:pageBreakBefore/>"))
+
+	rels := files["word/_rels/document.xml.rels"]
+	assert.Contains(t, rels, `Target="https://example.com?a=1&amp;b=2" TargetMode="External"`)
+	assert.Contains(t, rels, `Target="media/image1.png"`)
+
+	numbering := files["word/numbering.xml"]
+	assert.Contains(t, numbering, `<w:lvlText w:val="%1."/>`)
+	// Bullets plus one instance for each of the two ordered lists
+	assert.Equal(t, 3, strings.Count(numbering, "<w:num "))
+	assert.Contains(t, files["docProps/core.xml"], "<dc:title>My Note and 1 more</dc:title>")
+}
+
+func TestDOCXExporter_NoNotes(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Error(t, NewDOCXExporter(&markdown.Service{}, nil).Export(&buf, nil))
+}
@@ -0,0 +1,311 @@
+package export
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"html"
+	"image"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/google/uuid"
+)
+
+// EPUBExporter writes notes as an EPUB 3 e-book with one chapter per
+// top-level heading
+type EPUBExporter struct {
+	markdown *markdown.Service
+	images   ImageLoader
+}
+
+// NewEPUBExporter creates an EPUB exporter. Images referenced by notes are
+// packaged into the book using the loader, which may be nil.
+func NewEPUBExporter(markdown *markdown.Service, images ImageLoader) *EPUBExporter {
+	return &EPUBExporter{
+		markdown: markdown,
+		images:   images,
+	}
+}
+
+// ContentType returns the MIME type of EPUB files
+func (e *EPUBExporter) ContentType() string {
+	return "application/epub+zip"
+}
+
+// FileExtension returns the extension of EPUB files
+func (e *EPUBExporter) FileExtension() string {
+	return ".epub"
+}
+
+// epubChapter is one XHTML content document of the book
+type epubChapter struct {
+	file  string
+	title string
+	body  string
+	// note is the index of the note the chapter came from
+	note int
+}
+
+// epubImage is an image packaged into the book
+type epubImage struct {
+	file      string
+	mediaType string
+	data      []byte
+}
+
+var (
+	epubHeading = regexp.MustCompile(`<h[1-6] id="([^"]+)"`)
+	epubHref    = regexp.MustCompile(`href="#([^"]*)"`)
+	epubImg     = regexp.MustCompile(`<img src="([^"]*)" alt="([^"]*)"[^>]*/>`)
+	epubMedia   = map[string]string{"png": "image/png", "jpeg": "image/jpeg", "gif": "image/gif"}
+)
+
+// Export writes the notes to w as a single EPUB. Each note is split into
+// chapters at its highest-ranking headings.
+func (e *EPUBExporter) Export(w io.Writer, notes []*models.Note) error {
+	if len(notes) == 0 {
+		return fmt.Errorf("no notes to export")
+	}
+
+	var chapters []*epubChapter
+	// anchors maps heading IDs of each note to the chapter file holding them
+	anchors := make([]map[string]string, len(notes))
+	for i, note := range notes {
+		doc := e.markdown.Parse(note.Content)
+		anchors[i] = map[string]string{}
+		noteChapters := doc.Chapters()
+		if len(noteChapters) == 0 {
+			noteChapters = []markdown.Chapter{{}}
+		}
+		for j, chapter := range noteChapters {
+			ch := &epubChapter{
+				file:  fmt.Sprintf("chapter-%03d.xhtml", len(chapters)+1),
+				title: chapter.Title,
+				note:  i,
+			}
+			var body strings.Builder
+			if j == 0 && ch.title != note.Title {
+				// The first chapter of each note opens with the note title
+				fmt.Fprintf(&body, "<h1 class=\"note-title\">%s</h1>\n", html.EscapeString(note.Title))
+				if ch.title == "" {
+					ch.title = note.Title
+				}
+			}
+			body.WriteString(e.markdown.ToXHTML(doc, chapter.Nodes))
+			ch.body = body.String()
+			for _, m := range epubHeading.FindAllStringSubmatch(ch.body, -1) {
+				anchors[i][m[1]] = ch.file
+			}
+			chapters = append(chapters, ch)
+		}
+	}
+
+	// Point in-note links at the chapter holding the heading and package
+	// images into the book
+	var images []*epubImage
+	imageFiles := map[string]*epubImage{}
+	for _, ch := range chapters {
+		ch.body = epubHref.ReplaceAllStringFunc(ch.body, func(m string) string {
+			id := html.UnescapeString(epubHref.FindStringSubmatch(m)[1])
+			if file, ok := anchors[ch.note][id]; ok {
+				return `href="` + html.EscapeString(file+"#"+id) + `"`
+			}
+			return m
+		})
+		ch.body = epubImg.ReplaceAllStringFunc(ch.body, func(m string) string {
+			parts := epubImg.FindStringSubmatch(m)
+			src := html.UnescapeString(parts[1])
+			img, ok := imageFiles[src]
+			if !ok {
+				img = e.packageImage(src, len(images)+1)
+				imageFiles[src] = img
+				if img != nil {
+					images = append(images, img)
+				}
+			}
+			if img == nil {
+				return `<span class="missing-image">[` + parts[2] + `]</span>`
+			}
+			return `<img src="` + img.file + `" alt="` + parts[2] + `" />`
+		})
+	}
+
+	return writeEPUB(w, documentTitle(notes), bookID(notes), notes, chapters, images)
+}
+
+// packageImage loads an image for the book, or returns nil when it cannot be
+// loaded or is not a format e-readers are required to support
+func (e *EPUBExporter) packageImage(src string, n int) *epubImage {
+	data, err := loadImage(e.images, src)
+	if err != nil {
+		return nil
+	}
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	mediaType, ok := epubMedia[format]
+	if !ok {
+		return nil
+	}
+	ext := format
+	if ext == "jpeg" {
+		ext = "jpg"
+	}
+	return &epubImage{file: fmt.Sprintf("images/image-%03d.%s", n, ext), mediaType: mediaType, data: data}
+}
+
+// bookID derives a stable identifier from the exported note IDs, so
+// exporting the same notes again updates the book in e-reader libraries
+func bookID(notes []*models.Note) string {
+	ids := make([]string, len(notes))
+	for i, note := range notes {
+		ids[i] = note.ID
+	}
+	return "urn:uuid:" + uuid.NewHash(sha1.New(), uuid.NameSpaceURL, []byte(strings.Join(ids, "\n")), 5).String()
+}
+
+const epubCSS = `body { font-family: serif; line-height: 1.5; }
+h1, h2, h3, h4, h5, h6 { font-family: sans-serif; line-height: 1.2; }
+h1.note-title { border-bottom: 1px solid #ccc; }
+pre, code { font-family: monospace; font-size: 0.9em; }
+pre { background: #f4f4f4; padding: 0.5em; white-space: pre-wrap; }
+blockquote { border-left: 3px solid #ccc; margin-left: 0; padding-left: 1em; color: #555; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.5em; }
+img { max-width: 100%; }
+.callout { border-left: 4px solid #0969da; padding: 0.25em 1em; margin: 1em 0; }
+.callout-title { font-weight: bold; }
+.callout-warning, .callout-question { border-color: #9a6700; }
+.callout-caution, .callout-failure, .callout-bug { border-color: #cf222e; }
+.callout-tip, .callout-success { border-color: #1a7f37; }
+.diagram-error { border: 1px solid #cf222e; padding: 0.5em; }
+`
+
+func writeEPUB(w io.Writer, title, id string, notes []*models.Note, chapters []*epubChapter, images []*epubImage) error {
+	// The mimetype entry must come first and be stored uncompressed
+	files := []zipEntry{
+		{name: "mimetype", data: []byte("application/epub+zip"), store: true},
+		{name: "META-INF/container.xml", data: []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`)},
+		{name: "OEBPS/content.opf", data: []byte(epubPackage(title, id, chapters, images))},
+		{name: "OEBPS/nav.xhtml", data: []byte(epubNav(title, notes, chapters))},
+		{name: "OEBPS/toc.ncx", data: []byte(epubNCX(title, id, chapters))},
+		{name: "OEBPS/style.css", data: []byte(epubCSS)},
+	}
+	for _, ch := range chapters {
+		files = append(files, zipEntry{name: "OEBPS/" + ch.file, data: []byte(xhtmlDocument(ch.title, ch.body))})
+	}
+	for _, img := range images {
+		files = append(files, zipEntry{name: "OEBPS/" + img.file, data: img.data, store: true})
+	}
+	return writeZip(w, files)
+}
+
+func xhtmlDocument(title, body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="en" xml:lang="en">
+<head>
+<meta charset="UTF-8" />
+<title>` + html.EscapeString(title) + `</title>
+<link rel="stylesheet" type="text/css" href="style.css" />
+</head>
+<body>
+` + body + `</body>
+</html>
+`
+}
+
+// epubPackage builds the package document listing the book's metadata,
+// files and reading order
+func epubPackage(title, id string, chapters []*epubChapter, images []*epubImage) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+`)
+	fmt.Fprintf(&b, "    <dc:identifier id=\"book-id\">%s</dc:identifier>\n", id)
+	fmt.Fprintf(&b, "    <dc:title>%s</dc:title>\n", html.EscapeString(title))
+	b.WriteString("    <dc:language>en</dc:language>\n")
+	fmt.Fprintf(&b, "    <meta property=\"dcterms:modified\">%s</meta>\n", time.Now().UTC().Format("2006-01-02T15:04:05Z"))
+	b.WriteString(`  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+    <item id="css" href="style.css" media-type="text/css"/>
+`)
+	for i, ch := range chapters {
+		// Content documents must declare embedded MathML and SVG
+		var properties []string
+		if strings.Contains(ch.body, "<math") {
+			properties = append(properties, "mathml")
+		}
+		if strings.Contains(ch.body, "<svg") {
+			properties = append(properties, "svg")
+		}
+		attr := ""
+		if len(properties) > 0 {
+			attr = ` properties="` + strings.Join(properties, " ") + `"`
+		}
+		fmt.Fprintf(&b, "    <item id=\"chapter-%d\" href=\"%s\" media-type=\"application/xhtml+xml\"%s/>\n", i+1, ch.file, attr)
+	}
+	for i, img := range images {
+		fmt.Fprintf(&b, "    <item id=\"image-%d\" href=\"%s\" media-type=\"%s\"/>\n", i+1, img.file, img.mediaType)
+	}
+	b.WriteString("  </manifest>\n  <spine toc=\"ncx\">\n")
+	for i := range chapters {
+		fmt.Fprintf(&b, "    <itemref idref=\"chapter-%d\"/>\n", i+1)
+	}
+	b.WriteString("  </spine>\n</package>\n")
+	return b.String()
+}
+
+// epubNav builds the EPUB 3 navigation document. Chapters are grouped under
+// their note when several notes are exported together.
+func epubNav(title string, notes []*models.Note, chapters []*epubChapter) string {
+	var b strings.Builder
+	b.WriteString(`<nav epub:type="toc" id="toc">
+<h1>Contents</h1>
+<ol>
+`)
+	for i, ch := range chapters {
+		grouped := len(notes) > 1
+		if grouped && (i == 0 || chapters[i-1].note != ch.note) {
+			fmt.Fprintf(&b, "<li><a href=\"%s\">%s</a>\n<ol>\n", ch.file, html.EscapeString(notes[ch.note].Title))
+		}
+		fmt.Fprintf(&b, "<li><a href=\"%s\">%s</a></li>\n", ch.file, html.EscapeString(ch.title))
+		if grouped && (i == len(chapters)-1 || chapters[i+1].note != ch.note) {
+			b.WriteString("</ol>\n</li>\n")
+		}
+	}
+	b.WriteString("</ol>\n</nav>\n")
+	return xhtmlDocument(title, b.String())
+}
+
+// epubNCX builds the EPUB 2 table of contents older readers rely on
+func epubNCX(title, id string, chapters []*epubChapter) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head>
+`)
+	fmt.Fprintf(&b, "    <meta name=\"dtb:uid\" content=\"%s\"/>\n", id)
+	fmt.Fprintf(&b, "  </head>\n  <docTitle><text>%s</text></docTitle>\n  <navMap>\n", html.EscapeString(title))
+	for i, ch := range chapters {
+		fmt.Fprintf(&b, "    <navPoint id=\"nav-%d\" playOrder=\"%d\"><navLabel><text>%s</text></navLabel><content src=\"%s\"/></navPoint>\n",
+			i+1, i+1, html.EscapeString(ch.title), ch.file)
+	}
+	b.WriteString("  </navMap>\n</ncx>\n")
+	return b.String()
+}
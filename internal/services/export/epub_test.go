@@ -0,0 +1,153 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readZip unpacks an exported archive, checking every XML part is well-formed
+func readZip(t *testing.T, data []byte) ([]*zip.File, map[string]string) {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(content)
+
+		if strings.HasSuffix(f.Name, ".xml") || strings.HasSuffix(f.Name, ".rels") ||
+			strings.HasSuffix(f.Name, ".xhtml") || strings.HasSuffix(f.Name, ".opf") || strings.HasSuffix(f.Name, ".ncx") {
+			decoder := xml.NewDecoder(bytes.NewReader(content))
+			for {
+				_, err := decoder.Token()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err, "%s is not well-formed XML", f.Name)
+			}
+		}
+	}
+	return r.File, files
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func TestEPUBExporter_Export(t *testing.T) {
+	content := `Intro before any heading &amp; an entity&nbsp;here.
+
+# First
+
+See [the second chapter](#second) and <b>raw html</b>.
+
+![chart](chart.png)
+
+![missing](missing.png)
+
+## Subsection
+
+> [!NOTE]
+> Callout body.
+
+# Second
+
+| A | B |
+|---|---|
+| 1 | 2 |
+
+$E = mc^2$
+`
+	images := func(src string) ([]byte, error) {
+		if src == "chart.png" {
+			return testPNG(t, 4, 4), nil
+		}
+		return nil, fmt.Errorf("not found")
+	}
+	exporter := NewEPUBExporter(&markdown.Service{}, images)
+	assert.Equal(t, "application/epub+zip", exporter.ContentType())
+	assert.Equal(t, ".epub", exporter.FileExtension())
+
+	var buf bytes.Buffer
+	notes := []*models.Note{
+		{ID: "one", Title: "Book", Content: content},
+		{ID: "two", Title: "Empty", Content: ""},
+	}
+	require.NoError(t, exporter.Export(&buf, notes))
+	entries, files := readZip(t, buf.Bytes())
+
+	assert.Equal(t, "mimetype", entries[0].Name, "mimetype must be the first entry")
+	assert.Equal(t, zip.Store, entries[0].Method)
+	assert.Equal(t, "application/epub+zip", files["mimetype"])
+	assert.Contains(t, files["META-INF/container.xml"], `full-path="OEBPS/content.opf"`)
+
+	// Intro, First, Second and the empty note
+	for i := 1; i <= 4; i++ {
+		assert.Contains(t, files, fmt.Sprintf("OEBPS/chapter-%03d.xhtml", i))
+	}
+	assert.NotContains(t, files, "OEBPS/chapter-005.xhtml")
+
+	intro := files["OEBPS/chapter-001.xhtml"]
+	assert.Contains(t, intro, `<h1 class="note-title">Book</h1>`)
+	assert.Contains(t, intro, "an entity here")
+
+	first := files["OEBPS/chapter-002.xhtml"]
+	assert.Contains(t, first, `href="chapter-003.xhtml#second"`, "links point at the chapter holding the heading")
+	assert.NotContains(t, first, "<b>", "raw HTML is dropped")
+	assert.Contains(t, first, `<img src="images/image-001.png" alt="chart" />`)
+	assert.Contains(t, first, `<span class="missing-image">[missing]</span>`)
+	assert.Contains(t, first, "Subsection", "lower headings stay in their chapter")
+	assert.Contains(t, first, `<aside class="callout callout-note"`)
+	assert.Contains(t, files["OEBPS/chapter-003.xhtml"], "<math")
+	assert.Contains(t, files["OEBPS/chapter-004.xhtml"], `<h1 class="note-title">Empty</h1>`)
+	assert.Contains(t, files, "OEBPS/images/image-001.png")
+
+	opf := files["OEBPS/content.opf"]
+	assert.Contains(t, opf, `<dc:title>Book and 1 more</dc:title>`)
+	assert.Contains(t, opf, `media-type="image/png"`)
+	assert.Contains(t, opf, `properties="mathml"`)
+	assert.Contains(t, opf, `urn:uuid:`)
+
+	nav := files["OEBPS/nav.xhtml"]
+	assert.Contains(t, nav, `epub:type="toc"`)
+	assert.Contains(t, nav, `href="chapter-002.xhtml">First</a>`)
+	assert.Contains(t, nav, "Empty")
+}
+
+func TestEPUBExporter_StableID(t *testing.T) {
+	notes := []*models.Note{{ID: "abc", Title: "T", Content: "text"}}
+	assert.Equal(t, bookID(notes), bookID(notes))
+	assert.NotEqual(t, bookID(notes), bookID([]*models.Note{{ID: "def"}}))
+}
+
+func TestNew(t *testing.T) {
+	for _, format := range Formats() {
+		exporter, err := New(format, &markdown.Service{}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "."+format, exporter.FileExtension())
+	}
+	_, err := New("rtf", &markdown.Service{}, nil)
+	assert.ErrorContains(t, err, "expected one of docx, epub, pdf")
+	_, err = New("EPUB", &markdown.Service{}, nil)
+	assert.NoError(t, err)
+}
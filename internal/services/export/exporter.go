@@ -0,0 +1,131 @@
+// Package export converts notes into downloadable document formats.
+//
+// All formats are produced in pure Go from the same markdown syntax tree the
+// HTML view is rendered from, so exported documents match what users see in
+// the browser without requiring a headless browser or external tools.
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+)
+
+// Exporter converts an ordered list of notes into a single document
+type Exporter interface {
+	// Export writes the document for the notes to w
+	Export(w io.Writer, notes []*models.Note) error
+	// ContentType is the MIME type of the document
+	ContentType() string
+	// FileExtension is the file name extension including the dot
+	FileExtension() string
+}
+
+// formats maps export format names to exporter constructors
+var formats = map[string]func(md *markdown.Service, images ImageLoader) Exporter{
+	"pdf": func(md *markdown.Service, images ImageLoader) Exporter {
+		options := DefaultPDFOptions()
+		options.Images = images
+		return NewPDFExporter(md, options)
+	},
+	"epub": func(md *markdown.Service, images ImageLoader) Exporter {
+		return NewEPUBExporter(md, images)
+	},
+	"docx": func(md *markdown.Service, images ImageLoader) Exporter {
+		return NewDOCXExporter(md, images)
+	},
+}
+
+// Formats returns the supported export format names in sorted order
+func Formats() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New returns the exporter for a format name such as "epub". Images are
+// loaded with the given loader, which may be nil.
+func New(format string, md *markdown.Service, images ImageLoader) (Exporter, error) {
+	constructor, ok := formats[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unknown export format %q, expected one of %s", format, strings.Join(Formats(), ", "))
+	}
+	return constructor(md, images), nil
+}
+
+// documentTitle names a document made of the given notes
+func documentTitle(notes []*models.Note) string {
+	if len(notes) == 1 {
+		return notes[0].Title
+	}
+	return fmt.Sprintf("%s and %d more", notes[0].Title, len(notes)-1)
+}
+
+// loadImage returns image bytes from a data: URI or the loader
+func loadImage(images ImageLoader, src string) ([]byte, error) {
+	if strings.HasPrefix(src, "data:") {
+		return decodeDataURI(src)
+	}
+	if images == nil {
+		return nil, fmt.Errorf("no image loader configured")
+	}
+	return images(src)
+}
+
+// decodeDataURI returns the payload of a data: URI
+func decodeDataURI(uri string) ([]byte, error) {
+	comma := strings.IndexByte(uri, ',')
+	if comma < 0 {
+		return nil, fmt.Errorf("malformed data URI")
+	}
+	meta, payload := uri[len("data:"):comma], uri[comma+1:]
+	if strings.HasSuffix(meta, ";base64") {
+		return base64.StdEncoding.DecodeString(payload)
+	}
+	text, err := url.PathUnescape(payload)
+	return []byte(text), err
+}
+
+// zipEntry is a file in a ZIP-based document format
+type zipEntry struct {
+	name string
+	data []byte
+	// store skips compression, for already compressed data and for entries
+	// formats require to be stored as they are
+	store bool
+}
+
+// writeZip writes the entries to w as a ZIP archive in order
+func writeZip(w io.Writer, entries []zipEntry) error {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		if entry.store {
+			header.Method = zip.Store
+		}
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(entry.data); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
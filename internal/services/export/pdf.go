@@ -0,0 +1,135 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+)
+
+// ImageLoader returns the raw bytes of an image referenced by a note
+type ImageLoader func(src string) ([]byte, error)
+
+// Margins holds page margins in millimetres
+type Margins struct {
+	Top    float64 `json:"top"`
+	Right  float64 `json:"right"`
+	Bottom float64 `json:"bottom"`
+	Left   float64 `json:"left"`
+}
+
+// PDFOptions controls page layout of exported PDFs
+type PDFOptions struct {
+	// PageSize is one of the names in PageSizes, case-insensitive
+	PageSize string
+	Margins  Margins
+	// Header and Footer are templates printed on every page. The
+	// placeholders {title}, {page} and {pages} are replaced.
+	Header string
+	Footer string
+	// TableOfContents adds clickable contents pages at the front
+	TableOfContents bool
+	// Images loads images referenced by notes; when nil only data: URIs
+	// are embedded and other images are replaced by their alt text
+	Images ImageLoader
+}
+
+// PageSizes lists the supported page sizes in points (width, height)
+var PageSizes = map[string][2]float64{
+	"a3":     {841.89, 1190.55},
+	"a4":     {595.28, 841.89},
+	"a5":     {419.53, 595.28},
+	"letter": {612, 792},
+	"legal":  {612, 1008},
+}
+
+// DefaultPDFOptions returns A4 pages with 20mm margins, the note title in
+// the header and page numbers in the footer
+func DefaultPDFOptions() PDFOptions {
+	return PDFOptions{
+		PageSize: "A4",
+		Margins:  Margins{Top: 20, Right: 20, Bottom: 20, Left: 20},
+		Header:   "{title}",
+		Footer:   "Page {page} of {pages}",
+	}
+}
+
+// Validate checks that the page size is known and the margins leave room
+// for content
+func (o PDFOptions) Validate() error {
+	size, ok := PageSizes[strings.ToLower(o.PageSize)]
+	if !ok {
+		names := make([]string, 0, len(PageSizes))
+		for name := range PageSizes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown page size %q, expected one of %s", o.PageSize, strings.Join(names, ", "))
+	}
+	m := o.Margins
+	if m.Top < 0 || m.Right < 0 || m.Bottom < 0 || m.Left < 0 {
+		return fmt.Errorf("margins must not be negative")
+	}
+	if size[0]-mm(m.Left+m.Right) < minContentSize || size[1]-mm(m.Top+m.Bottom) < minContentSize {
+		return fmt.Errorf("margins are too large for page size %s", o.PageSize)
+	}
+	return nil
+}
+
+// minContentSize is the smallest content area in points margins may leave
+const minContentSize = 144
+
+// mm converts millimetres to points
+func mm(v float64) float64 {
+	return v * 72 / 25.4
+}
+
+// PDFExporter renders notes to paginated PDF documents
+type PDFExporter struct {
+	markdown *markdown.Service
+	options  PDFOptions
+}
+
+// NewPDFExporter creates a PDF exporter using the markdown service to parse
+// notes
+func NewPDFExporter(markdown *markdown.Service, options PDFOptions) *PDFExporter {
+	return &PDFExporter{
+		markdown: markdown,
+		options:  options,
+	}
+}
+
+// ContentType returns the MIME type of PDF documents
+func (e *PDFExporter) ContentType() string {
+	return "application/pdf"
+}
+
+// FileExtension returns the extension of PDF files
+func (e *PDFExporter) FileExtension() string {
+	return ".pdf"
+}
+
+// Export writes the notes to w as a single PDF. Each note starts on a new
+// page with its title.
+func (e *PDFExporter) Export(w io.Writer, notes []*models.Note) error {
+	if len(notes) == 0 {
+		return fmt.Errorf("no notes to export")
+	}
+	if err := e.options.Validate(); err != nil {
+		return err
+	}
+
+	l := newPDFLayout(e.options)
+	l.file.title = documentTitle(notes)
+	for i, note := range notes {
+		l.note(i, note, e.markdown.Parse(note.Content), len(notes) > 1)
+	}
+	if e.options.TableOfContents {
+		l.tableOfContents()
+	}
+	l.headersAndFooters()
+	return l.file.write(w)
+}
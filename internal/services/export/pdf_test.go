@@ -0,0 +1,315 @@
+package export
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parsedPDF is a minimal reading of the files the exporter writes
+type parsedPDF struct {
+	raw     string
+	objects map[int]string
+	// text holds the decompressed content streams of each page in order
+	pages []string
+}
+
+var (
+	objectPattern = regexp.MustCompile(`(?s)(\d+) 0 obj\n(.*?)\nendobj\n`)
+	streamPattern = regexp.MustCompile(`(?s)^<<(.*?)>>\nstream\n(.*)\nendstream$`)
+	kidsPattern   = regexp.MustCompile(`/Kids \[([^\]]*)\]`)
+	refPattern    = regexp.MustCompile(`(\d+) 0 R`)
+)
+
+func parsePDF(t *testing.T, data []byte) *parsedPDF {
+	t.Helper()
+	raw := string(data)
+	require.True(t, strings.HasPrefix(raw, "%PDF-1.4\n"))
+	require.True(t, strings.HasSuffix(raw, "%%EOF\n"))
+
+	pdf := &parsedPDF{raw: raw, objects: map[int]string{}}
+	for _, m := range objectPattern.FindAllStringSubmatchIndex(raw, -1) {
+		n, _ := strconv.Atoi(raw[m[2]:m[3]])
+		pdf.objects[n] = raw[m[4]:m[5]]
+	}
+
+	// Every xref entry must point at the start of its object
+	xrefAt := strings.LastIndex(raw, "startxref\n")
+	var xref int
+	fmt.Sscanf(raw[xrefAt+len("startxref\n"):], "%d", &xref)
+	require.True(t, strings.HasPrefix(raw[xref:], "xref\n0 "))
+	entries := strings.Split(raw[xref:], "\n")[3:]
+	for i := 1; i <= len(pdf.objects); i++ {
+		var offset int
+		fmt.Sscanf(entries[i-1], "%d", &offset)
+		require.True(t, strings.HasPrefix(raw[offset:], fmt.Sprintf("%d 0 obj\n", i)), "xref entry for object %d", i)
+	}
+
+	pagesRoot := kidsPattern.FindStringSubmatch(raw)
+	require.NotNil(t, pagesRoot)
+	for _, ref := range refPattern.FindAllStringSubmatch(pagesRoot[1], -1) {
+		n, _ := strconv.Atoi(ref[1])
+		contents := regexp.MustCompile(`/Contents (\d+) 0 R`).FindStringSubmatch(pdf.objects[n])
+		require.NotNil(t, contents)
+		c, _ := strconv.Atoi(contents[1])
+		pdf.pages = append(pdf.pages, pdf.stream(t, c))
+	}
+	return pdf
+}
+
+// stream returns the decompressed data of a stream object
+func (p *parsedPDF) stream(t *testing.T, n int) string {
+	t.Helper()
+	m := streamPattern.FindStringSubmatch(p.objects[n])
+	require.NotNil(t, m, "object %d is not a stream", n)
+	if !strings.Contains(m[1], "/FlateDecode") {
+		return m[2]
+	}
+	r, err := zlib.NewReader(strings.NewReader(m[2]))
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func exportPDF(t *testing.T, options PDFOptions, notes ...*models.Note) *parsedPDF {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, NewPDFExporter(&markdown.Service{}, options).Export(&buf, notes))
+	return parsePDF(t, buf.Bytes())
+}
+
+func TestPDFExporter_Export(t *testing.T) {
+	content := `# Overview
+
+Some **bold**, *italic* and ` + "`code`" + ` text with a [link](https://example.com)
+and a [jump](#details).
+
+- first item
+- second item
+  1. nested
+  2. ordered
+
+> [!WARNING] Careful
+> This is a callout.
+
+| Name | Value |
+|------|------:|
+| a    | 1     |
+
+` + "```go\nfunc main() {}\n```" + `
+
+---
+
+## Details
+
+Final paragraph.
+`
+	pdf := exportPDF(t, DefaultPDFOptions(), &models.Note{Title: "My Note", Content: content})
+
+	require.Len(t, pdf.pages, 1)
+	page := pdf.pages[0]
+	for _, text := range []string{
+		"(My Note)", "(Overview)", "(bold)", "(italic)", "(code)", "(link)",
+		"(first item)", "(\x95)", "(1.)", "(Careful)", "(This is a callout.)",
+		"(Name)", "(Value)", "(func main\\(\\) {})", "(Details)", "(Final paragraph.)",
+		"(Page 1 of 1)",
+	} {
+		assert.Contains(t, page, text)
+	}
+	assert.Contains(t, pdf.raw, "/URI (https://example.com)")
+	assert.Contains(t, pdf.raw, "/Subtype /Link /Rect")
+	assert.Contains(t, pdf.raw, "/Dest [", "internal link resolves to a destination")
+	assert.Contains(t, pdf.raw, "/Outlines")
+	assert.Contains(t, pdf.raw, "/BaseFont /Helvetica-Bold")
+	assert.Contains(t, pdf.raw, "/MediaBox [0 0 595.28 841.89]")
+}
+
+func TestPDFExporter_Pagination(t *testing.T) {
+	var content strings.Builder
+	for i := 0; i < 120; i++ {
+		fmt.Fprintf(&content, "Paragraph number %d with enough words to take up a line of text.\n\n", i)
+	}
+	options := DefaultPDFOptions()
+	options.PageSize = "letter"
+	options.Header = "Notes: {title}"
+	pdf := exportPDF(t, options, &models.Note{Title: "Long", Content: content.String()})
+
+	require.Greater(t, len(pdf.pages), 2)
+	total := len(pdf.pages)
+	for i, page := range pdf.pages {
+		assert.Contains(t, page, "(Notes: Long)")
+		assert.Contains(t, page, fmt.Sprintf("(Page %d of %d)", i+1, total))
+	}
+	assert.Contains(t, pdf.pages[total-1], "(Paragraph number 119 with enough words to take up a line of text.)")
+	assert.Contains(t, pdf.raw, "/MediaBox [0 0 612.00 792.00]")
+}
+
+func TestPDFExporter_TableHeaderRepeats(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("| Key | Description |\n|---|---|\n")
+	for i := 0; i < 80; i++ {
+		fmt.Fprintf(&content, "| k%d | row %d |\n", i, i)
+	}
+	pdf := exportPDF(t, DefaultPDFOptions(), &models.Note{Title: "Table", Content: content.String()})
+
+	require.Greater(t, len(pdf.pages), 1)
+	for _, page := range pdf.pages {
+		assert.Contains(t, page, "(Description)")
+	}
+}
+
+func TestPDFExporter_TableOfContents(t *testing.T) {
+	options := DefaultPDFOptions()
+	options.TableOfContents = true
+	options.Footer = "{page}"
+	pdf := exportPDF(t, options,
+		&models.Note{Title: "First", Content: "# Intro\n\ntext\n\n## Part\n\ntext"},
+		&models.Note{Title: "Second", Content: "# Intro\n\nmore"},
+	)
+
+	require.Len(t, pdf.pages, 3)
+	contents := pdf.pages[0]
+	assert.Contains(t, contents, "(Contents)")
+	assert.Contains(t, contents, "(First)")
+	assert.Contains(t, contents, "(Part)")
+	assert.Contains(t, contents, "(Second)")
+	assert.Contains(t, contents, "(2) Tj", "first note starts on page 2")
+	assert.Contains(t, contents, "(3) Tj", "second note starts on page 3")
+	assert.Equal(t, 5, strings.Count(pdf.raw, "/Border [0 0 0] /Dest ["), "one link per contents entry")
+	assert.Contains(t, pdf.raw, "/Title <FEFF")
+}
+
+func TestPDFExporter_Images(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 128})
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	dataURI := "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	options := DefaultPDFOptions()
+	var requested []string
+	options.Images = func(src string) ([]byte, error) {
+		requested = append(requested, src)
+		if src == "diagram.png" {
+			return buf.Bytes(), nil
+		}
+		return nil, fmt.Errorf("not found")
+	}
+	content := "![inline](" + dataURI + ")\n\n![loaded](diagram.png)\n\n![again](diagram.png)\n\n![missing](nope.png)"
+	pdf := exportPDF(t, options, &models.Note{Title: "Images", Content: content})
+
+	assert.Equal(t, []string{"diagram.png", "nope.png"}, requested, "images are loaded once")
+	assert.Equal(t, 2, strings.Count(pdf.raw, "/Subtype /Image /Width 4 /Height 2 /ColorSpace /DeviceRGB"))
+	assert.Contains(t, pdf.raw, "/SMask")
+	assert.Contains(t, pdf.pages[0], "/Im1 Do")
+	assert.Equal(t, 2, strings.Count(pdf.pages[0], "/Im2 Do"))
+	assert.Contains(t, pdf.pages[0], "([missing])", "unavailable images fall back to alt text")
+}
+
+func TestPDFExporter_Wrapping(t *testing.T) {
+	long := strings.Repeat("word ", 60) + strings.Repeat("x", 200)
+	pdf := exportPDF(t, DefaultPDFOptions(), &models.Note{Title: "Wrap", Content: long + "\n\n```\n" + strings.Repeat("y", 150) + "\n```"})
+
+	page := pdf.pages[0]
+	assert.Greater(t, strings.Count(page, "(word word"), 2, "prose wraps at spaces")
+	assert.Greater(t, strings.Count(page, "(xxxxxxxx"), 1, "long words are split")
+	assert.Equal(t, 2, strings.Count(page, "(yyyy"), "code lines are hard-wrapped")
+}
+
+func TestPDFOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*PDFOptions)
+		wantErr string
+	}{
+		{name: "defaults", modify: func(*PDFOptions) {}},
+		{name: "case insensitive size", modify: func(o *PDFOptions) { o.PageSize = "LeTtEr" }},
+		{name: "unknown size", modify: func(o *PDFOptions) { o.PageSize = "B5" }, wantErr: "unknown page size"},
+		{name: "negative margin", modify: func(o *PDFOptions) { o.Margins.Left = -1 }, wantErr: "negative"},
+		{name: "huge margins", modify: func(o *PDFOptions) { o.Margins.Top, o.Margins.Bottom = 140, 140 }, wantErr: "too large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultPDFOptions()
+			tt.modify(&options)
+			err := options.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	err := NewPDFExporter(&markdown.Service{}, DefaultPDFOptions()).Export(io.Discard, nil)
+	assert.Error(t, err)
+}
+
+func TestEncodeWinAnsi(t *testing.T) {
+	assert.Equal(t, []byte("caf\xe9 \x93quoted\x94 -> ?"), encodeWinAnsi("café “quoted” → 😀"))
+	assert.Equal(t, `(a\(b\)c\\)`, pdfString(`a(b)c\`))
+	assert.Equal(t, "<FEFF0041D83DDE00>", pdfTextString("A😀"))
+}
+
+func TestPDFExporter_UnicodeText(t *testing.T) {
+	content := "# Привет мир\n\nΓειά σου *κόσμε*, café → done\n\n```\nкод 😀\n```"
+	pdf := exportPDF(t, DefaultPDFOptions(), &models.Note{Title: "Unicode", Content: content})
+
+	sans := unicodeSans.load()
+	require.NotNil(t, sans)
+	glyphs := func(font *trueTypeFont, s string) string {
+		var ids []uint16
+		for _, r := range s {
+			require.NotZero(t, font.glyph(r), "font has %q", r)
+			ids = append(ids, font.glyph(r))
+		}
+		return pdfGlyphString(ids)
+	}
+	page := pdf.pages[0]
+	assert.Contains(t, page, glyphs(sans, "Привет")+" Tj")
+	assert.Contains(t, page, glyphs(sans, "Γειά")+" Tj")
+	assert.Contains(t, page, glyphs(unicodeMono.load(), "код")+" Tj")
+	assert.Contains(t, page, "(, caf\xe9 ) Tj", "WinAnsi text keeps the standard fonts")
+	assert.Contains(t, page, "( ?) Tj", "characters no font has are replaced")
+	assert.Equal(t, 2, strings.Count(pdf.raw, "/Subtype /Type0"))
+	assert.Contains(t, pdf.raw, "/Encoding /Identity-H")
+
+	// The embedded subset is a valid font with the outlines that were drawn
+	m := regexp.MustCompile(`/BaseFont /[A-Z]{6}\+DejaVuSans /Encoding /Identity-H /DescendantFonts \[\d+ 0 R\] /ToUnicode (\d+) 0 R`).FindStringSubmatch(pdf.raw)
+	require.NotNil(t, m)
+	toUnicode, _ := strconv.Atoi(m[1])
+	assert.Contains(t, pdf.stream(t, toUnicode), fmt.Sprintf("<%04X> <041F>", sans.glyph('П')))
+
+	m = regexp.MustCompile(`/FontName /[A-Z]{6}\+DejaVuSans /.*?/FontFile2 (\d+) 0 R`).FindStringSubmatch(pdf.raw)
+	require.NotNil(t, m)
+	file, _ := strconv.Atoi(m[1])
+	data := []byte(pdf.stream(t, file))
+	assert.Less(t, len(data), len(dejaVuSans)/10)
+	assert.Equal(t, uint32(0xB1B0AFBA), trueTypeChecksum(data))
+	subset, err := parseTrueType(data)
+	require.NoError(t, err)
+	assert.Equal(t, sans.numGlyphs, subset.numGlyphs)
+	for _, r := range "Пει" {
+		g := int(sans.glyph(r))
+		assert.NotEmpty(t, subset.glyphData(g))
+		assert.Equal(t, sans.glyphData(g), subset.glyphData(g))
+	}
+	assert.Empty(t, subset.glyphData(int(sans.glyph('Ж'))), "unused glyphs are left out")
+}
@@ -0,0 +1,304 @@
+package export
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// pdfFont identifies one of the standard Type 1 fonts every PDF viewer
+// provides, so nothing needs to be embedded in the file. Characters they
+// cannot encode are drawn with an embedded Unicode font.
+type pdfFont int
+
+const (
+	fontRegular pdfFont = iota
+	fontBold
+	fontItalic
+	fontBoldItalic
+	fontMono
+	fontMonoBold
+)
+
+var pdfFontNames = []string{
+	fontRegular:    "Helvetica",
+	fontBold:       "Helvetica-Bold",
+	fontItalic:     "Helvetica-Oblique",
+	fontBoldItalic: "Helvetica-BoldOblique",
+	fontMono:       "Courier",
+	fontMonoBold:   "Courier-Bold",
+}
+
+// resourceName is the name the font is registered under in page resources
+func (f pdfFont) resourceName() string {
+	return "F" + string(rune('1'+int(f)))
+}
+
+// unicode returns the embedded font that stands in for the font when text
+// has characters outside WinAnsi
+func (f pdfFont) unicode() *unicodeFont {
+	if f == fontMono || f == fontMonoBold {
+		return unicodeMono
+	}
+	return unicodeSans
+}
+
+func (f pdfFont) bold() bool {
+	return f == fontBold || f == fontBoldItalic || f == fontMonoBold
+}
+
+func (f pdfFont) italic() bool {
+	return f == fontItalic || f == fontBoldItalic
+}
+
+var (
+	//go:embed fonts/DejaVuSans.ttf
+	dejaVuSans []byte
+	//go:embed fonts/DejaVuSansMono.ttf
+	dejaVuSansMono []byte
+)
+
+// unicodeFont is a TrueType font embedded in the binary. Only the glyphs
+// a document uses are written to the PDF. Bold and italic are synthesized
+// by outlining and slanting the regular glyphs.
+type unicodeFont struct {
+	name     string
+	resource string
+	data     []byte
+
+	once sync.Once
+	font *trueTypeFont
+}
+
+var (
+	unicodeSans  = &unicodeFont{name: "DejaVuSans", resource: "F7", data: dejaVuSans}
+	unicodeMono  = &unicodeFont{name: "DejaVuSansMono", resource: "F8", data: dejaVuSansMono}
+	unicodeFonts = []*unicodeFont{unicodeSans, unicodeMono}
+)
+
+// load parses the font the first time it is needed. A font that cannot be
+// parsed is nil, and its characters fall back to WinAnsi.
+func (u *unicodeFont) load() *trueTypeFont {
+	u.once.Do(func() {
+		font, err := parseTrueType(u.data)
+		if err == nil {
+			u.font = font
+		}
+	})
+	return u.font
+}
+
+// pdfTextRun is a part of a string drawn with one font: a standard font,
+// or the Unicode font when glyphs is set
+type pdfTextRun struct {
+	text   string
+	glyphs []uint16
+}
+
+// textRuns splits text into runs for the standard font and runs of
+// characters only the Unicode font has
+func textRuns(font pdfFont, s string) []pdfTextRun {
+	ttf := font.unicode().load()
+	var runs []pdfTextRun
+	start := 0
+	var glyphs []uint16
+	for i, r := range s {
+		var g uint16
+		if !inWinAnsi(r) && ttf != nil {
+			g = ttf.glyph(r)
+		}
+		if (g != 0) != (glyphs != nil) && i > start {
+			runs = append(runs, pdfTextRun{text: s[start:i], glyphs: glyphs})
+			start, glyphs = i, nil
+		}
+		if g != 0 {
+			glyphs = append(glyphs, g)
+		}
+	}
+	if start < len(s) {
+		runs = append(runs, pdfTextRun{text: s[start:], glyphs: glyphs})
+	}
+	return runs
+}
+
+// inWinAnsi reports whether a character has a code in WinAnsi
+func inWinAnsi(r rune) bool {
+	_, extra := winAnsiExtras[r]
+	return (r >= 0x20 && r < 0x7F) || (r >= 0xA1 && r <= 0xFF) || extra
+}
+
+// Glyph widths in 1/1000 em for the printable ASCII range, from the Adobe
+// font metrics of Helvetica and Helvetica-Bold. The oblique variants share
+// the widths of their upright counterparts.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // 0-9
+	278, 278, 584, 584, 584, 556, 1015, // : to @
+	667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, // A-M
+	722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // N-Z
+	278, 278, 278, 469, 556, 333, // [ to `
+	556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, // a-m
+	556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, // n-z
+	334, 260, 334, 584, // { to ~
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556,
+	333, 333, 584, 584, 584, 611, 975,
+	722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833,
+	722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611,
+	333, 278, 333, 584, 556, 333,
+	556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889,
+	611, 611, 611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500,
+	389, 280, 389, 584,
+}
+
+// winAnsiExtras maps the characters Windows-1252 places in 0x80-0x9F
+var winAnsiExtras = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91,
+	'’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98,
+	'™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// transliterations covers common characters outside WinAnsi
+var transliterations = map[rune]string{
+	'→': "->", '←': "<-", '⇒': "=>", '≤': "<=", '≥': ">=", '≠': "!=", '≈': "~",
+	'✓': "v", '✔': "v", '✗': "x", '✘': "x", '☐': "[ ]", '☑': "[x]", '\u00A0': " ",
+	'\u200B': "", '\t': "    ",
+}
+
+// encodeWinAnsi converts text to the single-byte encoding used by the
+// standard fonts. Characters with no equivalent become "?".
+func encodeWinAnsi(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 0x20 && r < 0x7F:
+			out = append(out, byte(r))
+		case r >= 0xA1 && r <= 0xFF:
+			out = append(out, byte(r))
+		default:
+			if b, ok := winAnsiExtras[r]; ok {
+				out = append(out, b)
+			} else if t, ok := transliterations[r]; ok {
+				out = append(out, t...)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+// glyphWidth returns the width of an encoded byte in 1/1000 em
+func glyphWidth(font pdfFont, b byte) int {
+	if font == fontMono || font == fontMonoBold {
+		return 600
+	}
+	widths := &helveticaWidths
+	if font == fontBold || font == fontBoldItalic {
+		widths = &helveticaBoldWidths
+	}
+	switch {
+	case b >= 0x20 && b < 0x7F:
+		return widths[b-0x20]
+	case b == 0x85 || b == 0x97 || b == 0x89:
+		return 1000
+	case b == 0x95:
+		return 350
+	case b == 0x91 || b == 0x92 || b == 0x82:
+		return 222
+	case b == 0x93 || b == 0x94 || b == 0x84:
+		return 333
+	case b >= 0xC0:
+		// Accented letters are about as wide as their base letters
+		if b >= 0xE0 {
+			return widths['a'-0x20]
+		}
+		return widths['A'-0x20]
+	}
+	return 556
+}
+
+// textWidth returns the width in points of text set in font at size
+func textWidth(font pdfFont, size float64, s string) float64 {
+	total := 0
+	for _, run := range textRuns(font, s) {
+		total += runWidth(font, run)
+	}
+	return float64(total) * size / 1000
+}
+
+// runWidth returns the width of a run in 1/1000 em
+func runWidth(font pdfFont, run pdfTextRun) int {
+	total := 0
+	if run.glyphs != nil {
+		ttf := font.unicode().load()
+		for _, g := range run.glyphs {
+			total += ttf.advance(g)
+		}
+		return total
+	}
+	for _, b := range encodeWinAnsi(run.text) {
+		total += glyphWidth(font, b)
+	}
+	return total
+}
+
+// pdfString encodes text as a PDF literal string in WinAnsi encoding
+func pdfString(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, c := range encodeWinAnsi(s) {
+		switch c {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// pdfGlyphString encodes glyph numbers as a hex string for a font with
+// Identity-H encoding
+func pdfGlyphString(glyphs []uint16) string {
+	var b strings.Builder
+	b.WriteByte('<')
+	for _, g := range glyphs {
+		fmt.Fprintf(&b, "%04X", g)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// pdfTextString encodes text as a UTF-16 hex string, used for metadata and
+// bookmarks where viewers support the full Unicode range
+func pdfTextString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, r := range s {
+		if r > 0xFFFF {
+			r -= 0x10000
+			writeHex16(&b, 0xD800+(r>>10))
+			writeHex16(&b, 0xDC00+(r&0x3FF))
+			continue
+		}
+		writeHex16(&b, r)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+func writeHex16(b *strings.Builder, r rune) {
+	const digits = "0123456789ABCDEF"
+	b.WriteByte(digits[(r>>12)&0xF])
+	b.WriteByte(digits[(r>>8)&0xF])
+	b.WriteByte(digits[(r>>4)&0xF])
+	b.WriteByte(digits[r&0xF])
+}
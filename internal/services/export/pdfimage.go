@@ -0,0 +1,132 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register GIF decoding
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+// soleImage returns the image when a paragraph consists of nothing but one
+// image, optionally wrapped in a link
+func soleImage(para *blackfriday.Node) *blackfriday.Node {
+	child := onlyChild(para)
+	if child != nil && child.Type == blackfriday.Link {
+		child = onlyChild(child)
+	}
+	if child == nil || child.Type != blackfriday.Image {
+		return nil
+	}
+	return child
+}
+
+// onlyChild returns the single child of a node, ignoring the blank text
+// nodes blackfriday leaves around inline elements
+func onlyChild(node *blackfriday.Node) *blackfriday.Node {
+	var only *blackfriday.Node
+	for child := node.FirstChild; child != nil; child = child.Next {
+		if child.Type == blackfriday.Text && strings.TrimSpace(string(child.Literal)) == "" {
+			continue
+		}
+		if only != nil {
+			return nil
+		}
+		only = child
+	}
+	return only
+}
+
+// blockImage draws an image scaled to fit the content area. It returns
+// false when the image cannot be loaded, so the caller can fall back to the
+// alt text.
+func (l *pdfLayout) blockImage(node *blackfriday.Node) bool {
+	src := string(node.LinkData.Destination)
+	img, ok := l.images[src]
+	if !ok {
+		data, err := loadImage(l.options.Images, src)
+		if err == nil {
+			img, err = decodeImage(fmt.Sprintf("Im%d", len(l.file.images)+1), data)
+		}
+		if err != nil {
+			img = nil
+		} else {
+			l.file.images = append(l.file.images, img)
+		}
+		l.images[src] = img
+	}
+	if img == nil {
+		return false
+	}
+
+	// Images are assumed to be 96 dpi and are never scaled up
+	w := float64(img.width) * 0.75
+	h := float64(img.height) * 0.75
+	maxW, maxH := l.contentWidth(), (l.bottom-l.top)*0.9
+	if w > maxW {
+		h, w = h*maxW/w, maxW
+	}
+	if h > maxH {
+		w, h = w*maxH/h, maxH
+	}
+
+	l.ensureSpace(h)
+	l.drawMarker(bodySize)
+	x := l.left + l.indent
+	l.page.printf("q %.2f 0 0 %.2f %.2f %.2f cm /%s Do Q\n", w, h, x, l.height-l.y-h, img.name)
+	if parent := node.Parent; parent.Type == blackfriday.Link {
+		l.page.links = append(l.page.links, pdfLink{
+			x1: x, y1: l.height - l.y - h, x2: x + w, y2: l.height - l.y,
+			uri: string(parent.LinkData.Destination),
+		})
+	}
+	l.y += h + bodySize*0.6
+	return true
+}
+
+// decodeImage converts image bytes to an XObject. Baseline JPEGs are
+// embedded as they are; other formats are decoded to 8-bit RGB with an
+// optional alpha mask.
+func decodeImage(name string, data []byte) (*pdfImage, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if format == "jpeg" {
+		switch config.ColorModel {
+		case color.YCbCrModel:
+			return &pdfImage{name: name, width: config.Width, height: config.Height, colorSpace: "DeviceRGB", filter: "DCTDecode", data: data}, nil
+		case color.GrayModel:
+			return &pdfImage{name: name, width: config.Width, height: config.Height, colorSpace: "DeviceGray", filter: "DCTDecode", data: data}, nil
+		}
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	bounds := src.Bounds()
+	img := &pdfImage{name: name, width: bounds.Dx(), height: bounds.Dy(), colorSpace: "DeviceRGB"}
+	img.data = make([]byte, 0, img.width*img.height*3)
+	alpha := make([]byte, 0, img.width*img.height)
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(src.At(x, y)).(color.NRGBA)
+			img.data = append(img.data, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+			if c.A != 0xFF {
+				opaque = false
+			}
+		}
+	}
+	if !opaque {
+		img.smask = alpha
+	}
+	return img, nil
+}
@@ -0,0 +1,229 @@
+package export
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+// inlineRuns flattens the inline children of a block into styled runs
+func (l *pdfLayout) inlineRuns(block *blackfriday.Node, base inlineStyle, size float64) []textRun {
+	var runs []textRun
+	var walk func(parent *blackfriday.Node, style inlineStyle)
+	walk = func(parent *blackfriday.Node, style inlineStyle) {
+		for node := parent.FirstChild; node != nil; node = node.Next {
+			switch node.Type {
+			case blackfriday.Text:
+				text := strings.ReplaceAll(l.doc.Text(node.Literal), "\n", " ")
+				runs = append(runs, textRun{text: text, style: style, size: size})
+			case blackfriday.Code:
+				s := style
+				s.code = true
+				runs = append(runs, textRun{text: l.doc.Text(node.Literal), style: s, size: size * 0.92})
+			case blackfriday.Softbreak:
+				runs = append(runs, textRun{text: " ", style: style, size: size})
+			case blackfriday.Hardbreak:
+				runs = append(runs, textRun{hardBreak: true, size: size})
+			case blackfriday.Emph:
+				s := style
+				s.italic = true
+				walk(node, s)
+			case blackfriday.Strong:
+				s := style
+				s.bold = true
+				walk(node, s)
+			case blackfriday.Del:
+				s := style
+				s.strike = true
+				walk(node, s)
+			case blackfriday.Link:
+				s := style
+				s.link = string(node.LinkData.Destination)
+				s.color = linkBlue
+				walk(node, s)
+			case blackfriday.Image:
+				// Inline images are shown as their alt text
+				s := style
+				s.italic = true
+				if alt := l.doc.NodeText(node); alt != "" {
+					runs = append(runs, textRun{text: "[" + alt + "]", style: s, size: size})
+				}
+			case blackfriday.HTMLSpan:
+			default:
+				walk(node, style)
+			}
+		}
+	}
+	walk(block, base)
+	return runs
+}
+
+// layoutInline breaks runs into lines no wider than width. Lines break at
+// spaces; words wider than a whole line are split between characters.
+func (l *pdfLayout) layoutInline(runs []textRun, width float64) []pdfLine {
+	var lines []pdfLine
+	var cur pdfLine
+	flush := func() {
+		cur.trimTrailingSpace()
+		if cur.size == 0 {
+			cur.size = bodySize
+		}
+		lines = append(lines, cur)
+		cur = pdfLine{}
+	}
+
+	for _, run := range runs {
+		if run.hardBreak {
+			if cur.size == 0 {
+				cur.size = run.size
+			}
+			flush()
+			continue
+		}
+		font := run.style.font()
+		for _, word := range splitWords(run.text) {
+			w := textWidth(font, run.size, word)
+			if strings.TrimSpace(word) == "" {
+				if len(cur.fragments) > 0 {
+					cur.add(word, run, w)
+				}
+				continue
+			}
+			if cur.width+w > width && len(cur.fragments) > 0 {
+				flush()
+			}
+			for w > width {
+				head := fitPrefix(font, run.size, word, width-cur.width)
+				if head == "" && len(cur.fragments) > 0 {
+					flush()
+					continue
+				}
+				if head == "" {
+					_, n := utf8.DecodeRuneInString(word)
+					head = word[:n]
+				}
+				cur.add(head, run, textWidth(font, run.size, head))
+				flush()
+				word = word[len(head):]
+				w = textWidth(font, run.size, word)
+			}
+			if word != "" {
+				cur.add(word, run, w)
+			}
+		}
+	}
+	if len(cur.fragments) > 0 {
+		flush()
+	}
+	return lines
+}
+
+// add appends text to the line, merging it with the previous fragment when
+// the style is unchanged
+func (line *pdfLine) add(text string, run textRun, width float64) {
+	if line.size < run.size {
+		line.size = run.size
+	}
+	line.width += width
+	if n := len(line.fragments); n > 0 {
+		last := &line.fragments[n-1]
+		if last.style == run.style && last.size == run.size {
+			last.text += text
+			last.width += width
+			return
+		}
+	}
+	line.fragments = append(line.fragments, lineFragment{text: text, style: run.style, size: run.size, width: width})
+}
+
+func (line *pdfLine) trimTrailingSpace() {
+	for n := len(line.fragments); n > 0; n = len(line.fragments) {
+		last := &line.fragments[n-1]
+		trimmed := strings.TrimRightFunc(last.text, unicode.IsSpace)
+		if trimmed == last.text {
+			return
+		}
+		removed := textWidth(last.style.font(), last.size, last.text[len(trimmed):])
+		line.width -= removed
+		last.width -= removed
+		last.text = trimmed
+		if trimmed != "" {
+			return
+		}
+		line.fragments = line.fragments[:n-1]
+	}
+}
+
+// splitWords splits text into alternating runs of spaces and non-spaces
+func splitWords(text string) []string {
+	var words []string
+	start := 0
+	for i, r := range text {
+		if i == start {
+			continue
+		}
+		prev, _ := utf8.DecodeLastRuneInString(text[:i])
+		if unicode.IsSpace(prev) != unicode.IsSpace(r) {
+			words = append(words, text[start:i])
+			start = i
+		}
+	}
+	if start < len(text) {
+		words = append(words, text[start:])
+	}
+	return words
+}
+
+// fitPrefix returns the longest prefix of s no wider than width
+func fitPrefix(font pdfFont, size float64, s string, width float64) string {
+	end := 0
+	for i, r := range s {
+		next := i + utf8.RuneLen(r)
+		if textWidth(font, size, s[:next]) > width {
+			break
+		}
+		end = next
+	}
+	return s[:end]
+}
+
+// drawLine draws a laid out line with its top at top-down position y
+func (l *pdfLayout) drawLine(line pdfLine, x, y float64) {
+	baseline := y + line.size*1.05
+	for _, frag := range line.fragments {
+		if frag.style.code {
+			l.fillRect(x-1, baseline-frag.size*0.85, frag.width+2, frag.size*1.15, codeFill)
+		}
+		l.text(x, baseline, frag.style.font(), frag.size, frag.style.color, frag.text)
+		if frag.style.strike {
+			l.hline(x, x+frag.width, baseline-frag.size*0.3, frag.style.color, 0.6)
+		}
+		if frag.style.link != "" {
+			l.hline(x, x+frag.width, baseline+1.2, frag.style.color, 0.4)
+			link := pdfLink{
+				x1: x, y1: l.height - baseline - frag.size*0.25,
+				x2: x + frag.width, y2: l.height - baseline + frag.size*0.9,
+			}
+			if strings.HasPrefix(frag.style.link, "#") {
+				link.anchor = l.anchorKey(frag.style.link[1:])
+			} else {
+				link.uri = frag.style.link
+			}
+			l.page.links = append(l.page.links, link)
+		}
+		x += frag.width
+	}
+}
+
+// alignedX returns where a line starts inside a box of the given width
+func alignedX(line pdfLine, x, width float64, align blackfriday.CellAlignFlags) float64 {
+	switch align {
+	case blackfriday.TableAlignmentRight:
+		return x + width - line.width
+	case blackfriday.TableAlignmentCenter:
+		return x + (width-line.width)/2
+	}
+	return x
+}
@@ -0,0 +1,503 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/russross/blackfriday/v2"
+)
+
+// Type sizes in points
+const (
+	bodySize    = 10.5
+	codeSize    = 9
+	tableSize   = 9.5
+	titleSize   = 22
+	headerSize  = 8.5
+	lineSpacing = 1.4
+	listIndent  = 18
+	quoteIndent = 14
+	cellPadding = 4
+)
+
+var headingSizes = [7]float64{0, 20, 16, 13.5, 12, 11, 10.5}
+
+// rgb is a fill or stroke colour with components in 0-1
+type rgb struct{ r, g, b float64 }
+
+var (
+	black     = rgb{0, 0, 0}
+	grey      = rgb{0.4, 0.4, 0.4}
+	linkBlue  = rgb{0.02, 0.33, 0.71}
+	codeFill  = rgb{0.95, 0.95, 0.95}
+	ruleGrey  = rgb{0.8, 0.8, 0.8}
+	tableHead = rgb{0.93, 0.93, 0.93}
+)
+
+// calloutColors gives the accent colour of each callout type
+var calloutColors = map[string]rgb{
+	"note": {0.03, 0.41, 0.85}, "info": {0.03, 0.41, 0.85}, "todo": {0.03, 0.41, 0.85},
+	"abstract": {0.0, 0.6, 0.7}, "tip": {0.1, 0.5, 0.21}, "success": {0.1, 0.5, 0.21},
+	"important": {0.51, 0.31, 0.87}, "question": {0.8, 0.5, 0.0}, "warning": {0.6, 0.4, 0.0},
+	"caution": {0.81, 0.13, 0.18}, "failure": {0.81, 0.13, 0.18}, "bug": {0.81, 0.13, 0.18},
+	"example": {0.47, 0.33, 0.75}, "quote": {0.4, 0.4, 0.4},
+}
+
+// inlineStyle is the formatting of a run of inline text
+type inlineStyle struct {
+	bold, italic, code, strike bool
+	color                      rgb
+	link                       string
+}
+
+func (s inlineStyle) font() pdfFont {
+	switch {
+	case s.code && s.bold:
+		return fontMonoBold
+	case s.code:
+		return fontMono
+	case s.bold && s.italic:
+		return fontBoldItalic
+	case s.bold:
+		return fontBold
+	case s.italic:
+		return fontItalic
+	}
+	return fontRegular
+}
+
+// textRun is inline text sharing one style; hardBreak runs end the line
+type textRun struct {
+	text      string
+	style     inlineStyle
+	size      float64
+	hardBreak bool
+}
+
+// lineFragment is the part of a laid out line drawn in one style
+type lineFragment struct {
+	text  string
+	style inlineStyle
+	size  float64
+	width float64
+}
+
+// pdfLine is a laid out line of inline text
+type pdfLine struct {
+	fragments []lineFragment
+	width     float64
+	size      float64
+}
+
+func (l pdfLine) height() float64 {
+	return l.size * lineSpacing
+}
+
+// sideBar is a vertical bar drawn beside a blockquote or callout. Bars are
+// drawn when the quote ends or the page breaks.
+type sideBar struct {
+	x      float64
+	startY float64
+	color  rgb
+}
+
+// tocEntry is a heading listed in the table of contents
+type tocEntry struct {
+	title string
+	level int
+	dest  string
+}
+
+// pdfLayout flows markdown documents onto pages. Positions are tracked
+// top-down from the top edge of the page and converted to PDF user space
+// when drawing.
+type pdfLayout struct {
+	options PDFOptions
+	file    *pdfFile
+	page    *pdfPage
+
+	width, height            float64
+	top, bottom, left, right float64
+
+	// y is the top of the next line, indent the offset of the current block
+	y      float64
+	indent float64
+
+	// Per-note state
+	doc       *markdown.Document
+	noteIndex int
+	noteTitle string
+
+	marker string
+	bars   []*sideBar
+	toc    []tocEntry
+	images map[string]*pdfImage
+}
+
+func newPDFLayout(options PDFOptions) *pdfLayout {
+	size := PageSizes[strings.ToLower(options.PageSize)]
+	l := &pdfLayout{
+		options: options,
+		file: &pdfFile{
+			width:  size[0],
+			height: size[1],
+			dests:  map[string]pdfDest{},
+			glyphs: map[*unicodeFont]map[uint16]rune{},
+		},
+		width:  size[0],
+		height: size[1],
+		top:    mm(options.Margins.Top),
+		bottom: size[1] - mm(options.Margins.Bottom),
+		left:   mm(options.Margins.Left),
+		right:  size[0] - mm(options.Margins.Right),
+		images: map[string]*pdfImage{},
+	}
+	return l
+}
+
+// note lays out one note starting on a fresh page
+func (l *pdfLayout) note(index int, note *models.Note, doc *markdown.Document, inOutline bool) {
+	l.doc = doc
+	l.noteIndex = index
+	l.noteTitle = note.Title
+	l.newPage()
+
+	key := l.anchorKey("")
+	l.file.dests[key] = l.dest()
+	if inOutline {
+		l.file.outlines = append(l.file.outlines, pdfOutline{title: note.Title, level: 0, dest: l.dest()})
+		l.toc = append(l.toc, tocEntry{title: note.Title, level: 0, dest: key})
+	}
+
+	for _, line := range l.layoutInline([]textRun{{text: note.Title, style: inlineStyle{bold: true}, size: titleSize}}, l.contentWidth()) {
+		l.drawLine(line, l.left, l.y)
+		l.y += line.height()
+	}
+	l.y += 2
+	l.hline(l.left, l.right, l.y, ruleGrey, 0.75)
+	l.y += bodySize
+
+	l.blocks(doc.Root)
+}
+
+// newPage finishes any side bars on the current page and starts a new one
+func (l *pdfLayout) newPage() {
+	if l.page != nil {
+		for _, bar := range l.bars {
+			l.vline(bar.x, bar.startY, l.y, bar.color, 2.5)
+			bar.startY = l.top
+		}
+	}
+	l.page = &pdfPage{title: l.noteTitle}
+	l.file.pages = append(l.file.pages, l.page)
+	l.y = l.top
+}
+
+// ensureSpace starts a new page unless h points fit below the current
+// position. A block taller than a whole page is never moved.
+func (l *pdfLayout) ensureSpace(h float64) {
+	if l.y+h > l.bottom && l.y > l.top {
+		l.newPage()
+	}
+}
+
+func (l *pdfLayout) contentWidth() float64 {
+	return l.right - l.left - l.indent
+}
+
+func (l *pdfLayout) dest() pdfDest {
+	return pdfDest{page: len(l.file.pages) - 1, y: l.height - l.y}
+}
+
+// anchorKey namespaces heading anchors by note so notes exported together
+// can use the same heading names
+func (l *pdfLayout) anchorKey(id string) string {
+	return fmt.Sprintf("note%d#%s", l.noteIndex, id)
+}
+
+func (l *pdfLayout) blocks(parent *blackfriday.Node) {
+	for node := parent.FirstChild; node != nil; node = node.Next {
+		l.block(node)
+	}
+}
+
+func (l *pdfLayout) block(node *blackfriday.Node) {
+	switch node.Type {
+	case blackfriday.Paragraph:
+		if img := soleImage(node); img != nil && l.blockImage(img) {
+			return
+		}
+		spacing := bodySize * 0.6
+		if item := node.Parent; item.Type == blackfriday.Item && item.Parent.ListData.Tight {
+			spacing = 2
+		}
+		l.paragraph(l.inlineRuns(node, inlineStyle{}, bodySize), spacing)
+	case blackfriday.Heading:
+		l.heading(node)
+	case blackfriday.List:
+		l.list(node)
+	case blackfriday.BlockQuote:
+		l.blockquote(node)
+	case blackfriday.CodeBlock:
+		l.codeBlock(node)
+	case blackfriday.Table:
+		l.table(node)
+	case blackfriday.HorizontalRule:
+		l.ensureSpace(bodySize)
+		l.y += bodySize / 2
+		l.hline(l.left+l.indent, l.right, l.y, ruleGrey, 1)
+		l.y += bodySize / 2
+	case blackfriday.HTMLBlock:
+		// Raw HTML cannot be laid out and is left out of the PDF
+	default:
+		l.blocks(node)
+	}
+}
+
+// paragraph lays out inline runs followed by spacing
+func (l *pdfLayout) paragraph(runs []textRun, spacing float64) {
+	for _, line := range l.layoutInline(runs, l.contentWidth()) {
+		l.ensureSpace(line.height())
+		l.drawMarker(line.size)
+		l.drawLine(line, l.left+l.indent, l.y)
+		l.y += line.height()
+	}
+	l.y += spacing
+}
+
+func (l *pdfLayout) heading(node *blackfriday.Node) {
+	level := node.HeadingData.Level
+	if level < 1 || level > 6 {
+		level = 6
+	}
+	size := headingSizes[level]
+	lines := l.layoutInline(l.inlineRuns(node, inlineStyle{bold: true}, size), l.contentWidth())
+
+	// Keep the heading on the same page as at least two lines of text
+	if l.y > l.top {
+		l.y += size * 0.6
+	}
+	need := bodySize * lineSpacing * 2
+	for _, line := range lines {
+		need += line.height()
+	}
+	l.ensureSpace(need)
+
+	title := l.doc.NodeText(node)
+	key := l.anchorKey(node.HeadingData.HeadingID)
+	l.file.dests[key] = l.dest()
+	l.file.outlines = append(l.file.outlines, pdfOutline{title: title, level: level, dest: l.dest()})
+	if level <= 3 {
+		l.toc = append(l.toc, tocEntry{title: title, level: level, dest: key})
+	}
+
+	for _, line := range lines {
+		l.drawMarker(line.size)
+		l.drawLine(line, l.left+l.indent, l.y)
+		l.y += line.height()
+	}
+	l.y += size * 0.3
+}
+
+func (l *pdfLayout) list(node *blackfriday.Node) {
+	l.indent += listIndent
+	number := 1
+	for item := node.FirstChild; item != nil; item = item.Next {
+		flags := item.ListData.ListFlags
+		switch {
+		case flags&blackfriday.ListTypeTerm != 0:
+			// Definition terms sit flush with the surrounding text
+			l.indent -= listIndent
+			l.paragraph(l.inlineRuns(item, inlineStyle{bold: true}, bodySize), 2)
+			l.indent += listIndent
+			continue
+		case flags&blackfriday.ListTypeDefinition != 0:
+			l.marker = ""
+		case flags&blackfriday.ListTypeOrdered != 0:
+			delimiter := item.ListData.Delimiter
+			if delimiter == 0 {
+				delimiter = '.'
+			}
+			l.marker = fmt.Sprintf("%d%c", number, delimiter)
+			number++
+		default:
+			l.marker = "•"
+		}
+		l.blocks(item)
+		if l.marker != "" {
+			// Empty item: the marker still gets a line of its own
+			l.ensureSpace(bodySize * lineSpacing)
+			l.drawMarker(bodySize)
+			l.y += bodySize * lineSpacing
+		}
+	}
+	l.indent -= listIndent
+	if node.Parent.Type != blackfriday.Item {
+		l.y += bodySize * 0.4
+	}
+}
+
+// drawMarker draws a pending list marker beside the line about to be drawn
+func (l *pdfLayout) drawMarker(size float64) {
+	if l.marker == "" {
+		return
+	}
+	marker := l.marker
+	l.marker = ""
+	w := textWidth(fontRegular, size, marker)
+	l.text(l.left+l.indent-w-5, l.y+size*1.05, fontRegular, size, black, marker)
+}
+
+func (l *pdfLayout) blockquote(node *blackfriday.Node) {
+	callout, isCallout := l.doc.Callout(node)
+	color := ruleGrey
+	if isCallout {
+		color = calloutColors[callout.Type]
+		if color == (rgb{}) {
+			color = calloutColors["note"]
+		}
+	}
+
+	l.ensureSpace(bodySize * lineSpacing * 2)
+	bar := &sideBar{x: l.left + l.indent + 1.25, startY: l.y, color: color}
+	l.bars = append(l.bars, bar)
+	l.indent += quoteIndent
+
+	if isCallout {
+		l.paragraph([]textRun{{text: callout.Title, style: inlineStyle{bold: true, color: color}, size: bodySize}}, 2)
+	}
+	quoteStyle := inlineStyle{}
+	if !isCallout {
+		quoteStyle.color = grey
+	}
+	for child := node.FirstChild; child != nil; child = child.Next {
+		if child.Type == blackfriday.Paragraph && soleImage(child) == nil {
+			l.paragraph(l.inlineRuns(child, quoteStyle, bodySize), bodySize*0.6)
+			continue
+		}
+		l.block(child)
+	}
+
+	l.indent -= quoteIndent
+	l.bars = l.bars[:len(l.bars)-1]
+	l.vline(bar.x, bar.startY, l.y-bodySize*0.6, bar.color, 2.5)
+	l.y += 2
+}
+
+func (l *pdfLayout) codeBlock(node *blackfriday.Node) {
+	const pad = 5
+	lineHeight := codeSize * 1.35
+	charWidth := float64(glyphWidth(fontMono, ' ')) * codeSize / 1000
+	maxChars := int((l.contentWidth() - 2*pad) / charWidth)
+	if maxChars < 1 {
+		maxChars = 1
+	}
+
+	source := strings.TrimSuffix(l.doc.Text(node.Literal), "\n")
+	var lines []string
+	for _, line := range strings.Split(source, "\n") {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		// Hard-wrap long lines; code is never reflowed at spaces
+		for utf8.RuneCountInString(line) > maxChars {
+			cut := 0
+			for i := 0; i < maxChars; i++ {
+				_, size := utf8.DecodeRuneInString(line[cut:])
+				cut += size
+			}
+			lines = append(lines, line[:cut])
+			line = line[cut:]
+		}
+		lines = append(lines, line)
+	}
+
+	x := l.left + l.indent
+	w := l.contentWidth()
+	l.ensureSpace(pad + lineHeight)
+	l.fillRect(x, l.y, w, pad, codeFill)
+	l.y += pad
+	for _, line := range lines {
+		if l.y+lineHeight > l.bottom {
+			l.newPage()
+		}
+		l.fillRect(x, l.y, w, lineHeight, codeFill)
+		l.drawMarker(codeSize)
+		l.text(x+pad, l.y+codeSize*1.05, fontMono, codeSize, black, line)
+		l.y += lineHeight
+	}
+	l.fillRect(x, l.y, w, pad, codeFill)
+	l.y += pad + bodySize*0.6
+}
+
+// hline draws a horizontal line at top-down position y
+func (l *pdfLayout) hline(x1, x2, y float64, color rgb, width float64) {
+	l.page.printf("q %.3f %.3f %.3f RG %.2f w %.2f %.2f m %.2f %.2f l S Q\n",
+		color.r, color.g, color.b, width, x1, l.height-y, x2, l.height-y)
+}
+
+// vline draws a vertical line between top-down positions y1 and y2
+func (l *pdfLayout) vline(x, y1, y2 float64, color rgb, width float64) {
+	if y2 <= y1 {
+		return
+	}
+	l.page.printf("q %.3f %.3f %.3f RG %.2f w %.2f %.2f m %.2f %.2f l S Q\n",
+		color.r, color.g, color.b, width, x, l.height-y1, x, l.height-y2)
+}
+
+// fillRect fills a rectangle whose top-left corner is at top-down (x, y)
+func (l *pdfLayout) fillRect(x, y, w, h float64, color rgb) {
+	l.page.printf("q %.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f Q\n",
+		color.r, color.g, color.b, x, l.height-y-h, w, h)
+}
+
+// strokeRect outlines a rectangle whose top-left corner is at top-down (x, y)
+func (l *pdfLayout) strokeRect(x, y, w, h float64, color rgb) {
+	l.page.printf("q %.3f %.3f %.3f RG 0.5 w %.2f %.2f %.2f %.2f re S Q\n",
+		color.r, color.g, color.b, x, l.height-y-h, w, h)
+}
+
+// text draws a string with its baseline at top-down position y
+func (l *pdfLayout) text(x, baseline float64, font pdfFont, size float64, color rgb, s string) {
+	if s == "" {
+		return
+	}
+	runs := textRuns(font, s)
+	if len(runs) == 1 && runs[0].glyphs == nil {
+		l.page.printf("BT /%s %.2f Tf %.3f %.3f %.3f rg %.2f %.2f Td %s Tj ET\n",
+			font.resourceName(), size, color.r, color.g, color.b, x, l.height-baseline, pdfString(s))
+		return
+	}
+
+	// Each run is placed on its own, as the Unicode font is slanted for
+	// italics and outlined for bold
+	l.page.printf("q %.3f %.3f %.3f rg %.3f %.3f %.3f RG %.2f w BT",
+		color.r, color.g, color.b, color.r, color.g, color.b, size*0.04)
+	outlined := false
+	for _, run := range runs {
+		if run.glyphs == nil {
+			if outlined {
+				l.page.printf(" 0 Tr")
+				outlined = false
+			}
+			l.page.printf(" /%s %.2f Tf 1 0 0 1 %.2f %.2f Tm %s Tj",
+				font.resourceName(), size, x, l.height-baseline, pdfString(run.text))
+		} else {
+			if font.bold() && !outlined {
+				l.page.printf(" 2 Tr")
+				outlined = true
+			}
+			slant := 0.0
+			if font.italic() {
+				slant = 0.2
+			}
+			unicode := font.unicode()
+			l.file.useGlyphs(unicode, run)
+			l.page.printf(" /%s %.2f Tf 1 0 %.1f 1 %.2f %.2f Tm %s Tj",
+				unicode.resource, size, slant, x, l.height-baseline, pdfGlyphString(run.glyphs))
+		}
+		x += float64(runWidth(font, run)) * size / 1000
+	}
+	l.page.printf(" ET Q\n")
+}
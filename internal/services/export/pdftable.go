@@ -0,0 +1,168 @@
+package export
+
+import (
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+// tableCell is a laid out table cell
+type tableCell struct {
+	lines []pdfLine
+	align blackfriday.CellAlignFlags
+}
+
+// tableRow is a laid out table row
+type tableRow struct {
+	cells  []tableCell
+	header bool
+	height float64
+}
+
+func (l *pdfLayout) table(node *blackfriday.Node) {
+	var rows [][]*blackfriday.Node
+	var header []bool
+	node.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering && n.Type == blackfriday.TableRow {
+			var cells []*blackfriday.Node
+			for cell := n.FirstChild; cell != nil; cell = cell.Next {
+				cells = append(cells, cell)
+			}
+			rows = append(rows, cells)
+			header = append(header, n.Parent.Type == blackfriday.TableHead)
+			return blackfriday.SkipChildren
+		}
+		return blackfriday.GoToNext
+	})
+	columns := 0
+	for _, cells := range rows {
+		if len(cells) > columns {
+			columns = len(cells)
+		}
+	}
+	if columns == 0 {
+		return
+	}
+
+	runs := make([][][]textRun, len(rows))
+	for i, cells := range rows {
+		runs[i] = make([][]textRun, columns)
+		for j, cell := range cells {
+			runs[i][j] = l.inlineRuns(cell, inlineStyle{bold: header[i]}, tableSize)
+		}
+	}
+	widths := l.columnWidths(runs, columns)
+
+	laidOut := make([]tableRow, len(rows))
+	for i, cells := range rows {
+		row := tableRow{cells: make([]tableCell, columns), header: header[i]}
+		content := tableSize * lineSpacing
+		for j := range row.cells {
+			cell := tableCell{lines: l.layoutInline(runs[i][j], widths[j]-2*cellPadding)}
+			if j < len(cells) {
+				cell.align = cells[j].TableCellData.Align
+			}
+			h := 0.0
+			for _, line := range cell.lines {
+				h += line.height()
+			}
+			if h > content {
+				content = h
+			}
+			row.cells[j] = cell
+		}
+		row.height = content + 2*cellPadding
+		laidOut[i] = row
+	}
+
+	var headRows []tableRow
+	for _, row := range laidOut {
+		if row.header {
+			headRows = append(headRows, row)
+		}
+	}
+	for _, row := range laidOut {
+		if l.y+row.height > l.bottom && l.y > l.top {
+			l.newPage()
+			// Repeat the header at the top of each continued page
+			if !row.header {
+				for _, head := range headRows {
+					l.tableRow(head, widths)
+				}
+			}
+		}
+		l.tableRow(row, widths)
+	}
+	l.y += bodySize * 0.6
+}
+
+func (l *pdfLayout) tableRow(row tableRow, widths []float64) {
+	x := l.left + l.indent
+	for j, cell := range row.cells {
+		if row.header {
+			l.fillRect(x, l.y, widths[j], row.height, tableHead)
+		}
+		l.strokeRect(x, l.y, widths[j], row.height, ruleGrey)
+		y := l.y + cellPadding
+		for _, line := range cell.lines {
+			l.drawLine(line, alignedX(line, x+cellPadding, widths[j]-2*cellPadding, cell.align), y)
+			y += line.height()
+		}
+		x += widths[j]
+	}
+	l.y += row.height
+}
+
+// columnWidths shares the available width between columns. Every column
+// gets room for its longest word, and the rest is split in proportion to
+// how much each column needs to fit its text on one line.
+func (l *pdfLayout) columnWidths(runs [][][]textRun, columns int) []float64 {
+	natural := make([]float64, columns)
+	minimum := make([]float64, columns)
+	for _, row := range runs {
+		for j, cell := range row {
+			line := 0.0
+			for _, run := range cell {
+				font := run.style.font()
+				line += textWidth(font, run.size, run.text)
+				for _, word := range strings.Fields(run.text) {
+					if w := textWidth(font, run.size, word); w > minimum[j] {
+						minimum[j] = w
+					}
+				}
+			}
+			if line > natural[j] {
+				natural[j] = line
+			}
+		}
+	}
+
+	available := l.contentWidth()
+	widths := make([]float64, columns)
+	var totalNatural, totalMinimum float64
+	for j := range widths {
+		natural[j] += 2*cellPadding + 1
+		minimum[j] += 2*cellPadding + 1
+		if minimum[j] > available/float64(columns) && minimum[j] > natural[j]/2 {
+			// Very long words are split rather than squeezing other columns
+			minimum[j] = available / float64(columns)
+		}
+		totalNatural += natural[j]
+		totalMinimum += minimum[j]
+	}
+
+	switch {
+	case totalNatural <= available:
+		copy(widths, natural)
+	case totalMinimum >= available:
+		for j := range widths {
+			widths[j] = available * minimum[j] / totalMinimum
+		}
+	default:
+		spare := available - totalMinimum
+		for j := range widths {
+			widths[j] = minimum[j] + spare*(natural[j]-minimum[j])/(totalNatural-totalMinimum)
+		}
+	}
+	return widths
+}
@@ -0,0 +1,114 @@
+package export
+
+import (
+	"strconv"
+	"strings"
+)
+
+// tableOfContents inserts contents pages at the front of the document
+// listing the collected headings with page numbers and links
+func (l *pdfLayout) tableOfContents() {
+	if len(l.toc) == 0 {
+		return
+	}
+	// Lay out once to count the contents pages, then again with final page
+	// numbers now that the offset is known
+	offset := len(l.contentsPages(0))
+	pages := l.contentsPages(offset)
+
+	l.file.pages = append(pages, l.file.pages...)
+	for key, dest := range l.file.dests {
+		dest.page += len(pages)
+		l.file.dests[key] = dest
+	}
+	for i := range l.file.outlines {
+		l.file.outlines[i].dest.page += len(pages)
+	}
+}
+
+// contentsPages lays out the contents on new pages, numbering entries as if
+// offset pages precede the content
+func (l *pdfLayout) contentsPages(offset int) []*pdfPage {
+	content := l.file.pages
+	l.file.pages = nil
+	defer func() { l.file.pages = content }()
+
+	l.noteTitle = "Contents"
+	l.indent = 0
+	l.bars = nil
+	l.page = nil
+	l.newPage()
+
+	title := l.layoutInline([]textRun{{text: "Contents", style: inlineStyle{bold: true}, size: titleSize}}, l.contentWidth())
+	for _, line := range title {
+		l.drawLine(line, l.left, l.y)
+		l.y += line.height()
+	}
+	l.y += bodySize
+
+	minLevel := l.toc[0].level
+	for _, entry := range l.toc {
+		if entry.level < minLevel {
+			minLevel = entry.level
+		}
+	}
+
+	const leaderGap = 4
+	lineHeight := bodySize * 1.6
+	for _, entry := range l.toc {
+		l.ensureSpace(lineHeight)
+		font := fontRegular
+		if entry.level == minLevel {
+			font = fontBold
+		}
+		page := strconv.Itoa(l.file.dests[entry.dest].page + offset + 1)
+		x := l.left + float64(entry.level-minLevel)*12
+		pageWidth := textWidth(font, bodySize, page)
+		room := l.right - pageWidth - leaderGap*2 - x
+
+		text := entry.title
+		if textWidth(font, bodySize, text) > room {
+			text = strings.TrimSpace(fitPrefix(font, bodySize, text, room-textWidth(font, bodySize, "..."))) + "..."
+		}
+		baseline := l.y + bodySize*1.05
+		l.text(x, baseline, font, bodySize, black, text)
+		l.text(l.right-pageWidth, baseline, font, bodySize, black, page)
+
+		// Dot leaders between the title and the page number
+		start := x + textWidth(font, bodySize, text) + leaderGap
+		dot := textWidth(fontRegular, bodySize, ".")
+		if n := int((l.right - pageWidth - leaderGap - start) / dot); n > 0 {
+			l.text(l.right-pageWidth-leaderGap-float64(n)*dot, baseline, fontRegular, bodySize, grey, strings.Repeat(".", n))
+		}
+
+		l.page.links = append(l.page.links, pdfLink{
+			x1: x, y1: l.height - l.y - lineHeight, x2: l.right, y2: l.height - l.y,
+			anchor: entry.dest,
+		})
+		l.y += lineHeight
+	}
+	return l.file.pages
+}
+
+// headersAndFooters prints the header and footer templates on every page
+func (l *pdfLayout) headersAndFooters() {
+	total := strconv.Itoa(len(l.file.pages))
+	width := l.right - l.left
+	for i, page := range l.file.pages {
+		l.page = page
+		expand := func(template string) string {
+			text := strings.NewReplacer("{title}", page.title, "{page}", strconv.Itoa(i+1), "{pages}", total).Replace(template)
+			if textWidth(fontRegular, headerSize, text) > width {
+				text = strings.TrimSpace(fitPrefix(fontRegular, headerSize, text, width-textWidth(fontRegular, headerSize, "..."))) + "..."
+			}
+			return text
+		}
+		if header := expand(l.options.Header); header != "" {
+			l.text(l.left, l.top/2+headerSize/2, fontRegular, headerSize, grey, header)
+		}
+		if footer := expand(l.options.Footer); footer != "" {
+			x := l.left + (width-textWidth(fontRegular, headerSize, footer))/2
+			l.text(x, l.bottom+(l.height-l.bottom)/2+headerSize/2, fontRegular, headerSize, grey, footer)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package export
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// trueTypeFont is the part of a TrueType font needed to measure text,
+// describe the font in a PDF and embed a subset of its glyphs
+type trueTypeFont struct {
+	tables map[string][]byte
+
+	unitsPerEm int
+	numGlyphs  int
+	advances   []int
+	cmap       map[rune]uint16
+	// loca holds the offsets of the glyphs in the glyf table
+	loca []int
+
+	bbox        [4]int
+	ascent      int
+	descent     int
+	capHeight   int
+	fixedPitch  bool
+	italicAngle float64
+}
+
+var errBadTrueType = errors.New("malformed TrueType font")
+
+// parseTrueType reads the tables of a TrueType font with glyf outlines
+func parseTrueType(data []byte) (*trueTypeFont, error) {
+	if len(data) < 12 {
+		return nil, errBadTrueType
+	}
+	if v := binary.BigEndian.Uint32(data); v != 0x00010000 && v != 0x74727565 {
+		return nil, fmt.Errorf("unsupported font version %#x", v)
+	}
+	f := &trueTypeFont{tables: map[string][]byte{}}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*numTables {
+		return nil, errBadTrueType
+	}
+	for i := 0; i < numTables; i++ {
+		record := data[12+16*i:]
+		offset := int(binary.BigEndian.Uint32(record[8:]))
+		length := int(binary.BigEndian.Uint32(record[12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, errBadTrueType
+		}
+		f.tables[string(record[:4])] = data[offset : offset+length]
+	}
+	for _, tag := range []string{"head", "hhea", "hmtx", "maxp", "cmap", "loca", "glyf"} {
+		if f.tables[tag] == nil {
+			return nil, fmt.Errorf("font has no %s table", tag)
+		}
+	}
+
+	head := f.tables["head"]
+	hhea := f.tables["hhea"]
+	maxp := f.tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, errBadTrueType
+	}
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	if f.unitsPerEm == 0 {
+		return nil, errBadTrueType
+	}
+	for i := range f.bbox {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	f.capHeight = f.ascent
+	if os2 := f.tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		f.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:])))
+	}
+	if post := f.tables["post"]; len(post) >= 16 {
+		f.italicAngle = float64(int32(binary.BigEndian.Uint32(post[4:]))) / 65536
+		f.fixedPitch = binary.BigEndian.Uint32(post[12:]) != 0
+	}
+	f.numGlyphs = int(binary.BigEndian.Uint16(maxp[4:]))
+
+	// Glyphs past the last horizontal metric share its advance
+	hmtx := f.tables["hmtx"]
+	metrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	if metrics == 0 || metrics > f.numGlyphs || len(hmtx) < 4*metrics {
+		return nil, errBadTrueType
+	}
+	f.advances = make([]int, f.numGlyphs)
+	for i := range f.advances {
+		f.advances[i] = int(binary.BigEndian.Uint16(hmtx[4*min(i, metrics-1):]))
+	}
+
+	loca := f.tables["loca"]
+	f.loca = make([]int, f.numGlyphs+1)
+	long := binary.BigEndian.Uint16(head[50:]) != 0
+	if (long && len(loca) < 4*len(f.loca)) || (!long && len(loca) < 2*len(f.loca)) {
+		return nil, errBadTrueType
+	}
+	for i := range f.loca {
+		if long {
+			f.loca[i] = int(binary.BigEndian.Uint32(loca[4*i:]))
+		} else {
+			f.loca[i] = 2 * int(binary.BigEndian.Uint16(loca[2*i:]))
+		}
+		if f.loca[i] > len(f.tables["glyf"]) || (i > 0 && f.loca[i] < f.loca[i-1]) {
+			return nil, errBadTrueType
+		}
+	}
+
+	cmap, err := parseCmap(f.tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	f.cmap = cmap
+	return f, nil
+}
+
+// parseCmap reads the Unicode character map, preferring the full range
+// format 12 subtable over the Basic Multilingual Plane format 4 one
+func parseCmap(table []byte) (map[rune]uint16, error) {
+	if len(table) < 4 {
+		return nil, errBadTrueType
+	}
+	var format4, format12 []byte
+	for i := 0; i < int(binary.BigEndian.Uint16(table[2:])); i++ {
+		if len(table) < 4+8*i+8 {
+			return nil, errBadTrueType
+		}
+		record := table[4+8*i:]
+		platform := binary.BigEndian.Uint16(record)
+		encoding := binary.BigEndian.Uint16(record[2:])
+		offset := int(binary.BigEndian.Uint32(record[4:]))
+		if offset+4 > len(table) {
+			return nil, errBadTrueType
+		}
+		unicode := platform == 0 || (platform == 3 && (encoding == 1 || encoding == 10))
+		if !unicode {
+			continue
+		}
+		switch binary.BigEndian.Uint16(table[offset:]) {
+		case 4:
+			format4 = table[offset:]
+		case 12:
+			format12 = table[offset:]
+		}
+	}
+
+	cmap := map[rune]uint16{}
+	switch {
+	case format12 != nil:
+		if len(format12) < 16 {
+			return nil, errBadTrueType
+		}
+		groups := int(binary.BigEndian.Uint32(format12[12:]))
+		if len(format12) < 16+12*groups {
+			return nil, errBadTrueType
+		}
+		for i := 0; i < groups; i++ {
+			group := format12[16+12*i:]
+			start := rune(binary.BigEndian.Uint32(group))
+			end := rune(binary.BigEndian.Uint32(group[4:]))
+			glyph := binary.BigEndian.Uint32(group[8:])
+			for r := start; r <= end && r <= 0x10FFFF; r++ {
+				cmap[r] = uint16(glyph + uint32(r-start))
+			}
+		}
+	case format4 != nil:
+		if len(format4) < 14 {
+			return nil, errBadTrueType
+		}
+		segments := int(binary.BigEndian.Uint16(format4[6:])) / 2
+		ends := 14
+		starts := ends + 2*segments + 2
+		deltas := starts + 2*segments
+		offsets := deltas + 2*segments
+		if len(format4) < offsets+2*segments {
+			return nil, errBadTrueType
+		}
+		for i := 0; i < segments; i++ {
+			end := int(binary.BigEndian.Uint16(format4[ends+2*i:]))
+			start := int(binary.BigEndian.Uint16(format4[starts+2*i:]))
+			delta := binary.BigEndian.Uint16(format4[deltas+2*i:])
+			rangeOffset := int(binary.BigEndian.Uint16(format4[offsets+2*i:]))
+			for c := start; c <= end && c != 0xFFFF; c++ {
+				glyph := uint16(c) + delta
+				if rangeOffset != 0 {
+					at := offsets + 2*i + rangeOffset + 2*(c-start)
+					if at+2 > len(format4) {
+						return nil, errBadTrueType
+					}
+					glyph = binary.BigEndian.Uint16(format4[at:])
+					if glyph != 0 {
+						glyph += delta
+					}
+				}
+				cmap[rune(c)] = glyph
+			}
+		}
+	default:
+		return nil, errors.New("font has no Unicode character map")
+	}
+	return cmap, nil
+}
+
+// glyph returns the glyph for a character, or 0 when the font has none
+func (f *trueTypeFont) glyph(r rune) uint16 {
+	g := f.cmap[r]
+	if int(g) >= f.numGlyphs {
+		return 0
+	}
+	return g
+}
+
+// advance returns the advance width of a glyph in 1/1000 em
+func (f *trueTypeFont) advance(g uint16) int {
+	return f.scale(f.advances[g])
+}
+
+// scale converts font units to 1/1000 em
+func (f *trueTypeFont) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
+
+// glyphData returns the outline of a glyph, empty for blank glyphs
+func (f *trueTypeFont) glyphData(g int) []byte {
+	return f.tables["glyf"][f.loca[g]:f.loca[g+1]]
+}
+
+// Composite glyph flags
+const (
+	compositeArgsAreWords = 0x0001
+	compositeHaveScale    = 0x0008
+	compositeMore         = 0x0020
+	compositeXYScale      = 0x0040
+	compositeTwoByTwo     = 0x0080
+)
+
+// components returns the glyphs a composite glyph is built from
+func (f *trueTypeFont) components(g int) []int {
+	data := f.glyphData(g)
+	if len(data) < 10 || int16(binary.BigEndian.Uint16(data)) >= 0 {
+		return nil
+	}
+	var glyphs []int
+	for at := 10; at+4 <= len(data); {
+		flags := binary.BigEndian.Uint16(data[at:])
+		glyphs = append(glyphs, int(binary.BigEndian.Uint16(data[at+2:])))
+		at += 4
+		if flags&compositeArgsAreWords != 0 {
+			at += 4
+		} else {
+			at += 2
+		}
+		switch {
+		case flags&compositeHaveScale != 0:
+			at += 2
+		case flags&compositeXYScale != 0:
+			at += 4
+		case flags&compositeTwoByTwo != 0:
+			at += 8
+		}
+		if flags&compositeMore == 0 {
+			break
+		}
+	}
+	return glyphs
+}
+
+// subsetTables are copied into subsets: the tables a PDF viewer needs to
+// draw glyphs, the hinting programs the outlines rely on, and the
+// character map some viewers look glyphs up in
+var subsetTables = []string{"cmap", "cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "prep"}
+
+// subset returns a font file holding only the outlines of the given glyphs
+// and the glyphs they are composed of. Other glyphs are left empty, so
+// glyph numbers stay the same.
+func (f *trueTypeFont) subset(glyphs map[uint16]bool) []byte {
+	keep := make([]bool, f.numGlyphs)
+	pending := []int{0}
+	for g := range glyphs {
+		if int(g) < f.numGlyphs {
+			pending = append(pending, int(g))
+		}
+	}
+	for len(pending) > 0 {
+		g := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if keep[g] {
+			continue
+		}
+		keep[g] = true
+		for _, c := range f.components(g) {
+			if c < f.numGlyphs && !keep[c] {
+				pending = append(pending, c)
+			}
+		}
+	}
+
+	var glyf []byte
+	loca := make([]byte, 4*(f.numGlyphs+1))
+	for g := 0; g < f.numGlyphs; g++ {
+		binary.BigEndian.PutUint32(loca[4*g:], uint32(len(glyf)))
+		if keep[g] {
+			glyf = append(glyf, f.glyphData(g)...)
+			for len(glyf)%4 != 0 {
+				glyf = append(glyf, 0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(loca[4*f.numGlyphs:], uint32(len(glyf)))
+
+	// The new loca table uses long offsets, and the checksum adjustment is
+	// worked out once the file is complete
+	head := append([]byte(nil), f.tables["head"]...)
+	binary.BigEndian.PutUint16(head[50:], 1)
+	binary.BigEndian.PutUint32(head[8:], 0)
+
+	tables := map[string][]byte{}
+	for _, tag := range subsetTables {
+		if data, ok := f.tables[tag]; ok {
+			tables[tag] = data
+		}
+	}
+	tables["glyf"], tables["loca"], tables["head"] = glyf, loca, head
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	searchRange, selector := 1, 0
+	for searchRange*2 <= len(tags) {
+		searchRange *= 2
+		selector++
+	}
+	out := make([]byte, 12+16*len(tags))
+	binary.BigEndian.PutUint32(out, 0x00010000)
+	binary.BigEndian.PutUint16(out[4:], uint16(len(tags)))
+	binary.BigEndian.PutUint16(out[6:], uint16(16*searchRange))
+	binary.BigEndian.PutUint16(out[8:], uint16(selector))
+	binary.BigEndian.PutUint16(out[10:], uint16(16*(len(tags)-searchRange)))
+	headAt := 0
+	for i, tag := range tags {
+		data := tables[tag]
+		record := out[12+16*i:]
+		copy(record, tag)
+		binary.BigEndian.PutUint32(record[4:], trueTypeChecksum(data))
+		binary.BigEndian.PutUint32(record[8:], uint32(len(out)))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(data)))
+		if tag == "head" {
+			headAt = len(out)
+		}
+		out = append(out, data...)
+		for len(out)%4 != 0 {
+			out = append(out, 0)
+		}
+	}
+	binary.BigEndian.PutUint32(out[headAt+8:], 0xB1B0AFBA-trueTypeChecksum(out))
+	return out
+}
+
+// trueTypeChecksum sums data as big-endian 32-bit words
+func trueTypeChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
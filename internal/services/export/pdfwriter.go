@@ -0,0 +1,385 @@
+package export
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// pdfPage is a laid out page: its content stream and link annotations
+type pdfPage struct {
+	content bytes.Buffer
+	links   []pdfLink
+	// title is the title of the note the page belongs to, used in headers
+	title string
+}
+
+// printf appends an operator sequence to the page's content stream
+func (p *pdfPage) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&p.content, format, args...)
+}
+
+// pdfLink is a clickable area in PDF user space (origin bottom left)
+type pdfLink struct {
+	x1, y1, x2, y2 float64
+	// Exactly one of uri and anchor is set
+	uri    string
+	anchor string
+}
+
+// pdfDest is a position in the document a link or bookmark can jump to
+type pdfDest struct {
+	page int
+	y    float64
+}
+
+// pdfOutline is a bookmark entry; level 1 entries are top level
+type pdfOutline struct {
+	title string
+	level int
+	dest  pdfDest
+}
+
+// pdfImage is an image XObject ready to be written
+type pdfImage struct {
+	name       string
+	width      int
+	height     int
+	colorSpace string
+	filter     string
+	data       []byte
+	// smask holds 8-bit alpha for images with transparency
+	smask []byte
+}
+
+// pdfFile assembles pages, images, bookmarks and link targets into a
+// complete PDF 1.4 file
+type pdfFile struct {
+	width, height float64
+	title         string
+	pages         []*pdfPage
+	images        []*pdfImage
+	dests         map[string]pdfDest
+	outlines      []pdfOutline
+	// glyphs holds the glyphs drawn with each Unicode font and the
+	// characters they stand for
+	glyphs map[*unicodeFont]map[uint16]rune
+}
+
+// useGlyphs records the glyphs of a run drawn with a Unicode font
+func (f *pdfFile) useGlyphs(font *unicodeFont, run pdfTextRun) {
+	used := f.glyphs[font]
+	if used == nil {
+		used = map[uint16]rune{}
+		f.glyphs[font] = used
+	}
+	i := 0
+	for _, r := range run.text {
+		used[run.glyphs[i]] = r
+		i++
+	}
+}
+
+// pdfObjects collects indirect objects by number
+type pdfObjects struct {
+	bodies [][]byte
+}
+
+func (o *pdfObjects) reserve() int {
+	o.bodies = append(o.bodies, nil)
+	return len(o.bodies)
+}
+
+func (o *pdfObjects) set(n int, body string) {
+	o.bodies[n-1] = []byte(body)
+}
+
+func (o *pdfObjects) setStream(n int, dict string, data []byte, compress bool) error {
+	if compress {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+		dict += " /Filter /FlateDecode"
+	}
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "<< %s /Length %d >>\nstream\n", dict, len(data))
+	body.Write(data)
+	body.WriteString("\nendstream")
+	o.bodies[n-1] = body.Bytes()
+	return nil
+}
+
+func (f *pdfFile) write(w io.Writer) error {
+	var objs pdfObjects
+	catalog := objs.reserve()
+	pagesRoot := objs.reserve()
+	info := objs.reserve()
+
+	fontObjs := make([]int, len(pdfFontNames))
+	for i, name := range pdfFontNames {
+		fontObjs[i] = objs.reserve()
+		objs.set(fontObjs[i], fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+	}
+
+	// Every page shares one resource dictionary with all fonts and images
+	var resources strings.Builder
+	resources.WriteString("<< /ProcSet [/PDF /Text /ImageB /ImageC] /Font <<")
+	for i := range pdfFontNames {
+		fmt.Fprintf(&resources, " /%s %d 0 R", pdfFont(i).resourceName(), fontObjs[i])
+	}
+	for _, font := range unicodeFonts {
+		if len(f.glyphs[font]) == 0 {
+			continue
+		}
+		obj, err := writeUnicodeFont(&objs, font, f.glyphs[font])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&resources, " /%s %d 0 R", font.resource, obj)
+	}
+	resources.WriteString(" >>")
+	if len(f.images) > 0 {
+		resources.WriteString(" /XObject <<")
+		for _, img := range f.images {
+			obj := objs.reserve()
+			dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8",
+				img.width, img.height, img.colorSpace)
+			compress := img.filter == ""
+			if !compress {
+				dict += " /Filter /" + img.filter
+			}
+			if img.smask != nil {
+				mask := objs.reserve()
+				if err := objs.setStream(mask, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8",
+					img.width, img.height), img.smask, true); err != nil {
+					return err
+				}
+				dict += fmt.Sprintf(" /SMask %d 0 R", mask)
+			}
+			if err := objs.setStream(obj, dict, img.data, compress); err != nil {
+				return err
+			}
+			fmt.Fprintf(&resources, " /%s %d 0 R", img.name, obj)
+		}
+		resources.WriteString(" >>")
+	}
+	resources.WriteString(" >>")
+
+	pageObjs := make([]int, len(f.pages))
+	contentObjs := make([]int, len(f.pages))
+	for i := range f.pages {
+		pageObjs[i] = objs.reserve()
+		contentObjs[i] = objs.reserve()
+	}
+	destArray := func(d pdfDest) string {
+		return fmt.Sprintf("[%d 0 R /XYZ 0 %.2f 0]", pageObjs[d.page], d.y)
+	}
+
+	kids := make([]string, len(f.pages))
+	for i, page := range f.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pageObjs[i])
+
+		var annots []string
+		for _, link := range page.links {
+			action := ""
+			switch {
+			case link.uri != "":
+				action = "/A << /S /URI /URI " + pdfString(link.uri) + " >>"
+			case link.anchor != "":
+				dest, ok := f.dests[link.anchor]
+				if !ok {
+					continue
+				}
+				action = "/Dest " + destArray(dest)
+			}
+			annots = append(annots, fmt.Sprintf("<< /Type /Annot /Subtype /Link /Rect [%.2f %.2f %.2f %.2f] /Border [0 0 0] %s >>",
+				link.x1, link.y1, link.x2, link.y2, action))
+		}
+		dict := fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources %s /Contents %d 0 R",
+			pagesRoot, f.width, f.height, resources.String(), contentObjs[i])
+		if len(annots) > 0 {
+			dict += " /Annots [" + strings.Join(annots, " ") + "]"
+		}
+		objs.set(pageObjs[i], dict+" >>")
+		if err := objs.setStream(contentObjs[i], "", page.content.Bytes(), true); err != nil {
+			return err
+		}
+	}
+	objs.set(pagesRoot, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(f.pages)))
+
+	catalogDict := fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R", pagesRoot)
+	if len(f.outlines) > 0 {
+		catalogDict += fmt.Sprintf(" /Outlines %d 0 R /PageMode /UseOutlines", f.writeOutlines(&objs, destArray))
+	}
+	objs.set(catalog, catalogDict+" >>")
+	objs.set(info, fmt.Sprintf("<< /Title %s /Producer %s /CreationDate (D:%s) >>",
+		pdfTextString(f.title), pdfTextString("go-markdown-note-taking-app"), time.Now().UTC().Format("20060102150405Z")))
+
+	// Serialize with a cross-reference table of byte offsets
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	offsets := make([]int, len(objs.bodies))
+	for i, body := range objs.bodies {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(body)
+		out.WriteString("\nendobj\n")
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objs.bodies)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(objs.bodies)+1, catalog, info, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// writeUnicodeFont writes a subset of a Unicode font holding the used
+// glyphs as a Type 0 font, returning its object number. The ToUnicode map
+// lets viewers copy and search the text.
+func writeUnicodeFont(objs *pdfObjects, font *unicodeFont, used map[uint16]rune) (int, error) {
+	ttf := font.load()
+	glyphs := make([]uint16, 0, len(used))
+	keep := map[uint16]bool{}
+	for g := range used {
+		glyphs = append(glyphs, g)
+		keep[g] = true
+	}
+	sort.Slice(glyphs, func(i, j int) bool { return glyphs[i] < glyphs[j] })
+
+	// Subsets are named with a tag derived from their glyphs
+	sum := sha1.New()
+	for _, g := range glyphs {
+		sum.Write([]byte{byte(g >> 8), byte(g)})
+	}
+	var tag [6]byte
+	for i, b := range sum.Sum(nil)[:len(tag)] {
+		tag[i] = 'A' + b%26
+	}
+	name := string(tag[:]) + "+" + font.name
+
+	file := objs.reserve()
+	data := ttf.subset(keep)
+	if err := objs.setStream(file, fmt.Sprintf("/Length1 %d", len(data)), data, true); err != nil {
+		return 0, err
+	}
+
+	flags := 32
+	if ttf.fixedPitch {
+		flags |= 1
+	}
+	descriptor := objs.reserve()
+	objs.set(descriptor, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags %d /FontBBox [%d %d %d %d] /ItalicAngle %.1f /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		name, flags, ttf.scale(ttf.bbox[0]), ttf.scale(ttf.bbox[1]), ttf.scale(ttf.bbox[2]), ttf.scale(ttf.bbox[3]),
+		ttf.italicAngle, ttf.scale(ttf.ascent), ttf.scale(ttf.descent), ttf.scale(ttf.capHeight), file))
+
+	var widths strings.Builder
+	for _, g := range glyphs {
+		fmt.Fprintf(&widths, " %d [%d]", g, ttf.advance(g))
+	}
+	cid := objs.reserve()
+	objs.set(cid, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /W [%s ] >>",
+		name, descriptor, widths.String()))
+
+	var cmap strings.Builder
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(glyphs); start += 100 {
+		chunk := glyphs[start:min(start+100, len(glyphs))]
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", len(chunk))
+		for _, g := range chunk {
+			fmt.Fprintf(&cmap, "<%04X> <%s\n", g, strings.TrimPrefix(pdfTextString(string(used[g])), "<FEFF"))
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend")
+	toUnicode := objs.reserve()
+	if err := objs.setStream(toUnicode, "", []byte(cmap.String()), true); err != nil {
+		return 0, err
+	}
+
+	obj := objs.reserve()
+	objs.set(obj, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		name, cid, toUnicode))
+	return obj, nil
+}
+
+// outlineNode is a bookmark in the nested outline tree
+type outlineNode struct {
+	entry    pdfOutline
+	obj      int
+	children []*outlineNode
+}
+
+// writeOutlines nests the flat bookmark list by heading level and writes the
+// outline dictionaries, returning the object number of the outline root
+func (f *pdfFile) writeOutlines(objs *pdfObjects, destArray func(pdfDest) string) int {
+	root := &outlineNode{obj: objs.reserve()}
+	stack := []*outlineNode{root}
+	for _, entry := range f.outlines {
+		for len(stack) > 1 && stack[len(stack)-1].entry.level >= entry.level {
+			stack = stack[:len(stack)-1]
+		}
+		n := &outlineNode{entry: entry, obj: objs.reserve()}
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, n)
+		stack = append(stack, n)
+	}
+
+	var count func(n *outlineNode) int
+	count = func(n *outlineNode) int {
+		total := len(n.children)
+		for _, c := range n.children {
+			total += count(c)
+		}
+		return total
+	}
+
+	var write func(n, parent *outlineNode)
+	write = func(n, parent *outlineNode) {
+		var dict strings.Builder
+		if parent == nil {
+			dict.WriteString("<< /Type /Outlines")
+		} else {
+			fmt.Fprintf(&dict, "<< /Title %s /Parent %d 0 R /Dest %s", pdfTextString(n.entry.title), parent.obj, destArray(n.entry.dest))
+			siblings := parent.children
+			for i, s := range siblings {
+				if s != n {
+					continue
+				}
+				if i > 0 {
+					fmt.Fprintf(&dict, " /Prev %d 0 R", siblings[i-1].obj)
+				}
+				if i+1 < len(siblings) {
+					fmt.Fprintf(&dict, " /Next %d 0 R", siblings[i+1].obj)
+				}
+			}
+		}
+		if len(n.children) > 0 {
+			fmt.Fprintf(&dict, " /First %d 0 R /Last %d 0 R /Count %d", n.children[0].obj, n.children[len(n.children)-1].obj, count(n))
+		}
+		dict.WriteString(" >>")
+		objs.set(n.obj, dict.String())
+		for _, c := range n.children {
+			write(c, n)
+		}
+	}
+	write(root, nil)
+	return root.obj
+}
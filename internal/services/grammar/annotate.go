@@ -0,0 +1,101 @@
+package grammar
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+)
+
+// IssueAnchor returns the id of the element highlighting an issue in
+// annotated HTML
+func IssueAnchor(issue models.GrammarIssue) string {
+	return "grammar-issue-" + issue.ID
+}
+
+// Marks returns the marks highlighting issues in the HTML of the content
+// they were found in. Each mark has the class grammar-issue and the
+// severity, and data attributes with the issue's ID, rule, category,
+// severity and message; fixable issues have their replacement, and issues
+// with suggestions a JSON array of them.
+func Marks(issues []models.GrammarIssue) []markdown.Mark {
+	marks := make([]markdown.Mark, 0, len(issues))
+	for _, issue := range issues {
+		attributes := map[string]string{
+			"class":         "grammar-issue grammar-" + issue.Severity,
+			"title":         issue.Message,
+			"data-issue":    issue.ID,
+			"data-rule":     issue.Rule,
+			"data-category": issue.Type,
+			"data-severity": issue.Severity,
+			"data-message":  issue.Message,
+		}
+		if issue.Fixable {
+			attributes["data-replacement"] = issue.Replacement
+		}
+		if len(issue.Suggestions) > 0 {
+			suggestions, _ := json.Marshal(issue.Suggestions)
+			attributes["data-suggestions"] = string(suggestions)
+		}
+		marks = append(marks, markdown.Mark{
+			Start:      issue.Offset,
+			End:        issue.Offset + issue.Length,
+			ID:         IssueAnchor(issue),
+			Attributes: attributes,
+		})
+	}
+	return marks
+}
+
+// Summary returns a panel with the score of a check, its issues by
+// category and a list of the issues linking to their marks
+func Summary(result *models.GrammarCheckResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<aside class=\"grammar-summary\" data-score=\"%.1f\">\n<h2>Grammar</h2>\n", result.Score)
+	if result.Disabled {
+		b.WriteString("<p>Grammar checking is turned off in the front matter of this note.</p>\n</aside>\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "<p>Score <strong>%.1f</strong> &middot; %s", result.Score, plural(len(result.Issues), "issue"))
+	if result.Suppressed > 0 {
+		fmt.Fprintf(&b, " &middot; %d suppressed", result.Suppressed)
+	}
+	b.WriteString("</p>\n")
+	if len(result.Issues) == 0 {
+		b.WriteString("</aside>\n")
+		return b.String()
+	}
+
+	b.WriteString("<ul class=\"grammar-categories\">\n")
+	for _, category := range result.Breakdown {
+		if category.Issues > 0 {
+			fmt.Fprintf(&b, "<li data-category=\"%s\">%s: %d</li>\n", html.EscapeString(category.Category), html.EscapeString(category.Category), category.Issues)
+		}
+	}
+	b.WriteString("</ul>\n<ol class=\"grammar-issues\">\n")
+	for _, issue := range result.Issues {
+		fmt.Fprintf(&b, "<li class=\"grammar-%s\"><a href=\"#%s\">%s</a> <code>%s</code>",
+			html.EscapeString(issue.Severity), html.EscapeString(IssueAnchor(issue)), html.EscapeString(issue.Message), html.EscapeString(issue.Rule))
+		switch {
+		case issue.Fixable && issue.Replacement == "":
+			b.WriteString(" &rarr; remove")
+		case issue.Fixable:
+			fmt.Fprintf(&b, " &rarr; <q>%s</q>", html.EscapeString(issue.Replacement))
+		}
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</ol>\n</aside>\n")
+	return b.String()
+}
+
+// plural returns a count with a noun, in the plural unless it is one
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,53 @@
+package grammar
+
+import (
+	"testing"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarks(t *testing.T) {
+	service := &Service{}
+	result, err := service.Check("The the cat sat  on the mat.")
+	require.NoError(t, err)
+	require.Len(t, result.Issues, 2)
+
+	marks := Marks(result.Issues)
+	require.Len(t, marks, 2)
+	issue := result.Issues[0]
+	assert.Equal(t, issue.Offset, marks[0].Start)
+	assert.Equal(t, issue.Offset+issue.Length, marks[0].End)
+	assert.Equal(t, "grammar-issue-"+issue.ID, marks[0].ID)
+	assert.Equal(t, "grammar-issue grammar-"+issue.Severity, marks[0].Attributes["class"])
+	assert.Equal(t, issue.ID, marks[0].Attributes["data-issue"])
+	assert.Equal(t, "repeated-words", marks[0].Attributes["data-rule"])
+	assert.Equal(t, issue.Message, marks[0].Attributes["data-message"])
+	assert.Equal(t, issue.Replacement, marks[0].Attributes["data-replacement"])
+
+	// Issues without a fix have no replacement, and suggestions are JSON
+	marks = Marks([]models.GrammarIssue{{ID: "a", Suggestions: []string{"cat", "can't"}}})
+	_, ok := marks[0].Attributes["data-replacement"]
+	assert.False(t, ok)
+	assert.Equal(t, `["cat","can't"]`, marks[0].Attributes["data-suggestions"])
+}
+
+func TestSummary(t *testing.T) {
+	service := &Service{}
+	result, err := service.Check("<!-- grammar-ignore-next-line spacing -->\nThe the cat sat  on the mat & <b>.")
+	require.NoError(t, err)
+	require.Len(t, result.Issues, 1)
+
+	summary := Summary(result)
+	assert.Contains(t, summary, `<aside class="grammar-summary" data-score=`)
+	assert.Contains(t, summary, "1 issue &middot; 1 suppressed")
+	assert.Contains(t, summary, `<li data-category="grammar">grammar: 1</li>`)
+	assert.Contains(t, summary, `<a href="#grammar-issue-`+result.Issues[0].ID+`">`)
+	assert.Contains(t, summary, "<code>repeated-words</code>")
+
+	result, err = service.Check("---\ngrammar: off\n---\nThe the cat.")
+	require.NoError(t, err)
+	assert.Contains(t, Summary(result), "turned off")
+	assert.NotContains(t, Summary(result), "<ol")
+}
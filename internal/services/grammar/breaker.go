@@ -0,0 +1,91 @@
+package grammar
+
+import (
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// breaker is a circuit breaker. It opens after threshold failures in a row
+// and stays open for the cooldown, then lets a single trial call through:
+// the circuit closes when the trial succeeds and opens again when it fails.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	trial     bool
+	// now returns the current time; tests replace it
+	now func() time.Time
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow reports whether a call may be made. A caller that is allowed must
+// report the outcome with success or failure, or call release.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state() {
+	case BreakerClosed:
+		return true
+	case BreakerHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	}
+	return false
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.trial = false
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+	b.trial = false
+}
+
+// release ends a call without an outcome, such as one the caller
+// cancelled
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// State returns the state of the circuit
+func (b *breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state()
+}
+
+func (b *breaker) state() string {
+	if b.failures < b.threshold {
+		return BreakerClosed
+	}
+	if b.now().Sub(b.openedAt) < b.cooldown {
+		return BreakerOpen
+	}
+	return BreakerHalfOpen
+}
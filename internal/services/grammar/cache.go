@@ -0,0 +1,118 @@
+package grammar
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+)
+
+// maxCachedOptions is the most results cached per note, one for each set
+// of options it was checked with
+const maxCachedOptions = 8
+
+// resultCache holds the check results of stored notes by note ID and
+// options. A result is only used for the content and rule set it was
+// computed for.
+type resultCache struct {
+	mu    sync.Mutex
+	notes map[string]map[string]cachedResult
+}
+
+type cachedResult struct {
+	hash    string
+	version string
+	result  models.GrammarCheckResult
+}
+
+func (c *resultCache) get(id, key, hash, version string) (*models.GrammarCheckResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.notes[id][key]
+	if !ok || entry.hash != hash || entry.version != version {
+		return nil, false
+	}
+	result := entry.result
+	return &result, true
+}
+
+func (c *resultCache) put(id, key string, entry cachedResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.notes == nil {
+		c.notes = map[string]map[string]cachedResult{}
+	}
+	results := c.notes[id]
+	if results == nil || len(results) >= maxCachedOptions {
+		results = map[string]cachedResult{}
+		c.notes[id] = results
+	}
+	results[key] = entry
+}
+
+func (c *resultCache) invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.notes, id)
+}
+
+// ContentHash returns the SHA-256 hash of content, in hex
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheKey identifies the options of a check in the cache
+func (o Options) cacheKey() string {
+	ids := make([]string, 0, len(o.Rules))
+	for id, enabled := range o.Rules {
+		ids = append(ids, id+"="+strconv.FormatBool(enabled))
+	}
+	sort.Strings(ids)
+	ignored := append([]string(nil), o.Ignored...)
+	sort.Strings(ignored)
+	return o.Language + "\x00" + strings.Join(ids, ",") + "\x00" + strings.Join(ignored, ",")
+}
+
+// CheckNote checks the content of a stored note like CheckWithOptions. The
+// result is cached by the hash of the content, the rule set version and
+// the options, until InvalidateNote is called for the note; it reports
+// whether the result came from the cache. Checks of changed blocks, with
+// Previous set, are not cached.
+func (s *Service) CheckNote(id, content string, options Options) (*models.GrammarCheckResult, bool, error) {
+	return s.CheckNoteContext(context.Background(), id, content, options)
+}
+
+// CheckNoteContext is CheckNote with a context. Results of a checker that
+// fell back to another are not cached, so that the note is checked again
+// once the checker recovers.
+func (s *Service) CheckNoteContext(ctx context.Context, id, content string, options Options) (*models.GrammarCheckResult, bool, error) {
+	if options.Previous != nil {
+		result, err := s.CheckContext(ctx, content, options)
+		return result, false, err
+	}
+
+	key, hash, version := options.cacheKey(), ContentHash(content), s.RuleSetVersion()
+	if result, ok := s.cache.get(id, key, hash, version); ok {
+		return result, true, nil
+	}
+	result, err := s.CheckContext(ctx, content, options)
+	if err != nil {
+		return nil, false, err
+	}
+	if result.Checker == s.Checker().Name() {
+		s.cache.put(id, key, cachedResult{hash: hash, version: version, result: *result})
+	}
+	return result, false, nil
+}
+
+// InvalidateNote drops the cached results of a note. It is called when the
+// note is saved or deleted.
+func (s *Service) InvalidateNote(id string) {
+	s.cache.invalidate(id)
+}
@@ -0,0 +1,74 @@
+package grammar
+
+import (
+	"testing"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/spelling"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrammarService_CheckNote(t *testing.T) {
+	service := NewService()
+	const content = "The the cat sat."
+
+	first, cached, err := service.CheckNote("note", content, Options{})
+	require.NoError(t, err)
+	assert.False(t, cached)
+	second, cached, err := service.CheckNote("note", content, Options{})
+	require.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, first, second)
+
+	// Other options and other content are checked again
+	_, cached, err = service.CheckNote("note", content, Options{Rules: map[string]bool{"repeated-words": false}})
+	require.NoError(t, err)
+	assert.False(t, cached)
+	_, cached, err = service.CheckNote("note", "The cat sat.", Options{})
+	require.NoError(t, err)
+	assert.False(t, cached)
+
+	// Changing the rule set invalidates results
+	version := service.RuleSetVersion()
+	custom := spelling.NewCustomDictionary()
+	service.SetCustomDictionary(custom)
+	assert.NotEqual(t, version, service.RuleSetVersion())
+	version = service.RuleSetVersion()
+	require.NoError(t, custom.Add("Zentrix"))
+	assert.NotEqual(t, version, service.RuleSetVersion())
+	_, cached, err = service.CheckNote("note", "The cat sat.", Options{})
+	require.NoError(t, err)
+	assert.False(t, cached)
+
+	_, _, err = service.CheckNote("note", content, Options{})
+	require.NoError(t, err)
+	service.InvalidateNote("note")
+	_, cached, err = service.CheckNote("note", content, Options{})
+	require.NoError(t, err)
+	assert.False(t, cached)
+}
+
+func TestGrammarService_CheckWithOptions_Previous(t *testing.T) {
+	service := NewService()
+	previous := "The the first paragraph.\n\nA second paragraph.\n"
+	content := "The the first paragraph.\n\nA second  paragraph.\n\nthird one.\n"
+
+	result, err := service.CheckWithOptions(content, Options{Previous: &previous})
+	require.NoError(t, err)
+	var rules []string
+	for _, issue := range result.Issues {
+		rules = append(rules, issue.Rule)
+	}
+	assert.Equal(t, []string{"multiple-spaces", "sentence-capitalization"}, rules)
+	// Sentences are counted in the whole note
+	assert.Equal(t, 2, result.Issues[1].Sentence)
+
+	full, err := service.CheckWithOptions(content, Options{})
+	require.NoError(t, err)
+	assert.Len(t, full.Issues, 3)
+	assert.Equal(t, full.Issues[1:], result.Issues)
+
+	result, err = service.CheckWithOptions(content, Options{Previous: &content})
+	require.NoError(t, err)
+	assert.Empty(t, result.Issues)
+}
@@ -0,0 +1,93 @@
+package grammar
+
+import (
+	"context"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+)
+
+// LocalChecker is the name of the checker running the rules of the service
+const LocalChecker = "local"
+
+// Checker finds issues in the prose of a note. The service parses the
+// markdown, resolves the language and turns findings into issues, so that
+// checkers only look at prose.
+type Checker interface {
+	// Name identifies the checker in results
+	Name() string
+	Check(ctx context.Context, request CheckRequest) (*CheckResponse, error)
+}
+
+// CheckRequest is the prose a checker checks
+type CheckRequest struct {
+	// Language is the language of the prose, never LanguageAuto
+	Language string
+	// Blocks are the prose blocks to check
+	Blocks []markdown.ProseBlock
+	// Options select the rules; checkers apply them as far as they can,
+	// and the service drops findings of disabled rules and categories
+	Options Options
+}
+
+// CheckResponse holds the findings of a checker
+type CheckResponse struct {
+	Findings []Finding
+	// Checker is the name of the checker that found them, which is not
+	// the checker asked when it fell back to another
+	Checker string
+}
+
+// Finding is an issue a rule found in a prose block
+type Finding struct {
+	// Block is the index of the block in the request
+	Block    int
+	Rule     string
+	Category string
+	Severity string
+	// Match has offsets in the text of the block
+	Match Match
+}
+
+// localChecker runs the rules of a service
+type localChecker struct {
+	service *Service
+}
+
+// Local returns the checker running the registered, added and style rules
+// and the spelling rule of the service
+func (s *Service) Local() Checker {
+	return localChecker{service: s}
+}
+
+func (c localChecker) Name() string { return LocalChecker }
+
+func (c localChecker) Check(ctx context.Context, request CheckRequest) (*CheckResponse, error) {
+	var rules []Rule
+	for _, rule := range c.service.Rules(request.Language) {
+		if request.Options.Enabled(rule) {
+			rules = append(rules, rule)
+		}
+	}
+
+	response := &CheckResponse{Checker: LocalChecker}
+	for i, block := range request.Blocks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for _, rule := range rules {
+			if !appliesTo(rule, block.Kind) {
+				continue
+			}
+			for _, match := range rule.Match(block.Text) {
+				response.Findings = append(response.Findings, Finding{
+					Block:    i,
+					Rule:     rule.ID(),
+					Category: rule.Category(),
+					Severity: rule.Severity(),
+					Match:    match,
+				})
+			}
+		}
+	}
+	return response, nil
+}
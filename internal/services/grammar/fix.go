@@ -0,0 +1,100 @@
+package grammar
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+)
+
+// Reasons fixes are skipped
+const (
+	SkipNotFixable = "not-fixable"
+	SkipOverlap    = "overlap"
+)
+
+// severityRanks orders severities from the most to the least severe
+var severityRanks = map[string]int{
+	SeverityError:   0,
+	SeverityWarning: 1,
+	SeverityInfo:    2,
+}
+
+// issueID returns the ID of an issue of a rule at an offset of the checked
+// text
+func issueID(rule string, offset int, text string) string {
+	sum := sha256.Sum256([]byte(rule + "\x00" + strconv.Itoa(offset) + "\x00" + text))
+	return hex.EncodeToString(sum[:6])
+}
+
+// ApplyFixes replaces the text of the fixable issues with their
+// replacements and returns the fixed text, the issues applied, sorted by
+// offset, and those skipped. Issues without a fix are skipped, and so are
+// issues that overlap another: the more severe issue is applied, then the
+// one that comes first. Two fixes that insert text at the same offset
+// overlap too.
+func ApplyFixes(text string, issues []models.GrammarIssue) (string, []models.GrammarIssue, []models.SkippedFix) {
+	candidates := make([]models.GrammarIssue, 0, len(issues))
+	var skipped []models.SkippedFix
+	for _, issue := range issues {
+		if !issue.Fixable || issue.Offset < 0 || issue.Offset+issue.Length > len(text) {
+			skipped = append(skipped, models.SkippedFix{Issue: issue, Reason: SkipNotFixable})
+			continue
+		}
+		candidates = append(candidates, issue)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if ra, rb := severityRanks[a.Severity], severityRanks[b.Severity]; ra != rb {
+			return ra < rb
+		}
+		return a.Offset < b.Offset
+	})
+
+	var applied []models.GrammarIssue
+	for _, issue := range candidates {
+		if other, ok := overlapping(issue, applied); ok {
+			skipped = append(skipped, models.SkippedFix{
+				Issue:  issue,
+				Reason: SkipOverlap,
+				Detail: fmt.Sprintf("overlaps issue %s", other.ID),
+			})
+			continue
+		}
+		applied = append(applied, issue)
+	}
+	sort.Slice(applied, func(i, j int) bool { return applied[i].Offset < applied[j].Offset })
+	sort.SliceStable(skipped, func(i, j int) bool { return skipped[i].Issue.Offset < skipped[j].Issue.Offset })
+
+	var b strings.Builder
+	last := 0
+	for _, issue := range applied {
+		b.WriteString(text[last:issue.Offset])
+		b.WriteString(issue.Replacement)
+		last = issue.Offset + issue.Length
+	}
+	b.WriteString(text[last:])
+	return b.String(), applied, skipped
+}
+
+// overlapping returns an issue of others whose text overlaps that of
+// issue, or that inserts text at the same offset
+func overlapping(issue models.GrammarIssue, others []models.GrammarIssue) (models.GrammarIssue, bool) {
+	start, end := issue.Offset, issue.Offset+issue.Length
+	for _, other := range others {
+		otherStart, otherEnd := other.Offset, other.Offset+other.Length
+		if start < otherEnd && otherStart < end {
+			return other, true
+		}
+		// Empty ranges insert text; they clash with edits starting or
+		// ending where they insert
+		if (start == end || otherStart == otherEnd) && (start == otherStart || start == otherEnd || end == otherStart) {
+			return other, true
+		}
+	}
+	return models.GrammarIssue{}, false
+}
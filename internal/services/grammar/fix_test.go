@@ -0,0 +1,124 @@
+package grammar
+
+import (
+	"testing"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrammarService_Check_Fixable(t *testing.T) {
+	service := NewService()
+	result, err := service.Check("It works , (really.")
+	require.NoError(t, err)
+
+	fixable := map[string]models.GrammarIssue{}
+	for _, issue := range result.Issues {
+		assert.Len(t, issue.ID, 12)
+		if issue.Fixable {
+			fixable[issue.Rule] = issue
+		}
+	}
+	// Removing the space is a fix with an empty replacement, while the
+	// unbalanced parenthesis has no fix
+	require.Contains(t, fixable, "space-before-punctuation")
+	assert.Equal(t, "", fixable["space-before-punctuation"].Replacement)
+	assert.NotContains(t, fixable, "unbalanced-parentheses")
+
+	again, err := service.Check("It works , (really.")
+	require.NoError(t, err)
+	assert.Equal(t, result.Issues, again.Issues)
+	changed, err := service.Check("Now it works , (really.")
+	require.NoError(t, err)
+	assert.NotEqual(t, result.Issues[0].ID, changed.Issues[0].ID)
+}
+
+func TestApplyFixes(t *testing.T) {
+	issue := func(id string, offset, length int, replacement, severity string) models.GrammarIssue {
+		return models.GrammarIssue{ID: id, Offset: offset, Length: length, Replacement: replacement, Severity: severity, Fixable: true}
+	}
+
+	tests := []struct {
+		name        string
+		text        string
+		issues      []models.GrammarIssue
+		want        string
+		wantApplied []string
+		wantSkipped map[string]string
+	}{
+		{
+			name:        "replace and delete",
+			text:        "The the cat , sat.",
+			issues:      []models.GrammarIssue{issue("a", 0, 7, "The", SeverityError), issue("b", 11, 1, "", SeverityWarning)},
+			want:        "The cat, sat.",
+			wantApplied: []string{"a", "b"},
+		},
+		{
+			name: "not fixable",
+			text: "An (open one.",
+			issues: []models.GrammarIssue{
+				{ID: "a", Offset: 3, Length: 1, Severity: SeverityWarning},
+				issue("b", 20, 1, "x", SeverityWarning),
+			},
+			want:        "An (open one.",
+			wantSkipped: map[string]string{"a": SkipNotFixable, "b": SkipNotFixable},
+		},
+		{
+			name: "overlap keeps the more severe fix",
+			text: "Their are many.",
+			issues: []models.GrammarIssue{
+				issue("style", 0, 5, "Its", SeverityInfo),
+				issue("grammar", 0, 9, "There are", SeverityError),
+			},
+			want:        "There are many.",
+			wantApplied: []string{"grammar"},
+			wantSkipped: map[string]string{"style": SkipOverlap},
+		},
+		{
+			name: "overlap keeps the first fix",
+			text: "abcdef",
+			issues: []models.GrammarIssue{
+				issue("second", 2, 3, "X", SeverityWarning),
+				issue("first", 0, 3, "Y", SeverityWarning),
+				issue("adjacent", 5, 1, "Z", SeverityWarning),
+			},
+			want:        "YdeZ",
+			wantApplied: []string{"first", "adjacent"},
+			wantSkipped: map[string]string{"second": SkipOverlap},
+		},
+		{
+			name: "insertions at the same offset",
+			text: "Hola",
+			issues: []models.GrammarIssue{
+				issue("a", 4, 0, "!", SeverityWarning),
+				issue("b", 4, 0, ".", SeverityWarning),
+			},
+			want:        "Hola!",
+			wantApplied: []string{"a"},
+			wantSkipped: map[string]string{"b": SkipOverlap},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixed, applied, skipped := ApplyFixes(tt.text, tt.issues)
+			assert.Equal(t, tt.want, fixed)
+
+			var appliedIDs []string
+			for _, issue := range applied {
+				appliedIDs = append(appliedIDs, issue.ID)
+			}
+			assert.Equal(t, tt.wantApplied, appliedIDs)
+
+			skippedIDs := map[string]string{}
+			for _, fix := range skipped {
+				skippedIDs[fix.Issue.ID] = fix.Reason
+			}
+			if tt.wantSkipped == nil {
+				tt.wantSkipped = map[string]string{}
+			}
+			assert.Equal(t, tt.wantSkipped, skippedIDs)
+		})
+	}
+}
@@ -1,96 +1,538 @@
 package grammar
 
 import (
+	"context"
+	"fmt"
+	"sort"
 	"strings"
-	"unicode"
+	"sync"
 
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/spelling"
 )
 
-// Service provides grammar checking functionality
+// parser splits notes into prose blocks; it needs no configuration
+var parser markdown.Service
+
+// Service provides grammar checking functionality. Registered rules
+// check the languages they support; rules added to the service and
+// dictionaries apply to one language each. Spelling is checked in the
+// languages that have a dictionary, and style rules loaded from rule packs
+// enforce a style guide.
 type Service struct {
-	// In a real implementation, this might integrate with LanguageTool API
+	mu           sync.RWMutex
+	rules        map[string][]Rule
+	styles       []Rule
+	dictionaries map[string]*spelling.Dictionary
+	custom       *spelling.CustomDictionary
+	// checker finds the issues; nil runs the rules of the service
+	checker Checker
+	// version counts the changes to the rules, dictionaries and checker
+	version int
+	cache   resultCache
 }
 
-// NewService creates a new grammar service
+// NewService creates a new grammar service with an empty custom dictionary
+// kept in memory
 func NewService() *Service {
-	return &Service{}
+	return &Service{
+		custom: spelling.NewCustomDictionary(),
+	}
+}
+
+// AddRule adds a rule that checks one language. The same rule may be added
+// to several languages, but its ID must not be that of a registered rule
+// or of another rule of the language, as options refer to rules by ID.
+func (s *Service) AddRule(language string, rule Rule) error {
+	if language == "" || language == LanguageAuto {
+		return fmt.Errorf("invalid language %q", language)
+	}
+	if rule.ID() == "" {
+		return fmt.Errorf("rule without an ID")
+	}
+	existing := append(Rules(), s.Rules(language)...)
+	for _, other := range existing {
+		if other.ID() == rule.ID() || rule.ID() == SpellingRuleID {
+			return fmt.Errorf("rule %q already exists", rule.ID())
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rules == nil {
+		s.rules = map[string][]Rule{}
+	}
+	s.rules[language] = append(s.rules[language], rule)
+	s.version++
+	return nil
+}
+
+// SetStyles sets the style rules of the service, replacing those set
+// before. Style rules check the languages they are limited to, or all of
+// them; their IDs must not be those of other rules.
+func (s *Service) SetStyles(rules []Rule) error {
+	seen := map[string]bool{SpellingRuleID: true}
+	for _, rule := range Rules() {
+		seen[rule.ID()] = true
+	}
+	s.mu.RLock()
+	for _, added := range s.rules {
+		for _, rule := range added {
+			seen[rule.ID()] = true
+		}
+	}
+	s.mu.RUnlock()
+	for _, rule := range rules {
+		if rule.ID() == "" {
+			return fmt.Errorf("rule without an ID")
+		}
+		if seen[rule.ID()] {
+			return fmt.Errorf("rule %q already exists", rule.ID())
+		}
+		seen[rule.ID()] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.styles = append([]Rule(nil), rules...)
+	s.version++
+	return nil
+}
+
+// SetChecker sets the checker finding issues, such as a LanguageTool
+// server; nil runs the rules of the service
+func (s *Service) SetChecker(checker Checker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checker = checker
+	s.version++
+}
+
+// Checker returns the checker finding issues
+func (s *Service) Checker() Checker {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.checker == nil {
+		return s.Local()
+	}
+	return s.checker
+}
+
+// SetDictionary sets the Hunspell dictionary words in a language are
+// checked against; nil disables spell checking for the language
+func (s *Service) SetDictionary(language string, dictionary *spelling.Dictionary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	if dictionary == nil {
+		delete(s.dictionaries, language)
+		return
+	}
+	if s.dictionaries == nil {
+		s.dictionaries = map[string]*spelling.Dictionary{}
+	}
+	s.dictionaries[language] = dictionary
+}
+
+// SetCustomDictionary sets the dictionary of extra correct words, which
+// applies to every language
+func (s *Service) SetCustomDictionary(custom *spelling.CustomDictionary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.custom = custom
+	s.version++
+}
+
+// CustomDictionary returns the dictionary of extra correct words, or nil
+// if the service has none
+func (s *Service) CustomDictionary() *spelling.CustomDictionary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.custom
+}
+
+// RuleSetVersion returns a version of the rules and dictionaries that
+// changes whenever they do, including words added to the custom dictionary
+func (s *Service) RuleSetVersion() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	custom := 0
+	if s.custom != nil {
+		custom = s.custom.Version()
+	}
+	return fmt.Sprintf("%d.%d", s.version, custom)
+}
+
+// Languages returns the languages the service checks: those detection
+// knows and those with rules or a dictionary of their own, sorted
+func (s *Service) Languages() []string {
+	seen := map[string]bool{}
+	for _, language := range DetectableLanguages() {
+		seen[language] = true
+	}
+	s.mu.RLock()
+	for language := range s.rules {
+		seen[language] = true
+	}
+	for language := range s.dictionaries {
+		seen[language] = true
+	}
+	s.mu.RUnlock()
+
+	languages := make([]string, 0, len(seen))
+	for language := range seen {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Rules returns the rules that check a language, sorted by ID: the
+// registered rules and style rules for the language, the rules added for
+// it and, when it has a dictionary, the spelling rule
+func (s *Service) Rules(language string) []Rule {
+	var rules []Rule
+	for _, rule := range Rules() {
+		if forLanguage(rule, language) {
+			rules = append(rules, rule)
+		}
+	}
+
+	s.mu.RLock()
+	for _, rule := range s.styles {
+		if forLanguage(rule, language) {
+			rules = append(rules, rule)
+		}
+	}
+	rules = append(rules, s.rules[language]...)
+	if dictionary := s.dictionaries[language]; dictionary != nil {
+		rules = append(rules, newSpellingRule(language, spelling.NewChecker(dictionary, s.custom)))
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID() < rules[j].ID() })
+	return rules
+}
+
+// language returns the language to check text in. An empty language is
+// DefaultLanguage, and LanguageAuto detects the language of the prose,
+// falling back to DefaultLanguage for text too short to tell.
+func (s *Service) language(blocks []markdown.ProseBlock, language string) (string, error) {
+	switch language {
+	case "":
+		return DefaultLanguage, nil
+	case LanguageAuto:
+		texts := make([]string, len(blocks))
+		for i, block := range blocks {
+			texts[i] = block.Text
+		}
+		if detected, ok := DetectLanguage(strings.Join(texts, "\n")); ok {
+			return detected, nil
+		}
+		return DefaultLanguage, nil
+	}
+	for _, known := range s.Languages() {
+		if known == language {
+			return language, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported language %q", language)
+}
+
+// Options select the language and the rules a check runs
+type Options struct {
+	// Language is the language of the text, LanguageAuto to detect it or
+	// empty for DefaultLanguage
+	Language string
+	// Rules enables or disables rules by rule ID, by style pack or by
+	// category. A rule ID takes precedence over its pack, and a pack over
+	// its category; rules not listed run.
+	Rules map[string]bool
+	// Previous is an earlier version of the text. When set, only the prose
+	// blocks that are not in it are checked, so that issues are only
+	// reported for changed paragraphs.
+	Previous *string
+	// Ignored lists the fingerprints of issues not to report, such as
+	// those ignored on a note
+	Ignored []string
+	// touched holds byte ranges of the text whose blocks are checked even
+	// when they are in Previous, as they were edited since
+	touched [][2]int
+}
+
+// Validate checks that the options only refer to known rules and
+// categories
+func (o Options) Validate() error {
+	return o.validate(Rules())
+}
+
+func (o Options) validate(rules []Rule) error {
+	known := map[string]bool{}
+	for _, category := range Categories {
+		known[category] = true
+	}
+	for _, rule := range rules {
+		known[rule.ID()] = true
+		if pack := StylePack(rule.ID()); pack != "" {
+			known[pack] = true
+		}
+	}
+	for id := range o.Rules {
+		if !known[id] {
+			return fmt.Errorf("unknown grammar rule %q", id)
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether a rule runs under the options
+func (o Options) Enabled(rule Rule) bool {
+	return o.enabled(rule.ID(), rule.Category())
 }
 
-// Check performs grammar checking on the provided text
+func (o Options) enabled(id, category string) bool {
+	if enabled, ok := o.Rules[id]; ok {
+		return enabled
+	}
+	if pack := StylePack(id); pack != "" {
+		if enabled, ok := o.Rules[pack]; ok {
+			return enabled
+		}
+	}
+	if enabled, ok := o.Rules[category]; ok {
+		return enabled
+	}
+	return true
+}
+
+// Check performs grammar checking on the provided markdown with all rules
 func (s *Service) Check(text string) (*models.GrammarCheckResult, error) {
-	// This is a simple implementation. In production, you would integrate
-	// with a proper grammar checking service like LanguageTool API
-	
+	return s.CheckWithOptions(text, Options{})
+}
+
+// CheckWithOptions performs grammar checking on the provided markdown with
+// the rules the options enable. Only prose is checked: code, math, URLs,
+// HTML and front matter are skipped. Issue offsets are positions in the
+// markdown, and issues are sorted by them.
+func (s *Service) CheckWithOptions(text string, options Options) (*models.GrammarCheckResult, error) {
+	return s.CheckContext(context.Background(), text, options)
+}
+
+// CheckContext is CheckWithOptions with a context, which cancels checks
+// by remote checkers
+func (s *Service) CheckContext(ctx context.Context, text string, options Options) (*models.GrammarCheckResult, error) {
+	blocks := parser.Prose(text)
+	language, err := s.language(blocks, options.Language)
+	if err != nil {
+		return nil, err
+	}
+	// Options may name rules of other languages, as the language of a
+	// note is not always known in advance
+	var known []Rule
+	for _, l := range s.Languages() {
+		known = append(known, s.Rules(l)...)
+	}
+	if err := options.validate(known); err != nil {
+		return nil, err
+	}
+	if checkingOff(text) {
+		score, breakdown := Score(nil, 0)
+		return &models.GrammarCheckResult{
+			Issues:    []models.GrammarIssue{},
+			Score:     score,
+			Breakdown: breakdown,
+			Language:  language,
+			Checker:   s.Checker().Name(),
+			Disabled:  true,
+		}, nil
+	}
+
+	// unchanged counts the blocks of the previous text by their prose
+	unchanged := map[string]int{}
+	if options.Previous != nil {
+		for _, block := range parser.Prose(*options.Previous) {
+			unchanged[block.Text]++
+		}
+	}
+	// checked holds the blocks to check, and sentences the index of the
+	// first sentence of each block in the note
+	var checked []markdown.ProseBlock
+	var firstSentences []int
+	var ranges [][2]int
+	sentence := 0
+	for _, block := range blocks {
+		n := len(Sentences(block.Text))
+		start, end := block.Source(0, len(block.Text))
+		if unchanged[block.Text] > 0 && !touches(options.touched, start, end) {
+			unchanged[block.Text]--
+		} else {
+			checked = append(checked, block)
+			firstSentences = append(firstSentences, sentence)
+			ranges = append(ranges, [2]int{start, end})
+		}
+		sentence += n
+	}
+
+	response, err := s.Checker().Check(ctx, CheckRequest{Language: language, Blocks: checked, Options: options})
+	if err != nil {
+		return nil, err
+	}
+	custom := s.CustomDictionary()
+	suppressed := suppressions(text)
+	ignored := map[string]bool{}
+	for _, fingerprint := range options.Ignored {
+		ignored[fingerprint] = true
+	}
+
 	issues := []models.GrammarIssue{}
-	
-	// Simple checks for demonstration
-	
-	// Check for multiple spaces - find all occurrences
-	for i := 0; i < len(text)-1; i++ {
-		if text[i] == ' ' && text[i+1] == ' ' {
-			// Found consecutive spaces
-			spaceStart := i
-			spaceLength := 2
-			
-			// Count how many consecutive spaces there are
-			for j := i + 2; j < len(text); j++ {
-				if text[j] == ' ' {
-					spaceLength++
-				} else {
-					break
-				}
-			}
-			
-			issues = append(issues, models.GrammarIssue{
-				Message:     "Multiple consecutive spaces found",
-				Offset:      spaceStart,
-				Length:      spaceLength,
-				Replacement: " ",
-				Type:        "spacing",
-			})
-			
-			// Skip ahead past the spaces we just processed
-			i += spaceLength - 1
-		}
-	}
-	
-	// Check for missing capital letter at start
-	if len(text) > 0 && unicode.IsLower(rune(text[0])) {
+	suppressedIssues := 0
+	for _, finding := range response.Findings {
+		if finding.Block < 0 || finding.Block >= len(checked) || !options.enabled(finding.Rule, finding.Category) {
+			continue
+		}
+		block := checked[finding.Block]
+		match := finding.Match
+		if match.Offset < 0 || match.Length < 0 || match.Offset+match.Length > len(block.Text) {
+			continue
+		}
+		// Remote checkers do not know the custom dictionary
+		if finding.Category == CategorySpelling && custom != nil && custom.Check(block.Text[match.Offset:match.Offset+match.Length]) {
+			continue
+		}
+		match = alignMatch(block.Text, match)
+		start, end := block.Source(match.Offset, match.Offset+match.Length)
+		sentences := Sentences(block.Text)
+		index := sentenceIndex(sentences, match.Offset)
+		sentenceText := ""
+		if index < len(sentences) {
+			sentenceText = sentences[index].Text
+		}
+		fingerprint := Fingerprint(finding.Rule, block.Text[match.Offset:match.Offset+match.Length], sentenceText)
+		if ignored[fingerprint] || isSuppressed(suppressed, start, finding.Rule, finding.Category) {
+			suppressedIssues++
+			continue
+		}
 		issues = append(issues, models.GrammarIssue{
-			Message:     "Sentence should start with a capital letter",
-			Offset:      0,
-			Length:      1,
-			Replacement: strings.ToUpper(string(text[0])),
-			Type:        "capitalization",
+			ID:          issueID(finding.Rule, start, text[start:end]),
+			Fingerprint: fingerprint,
+			Rule:        finding.Rule,
+			Severity:    finding.Severity,
+			Message:     match.Message,
+			Offset:      start,
+			Length:      end - start,
+			Replacement: match.Replacement,
+			Suggestions: match.Suggestions,
+			Fixable:     match.Replacement != "" || match.Delete,
+			Type:        finding.Category,
+			Sentence:    firstSentences[finding.Block] + index,
 		})
 	}
-	
-	// Check for missing period at end
-	trimmed := strings.TrimSpace(text)
-	if len(trimmed) > 0 && !strings.HasSuffix(trimmed, ".") && !strings.HasSuffix(trimmed, "!") && !strings.HasSuffix(trimmed, "?") {
-		issues = append(issues, models.GrammarIssue{
-			Message: "Sentence should end with proper punctuation",
-			Offset:  len(trimmed),
-			Length:  0,
-			Type:    "punctuation",
-		})
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Offset < issues[j].Offset
+	})
+	setUnicodeOffsets(text, issues)
+
+	words := 0
+	for _, block := range checked {
+		words += countWords(block.Text)
+	}
+	score, breakdown := Score(issues, words)
+
+	result := &models.GrammarCheckResult{
+		Issues:     issues,
+		Score:      score,
+		Words:      words,
+		Breakdown:  breakdown,
+		Language:   language,
+		Checker:    response.Checker,
+		Suppressed: suppressedIssues,
+	}
+	if options.Previous != nil {
+		result.Checked = textRanges(text, ranges)
 	}
-	
-	// Calculate a simple score (100 - 10 points per issue)
-	score := 100.0 - float64(len(issues)*10)
-	if score < 0 {
-		score = 0
+	return result, nil
+}
+
+// isSuppressed reports whether one of suppressions covers an issue
+func isSuppressed(suppressions []suppression, offset int, rule, category string) bool {
+	for _, s := range suppressions {
+		if s.suppresses(offset, rule, category) {
+			return true
+		}
 	}
-	
-	return &models.GrammarCheckResult{
-		Issues: issues,
-		Score:  score,
-	}, nil
+	return false
 }
 
-// For a production implementation, you would add methods like:
-// - Integration with LanguageTool API
-// - More sophisticated grammar rules
-// - Support for different languages
-// - Caching of results
+// touches reports whether a byte range touches one of ranges, including
+// ranges that end where it starts or start where it ends
+func touches(ranges [][2]int, start, end int) bool {
+	for _, r := range ranges {
+		if r[0] <= end && r[1] >= start {
+			return true
+		}
+	}
+	return false
+}
+
+// alignMatch widens a match that splits a grapheme cluster to the whole
+// cluster, keeping the rest of the cluster in the replacement, so that
+// fixing "e\u0301" keeps its accent
+func alignMatch(text string, match Match) Match {
+	start, end := graphemeBounds(text, match.Offset, match.Offset+match.Length)
+	if start == match.Offset && end == match.Offset+match.Length {
+		return match
+	}
+	before, after := text[start:match.Offset], text[match.Offset+match.Length:end]
+	if match.Replacement != "" || match.Delete {
+		match.Replacement = before + match.Replacement + after
+		match.Delete = match.Replacement == ""
+	}
+	if len(match.Suggestions) > 0 {
+		suggestions := make([]string, len(match.Suggestions))
+		for i, suggestion := range match.Suggestions {
+			suggestions[i] = before + suggestion + after
+		}
+		match.Suggestions = suggestions
+	}
+	match.Offset, match.Length = start, end-start
+	return match
+}
+
+// textRanges converts byte ranges of text to text ranges with rune and
+// UTF-16 offsets
+func textRanges(text string, ranges [][2]int) []models.TextRange {
+	offsets := make([]int, 0, 2*len(ranges))
+	for _, r := range ranges {
+		offsets = append(offsets, r[0], r[1])
+	}
+	positions := unicodeOffsets(text, offsets)
+	result := make([]models.TextRange, len(ranges))
+	for i, r := range ranges {
+		start, end := positions[r[0]], positions[r[1]]
+		result[i] = models.TextRange{
+			Offset:      r[0],
+			Length:      r[1] - r[0],
+			RuneOffset:  start.runes,
+			RuneLength:  end.runes - start.runes,
+			UTF16Offset: start.utf16,
+			UTF16Length: end.utf16 - start.utf16,
+		}
+	}
+	return result
+}
+
+// setUnicodeOffsets sets the rune and UTF-16 offsets of issues from their
+// byte offsets in text
+func setUnicodeOffsets(text string, issues []models.GrammarIssue) {
+	offsets := make([]int, 0, 2*len(issues))
+	for _, issue := range issues {
+		offsets = append(offsets, issue.Offset, issue.Offset+issue.Length)
+	}
+	positions := unicodeOffsets(text, offsets)
+	for i := range issues {
+		start, end := positions[issues[i].Offset], positions[issues[i].Offset+issues[i].Length]
+		issues[i].RuneOffset, issues[i].RuneLength = start.runes, end.runes-start.runes
+		issues[i].UTF16Offset, issues[i].UTF16Length = start.utf16, end.utf16-start.utf16
+	}
+}
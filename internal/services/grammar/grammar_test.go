@@ -3,6 +3,7 @@ package grammar
 import (
 	"testing"
 
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/spelling"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -82,3 +83,97 @@ func TestGrammarService_Check(t *testing.T) {
 		})
 	}
 }
+
+func TestGrammarService_Check_Markdown(t *testing.T) {
+	service := NewService()
+	input := "---\ntitle: my  note\n---\n" +
+		"# getting started\n\n" +
+		"Run `go  build` and see https://example.com/the/the.\n\n" +
+		"```\nthis  is code\n```\n\n" +
+		"<div>raw  html</div>\n\n" +
+		"| Name   | Value |\n|--------|-------|\n| alpha  | 1     |\n\n" +
+		"- A list item without a period\n\n" +
+		"It is is **the the** end.\n"
+
+	result, err := service.Check(input)
+	require.NoError(t, err)
+
+	type found struct{ rule, text string }
+	var issues []found
+	for _, issue := range result.Issues {
+		issues = append(issues, found{issue.Rule, input[issue.Offset : issue.Offset+issue.Length]})
+	}
+	assert.Equal(t, []found{
+		{"sentence-capitalization", "g"},
+		{"repeated-words", "is is"},
+		{"repeated-words", "the the"},
+	}, issues)
+}
+
+func TestGrammarService_Check_Sentences(t *testing.T) {
+	service := NewService()
+	input := "# A title\n\nFirst sentence. second sentence, e.g. with an abbreviation.\n\n- item one. item two\n"
+
+	result, err := service.Check(input)
+	require.NoError(t, err)
+
+	type found struct {
+		rule     string
+		text     string
+		sentence int
+	}
+	var issues []found
+	for _, issue := range result.Issues {
+		issues = append(issues, found{issue.Rule, input[issue.Offset : issue.Offset+issue.Length], issue.Sentence})
+	}
+	assert.Equal(t, []found{
+		{"sentence-capitalization", "s", 2},
+		{"sentence-capitalization", "i", 3},
+		{"sentence-capitalization", "i", 4},
+	}, issues)
+}
+
+func TestGrammarService_Check_Spelling(t *testing.T) {
+	dictionary, err := spelling.Load("../spelling/testdata", "en_test")
+	require.NoError(t, err)
+	custom := spelling.NewCustomDictionary()
+	require.NoError(t, custom.Add("Zentrix"))
+
+	service := NewService()
+	service.SetDictionary(LanguageEnglish, dictionary)
+	service.SetCustomDictionary(custom)
+
+	input := "The ctas walked in Paris and Zentrix, in the kitchn.\n\n" +
+		"Skipped: `the kitchn`, API, iPhone, main.go, x and the zentrix.\n"
+	result, err := service.CheckWithOptions(input, Options{Rules: map[string]bool{CategorySpelling: true, CategoryStyle: false}})
+	require.NoError(t, err)
+
+	type found struct{ text, replacement string }
+	var issues []found
+	for _, issue := range result.Issues {
+		if issue.Rule != SpellingRuleID {
+			continue
+		}
+		assert.Equal(t, CategorySpelling, issue.Type)
+		if issue.Replacement != "" {
+			assert.Equal(t, issue.Replacement, issue.Suggestions[0])
+		}
+		issues = append(issues, found{input[issue.Offset : issue.Offset+issue.Length], issue.Replacement})
+	}
+	assert.Equal(t, []found{
+		{"ctas", "cats"},
+		{"kitchn", "kitchen"},
+		{"Skipped", ""},
+		{"zentrix", "Zentrix"},
+	}, issues)
+
+	// The spelling rule only exists for languages with a dictionary
+	assert.Len(t, service.Rules(LanguageEnglish), len(NewService().Rules(LanguageEnglish))+1)
+	assert.Len(t, service.Rules(LanguageGerman), len(NewService().Rules(LanguageGerman)))
+
+	result, err = service.CheckWithOptions(input, Options{Rules: map[string]bool{SpellingRuleID: false}})
+	require.NoError(t, err)
+	for _, issue := range result.Issues {
+		assert.NotEqual(t, SpellingRuleID, issue.Rule)
+	}
+}
@@ -0,0 +1,196 @@
+package grammar
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+)
+
+const (
+	// historyMetadata is the metadata key of the score history of a note
+	historyMetadata = "grammar_history"
+	// MaxHistory is the number of scores kept for each note
+	MaxHistory = 500
+	// historyQueue is the number of changed notes that can wait to be
+	// scored
+	historyQueue = 256
+)
+
+// History keeps the grammar scores of the revisions of stored notes, in
+// their metadata, so that clients can tell whether a note improves.
+// Revisions are checked with the language detected, like ?language=auto,
+// and without the issues ignored on the note.
+type History struct {
+	service *Service
+	storage *storage.FileStorage
+	// now returns the current time; tests replace it
+	now func() time.Time
+	// queue holds the IDs of changed notes for Run
+	queue chan string
+}
+
+// NewHistory creates a score history of the notes of storage
+func NewHistory(service *Service, storage *storage.FileStorage) *History {
+	return &History{service: service, storage: storage, now: time.Now, queue: make(chan string, historyQueue)}
+}
+
+// Enqueue queues a changed note to be scored by Run, so that saving a
+// note does not wait for its check. When the queue is full the note is
+// left out; its revision is scored when the history is next read.
+func (h *History) Enqueue(id string) {
+	select {
+	case h.queue <- id:
+	default:
+	}
+}
+
+// Run records the notes queued by Enqueue until ctx is done
+func (h *History) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-h.queue:
+			if err := h.recordQueued(id); err != nil {
+				log.Printf("Failed to record grammar score of note %s: %v", id, err)
+			}
+		}
+	}
+}
+
+// recordQueued records a note for Run. A panic while checking the note,
+// such as one raised by the markdown parser, is returned as an error so
+// that the note is skipped instead of stopping the server.
+func (h *History) recordQueued(id string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while scoring: %v", r)
+		}
+	}()
+	return h.Record(id)
+}
+
+// Record scores the current revision of a note. It is called when a note
+// changes, and does nothing when the note was deleted.
+func (h *History) Record(id string) error {
+	note, err := h.storage.Get(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		return err
+	}
+	entry, err := h.score(note, true)
+	if err != nil {
+		return err
+	}
+	return h.add(id, []models.GrammarScoreEntry{entry})
+}
+
+// Get returns the score history of a note, oldest revision first.
+// Revisions still kept by the storage that were not scored, such as those
+// saved before scores were kept, are scored first.
+func (h *History) Get(id string) ([]models.GrammarScoreEntry, error) {
+	note, err := h.storage.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	var history []models.GrammarScoreEntry
+	if _, err := h.storage.Metadata(id, historyMetadata, &history); err != nil {
+		return nil, err
+	}
+	scored := map[int]bool{}
+	for _, entry := range history {
+		scored[entry.Revision] = true
+	}
+
+	var missing []models.GrammarScoreEntry
+	for revision := max(note.Revision-storage.MaxRevisions+1, 1); revision <= note.Revision; revision++ {
+		if scored[revision] {
+			continue
+		}
+		old, err := h.storage.GetRevision(id, revision)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				continue
+			}
+			return nil, err
+		}
+		entry, err := h.score(old, revision == note.Revision)
+		if err != nil {
+			return nil, err
+		}
+		missing = append(missing, entry)
+	}
+	if len(missing) == 0 {
+		return history, nil
+	}
+	if err := h.add(id, missing); err != nil {
+		return nil, err
+	}
+	history = nil
+	if _, err := h.storage.Metadata(id, historyMetadata, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// score checks a revision of a note. The current revision is checked
+// with CheckNote, so that its result is cached.
+func (h *History) score(note *models.Note, current bool) (models.GrammarScoreEntry, error) {
+	ignored, err := IgnoredFingerprints(h.storage, note.ID)
+	if err != nil {
+		return models.GrammarScoreEntry{}, err
+	}
+	options := Options{Language: LanguageAuto, Ignored: ignored}
+	var result *models.GrammarCheckResult
+	if current {
+		result, _, err = h.service.CheckNote(note.ID, note.Content, options)
+	} else {
+		result, err = h.service.CheckWithOptions(note.Content, options)
+	}
+	if err != nil {
+		return models.GrammarScoreEntry{}, fmt.Errorf("failed to check revision %d: %w", note.Revision, err)
+	}
+	return models.GrammarScoreEntry{
+		Revision:       note.Revision,
+		Score:          result.Score,
+		Issues:         len(result.Issues),
+		Words:          result.Words,
+		Breakdown:      result.Breakdown,
+		Language:       result.Language,
+		RuleSetVersion: h.service.RuleSetVersion(),
+		CheckedAt:      h.now(),
+	}, nil
+}
+
+// add adds entries to the history of a note, replacing the entries of the
+// same revisions
+func (h *History) add(id string, entries []models.GrammarScoreEntry) error {
+	var history []models.GrammarScoreEntry
+	return h.storage.UpdateMetadata(id, historyMetadata, &history, func() error {
+		byRevision := map[int]int{}
+		for i, entry := range history {
+			byRevision[entry.Revision] = i
+		}
+		for _, entry := range entries {
+			if i, ok := byRevision[entry.Revision]; ok {
+				history[i] = entry
+				continue
+			}
+			byRevision[entry.Revision] = len(history)
+			history = append(history, entry)
+		}
+		sort.Slice(history, func(i, j int) bool { return history[i].Revision < history[j].Revision })
+		if len(history) > MaxHistory {
+			history = history[len(history)-MaxHistory:]
+		}
+		return nil
+	})
+}
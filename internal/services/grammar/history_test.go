@@ -0,0 +1,101 @@
+package grammar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	store := storage.NewFileStorage(t.TempDir())
+	service := NewService()
+
+	// Revisions saved before scores were kept are scored when the history
+	// is read
+	note := &models.Note{Title: "Cat", Content: "The the cat sat  on the mat."}
+	require.NoError(t, store.Save(note))
+	note.Content = "The the cat sat on the mat."
+	require.NoError(t, store.Save(note))
+
+	history := NewHistory(service, store)
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	history.now = func() time.Time { return now }
+	store.OnChange(service.InvalidateNote)
+	store.OnChange(func(id string) { require.NoError(t, history.Record(id)) })
+
+	note.Content = "The cat sat on the mat."
+	require.NoError(t, store.Save(note))
+
+	entries, err := history.Get(note.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	for i, entry := range entries {
+		assert.Equal(t, i+1, entry.Revision)
+		assert.Equal(t, LanguageEnglish, entry.Language)
+		assert.Equal(t, service.RuleSetVersion(), entry.RuleSetVersion)
+		assert.Equal(t, now, entry.CheckedAt)
+	}
+	assert.Equal(t, 2, entries[0].Issues)
+	assert.Equal(t, 1, entries[1].Issues)
+	assert.Equal(t, 0, entries[2].Issues)
+	assert.Less(t, entries[0].Score, entries[1].Score)
+	assert.Equal(t, 100.0, entries[2].Score)
+
+	// Reading the history again scores nothing
+	now = now.Add(time.Hour)
+	again, err := history.Get(note.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entries, again)
+
+	// Deleted notes have no history
+	require.NoError(t, store.Delete(note.ID))
+	_, err = history.Get(note.ID)
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestHistory_Run(t *testing.T) {
+	store := storage.NewFileStorage(t.TempDir())
+	service := NewService()
+	history := NewHistory(service, store)
+	store.OnChange(service.InvalidateNote)
+	store.OnChange(history.Enqueue)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		history.Run(ctx)
+		close(done)
+	}()
+
+	// Saves are scored in the background
+	note := &models.Note{Title: "Cat", Content: "The the cat sat on the mat."}
+	require.NoError(t, store.Save(note))
+	require.Eventually(t, func() bool {
+		var entries []models.GrammarScoreEntry
+		_, err := store.Metadata(note.ID, historyMetadata, &entries)
+		return err == nil && len(entries) == 1 && entries[0].Issues == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// A note the markdown parser panics on is skipped, and later notes are
+	// still scored
+	require.NoError(t, store.Save(&models.Note{Title: "Bad", Content: "Term\n\n:\nab"}))
+	next := &models.Note{Title: "Dog", Content: "The dog sat on the mat."}
+	require.NoError(t, store.Save(next))
+	require.Eventually(t, func() bool {
+		var entries []models.GrammarScoreEntry
+		_, err := store.Metadata(next.ID, historyMetadata, &entries)
+		return err == nil && len(entries) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not stop when its context was cancelled")
+	}
+}
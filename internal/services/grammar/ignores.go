@@ -0,0 +1,79 @@
+package grammar
+
+import (
+	"time"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+)
+
+// ignoresMetadata is the metadata key of the issues ignored on a note
+const ignoresMetadata = "grammar_ignores"
+
+// Ignores returns the issues ignored on a stored note, in the order they
+// were ignored
+func Ignores(store *storage.FileStorage, id string) ([]models.GrammarIgnore, error) {
+	var ignores []models.GrammarIgnore
+	if _, err := store.Metadata(id, ignoresMetadata, &ignores); err != nil {
+		return nil, err
+	}
+	return ignores, nil
+}
+
+// IgnoredFingerprints returns the fingerprints of the issues ignored on a
+// stored note, for Options.Ignored
+func IgnoredFingerprints(store *storage.FileStorage, id string) ([]string, error) {
+	ignores, err := Ignores(store, id)
+	if err != nil {
+		return nil, err
+	}
+	fingerprints := make([]string, len(ignores))
+	for i, ignore := range ignores {
+		fingerprints[i] = ignore.Fingerprint
+	}
+	return fingerprints, nil
+}
+
+// Ignore ignores an issue on a stored note, so that checks of the note no
+// longer report it or issues with the same fingerprint. It returns the
+// ignore, which is the existing one when the issue was already ignored.
+func Ignore(store *storage.FileStorage, id string, issue models.GrammarIssue, text string) (models.GrammarIgnore, error) {
+	ignore := models.GrammarIgnore{
+		Fingerprint: issue.Fingerprint,
+		Rule:        issue.Rule,
+		Text:        text,
+		CreatedAt:   time.Now(),
+	}
+	var ignores []models.GrammarIgnore
+	err := store.UpdateMetadata(id, ignoresMetadata, &ignores, func() error {
+		for _, existing := range ignores {
+			if existing.Fingerprint == ignore.Fingerprint {
+				ignore = existing
+				return nil
+			}
+		}
+		ignores = append(ignores, ignore)
+		return nil
+	})
+	return ignore, err
+}
+
+// Unignore stops ignoring the issues of a fingerprint on a stored note. It
+// reports whether they were ignored.
+func Unignore(store *storage.FileStorage, id, fingerprint string) (bool, error) {
+	found := false
+	var ignores []models.GrammarIgnore
+	err := store.UpdateMetadata(id, ignoresMetadata, &ignores, func() error {
+		kept := ignores[:0]
+		for _, ignore := range ignores {
+			if ignore.Fingerprint == fingerprint {
+				found = true
+				continue
+			}
+			kept = append(kept, ignore)
+		}
+		ignores = kept
+		return nil
+	})
+	return found, err
+}
@@ -0,0 +1,127 @@
+package grammar
+
+import (
+	"embed"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Languages, as ISO 639-1 codes
+const (
+	LanguageEnglish = "en"
+	LanguageGerman  = "de"
+	LanguageSpanish = "es"
+	// LanguageAuto detects the language of the text
+	LanguageAuto = "auto"
+)
+
+// DefaultLanguage is checked when no language is given or when detection
+// fails
+const DefaultLanguage = LanguageEnglish
+
+// english limits a rule to English text
+var english = []string{LanguageEnglish}
+
+// minDetectLetters is the fewest letters language detection needs
+const minDetectLetters = 20
+
+// profileFiles holds sample text of each language that detection compares
+// text with, in profiles/<language>.txt
+//
+//go:embed profiles/*.txt
+var profileFiles embed.FS
+
+// languageProfiles maps languages to the trigram frequencies of their
+// sample text
+var languageProfiles = loadProfiles()
+
+func loadProfiles() map[string]ngramProfile {
+	entries, err := profileFiles.ReadDir("profiles")
+	if err != nil {
+		panic(err)
+	}
+	profiles := map[string]ngramProfile{}
+	for _, entry := range entries {
+		data, err := profileFiles.ReadFile(path.Join("profiles", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		profiles[strings.TrimSuffix(entry.Name(), ".txt")] = newProfile(string(data))
+	}
+	return profiles
+}
+
+// ngramProfile holds the relative frequencies of the letter trigrams of a
+// text, and their norm
+type ngramProfile struct {
+	freq map[string]float64
+	norm float64
+}
+
+// newProfile counts the trigrams of the words of text. Words are padded
+// with spaces, so that trigrams at their start and end count too.
+func newProfile(text string) ngramProfile {
+	counts := map[string]float64{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		runes := []rune(" " + w + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			counts[string(runes[i:i+3])]++
+		}
+	}
+	p := ngramProfile{freq: counts}
+	for _, c := range counts {
+		p.norm += c * c
+	}
+	p.norm = math.Sqrt(p.norm)
+	return p
+}
+
+// similarity returns the cosine similarity of two profiles, from 0 for
+// no shared trigrams to 1 for the same distribution
+func (p ngramProfile) similarity(other ngramProfile) float64 {
+	if p.norm == 0 || other.norm == 0 {
+		return 0
+	}
+	dot := 0.0
+	for gram, c := range p.freq {
+		dot += c * other.freq[gram]
+	}
+	return dot / (p.norm * other.norm)
+}
+
+// DetectableLanguages returns the languages DetectLanguage can return,
+// sorted
+func DetectableLanguages() []string {
+	languages := make([]string, 0, len(languageProfiles))
+	for language := range languageProfiles {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// DetectLanguage returns the language whose trigram profile is closest to
+// that of text. It reports false for text with too few letters to tell.
+func DetectLanguage(text string) (string, bool) {
+	letters := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	if letters < minDetectLetters {
+		return "", false
+	}
+
+	profile := newProfile(text)
+	best, bestScore := "", 0.0
+	for _, language := range DetectableLanguages() {
+		if score := profile.similarity(languageProfiles[language]); score > bestScore {
+			best, bestScore = language, score
+		}
+	}
+	return best, best != ""
+}
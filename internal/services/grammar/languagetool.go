@@ -0,0 +1,326 @@
+package grammar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// LanguageToolCheckerName is the name of the LanguageTool checker
+const LanguageToolCheckerName = "languagetool"
+
+// languageToolLanguages maps languages to the LanguageTool variants they
+// are checked in; LanguageTool only checks spelling in variants
+var languageToolLanguages = map[string]string{
+	LanguageEnglish: "en-US",
+	LanguageGerman:  "de-DE",
+	LanguageSpanish: "es",
+}
+
+// languageToolCategories maps LanguageTool rule categories to ours
+var languageToolCategories = map[string]string{
+	"TYPOS":         CategorySpelling,
+	"CASING":        CategoryCapitalization,
+	"PUNCTUATION":   CategoryPunctuation,
+	"TYPOGRAPHY":    CategoryPunctuation,
+	"STYLE":         CategoryStyle,
+	"REDUNDANCY":    CategoryStyle,
+	"PLAIN_ENGLISH": CategoryStyle,
+	"GRAMMAR":       CategoryGrammar,
+}
+
+// LanguageToolOptions configure a LanguageTool checker
+type LanguageToolOptions struct {
+	// URL is the base URL of the server, such as http://localhost:8081
+	URL string
+	// Timeout limits each request; it defaults to 10 seconds
+	Timeout time.Duration
+	// Retries is how often a request failing with a network error, a
+	// server error or 429 Too Many Requests is retried
+	Retries int
+	// RetryDelay is the wait before the first retry, doubled for each
+	// further one; it defaults to 200 milliseconds
+	RetryDelay time.Duration
+	// FailureThreshold is the number of failed checks in a row that opens
+	// the circuit, skipping the server; it defaults to 5
+	FailureThreshold int
+	// Cooldown is how long the circuit stays open before a check tries the
+	// server again; it defaults to 30 seconds
+	Cooldown time.Duration
+	// Client sends the requests; it defaults to http.DefaultClient
+	Client *http.Client
+}
+
+// LanguageToolChecker checks prose with the /v2/check endpoint of a
+// self-hosted LanguageTool server. When the server fails, or the circuit
+// is open after repeated failures, it checks with a fallback checker.
+type LanguageToolChecker struct {
+	options  LanguageToolOptions
+	endpoint string
+	fallback Checker
+	breaker  *breaker
+}
+
+// NewLanguageToolChecker creates a LanguageTool checker. The fallback
+// checker, usually the local checker of a service, checks prose the server
+// cannot; with no fallback, those checks fail.
+func NewLanguageToolChecker(options LanguageToolOptions, fallback Checker) (*LanguageToolChecker, error) {
+	base, err := url.Parse(options.URL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid LanguageTool URL %q", options.URL)
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+	if options.Retries < 0 {
+		options.Retries = 0
+	}
+	if options.RetryDelay <= 0 {
+		options.RetryDelay = 200 * time.Millisecond
+	}
+	if options.FailureThreshold <= 0 {
+		options.FailureThreshold = 5
+	}
+	if options.Cooldown <= 0 {
+		options.Cooldown = 30 * time.Second
+	}
+	if options.Client == nil {
+		options.Client = http.DefaultClient
+	}
+	return &LanguageToolChecker{
+		options:  options,
+		endpoint: strings.TrimSuffix(base.String(), "/") + "/v2/check",
+		fallback: fallback,
+		breaker:  newBreaker(options.FailureThreshold, options.Cooldown),
+	}, nil
+}
+
+// Name returns LanguageToolCheckerName
+func (c *LanguageToolChecker) Name() string { return LanguageToolCheckerName }
+
+// State returns the state of the circuit breaker: BreakerClosed,
+// BreakerOpen or BreakerHalfOpen
+func (c *LanguageToolChecker) State() string {
+	return c.breaker.State()
+}
+
+// Check checks the blocks in a single request, joined by blank lines
+func (c *LanguageToolChecker) Check(ctx context.Context, request CheckRequest) (*CheckResponse, error) {
+	if len(request.Blocks) == 0 {
+		return &CheckResponse{Checker: LanguageToolCheckerName}, nil
+	}
+	if !c.breaker.allow() {
+		return c.fallbackCheck(ctx, request, errors.New("LanguageTool circuit is open"))
+	}
+	response, err := c.check(ctx, request)
+	if err != nil {
+		var status statusError
+		if errors.As(err, &status) && status.permanent() {
+			// The server is up but rejected the request, such as for a
+			// language it does not know
+			c.breaker.success()
+		} else if ctx.Err() != nil {
+			// The caller gave up, which says nothing about the server
+			c.breaker.release()
+		} else {
+			c.breaker.failure()
+		}
+		return c.fallbackCheck(ctx, request, err)
+	}
+	c.breaker.success()
+	return response, nil
+}
+
+// fallbackCheck checks with the fallback checker after the server failed,
+// unless the caller gave up
+func (c *LanguageToolChecker) fallbackCheck(ctx context.Context, request CheckRequest, err error) (*CheckResponse, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if c.fallback == nil {
+		return nil, fmt.Errorf("LanguageTool check failed: %w", err)
+	}
+	return c.fallback.Check(ctx, request)
+}
+
+// statusError is an unexpected status of the server
+type statusError struct {
+	code int
+}
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("LanguageTool responded with status %d", e.code)
+}
+
+// permanent reports whether retrying cannot help
+func (e statusError) permanent() bool {
+	return e.code < 500 && e.code != http.StatusTooManyRequests
+}
+
+// languageToolResponse is the part of a /v2/check response the checker uses
+type languageToolResponse struct {
+	Matches []struct {
+		Message      string `json:"message"`
+		Replacements []struct {
+			Value string `json:"value"`
+		} `json:"replacements"`
+		// Offset and Length count UTF-16 code units
+		Offset int `json:"offset"`
+		Length int `json:"length"`
+		Rule   struct {
+			ID        string `json:"id"`
+			IssueType string `json:"issueType"`
+			Category  struct {
+				ID string `json:"id"`
+			} `json:"category"`
+		} `json:"rule"`
+	} `json:"matches"`
+}
+
+func (c *LanguageToolChecker) check(ctx context.Context, request CheckRequest) (*CheckResponse, error) {
+	texts := make([]string, len(request.Blocks))
+	for i, block := range request.Blocks {
+		texts[i] = block.Text
+	}
+	text := strings.Join(texts, "\n\n")
+	language, ok := languageToolLanguages[request.Language]
+	if !ok {
+		language = request.Language
+	}
+	form := url.Values{"text": {text}, "language": {language}}
+
+	var result languageToolResponse
+	delay := c.options.RetryDelay
+	for attempt := 0; ; attempt++ {
+		err := c.post(ctx, form, &result)
+		if err == nil {
+			break
+		}
+		var status statusError
+		if attempt == c.options.Retries || ctx.Err() != nil || (errors.As(err, &status) && status.permanent()) {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+
+	// starts holds the offset of each block in the text, and bytes the
+	// byte offset of each UTF-16 offset
+	starts := make([]int, len(texts))
+	offset := 0
+	for i, t := range texts {
+		starts[i] = offset
+		offset += len(t) + len("\n\n")
+	}
+	bytes := utf16Offsets(text)
+
+	response := &CheckResponse{Checker: LanguageToolCheckerName}
+	for _, m := range result.Matches {
+		if m.Offset < 0 || m.Length < 0 || m.Offset+m.Length >= len(bytes) {
+			continue
+		}
+		start, end := bytes[m.Offset], bytes[m.Offset+m.Length]
+		if start < 0 || end < 0 {
+			continue
+		}
+		block := len(starts) - 1
+		for block > 0 && starts[block] > start {
+			block--
+		}
+		// Matches across blocks cannot be mapped to the note
+		if end > starts[block]+len(texts[block]) {
+			continue
+		}
+		match := Match{Offset: start - starts[block], Length: end - start, Message: m.Message}
+		for _, replacement := range m.Replacements {
+			match.Suggestions = append(match.Suggestions, replacement.Value)
+		}
+		if len(match.Suggestions) > 0 {
+			match.Replacement = match.Suggestions[0]
+		}
+		category, severity := languageToolCategory(m.Rule.Category.ID, m.Rule.IssueType)
+		response.Findings = append(response.Findings, Finding{
+			Block:    block,
+			Rule:     m.Rule.ID,
+			Category: category,
+			Severity: severity,
+			Match:    match,
+		})
+	}
+	return response, nil
+}
+
+// post sends a single request, limited by the timeout
+func (c *LanguageToolChecker) post(ctx context.Context, form url.Values, result *languageToolResponse) error {
+	ctx, cancel := context.WithTimeout(ctx, c.options.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.options.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return statusError{code: resp.StatusCode}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("invalid LanguageTool response: %w", err)
+	}
+	return nil
+}
+
+// languageToolCategory returns the category and severity of a LanguageTool
+// rule by its category, or by its issue type for categories we do not map
+func languageToolCategory(category, issueType string) (string, string) {
+	severity := SeverityWarning
+	switch issueType {
+	case "misspelling", "grammar":
+		severity = SeverityError
+	case "style", "locale-violation", "register":
+		severity = SeverityInfo
+	}
+	if mapped, ok := languageToolCategories[category]; ok {
+		return mapped, severity
+	}
+	switch issueType {
+	case "misspelling":
+		return CategorySpelling, severity
+	case "whitespace":
+		return CategorySpacing, severity
+	case "style", "locale-violation", "register":
+		return CategoryStyle, severity
+	case "typographical":
+		return CategoryPunctuation, severity
+	}
+	return CategoryGrammar, severity
+}
+
+// utf16Offsets maps the UTF-16 offsets of text, as LanguageTool counts
+// them, to byte offsets. The result has an entry for each code unit and one
+// for the end of text; offsets inside a surrogate pair map to -1.
+func utf16Offsets(text string) []int {
+	offsets := make([]int, 0, len(text)+1)
+	for i, r := range text {
+		offsets = append(offsets, i)
+		if r >= 0x10000 {
+			offsets = append(offsets, -1)
+		}
+	}
+	return append(offsets, len(text))
+}
@@ -0,0 +1,221 @@
+package grammar
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLanguageTool is a LanguageTool server that reports each occurrence
+// of teh as a misspelling, with offsets in UTF-16 code units like the real
+// one. Requests fail with the statuses in fail, one per request, before
+// it answers.
+type fakeLanguageTool struct {
+	*httptest.Server
+	requests atomic.Int32
+	fail     []int
+	delay    time.Duration
+	language atomic.Value
+}
+
+func newFakeLanguageTool(t *testing.T) *fakeLanguageTool {
+	fake := &fakeLanguageTool{}
+	fake.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(fake.requests.Add(1))
+		if r.Method != http.MethodPost || r.URL.Path != "/v2/check" {
+			http.NotFound(w, r)
+			return
+		}
+		if fake.delay > 0 {
+			select {
+			case <-time.After(fake.delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if n <= len(fake.fail) {
+			w.WriteHeader(fake.fail[n-1])
+			return
+		}
+		text := r.FormValue("text")
+		fake.language.Store(r.FormValue("language"))
+
+		type match map[string]any
+		matches := []match{}
+		units := 0
+		for i, r := range text {
+			if strings.HasPrefix(text[i:], "teh") {
+				matches = append(matches, match{
+					"message":      "Possible spelling mistake found.",
+					"replacements": []match{{"value": "the"}, {"value": "ten"}},
+					"offset":       units,
+					"length":       3,
+					"rule": match{
+						"id":          "MORFOLOGIK_RULE_EN_US",
+						"description": "Possible spelling mistake",
+						"issueType":   "misspelling",
+						"category":    match{"id": "TYPOS", "name": "Possible Typo"},
+					},
+				})
+			}
+			units++
+			if r >= 0x10000 {
+				units++
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(match{
+			"language": match{"code": r.FormValue("language")},
+			"matches":  matches,
+		})
+	}))
+	t.Cleanup(fake.Close)
+	return fake
+}
+
+func newTestLanguageTool(t *testing.T, fake *fakeLanguageTool, service *Service, options LanguageToolOptions) *LanguageToolChecker {
+	options.URL = fake.URL
+	if options.RetryDelay == 0 {
+		options.RetryDelay = time.Millisecond
+	}
+	checker, err := NewLanguageToolChecker(options, service.Local())
+	require.NoError(t, err)
+	service.SetChecker(checker)
+	return checker
+}
+
+func TestLanguageToolChecker(t *testing.T) {
+	fake := newFakeLanguageTool(t)
+	service := NewService()
+	newTestLanguageTool(t, fake, service, LanguageToolOptions{})
+
+	content := "# Notes\n\nI saw teh 😀 cat.\n\n```\nteh code\n```\n\nThen teh `teh` dog.\n"
+	result, err := service.Check(content)
+	require.NoError(t, err)
+	assert.Equal(t, LanguageToolCheckerName, result.Checker)
+	assert.Equal(t, "en-US", fake.language.Load())
+
+	// Offsets are mapped back to the markdown, past the emoji and the code
+	require.Len(t, result.Issues, 2)
+	for _, issue := range result.Issues {
+		assert.Equal(t, "teh", content[issue.Offset:issue.Offset+issue.Length])
+		assert.Equal(t, "MORFOLOGIK_RULE_EN_US", issue.Rule)
+		assert.Equal(t, CategorySpelling, issue.Type)
+		assert.Equal(t, SeverityError, issue.Severity)
+		assert.Equal(t, "the", issue.Replacement)
+		assert.Equal(t, []string{"the", "ten"}, issue.Suggestions)
+		assert.True(t, issue.Fixable)
+	}
+	assert.Equal(t, 2, result.Issues[1].Sentence)
+
+	// Findings of disabled categories and custom words are dropped
+	result, err = service.CheckWithOptions(content, Options{Rules: map[string]bool{CategorySpelling: false}})
+	require.NoError(t, err)
+	assert.Empty(t, result.Issues)
+	require.NoError(t, service.CustomDictionary().Add("teh"))
+	result, err = service.Check(content)
+	require.NoError(t, err)
+	assert.Empty(t, result.Issues)
+}
+
+func TestLanguageToolChecker_Retries(t *testing.T) {
+	fake := newFakeLanguageTool(t)
+	fake.fail = []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}
+	service := NewService()
+	newTestLanguageTool(t, fake, service, LanguageToolOptions{Retries: 2})
+
+	result, err := service.Check("I saw teh cat.")
+	require.NoError(t, err)
+	assert.Equal(t, LanguageToolCheckerName, result.Checker)
+	assert.Len(t, result.Issues, 1)
+	assert.EqualValues(t, 3, fake.requests.Load())
+
+	// Client errors are not retried
+	fake = newFakeLanguageTool(t)
+	fake.fail = []int{http.StatusBadRequest}
+	newTestLanguageTool(t, fake, service, LanguageToolOptions{Retries: 2})
+	result, err = service.Check("The the cat.")
+	require.NoError(t, err)
+	assert.Equal(t, LocalChecker, result.Checker)
+	assert.EqualValues(t, 1, fake.requests.Load())
+}
+
+func TestLanguageToolChecker_Timeout(t *testing.T) {
+	fake := newFakeLanguageTool(t)
+	fake.delay = time.Second
+	service := NewService()
+	newTestLanguageTool(t, fake, service, LanguageToolOptions{Timeout: 20 * time.Millisecond})
+
+	start := time.Now()
+	result, err := service.Check("The the cat saw teh dog.")
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), fake.delay)
+	assert.Equal(t, LocalChecker, result.Checker)
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, "repeated-words", result.Issues[0].Rule)
+
+	// A cancelled check does not fall back
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = service.CheckContext(ctx, "The the cat.", Options{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestLanguageToolChecker_CircuitBreaker(t *testing.T) {
+	fake := newFakeLanguageTool(t)
+	fake.fail = []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusInternalServerError}
+	service := NewService()
+	checker := newTestLanguageTool(t, fake, service, LanguageToolOptions{FailureThreshold: 2, Cooldown: time.Minute})
+	now := time.Now()
+	checker.breaker.now = func() time.Time { return now }
+
+	check := func() string {
+		result, _, err := service.CheckNote("note", "I saw teh cat.", Options{})
+		require.NoError(t, err)
+		return result.Checker
+	}
+
+	assert.Equal(t, LocalChecker, check())
+	assert.Equal(t, BreakerClosed, checker.State())
+	assert.Equal(t, LocalChecker, check())
+	assert.Equal(t, BreakerOpen, checker.State())
+
+	// The open circuit skips the server, and results of the fallback are
+	// not cached
+	assert.Equal(t, LocalChecker, check())
+	assert.EqualValues(t, 2, fake.requests.Load())
+
+	// After the cooldown a failed trial opens the circuit again
+	now = now.Add(time.Minute)
+	assert.Equal(t, BreakerHalfOpen, checker.State())
+	assert.Equal(t, LocalChecker, check())
+	assert.Equal(t, BreakerOpen, checker.State())
+	assert.EqualValues(t, 3, fake.requests.Load())
+
+	// and a successful one closes it
+	now = now.Add(time.Minute)
+	assert.Equal(t, LanguageToolCheckerName, check())
+	assert.Equal(t, BreakerClosed, checker.State())
+	assert.EqualValues(t, 4, fake.requests.Load())
+	_, cached, err := service.CheckNote("note", "I saw teh cat.", Options{})
+	require.NoError(t, err)
+	assert.True(t, cached)
+}
+
+func TestNewLanguageToolChecker(t *testing.T) {
+	for _, url := range []string{"", "localhost:8081", "ftp://localhost", "http://"} {
+		_, err := NewLanguageToolChecker(LanguageToolOptions{URL: url}, nil)
+		assert.Error(t, err, url)
+	}
+	checker, err := NewLanguageToolChecker(LanguageToolOptions{URL: "http://localhost:8081/"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8081/v2/check", checker.endpoint)
+}
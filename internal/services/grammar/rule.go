@@ -0,0 +1,319 @@
+package grammar
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Issue categories, reported as the type of an issue
+const (
+	CategorySpelling       = "spelling"
+	CategoryGrammar        = "grammar"
+	CategoryPunctuation    = "punctuation"
+	CategoryCapitalization = "capitalization"
+	CategorySpacing        = "spacing"
+	CategoryStyle          = "style"
+)
+
+// Issue severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Categories lists the issue categories
+var Categories = []string{
+	CategorySpelling,
+	CategoryGrammar,
+	CategoryPunctuation,
+	CategoryCapitalization,
+	CategorySpacing,
+	CategoryStyle,
+}
+
+// Rule finds one kind of issue in text
+type Rule interface {
+	// ID identifies the rule in requests and results
+	ID() string
+	// Category is one of the Category constants
+	Category() string
+	// Severity is one of the Severity constants
+	Severity() string
+	Description() string
+	// Match returns the issues the rule finds in text
+	Match(text string) []Match
+}
+
+// Match is an issue found by a rule. Offset and Length are in bytes.
+type Match struct {
+	Offset      int
+	Length      int
+	Message     string
+	Replacement string
+	// Suggestions lists alternative replacements, best first, when there
+	// is more than one; Replacement is the first
+	Suggestions []string
+	// Delete is set when the fix removes the text. An empty Replacement
+	// otherwise means that the rule suggests no fix.
+	Delete bool
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Rule{}
+)
+
+// Register makes a rule available to the grammar service. It panics when
+// a rule with the same ID is already registered, and is meant to be
+// called from init functions.
+func Register(rule Rule) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if rule.ID() == "" {
+		panic("grammar: rule without an ID")
+	}
+	if _, ok := registry[rule.ID()]; ok {
+		panic(fmt.Sprintf("grammar: rule %q registered twice", rule.ID()))
+	}
+	registry[rule.ID()] = rule
+}
+
+// Rules returns the registered rules sorted by ID
+func Rules() []Rule {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	rules := make([]Rule, 0, len(registry))
+	for _, rule := range registry {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID() < rules[j].ID() })
+	return rules
+}
+
+// languageRule is implemented by rules that only apply to some languages
+type languageRule interface {
+	ForLanguage(language string) bool
+}
+
+// forLanguage reports whether a rule checks text in a language
+func forLanguage(rule Rule, language string) bool {
+	if r, ok := rule.(languageRule); ok {
+		return r.ForLanguage(language)
+	}
+	return true
+}
+
+// ruleLanguages returns the languages a rule is limited to, or nil if it
+// checks every language
+func ruleLanguages(rule Rule) []string {
+	if r, ok := rule.(interface{ Languages() []string }); ok {
+		return r.Languages()
+	}
+	return nil
+}
+
+// blockRule is implemented by rules that only apply to some kinds of
+// markdown block, such as paragraphs but not headings
+type blockRule interface {
+	AppliesTo(kind string) bool
+}
+
+// appliesTo reports whether a rule checks a kind of prose block
+func appliesTo(rule Rule, kind string) bool {
+	if r, ok := rule.(blockRule); ok {
+		return r.AppliesTo(kind)
+	}
+	return true
+}
+
+// ruleInfo implements the descriptive methods of Rule
+type ruleInfo struct {
+	id          string
+	category    string
+	severity    string
+	description string
+	// blocks lists the kinds of prose block the rule checks; nil means
+	// all of them
+	blocks []string
+	// languages lists the languages the rule checks; nil means all of them
+	languages []string
+}
+
+func (r ruleInfo) ID() string          { return r.id }
+func (r ruleInfo) Category() string    { return r.category }
+func (r ruleInfo) Severity() string    { return r.severity }
+func (r ruleInfo) Description() string { return r.description }
+
+// Languages returns the languages the rule checks, or nil for all
+func (r ruleInfo) Languages() []string { return r.languages }
+
+// ForLanguage reports whether the rule checks text in a language
+func (r ruleInfo) ForLanguage(language string) bool {
+	if r.languages == nil {
+		return true
+	}
+	for _, l := range r.languages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// AppliesTo reports whether the rule checks a kind of prose block
+func (r ruleInfo) AppliesTo(kind string) bool {
+	if r.blocks == nil {
+		return true
+	}
+	for _, block := range r.blocks {
+		if block == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// funcRule is a rule implemented by a function
+type funcRule struct {
+	ruleInfo
+	match func(text string) []Match
+}
+
+func (r *funcRule) Match(text string) []Match {
+	return r.match(text)
+}
+
+// sentenceRule is a rule checking one sentence at a time
+type sentenceRule struct {
+	ruleInfo
+	// match returns matches with offsets in the sentence text
+	match func(sentence Sentence) []Match
+}
+
+func (r *sentenceRule) Match(text string) []Match {
+	var matches []Match
+	for _, sentence := range Sentences(text) {
+		for _, match := range r.match(sentence) {
+			match.Offset += sentence.Start
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+// regexpRule reports each match of a pattern. The first submatch that
+// took part in the match is reported, or the whole match when there is
+// none.
+type regexpRule struct {
+	ruleInfo
+	pattern *regexp.Regexp
+	message string
+	// replace returns the replacement of the reported text, or "" to
+	// delete it; nil suggests no replacement
+	replace func(text string) string
+	// words only reports text that is not part of a longer word
+	words bool
+}
+
+func (r *regexpRule) Match(text string) []Match {
+	var matches []Match
+	for _, loc := range r.pattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		for i := 2; i < len(loc); i += 2 {
+			if loc[i] >= 0 {
+				start, end = loc[i], loc[i+1]
+				break
+			}
+		}
+		if r.words && !wholeWord(text, start, end) {
+			continue
+		}
+		match := Match{Offset: start, Length: end - start, Message: r.message}
+		if r.replace != nil {
+			match.Replacement = r.replace(text[start:end])
+			match.Delete = match.Replacement == ""
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// phraseRule reports words and phrases that have a preferred replacement
+type phraseRule struct {
+	ruleInfo
+	pattern *regexp.Regexp
+	phrases map[string]string
+	// message is formatted with the phrase found and its replacement
+	message string
+}
+
+// newPhraseRule creates a rule replacing each lowercase phrase with its
+// value. Phrases match regardless of case and of the whitespace between
+// their words.
+func newPhraseRule(info ruleInfo, message string, phrases map[string]string) *phraseRule {
+	alternatives := make([]string, 0, len(phrases))
+	for phrase := range phrases {
+		words := strings.Fields(phrase)
+		for i, word := range words {
+			words[i] = regexp.QuoteMeta(word)
+		}
+		alternatives = append(alternatives, strings.Join(words, `\s+`))
+	}
+	// Longer phrases first, so they win over phrases they start with
+	sort.Slice(alternatives, func(i, j int) bool {
+		if len(alternatives[i]) != len(alternatives[j]) {
+			return len(alternatives[i]) > len(alternatives[j])
+		}
+		return alternatives[i] < alternatives[j]
+	})
+	return &phraseRule{
+		ruleInfo: info,
+		pattern:  regexp.MustCompile(`(?i)\b(?:` + strings.Join(alternatives, "|") + `)\b`),
+		phrases:  phrases,
+		message:  message,
+	}
+}
+
+func (r *phraseRule) Match(text string) []Match {
+	var matches []Match
+	for _, loc := range r.pattern.FindAllStringIndex(text, -1) {
+		if !wholeWord(text, loc[0], loc[1]) {
+			continue
+		}
+		found := text[loc[0]:loc[1]]
+		replacement := matchCase(found, r.phrases[strings.ToLower(strings.Join(strings.Fields(found), " "))])
+		matches = append(matches, Match{
+			Offset:      loc[0],
+			Length:      loc[1] - loc[0],
+			Message:     fmt.Sprintf(r.message, found, replacement),
+			Replacement: replacement,
+		})
+	}
+	return matches
+}
+
+// matchCase capitalizes the replacement when the text it replaces starts
+// with a capital letter
+func matchCase(text, replacement string) string {
+	first, _ := utf8.DecodeRuneInString(text)
+	if !unicode.IsUpper(first) {
+		return replacement
+	}
+	return capitalize(replacement)
+}
+
+// capitalize returns s with its first letter in title case, which is
+// upper case except for digraphs such as "ǆ"
+func capitalize(s string) string {
+	first, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
+		return s
+	}
+	return string(unicode.ToTitle(first)) + s[size:]
+}
@@ -0,0 +1,97 @@
+package grammar
+
+import (
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+)
+
+func init() {
+	Register(&sentenceRule{
+		ruleInfo: ruleInfo{
+			id:          "sentence-capitalization",
+			category:    CategoryCapitalization,
+			severity:    SeverityWarning,
+			description: "Sentences should start with a capital letter",
+			blocks:      []string{markdown.ProseParagraph, markdown.ProseHeading, markdown.ProseListItem},
+		},
+		match: matchSentenceCapitalization,
+	})
+
+	Register(&regexpRule{
+		ruleInfo: ruleInfo{
+			id:          "lowercase-i",
+			category:    CategoryCapitalization,
+			severity:    SeverityError,
+			description: `The pronoun "I" is always capitalized`,
+			languages:   english,
+		},
+		pattern: regexp.MustCompile(`(?:^|[\s("])(i)(?:[\s,;:!?)"]|'(?:m|d|ll|ve)\b|$)`),
+		message: `The pronoun "I" should be capitalized`,
+		replace: func(string) string { return "I" },
+	})
+
+	Register(&regexpRule{
+		ruleInfo: ruleInfo{
+			id:          "day-capitalization",
+			category:    CategoryCapitalization,
+			severity:    SeverityWarning,
+			description: "Days of the week are capitalized",
+			languages:   english,
+		},
+		pattern: regexp.MustCompile(`\b(?:monday|tuesday|wednesday|thursday|friday|saturday|sunday)s?\b`),
+		message: "Days of the week should be capitalized",
+		replace: capitalize,
+		words:   true,
+	})
+
+	// March and May are left out, they are common words too
+	Register(&regexpRule{
+		ruleInfo: ruleInfo{
+			id:          "month-capitalization",
+			category:    CategoryCapitalization,
+			severity:    SeverityWarning,
+			description: "Names of months are capitalized",
+			languages:   english,
+		},
+		pattern: regexp.MustCompile(`\b(?:january|february|april|june|july|august|september|october|november|december)\b`),
+		message: "Names of months should be capitalized",
+		replace: capitalize,
+		words:   true,
+	})
+
+	Register(&regexpRule{
+		ruleInfo: ruleInfo{
+			id:          "language-capitalization",
+			category:    CategoryCapitalization,
+			severity:    SeverityWarning,
+			description: "Names of languages and nationalities are capitalized",
+			languages:   english,
+		},
+		pattern: regexp.MustCompile(`\b(?:english|french|german|spanish|italian|portuguese|russian|chinese|japanese|korean|dutch|arabic)\b`),
+		message: "Names of languages and nationalities should be capitalized",
+		replace: capitalize,
+		words:   true,
+	})
+}
+
+// matchSentenceCapitalization reports a sentence starting with a
+// lowercase letter. Sentences starting with code or a symbol are skipped.
+func matchSentenceCapitalization(sentence Sentence) []Match {
+	for i, r := range sentence.Text {
+		if unicode.IsLower(r) {
+			return []Match{{
+				Offset:      i,
+				Length:      utf8.RuneLen(r),
+				Message:     "Sentence should start with a capital letter",
+				Replacement: string(unicode.ToTitle(r)),
+			}}
+		}
+		if !unicode.IsPunct(r) {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package grammar
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+func init() {
+	Register(&funcRule{
+		ruleInfo: ruleInfo{
+			id:          "repeated-words",
+			category:    CategoryGrammar,
+			severity:    SeverityError,
+			description: "A word should not be repeated by mistake, as in \"the the\"",
+		},
+		match: matchRepeatedWords,
+	})
+
+	Register(&funcRule{
+		ruleInfo: ruleInfo{
+			id:          "a-an",
+			category:    CategoryGrammar,
+			severity:    SeverityError,
+			description: `Use "an" before a vowel sound and "a" before a consonant sound`,
+			languages:   english,
+		},
+		match: matchArticles,
+	})
+
+	Register(&sentenceRule{
+		ruleInfo: ruleInfo{
+			id:          "run-on-sentence",
+			category:    CategoryGrammar,
+			severity:    SeverityWarning,
+			description: "Long sentences should separate their clauses with punctuation",
+			languages:   english,
+		},
+		match: matchRunOn,
+	})
+
+	Register(newPhraseRule(ruleInfo{
+		id:          "its-its",
+		category:    CategoryGrammar,
+		severity:    SeverityError,
+		description: `"It's" means "it is"; "its" is possessive`,
+		languages:   english,
+	}, "Did you mean %[2]q instead of %[1]q?", map[string]string{
+		"its been":      "it's been",
+		"its not":       "it's not",
+		"its going":     "it's going",
+		"its a":         "it's a",
+		"its an":        "it's an",
+		"its the":       "it's the",
+		"its very":      "it's very",
+		"its really":    "it's really",
+		"its time":      "it's time",
+		"its possible":  "it's possible",
+		"its important": "it's important",
+		"it's own":      "its own",
+		"it's self":     "itself",
+	}))
+
+	Register(newPhraseRule(ruleInfo{
+		id:          "then-than",
+		category:    CategoryGrammar,
+		severity:    SeverityError,
+		description: `Comparisons use "than", not "then"`,
+		languages:   english,
+	}, "Did you mean %[2]q instead of %[1]q?", comparisons("then", "than")))
+
+	Register(newPhraseRule(ruleInfo{
+		id:          "your-youre",
+		category:    CategoryGrammar,
+		severity:    SeverityError,
+		description: `"You're" means "you are"; "your" is possessive`,
+		languages:   english,
+	}, "Did you mean %[2]q instead of %[1]q?", map[string]string{
+		"your welcome": "you're welcome",
+		"your right":   "you're right",
+		"your wrong":   "you're wrong",
+		"your going":   "you're going",
+		"your not":     "you're not",
+		"your being":   "you're being",
+		"your sure":    "you're sure",
+		"you're own":   "your own",
+	}))
+
+	Register(newPhraseRule(ruleInfo{
+		id:          "their-there",
+		category:    CategoryGrammar,
+		severity:    SeverityError,
+		description: `Use "there" for places and existence, "their" for possession and "they're" for "they are"`,
+		languages:   english,
+	}, "Did you mean %[2]q instead of %[1]q?", map[string]string{
+		"their is":     "there is",
+		"their are":    "there are",
+		"their was":    "there was",
+		"their were":   "there were",
+		"there own":    "their own",
+		"they're own":  "their own",
+		"their going":  "they're going",
+		"over their":   "over there",
+		"out their":    "out there",
+		"their fore":   "therefore",
+		"theirselves":  "themselves",
+		"their self":   "themselves",
+		"there selves": "themselves",
+	}))
+
+	Register(newPhraseRule(ruleInfo{
+		id:          "could-of",
+		category:    CategoryGrammar,
+		severity:    SeverityError,
+		description: `"Could of" is a mishearing of "could have"`,
+		languages:   english,
+	}, "Did you mean %[2]q instead of %[1]q?", map[string]string{
+		"could of":  "could have",
+		"should of": "should have",
+		"would of":  "would have",
+		"might of":  "might have",
+		"must of":   "must have",
+	}))
+
+	Register(newPhraseRule(ruleInfo{
+		id:          "alot",
+		category:    CategoryGrammar,
+		severity:    SeverityError,
+		description: `"A lot" is two words`,
+		languages:   english,
+	}, "Did you mean %[2]q instead of %[1]q?", map[string]string{
+		"alot": "a lot",
+	}))
+
+	Register(newPhraseRule(ruleInfo{
+		id:          "affect-effect",
+		category:    CategoryGrammar,
+		severity:    SeverityWarning,
+		description: `"Affect" is usually a verb and "effect" a noun`,
+		languages:   english,
+	}, "Did you mean %[2]q instead of %[1]q?", map[string]string{
+		"an affect":   "an effect",
+		"the affect":  "the effect",
+		"no affect":   "no effect",
+		"side affect": "side effect",
+	}))
+
+	Register(newPhraseRule(ruleInfo{
+		id:          "subject-verb-agreement",
+		category:    CategoryGrammar,
+		severity:    SeverityError,
+		description: "The verb should agree with its subject",
+		languages:   english,
+	}, "Did you mean %[2]q instead of %[1]q?", map[string]string{
+		"he don't":     "he doesn't",
+		"she don't":    "she doesn't",
+		"it don't":     "it doesn't",
+		"i doesn't":    "I don't",
+		"you doesn't":  "you don't",
+		"we doesn't":   "we don't",
+		"they doesn't": "they don't",
+		"he have":      "he has",
+		"she have":     "she has",
+		"it have":      "it has",
+		"they has":     "they have",
+		"we has":       "we have",
+		"you was":      "you were",
+		"we was":       "we were",
+		"they was":     "they were",
+		"i were":       "I was",
+	}))
+}
+
+// Run-on sentences have at least runOnWords words and no punctuation
+// between their clauses
+const runOnWords = 25
+
+// clausePunctuation separates clauses within a sentence
+const clausePunctuation = ",;:—–()"
+
+// matchRunOn reports long sentences without any punctuation separating
+// their clauses
+func matchRunOn(sentence Sentence) []Match {
+	body := strings.TrimRight(sentence.Text, ".!?…"+closingMarks)
+	if strings.ContainsAny(body, clausePunctuation) {
+		return nil
+	}
+	if words := len(word.FindAllStringIndex(body, -1)); words < runOnWords {
+		return nil
+	}
+	return []Match{{
+		Length:  len(sentence.Text),
+		Message: "This may be a run-on sentence; separate its clauses with punctuation or split it",
+	}}
+}
+
+// comparisons maps comparative adjectives followed by wrong to the same
+// adjectives followed by right
+func comparisons(wrong, right string) map[string]string {
+	phrases := map[string]string{}
+	for _, word := range []string{
+		"more", "less", "better", "worse", "rather", "other", "greater",
+		"fewer", "larger", "smaller", "higher", "lower", "faster",
+		"slower", "bigger", "longer", "shorter", "easier", "harder",
+		"older", "newer",
+	} {
+		phrases[word+" "+wrong] = word + " " + right
+	}
+	return phrases
+}
+
+// word matches words with their combining marks, including contractions
+var word = regexp.MustCompile(`\pL[\pL\pM]*(?:['’]\pL[\pL\pM]*)*`)
+
+// repeatable lists words that are correctly repeated, as in "had had" and
+// the German "die die"
+var repeatable = map[string]bool{"had": true, "that": true, "der": true, "die": true, "das": true}
+
+// matchRepeatedWords reports a word repeated with only whitespace between
+func matchRepeatedWords(text string) []Match {
+	var matches []Match
+	locs := word.FindAllStringIndex(text, -1)
+	for i := 1; i < len(locs); i++ {
+		prev, cur := locs[i-1], locs[i]
+		first, second := text[prev[0]:prev[1]], text[cur[0]:cur[1]]
+		if !strings.EqualFold(first, second) || repeatable[strings.ToLower(first)] {
+			continue
+		}
+		if strings.TrimSpace(text[prev[1]:cur[0]]) != "" {
+			continue
+		}
+		matches = append(matches, Match{
+			Offset:      prev[0],
+			Length:      cur[1] - prev[0],
+			Message:     fmt.Sprintf("The word %q is repeated", first),
+			Replacement: first,
+		})
+	}
+	return matches
+}
+
+// article matches an indefinite article and the word after it
+var article = regexp.MustCompile(`(?i)\b(an?)\s+([\pL\pN][\pL\pM\pN'’-]*)`)
+
+// Prefixes of words whose sound differs from their first letter
+var (
+	consonantSounds = []string{"eu", "one", "once", "uni", "use", "usa", "usu", "uti", "ubiq", "ukr", "ura", "uro", "ewe"}
+	vowelSounds     = []string{"hour", "honest", "honor", "honour", "heir"}
+	// Words starting with these prefixes are exceptions to consonantSounds
+	vowelExceptions = []string{"unin", "unim"}
+)
+
+// matchArticles reports "a" before a vowel sound and "an" before a
+// consonant sound. Numbers, acronyms and single letters are skipped, as
+// their sound depends on how they are read.
+func matchArticles(text string) []Match {
+	var matches []Match
+	for _, loc := range article.FindAllStringSubmatchIndex(text, -1) {
+		found, next := text[loc[2]:loc[3]], text[loc[4]:loc[5]]
+		if !wholeWord(text, loc[2], loc[3]) {
+			continue
+		}
+		expected := articleFor(next)
+		if expected == "" || strings.EqualFold(found, expected) {
+			continue
+		}
+		replacement := matchCase(found, expected)
+		matches = append(matches, Match{
+			Offset:      loc[2],
+			Length:      loc[3] - loc[2],
+			Message:     fmt.Sprintf("Use %q instead of %q before %q", replacement, found, next),
+			Replacement: replacement,
+		})
+	}
+	return matches
+}
+
+// articleFor returns the indefinite article for a word, or "" when it
+// cannot be told from its spelling
+func articleFor(word string) string {
+	runes := []rune(word)
+	if len(runes) < 2 || !unicode.IsLetter(runes[0]) || strings.ToUpper(word) == word {
+		return ""
+	}
+	lower := strings.ToLower(word)
+	if hasAnyPrefix(lower, vowelSounds) {
+		return "an"
+	}
+	if hasAnyPrefix(lower, consonantSounds) && !hasAnyPrefix(lower, vowelExceptions) {
+		return "a"
+	}
+	if strings.ContainsRune("aeiou", unicode.ToLower(runes[0])) {
+		return "an"
+	}
+	return "a"
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package grammar
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+)
+
+func init() {
+	Register(&sentenceRule{
+		ruleInfo: ruleInfo{
+			id:          "missing-end-punctuation",
+			category:    CategoryPunctuation,
+			severity:    SeverityWarning,
+			description: "Sentences in paragraphs should end with a period, question mark or exclamation mark",
+			blocks:      []string{markdown.ProseParagraph},
+		},
+		match: matchMissingEndPunctuation,
+	})
+
+	Register(&regexpRule{
+		ruleInfo: ruleInfo{
+			id:          "repeated-punctuation",
+			category:    CategoryPunctuation,
+			severity:    SeverityWarning,
+			description: "Commas, semicolons, colons, question and exclamation marks should not be repeated",
+		},
+		pattern: regexp.MustCompile(`,{2,}|;{2,}|!{2,}|\?{2,}`),
+		message: "Repeated punctuation",
+		replace: func(text string) string { return text[:1] },
+	})
+
+	Register(&regexpRule{
+		ruleInfo: ruleInfo{
+			id:          "double-period",
+			category:    CategoryPunctuation,
+			severity:    SeverityWarning,
+			description: "Two periods should be one period or an ellipsis",
+		},
+		pattern: regexp.MustCompile(`(?:^|[^.])(\.\.)(?:[^.]|$)`),
+		message: "Two periods found; use one period or an ellipsis",
+		replace: func(string) string { return "." },
+	})
+
+	Register(&regexpRule{
+		ruleInfo: ruleInfo{
+			id:          "comma-before-period",
+			category:    CategoryPunctuation,
+			severity:    SeverityWarning,
+			description: "A comma or semicolon should not come right before a period",
+		},
+		pattern: regexp.MustCompile(`([,;]\.)(?:[^.]|$)`),
+		message: "Remove the punctuation before the period",
+		replace: func(string) string { return "." },
+	})
+
+	Register(&funcRule{
+		ruleInfo: ruleInfo{
+			id:          "unbalanced-parentheses",
+			category:    CategoryPunctuation,
+			severity:    SeverityWarning,
+			description: "Every opening parenthesis should have a closing one",
+		},
+		match: matchUnbalancedParentheses,
+	})
+
+	Register(&funcRule{
+		ruleInfo: ruleInfo{
+			id:          "unbalanced-quotes",
+			category:    CategoryPunctuation,
+			severity:    SeverityWarning,
+			description: "Quotation marks should come in pairs",
+		},
+		match: matchUnbalancedQuotes,
+	})
+
+	Register(&sentenceRule{
+		ruleInfo: ruleInfo{
+			id:          "inverted-punctuation",
+			category:    CategoryPunctuation,
+			severity:    SeverityWarning,
+			description: `Questions and exclamations open with "¿" and "¡" in Spanish`,
+			languages:   []string{LanguageSpanish},
+		},
+		match: matchInvertedPunctuation,
+	})
+}
+
+// endPunctuation matches the end of text that ends a sentence, possibly
+// followed by closing quotes or brackets
+var endPunctuation = regexp.MustCompile(`[.!?…:]["'”’)\]]*$`)
+
+// matchMissingEndPunctuation reports a sentence with words that does not
+// end with punctuation
+func matchMissingEndPunctuation(sentence Sentence) []Match {
+	if endPunctuation.MatchString(sentence.Text) || strings.IndexFunc(sentence.Text, unicode.IsLetter) < 0 {
+		return nil
+	}
+	return []Match{{
+		Offset:  len(sentence.Text),
+		Message: "Sentence should end with proper punctuation",
+	}}
+}
+
+// listLabel matches list labels such as "1)" and "a)" at the start of a
+// line
+var listLabel = regexp.MustCompile(`(?m)^\s*[\pL\pN]{1,3}\)`)
+
+// matchUnbalancedParentheses reports closing parentheses without an
+// opening one, and opening parentheses that are never closed. Smileys and
+// list labels are ignored.
+func matchUnbalancedParentheses(text string) []Match {
+	labels := map[int]bool{}
+	for _, loc := range listLabel.FindAllStringIndex(text, -1) {
+		labels[loc[1]-1] = true
+	}
+
+	var matches []Match
+	var open []int
+	var prev rune
+	for i, r := range text {
+		switch {
+		case r == '(':
+			open = append(open, i)
+		case r == ')' && !labels[i] && prev != ':' && prev != ';':
+			if len(open) == 0 {
+				matches = append(matches, Match{Offset: i, Length: 1, Message: "Closing parenthesis without an opening one"})
+			} else {
+				open = open[:len(open)-1]
+			}
+		}
+		prev = r
+	}
+	for _, i := range open {
+		matches = append(matches, Match{Offset: i, Length: 1, Message: "Opening parenthesis is never closed"})
+	}
+	return matches
+}
+
+// quotePairs maps curly and angle closing quotation marks to their
+// opening ones. German quotes open with "„" and close with "“", which
+// opens English quotes.
+var quotePairs = map[rune]rune{'”': '“', '“': '„', '»': '«'}
+
+// matchUnbalancedQuotes reports the last straight double quote when there
+// is an odd number of them, and curly and angle quotes without a partner
+func matchUnbalancedQuotes(text string) []Match {
+	var matches []Match
+	straight := strings.Count(text, `"`)
+	if straight%2 == 1 {
+		matches = append(matches, Match{Offset: strings.LastIndex(text, `"`), Length: 1, Message: "Quotation mark without a partner"})
+	}
+
+	type quote struct {
+		mark   rune
+		offset int
+	}
+	var open []quote
+	for i, r := range text {
+		switch r {
+		case '“', '”', '»':
+			if len(open) > 0 && open[len(open)-1].mark == quotePairs[r] {
+				open = open[:len(open)-1]
+				continue
+			}
+			if r == '“' {
+				open = append(open, quote{r, i})
+				continue
+			}
+			matches = append(matches, Match{Offset: i, Length: utf8.RuneLen(r), Message: "Closing quotation mark without an opening one"})
+		case '„', '«':
+			open = append(open, quote{r, i})
+		}
+	}
+	for _, q := range open {
+		matches = append(matches, Match{Offset: q.offset, Length: utf8.RuneLen(q.mark), Message: "Opening quotation mark is never closed"})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Offset < matches[j].Offset })
+	return matches
+}
+
+// matchInvertedPunctuation reports a Spanish question or exclamation
+// without its opening mark, as in "Qué hora es?"
+func matchInvertedPunctuation(sentence Sentence) []Match {
+	text := strings.TrimRight(sentence.Text, closingMarks)
+	end, _ := utf8.DecodeLastRuneInString(text)
+	opening := map[rune]string{'?': "¿", '!': "¡"}[end]
+	if opening == "" || strings.Contains(text, opening) {
+		return nil
+	}
+	start := strings.IndexFunc(text, unicode.IsLetter)
+	if start < 0 {
+		return nil
+	}
+	n := graphemeLength(text[start:])
+	return []Match{{
+		Offset:      start,
+		Length:      n,
+		Message:     fmt.Sprintf("Open the sentence with %q", opening),
+		Replacement: opening + text[start:start+n],
+	}}
+}
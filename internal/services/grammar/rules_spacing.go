@@ -0,0 +1,106 @@
+package grammar
+
+import (
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	Register(&regexpRule{
+		ruleInfo: ruleInfo{
+			id:          "multiple-spaces",
+			category:    CategorySpacing,
+			severity:    SeverityWarning,
+			description: "Words should be separated by a single space",
+		},
+		pattern: regexp.MustCompile(` {2,}`),
+		message: "Multiple consecutive spaces found",
+		replace: func(string) string { return " " },
+	})
+
+	Register(&regexpRule{
+		ruleInfo: ruleInfo{
+			id:          "space-before-punctuation",
+			category:    CategorySpacing,
+			severity:    SeverityWarning,
+			description: "Commas, periods and other punctuation should follow the word without a space",
+		},
+		pattern: regexp.MustCompile(`[\pL\pN]( +)[,.;:!?](?:\s|$)`),
+		message: "Remove the space before the punctuation",
+		replace: func(string) string { return "" },
+	})
+
+	Register(&funcRule{
+		ruleInfo: ruleInfo{
+			id:          "space-after-punctuation",
+			category:    CategorySpacing,
+			severity:    SeverityWarning,
+			description: "Commas and semicolons between words should be followed by a space",
+		},
+		match: matchMissingSpaceAfter,
+	})
+
+	Register(&funcRule{
+		ruleInfo: ruleInfo{
+			id:          "space-after-sentence",
+			category:    CategorySpacing,
+			severity:    SeverityWarning,
+			description: "A new sentence should be separated from the previous one by a space",
+		},
+		match: matchMissingSentenceSpace,
+	})
+
+	Register(&regexpRule{
+		ruleInfo: ruleInfo{
+			id:          "space-inside-parentheses",
+			category:    CategorySpacing,
+			severity:    SeverityInfo,
+			description: "Parentheses should not have spaces just inside them",
+		},
+		pattern: regexp.MustCompile(`\(( +)[^\s)]|[^\s(]( +)\)`),
+		message: "Remove the space inside the parentheses",
+		replace: func(string) string { return "" },
+	})
+}
+
+// matchMissingSpaceAfter reports commas and semicolons between two letters
+func matchMissingSpaceAfter(text string) []Match {
+	var matches []Match
+	var prev rune
+	for i, r := range text {
+		if r == ',' || r == ';' {
+			next, _ := utf8.DecodeRuneInString(text[i+1:])
+			if unicode.IsLetter(prev) && unicode.IsLetter(next) {
+				matches = append(matches, Match{
+					Offset:      i,
+					Length:      1,
+					Message:     "Add a space after the punctuation",
+					Replacement: string(r) + " ",
+				})
+			}
+		}
+		prev = r
+	}
+	return matches
+}
+
+// sentenceJoin matches a sentence ending right before the next one starts,
+// as in "end.Next"; file names and domains rarely have a capital after
+// the dot
+var sentenceJoin = regexp.MustCompile(`\p{Ll}{2}([.!?])\p{Lu}\p{Ll}`)
+
+// matchMissingSentenceSpace reports sentences that start right after the
+// end of the previous one
+func matchMissingSentenceSpace(text string) []Match {
+	var matches []Match
+	for _, loc := range sentenceJoin.FindAllStringSubmatchIndex(text, -1) {
+		matches = append(matches, Match{
+			Offset:      loc[2],
+			Length:      loc[3] - loc[2],
+			Message:     "Add a space after the end of the sentence",
+			Replacement: text[loc[2]:loc[3]] + " ",
+		})
+	}
+	return matches
+}
@@ -0,0 +1,89 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/spelling"
+)
+
+// SpellingRuleID is the ID of the rule reporting misspelled words. It is
+// only available for languages with a dictionary.
+const SpellingRuleID = "spelling"
+
+// spellingRule reports words that are in neither the dictionary nor the
+// custom dictionary
+type spellingRule struct {
+	ruleInfo
+	checker *spelling.Checker
+}
+
+func newSpellingRule(language string, checker *spelling.Checker) Rule {
+	return spellingRule{
+		ruleInfo: ruleInfo{
+			id:          SpellingRuleID,
+			category:    CategorySpelling,
+			severity:    SeverityError,
+			description: "Words that are not in the dictionary or the custom dictionary",
+			languages:   []string{language},
+		},
+		checker: checker,
+	}
+}
+
+// Match checks the words of text. Single letters, words with an uppercase
+// letter after the first, such as acronyms and identifiers, and words
+// joined to digits, paths, domains or mentions are not checked.
+func (r spellingRule) Match(text string) []Match {
+	var matches []Match
+	for _, loc := range word.FindAllStringIndex(text, -1) {
+		w := text[loc[0]:loc[1]]
+		if !checkable(text, loc[0], loc[1]) || r.checker.Check(w) {
+			continue
+		}
+		match := Match{
+			Offset:  loc[0],
+			Length:  loc[1] - loc[0],
+			Message: fmt.Sprintf("%q may be misspelled", w),
+		}
+		if suggestions := r.checker.Suggest(w); len(suggestions) > 0 {
+			match.Replacement = suggestions[0]
+			match.Suggestions = suggestions
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// checkable reports whether the word text[start:end] should be spell
+// checked
+func checkable(text string, start, end int) bool {
+	w := text[start:end]
+	_, size := utf8.DecodeRuneInString(w)
+	if size == len(w) || strings.IndexFunc(w[size:], unicode.IsUpper) >= 0 {
+		return false
+	}
+
+	before, beforeSize := utf8.DecodeLastRuneInString(text[:start])
+	after, afterSize := utf8.DecodeRuneInString(text[end:])
+	if start > 0 && (isWordRune(before) || strings.ContainsRune(`@#/\$`, before)) {
+		return false
+	}
+	if end < len(text) && (isWordRune(after) || strings.ContainsRune(`@/\`, after)) {
+		return false
+	}
+	// File names and domains, as in "main.go" and "example.com"
+	if before == '.' && start > beforeSize {
+		if r, _ := utf8.DecodeLastRuneInString(text[:start-beforeSize]); isWordRune(r) {
+			return false
+		}
+	}
+	if after == '.' && end+afterSize < len(text) {
+		if r, _ := utf8.DecodeRuneInString(text[end+afterSize:]); isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package grammar
+
+import "fmt"
+
+func init() {
+	Register(newPhraseRule(ruleInfo{
+		id:          "wordy-phrases",
+		category:    CategoryStyle,
+		severity:    SeverityInfo,
+		description: "Long phrases that say the same as a shorter one",
+		languages:   english,
+	}, "Consider %[2]q instead of %[1]q", map[string]string{
+		"in order to":                  "to",
+		"due to the fact that":         "because",
+		"owing to the fact that":       "because",
+		"in spite of the fact that":    "although",
+		"at this point in time":        "now",
+		"at the present time":          "now",
+		"in the event that":            "if",
+		"for the purpose of":           "for",
+		"in the near future":           "soon",
+		"a large number of":            "many",
+		"has the ability to":           "can",
+		"is able to":                   "can",
+		"with regard to":               "about",
+		"in regard to":                 "about",
+		"with reference to":            "about",
+		"on a daily basis":             "daily",
+		"prior to":                     "before",
+		"subsequent to":                "after",
+		"in close proximity to":        "near",
+		"despite the fact that":        "although",
+		"until such time as":           "until",
+		"it is important to note that": "note that",
+	}))
+
+	Register(newPhraseRule(ruleInfo{
+		id:          "redundant-phrases",
+		category:    CategoryStyle,
+		severity:    SeverityInfo,
+		description: "Phrases that repeat themselves, such as \"end result\"",
+		languages:   english,
+	}, "%[1]q is redundant; consider %[2]q", map[string]string{
+		"end result":          "result",
+		"free gift":           "gift",
+		"past history":        "history",
+		"added bonus":         "bonus",
+		"close proximity":     "proximity",
+		"each and every":      "each",
+		"revert back":         "revert",
+		"return back":         "return",
+		"repeat again":        "repeat",
+		"very unique":         "unique",
+		"completely full":     "full",
+		"future plans":        "plans",
+		"final outcome":       "outcome",
+		"new innovation":      "innovation",
+		"advance warning":     "warning",
+		"join together":       "join",
+		"merge together":      "merge",
+		"combine together":    "combine",
+		"unexpected surprise": "surprise",
+	}))
+
+	Register(newPhraseRule(ruleInfo{
+		id:          "nonstandard-words",
+		category:    CategoryStyle,
+		severity:    SeverityWarning,
+		description: "Words and idioms that are not standard English",
+		languages:   english,
+	}, "%[1]q is nonstandard; use %[2]q", map[string]string{
+		"irregardless":               "regardless",
+		"could care less":            "couldn't care less",
+		"supposably":                 "supposedly",
+		"alright":                    "all right",
+		"anyways":                    "anyway",
+		"nowheres":                   "nowhere",
+		"for all intensive purposes": "for all intents and purposes",
+		"first come, first serve":    "first come, first served",
+	}))
+}
+
+// maxSentenceWords is the number of words above which a sentence is long
+const maxSentenceWords = 40
+
+func init() {
+	Register(&sentenceRule{
+		ruleInfo: ruleInfo{
+			id:          "long-sentence",
+			category:    CategoryStyle,
+			severity:    SeverityInfo,
+			description: fmt.Sprintf("Sentences longer than %d words are hard to read", maxSentenceWords),
+		},
+		match: func(sentence Sentence) []Match {
+			words := len(word.FindAllStringIndex(sentence.Text, -1))
+			if words <= maxSentenceWords {
+				return nil
+			}
+			return []Match{{
+				Length:  len(sentence.Text),
+				Message: fmt.Sprintf("Sentence has %d words; consider splitting it", words),
+			}}
+		},
+	})
+}
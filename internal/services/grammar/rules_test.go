@@ -0,0 +1,231 @@
+package grammar
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules(t *testing.T) {
+	rules := Rules()
+	assert.GreaterOrEqual(t, len(rules), 25)
+
+	categories := map[string]bool{}
+	for _, category := range Categories {
+		categories[category] = true
+	}
+	for i, rule := range rules {
+		assert.NotEmpty(t, rule.Description(), rule.ID())
+		assert.True(t, categories[rule.Category()], rule.ID())
+		assert.Contains(t, []string{SeverityError, SeverityWarning, SeverityInfo}, rule.Severity(), rule.ID())
+		if i > 0 {
+			assert.Less(t, rules[i-1].ID(), rule.ID())
+		}
+	}
+
+	assert.Panics(t, func() { Register(rules[0]) })
+}
+
+// matched is a match reduced to what the rule tests compare
+type matched struct {
+	text        string
+	replacement string
+}
+
+func TestRules_Match(t *testing.T) {
+	tests := []struct {
+		rule     string
+		text     string
+		expected []matched
+	}{
+		{"multiple-spaces", "One  two   three.", []matched{{"  ", " "}, {"   ", " "}}},
+		{"space-before-punctuation", "Hello , world !", []matched{{" ", ""}, {" ", ""}}},
+		{"space-before-punctuation", "A .NET app, 3 :) and ...", nil},
+		{"space-after-punctuation", "Red,green;blue and 1,000.", []matched{{",", ", "}, {";", "; "}}},
+		{"space-after-sentence", "It ended.Then it began. See example.com and Node.JS.", []matched{{".", ". "}}},
+		{"space-inside-parentheses", "A ( note ) and (fine).", []matched{{" ", ""}, {" ", ""}}},
+		{"missing-end-punctuation", "No end", []matched{{"", ""}}},
+		{"missing-end-punctuation", "Ends (like this.) \n", nil},
+		{"missing-end-punctuation", "Quoted \"end.\"", nil},
+		{"missing-end-punctuation", "First sentence. Second one", []matched{{"", ""}}},
+		{"missing-end-punctuation", "\ufffc", nil},
+		{"repeated-punctuation", "Really?? Yes!!!", []matched{{"??", "?"}, {"!!!", "!"}}},
+		{"double-period", "End.. Then... more.", []matched{{"..", "."}}},
+		{"comma-before-period", "Apples, pears,. And more.", []matched{{",.", "."}}},
+		{"unbalanced-parentheses", "An (open one and a) closed one) :)", []matched{{")", ""}}},
+		{"unbalanced-parentheses", "Never (closed.\n1) list item", []matched{{"(", ""}}},
+		{"unbalanced-quotes", `He said "hi" and "bye.`, []matched{{`"`, ""}}},
+		{"unbalanced-quotes", "“Open and “closed”.", []matched{{"“", ""}}},
+		{"unbalanced-quotes", "Er sagte „Hallo“ und «adiós» und „tschüss.", []matched{{"„", ""}}},
+		{"unbalanced-quotes", "Fin» y ”fin.", []matched{{"»", ""}, {"”", ""}}},
+		{"inverted-punctuation", "Qué hora es? Dime, ¿vienes? ¡Hola! Adiós!", []matched{{"Q", "¿Q"}, {"A", "¡A"}}},
+		{"inverted-punctuation", "No es una pregunta.", nil},
+		{"sentence-capitalization", "first. Second! third? 4th is fine. \"quoted\" too.", []matched{{"f", "F"}, {"t", "T"}, {"q", "Q"}}},
+		{"sentence-capitalization", "We met in Washington D.C. yesterday.", nil},
+		{"lowercase-i", "Then i said i'm in, i.e. ready.", []matched{{"i", "I"}, {"i", "I"}}},
+		{"day-capitalization", "See you monday or on Tuesdays and fridays.", []matched{{"monday", "Monday"}, {"fridays", "Fridays"}}},
+		{"month-capitalization", "In january, may or March.", []matched{{"january", "January"}}},
+		{"language-capitalization", "Written in english and German.", []matched{{"english", "English"}}},
+		{"repeated-words", "The the cat had had enough of of it.", []matched{{"The the", "The"}, {"of of", "of"}}},
+		{"repeated-words", "Bye, bye.", nil},
+		{"a-an", "A apple, an banana, a hour, an university and a URL, an FAQ.", []matched{{"A", "An"}, {"an", "a"}, {"a", "an"}, {"an", "a"}}},
+		{"a-an", "A one-off, an uninstall, a European and a 8.", nil},
+		{"its-its", "Its been long and it's own way.", []matched{{"Its been", "It's been"}, {"it's own", "its own"}}},
+		{"then-than", "Better then before and more\nthen that.", []matched{{"Better then", "Better than"}, {"more\nthen", "more than"}}},
+		{"your-youre", "Your welcome to your room.", []matched{{"Your welcome", "You're welcome"}}},
+		{"their-there", "Their are cats over their.", []matched{{"Their are", "There are"}, {"over their", "over there"}}},
+		{"their-there", "Is there not a better way? Is there going to be one?", nil},
+		{"could-of", "I could of known.", []matched{{"could of", "could have"}}},
+		{"alot", "Thanks alot.", []matched{{"alot", "a lot"}}},
+		{"affect-effect", "The affect of rain.", []matched{{"The affect", "The effect"}}},
+		{"affect-effect", "We need to effect change, and it will effect a new policy.", nil},
+		{"subject-verb-agreement", "He don't know and they was late.", []matched{{"He don't", "He doesn't"}, {"they was", "they were"}}},
+		{"run-on-sentence", "This sentence goes on and on and it never stops because the writer did not want to stop and so it keeps going until the reader gets tired. Short one.", []matched{{"This sentence goes on and on and it never stops because the writer did not want to stop and so it keeps going until the reader gets tired.", ""}}},
+		{"run-on-sentence", "This sentence goes on and on, but it has a comma that separates clauses so that the reader is able to follow it until the end of it.", nil},
+		{"long-sentence", "Word " + strings.Repeat("word ", 40) + "end. Short one.", []matched{{"Word " + strings.Repeat("word ", 40) + "end.", ""}}},
+		{"wordy-phrases", "In order to win, act prior to noon.", []matched{{"In order to", "To"}, {"prior to", "before"}}},
+		{"redundant-phrases", "The end result was a free gift.", []matched{{"end result", "result"}, {"free gift", "gift"}}},
+		{"nonstandard-words", "Irregardless, it is alright.", []matched{{"Irregardless", "Regardless"}, {"alright", "all right"}}},
+	}
+
+	registered := map[string]Rule{}
+	for _, rule := range Rules() {
+		registered[rule.ID()] = rule
+	}
+	tested := map[string]bool{}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, ok := registered[tt.rule]
+			require.True(t, ok, "rule %s is not registered", tt.rule)
+			tested[tt.rule] = true
+
+			var actual []matched
+			for _, match := range rule.Match(tt.text) {
+				assert.NotEmpty(t, match.Message)
+				actual = append(actual, matched{tt.text[match.Offset : match.Offset+match.Length], match.Replacement})
+			}
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+
+	for id := range registered {
+		assert.True(t, tested[id], "rule %s has no test", id)
+	}
+}
+
+func TestGrammarService_CheckWithOptions(t *testing.T) {
+	service := NewService()
+	text := "this is is a test"
+
+	result, err := service.CheckWithOptions(text, Options{})
+	require.NoError(t, err)
+	rules := []string{}
+	for _, issue := range result.Issues {
+		rules = append(rules, issue.Rule)
+	}
+	assert.Equal(t, []string{"sentence-capitalization", "repeated-words", "missing-end-punctuation"}, rules)
+	assert.Equal(t, SeverityError, result.Issues[1].Severity)
+	assert.Equal(t, CategoryGrammar, result.Issues[1].Type)
+
+	result, err = service.CheckWithOptions(text, Options{Rules: map[string]bool{CategoryPunctuation: false, "repeated-words": false}})
+	require.NoError(t, err)
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, "sentence-capitalization", result.Issues[0].Rule)
+
+	// A rule ID takes precedence over its category
+	result, err = service.CheckWithOptions(text, Options{Rules: map[string]bool{CategoryGrammar: false, "repeated-words": true}})
+	require.NoError(t, err)
+	assert.Len(t, result.Issues, 3)
+
+	_, err = service.CheckWithOptions(text, Options{Rules: map[string]bool{"no-such-rule": false}})
+	assert.ErrorContains(t, err, "unknown grammar rule")
+}
+
+func TestGrammarService_Languages(t *testing.T) {
+	service := NewService()
+	assert.Equal(t, []string{LanguageGerman, LanguageEnglish, LanguageSpanish}, service.Languages())
+
+	ids := func(rules []Rule) map[string]bool {
+		found := map[string]bool{}
+		for _, rule := range rules {
+			found[rule.ID()] = true
+		}
+		return found
+	}
+	english, german, spanish := ids(service.Rules(LanguageEnglish)), ids(service.Rules(LanguageGerman)), ids(service.Rules(LanguageSpanish))
+	assert.True(t, english["a-an"])
+	assert.False(t, german["a-an"])
+	assert.True(t, german["repeated-words"])
+	assert.True(t, spanish["inverted-punctuation"])
+	assert.False(t, english["inverted-punctuation"])
+
+	// Rules added to the service check one language
+	rule := &funcRule{
+		ruleInfo: ruleInfo{id: "dass-das", category: CategoryGrammar, severity: SeverityWarning, description: "test"},
+		match: func(text string) []Match {
+			if i := strings.Index(text, ", das "); i >= 0 {
+				return []Match{{Offset: i + 2, Length: 3, Message: "Meinten Sie „dass“?", Replacement: "dass"}}
+			}
+			return nil
+		},
+	}
+	require.NoError(t, service.AddRule(LanguageGerman, rule))
+	assert.Error(t, service.AddRule(LanguageGerman, rule))
+	assert.Error(t, service.AddRule(LanguageGerman, Rules()[0]))
+	assert.Error(t, service.AddRule(LanguageAuto, rule))
+	require.NoError(t, service.AddRule("fr", rule))
+	assert.Contains(t, service.Languages(), "fr")
+
+	text := "Ich glaube, das es morgen regnet."
+	result, err := service.CheckWithOptions(text, Options{Language: LanguageGerman})
+	require.NoError(t, err)
+	assert.Equal(t, LanguageGerman, result.Language)
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, "dass-das", result.Issues[0].Rule)
+
+	result, err = service.CheckWithOptions(text, Options{Language: LanguageEnglish})
+	require.NoError(t, err)
+	assert.Empty(t, result.Issues)
+
+	// Rules of other languages may be named in options
+	_, err = service.CheckWithOptions(text, Options{Rules: map[string]bool{"dass-das": false}})
+	assert.NoError(t, err)
+
+	_, err = service.CheckWithOptions(text, Options{Language: "xx"})
+	assert.ErrorContains(t, err, "unsupported language")
+}
+
+func TestGrammarService_CheckWithOptions_DetectLanguage(t *testing.T) {
+	service := NewService()
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"english", "We should write the documentation before the release, so that everyone knows what changed.", LanguageEnglish},
+		{"german", "Wir sollten die Dokumentation vor der Veröffentlichung schreiben, damit alle wissen, was sich geändert hat.", LanguageGerman},
+		{"spanish", "Deberíamos escribir la documentación antes de la publicación, para que todos sepan qué ha cambiado.", LanguageSpanish},
+		{"markdown", "# Notizen\n\n- Die Besprechung ist am Montag\n- Bitte `make test` ausführen, bevor ihr etwas hochladet\n", LanguageGerman},
+		{"too short", "Hola.", DefaultLanguage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := service.CheckWithOptions(tt.text, Options{Language: LanguageAuto})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result.Language)
+		})
+	}
+
+	// English rules do not run on Spanish text
+	result, err := service.CheckWithOptions("Necesito una hora para terminar esto, qué dices?", Options{Language: LanguageAuto})
+	require.NoError(t, err)
+	var rules []string
+	for _, issue := range result.Issues {
+		rules = append(rules, issue.Rule)
+	}
+	assert.Equal(t, []string{"inverted-punctuation"}, rules)
+}
@@ -0,0 +1,101 @@
+package grammar
+
+import (
+	"math"
+	"strings"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+)
+
+// categoryWeights weigh the penalty of issues by category: misspelled
+// words and broken grammar weigh most, style and spacing least
+var categoryWeights = map[string]float64{
+	CategorySpelling:       1,
+	CategoryGrammar:        1,
+	CategoryPunctuation:    0.7,
+	CategoryCapitalization: 0.7,
+	CategorySpacing:        0.4,
+	CategoryStyle:          0.5,
+}
+
+// severityWeights are the penalties of issues by severity
+var severityWeights = map[string]float64{
+	SeverityError:   3,
+	SeverityWarning: 2,
+	SeverityInfo:    1,
+}
+
+const (
+	// minScoreWords is the fewest words penalties are spread over, so that
+	// an issue in a short note does not ruin its score
+	minScoreWords = 50
+	// scoreDecay is the penalty per 100 words that lowers a score to 37,
+	// 1/e of 100
+	scoreDecay = 20
+)
+
+// CategoryWeight returns the weight of the issues of a category
+func CategoryWeight(category string) float64 {
+	if weight, ok := categoryWeights[category]; ok {
+		return weight
+	}
+	return 1
+}
+
+// SeverityWeight returns the penalty of an issue of a severity
+func SeverityWeight(severity string) float64 {
+	if weight, ok := severityWeights[severity]; ok {
+		return weight
+	}
+	return severityWeights[SeverityWarning]
+}
+
+// Score scores issues found in text of a number of words. Each issue is
+// penalized by the weights of its severity and category, and the score
+// decays from 100 with the penalty per 100 words, so that long notes are
+// not scored worse than short ones for the same density of issues. The
+// breakdown scores each category the same way.
+func Score(issues []models.GrammarIssue, words int) (float64, []models.GrammarCategoryScore) {
+	breakdown := make([]models.GrammarCategoryScore, len(Categories))
+	index := map[string]int{}
+	for i, category := range Categories {
+		breakdown[i] = models.GrammarCategoryScore{Category: category, Weight: CategoryWeight(category)}
+		index[category] = i
+	}
+
+	total := 0.0
+	for _, issue := range issues {
+		i, ok := index[issue.Type]
+		if !ok {
+			i = len(breakdown)
+			index[issue.Type] = i
+			breakdown = append(breakdown, models.GrammarCategoryScore{Category: issue.Type, Weight: CategoryWeight(issue.Type)})
+		}
+		penalty := SeverityWeight(issue.Severity) * breakdown[i].Weight
+		breakdown[i].Issues++
+		breakdown[i].Penalty += penalty
+		total += penalty
+	}
+	for i := range breakdown {
+		breakdown[i].Penalty = round(breakdown[i].Penalty)
+		breakdown[i].Score = penaltyScore(breakdown[i].Penalty, words)
+	}
+	return penaltyScore(total, words), breakdown
+}
+
+// penaltyScore turns a penalty of text of a number of words into a score
+// from 0 to 100
+func penaltyScore(penalty float64, words int) float64 {
+	perHundred := penalty * 100 / float64(max(words, minScoreWords))
+	return round(100 * math.Exp(-perHundred/scoreDecay))
+}
+
+// round rounds x to one decimal
+func round(x float64) float64 {
+	return math.Round(x*10) / 10
+}
+
+// countWords counts the words of prose
+func countWords(text string) int {
+	return len(strings.FieldsFunc(text, func(r rune) bool { return !isWordRune(r) }))
+}
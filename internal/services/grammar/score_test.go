@@ -0,0 +1,81 @@
+package grammar
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScore(t *testing.T) {
+	spelling := models.GrammarIssue{Type: CategorySpelling, Severity: SeverityError}
+	style := models.GrammarIssue{Type: CategoryStyle, Severity: SeverityInfo}
+
+	score, breakdown := Score(nil, 200)
+	assert.Equal(t, 100.0, score)
+	require.Len(t, breakdown, len(Categories))
+	for i, category := range breakdown {
+		assert.Equal(t, Categories[i], category.Category)
+		assert.Equal(t, 100.0, category.Score)
+	}
+
+	// An error in 100 words costs 3 points per 100 words
+	score, breakdown = Score([]models.GrammarIssue{spelling}, 100)
+	assert.Equal(t, 86.1, score)
+	assert.Equal(t, models.GrammarCategoryScore{Category: CategorySpelling, Issues: 1, Weight: 1, Penalty: 3, Score: 86.1}, breakdown[0])
+	assert.Equal(t, 100.0, breakdown[5].Score)
+
+	for _, tt := range []struct {
+		name          string
+		better, worse []models.GrammarIssue
+		betterWords   int
+		worseWords    int
+	}{
+		{"longer notes", []models.GrammarIssue{spelling}, []models.GrammarIssue{spelling}, 1000, 100},
+		{"fewer issues", []models.GrammarIssue{spelling}, []models.GrammarIssue{spelling, spelling}, 100, 100},
+		{"lighter issues", []models.GrammarIssue{style}, []models.GrammarIssue{spelling}, 100, 100},
+		{"short notes count as minScoreWords", []models.GrammarIssue{spelling}, []models.GrammarIssue{spelling, spelling}, 5, 50},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			better, _ := Score(tt.better, tt.betterWords)
+			worse, _ := Score(tt.worse, tt.worseWords)
+			assert.Greater(t, better, worse)
+		})
+	}
+
+	// Same density, same score
+	one, _ := Score([]models.GrammarIssue{spelling}, 100)
+	ten, _ := Score([]models.GrammarIssue{spelling, spelling, spelling, spelling, spelling, spelling, spelling, spelling, spelling, spelling}, 1000)
+	assert.Equal(t, one, ten)
+
+	// Categories of other checkers are scored too
+	_, breakdown = Score([]models.GrammarIssue{{Type: "typography", Severity: SeverityWarning}}, 100)
+	require.Len(t, breakdown, len(Categories)+1)
+	assert.Equal(t, "typography", breakdown[len(Categories)].Category)
+	assert.Equal(t, 2.0, breakdown[len(Categories)].Penalty)
+}
+
+func TestGrammarService_Check_Score(t *testing.T) {
+	service := Service{}
+	clean := strings.Repeat("The cat sat on the mat. ", 20)
+	result, err := service.Check(clean)
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, result.Score)
+	assert.Equal(t, 120, result.Words)
+
+	// Code is not counted as words
+	result, err = service.Check("The the cat sat.\n\n```\nnot counted at all\n```\n")
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Words)
+	assert.Less(t, result.Score, 100.0)
+	var grammar models.GrammarCategoryScore
+	for _, category := range result.Breakdown {
+		if category.Category == CategoryGrammar {
+			grammar = category
+		}
+	}
+	assert.Equal(t, 1, grammar.Issues)
+	assert.Equal(t, result.Score, grammar.Score)
+}
@@ -0,0 +1,228 @@
+package grammar
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Sentence is a sentence of text. Start and End are byte offsets in the
+// text it was found in.
+type Sentence struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// abbreviations lists lowercase abbreviations in the supported languages,
+// without their final period, that do not end a sentence
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "sr": true, "jr": true, "st": true,
+	"vs": true, "e.g": true, "i.e": true, "cf": true, "approx": true, "esp": true, "incl": true,
+	"fig": true, "figs": true, "vol": true, "nos": true, "p": true, "pp": true, "ch": true, "sec": true,
+	"jan": true, "feb": true, "apr": true, "jun": true, "jul": true, "aug": true,
+	"sep": true, "sept": true, "oct": true, "nov": true, "dec": true,
+	"u.s": true, "u.k": true, "dept": true, "ca": true,
+	// German
+	"z.b": true, "d.h": true, "u.a": true, "bzw": true, "ggf": true, "evtl": true, "vgl": true,
+	"nr": true, "hr": true, "fr": true,
+	// Spanish
+	"sra": true, "srta": true, "dra": true, "ud": true, "uds": true, "pág": true, "núm": true,
+}
+
+// finalAbbreviations lists abbreviations that end a sentence when the next
+// word is capitalized, as in "and so on, etc. The next"
+var finalAbbreviations = map[string]bool{
+	"etc": true, "inc": true, "ltd": true, "co": true, "corp": true, "a.m": true, "p.m": true,
+	"usw": true, "gmbh": true,
+}
+
+// initialism matches dotted initialisms without their final period, such
+// as "D.C" or "a.k.a"
+var initialism = regexp.MustCompile(`^(?:\pL\.)+\pL$`)
+
+// listMarker matches a list marker at the start of a line
+var listMarker = regexp.MustCompile(`^(?:[-*+•]|\d+[.)]|[a-z][.)])\s+`)
+
+// closingMarks can follow the end of a sentence, as in `"Stop."`
+const closingMarks = `"'”’)]»`
+
+// Sentences splits text into sentences. Periods after abbreviations and
+// initials, decimal points, ellipses followed by a lowercase word and
+// punctuation inside quotes followed by a lowercase word do not end a
+// sentence. A new line starting with a list marker starts a new sentence,
+// and the marker is not part of it.
+func Sentences(text string) []Sentence {
+	var sentences []Sentence
+	start := 0
+	add := func(end int) {
+		s, e := trimSentence(text, start, end)
+		if s < e {
+			sentences = append(sentences, Sentence{Start: s, End: e, Text: text[s:e]})
+		}
+		start = end
+	}
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == '\n':
+			if listMarker.MatchString(strings.TrimLeft(text[i+1:], " \t")) {
+				add(i)
+			}
+			i += size
+			continue
+		case !isTerminator(r):
+			i += size
+			continue
+		}
+
+		// The end of the terminators and closing marks after them
+		end := i
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !isTerminator(r) {
+				break
+			}
+			end += size
+		}
+		terminators := text[i:end]
+		closed := end
+		for closed < len(text) {
+			r, size := utf8.DecodeRuneInString(text[closed:])
+			if !strings.ContainsRune(closingMarks, r) {
+				break
+			}
+			closed += size
+		}
+
+		if closed == len(text) || endsSentence(text, start, i, terminators, closed) {
+			add(closed)
+		}
+		i = closed
+	}
+	add(len(text))
+	return sentences
+}
+
+func isTerminator(r rune) bool {
+	return r == '.' || r == '!' || r == '?' || r == '…'
+}
+
+// endsSentence reports whether the terminators at offset i, followed by
+// closing marks up to closed, end the sentence started at start
+func endsSentence(text string, start, i int, terminators string, closed int) bool {
+	next, _ := utf8.DecodeRuneInString(text[closed:])
+	if !unicode.IsSpace(next) {
+		// Decimals, domains and "e.g" in the middle of an abbreviation
+		return false
+	}
+	following := nextWord(text[closed:])
+	lowerNext := following != "" && unicode.IsLower([]rune(following)[0])
+
+	if strings.Contains(terminators, "..") || terminators == "…" {
+		return !lowerNext
+	}
+	if closed > i+len(terminators) && lowerNext {
+		// Punctuation inside quotes, as in `"Why?" she asked`
+		return false
+	}
+	if terminators != "." {
+		return true
+	}
+
+	before := lastWord(text[start:i])
+	lower := strings.ToLower(before)
+	switch {
+	case abbreviations[lower]:
+		return false
+	case finalAbbreviations[lower]:
+		return !lowerNext
+	case utf8.RuneCountInString(before) == 1 && unicode.IsUpper([]rune(before)[0]) && before != "I":
+		// An initial, as in "J. Smith"
+		return false
+	case initialism.MatchString(before):
+		// Such as "D.C.", which ends a sentence before a capitalized word
+		return !lowerNext
+	case isListNumber(text[start:i]):
+		return false
+	}
+	return true
+}
+
+// lastWord returns the letters and inner periods before the end of text,
+// such as "e.g" in "see e.g"
+func lastWord(text string) string {
+	i := len(text)
+	for i > 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:i])
+		if !unicode.IsLetter(r) && r != '.' {
+			break
+		}
+		i -= size
+	}
+	return strings.TrimLeft(text[i:], ".")
+}
+
+// nextWord returns the word after leading spaces and opening marks
+func nextWord(text string) string {
+	text = strings.TrimLeftFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`"'“‘([«`, r)
+	})
+	end := strings.IndexFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) })
+	if end < 0 {
+		return text
+	}
+	return text[:end]
+}
+
+// isListNumber reports whether a sentence so far is only a number, as in
+// "1." starting an item
+func isListNumber(text string) bool {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return false
+	}
+	for _, r := range text {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// trimSentence trims the whitespace and any list marker around a sentence
+func trimSentence(text string, start, end int) (int, int) {
+	for start < end {
+		r, size := utf8.DecodeRuneInString(text[start:end])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		start += size
+	}
+	if loc := listMarker.FindStringIndex(text[start:end]); loc != nil && start+loc[1] < end {
+		start += loc[1]
+	}
+	for end > start {
+		r, size := utf8.DecodeLastRuneInString(text[start:end])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		end -= size
+	}
+	return start, end
+}
+
+// sentenceIndex returns the index of the sentence containing an offset,
+// or of the last sentence before it
+func sentenceIndex(sentences []Sentence, offset int) int {
+	index := 0
+	for i, sentence := range sentences {
+		if sentence.Start > offset {
+			break
+		}
+		index = i
+	}
+	return index
+}
@@ -0,0 +1,42 @@
+package grammar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSentences(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{"simple", "One. Two! Three? Four", []string{"One.", "Two!", "Three?", "Four"}},
+		{"abbreviations", "Ask Dr. Smith, e.g. about the U.S. economy. Then leave.", []string{"Ask Dr. Smith, e.g. about the U.S. economy.", "Then leave."}},
+		{"initialisms", "Back from Washington D.C. yesterday, a.k.a. home. We moved to D.C. Then we left.", []string{"Back from Washington D.C. yesterday, a.k.a. home.", "We moved to D.C.", "Then we left."}},
+		{"final abbreviations", "Apples, pears etc. are fruit. Bring food, drinks etc. The party starts at 8 p.m. today.", []string{"Apples, pears etc. are fruit.", "Bring food, drinks etc.", "The party starts at 8 p.m. today."}},
+		{"initials", "J. R. R. Tolkien wrote it. So did I. Really.", []string{"J. R. R. Tolkien wrote it.", "So did I.", "Really."}},
+		{"decimals", "It costs 3.50 dollars. Pi is 3.14159.", []string{"It costs 3.50 dollars.", "Pi is 3.14159."}},
+		{"ellipses", "Wait... what was that? Well… Nothing.", []string{"Wait... what was that?", "Well…", "Nothing."}},
+		{"quotes", `He said "Stop." Then he left. "Why?" she asked. "Go!"`, []string{`He said "Stop."`, "Then he left.", `"Why?" she asked.`, `"Go!"`}},
+		{"parentheses", "This is an aside (really.) Next one.", []string{"This is an aside (really.)", "Next one."}},
+		{"list items", "1. First item\n2. Second item.\n- Third", []string{"First item", "Second item.", "Third"}},
+		{"lowercase starts", "first. second.", []string{"first.", "second."}},
+		{"line breaks", "A sentence\nover lines. Next.", []string{"A sentence\nover lines.", "Next."}},
+		{"urls and domains", "See example.com for more. Done.", []string{"See example.com for more.", "Done."}},
+		{"empty", "  ", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sentences := Sentences(tt.text)
+			var texts []string
+			for _, sentence := range sentences {
+				assert.Equal(t, sentence.Text, tt.text[sentence.Start:sentence.End])
+				texts = append(texts, sentence.Text)
+			}
+			assert.Equal(t, tt.expected, texts)
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package grammar
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+)
+
+// Units of the offsets of edits
+const (
+	UnitByte  = "byte"
+	UnitRune  = "rune"
+	UnitUTF16 = "utf16"
+)
+
+const (
+	// DefaultDebounce is how long a session waits for more edits before
+	// checking
+	DefaultDebounce = 300 * time.Millisecond
+	// MaxDebounce is the longest debounce a session accepts
+	MaxDebounce = 5 * time.Second
+)
+
+// SessionOptions configure a live session
+type SessionOptions struct {
+	Options
+	// Unit is what the offsets of edits count; it defaults to UnitByte
+	Unit string
+	// Debounce is how long the session waits for more edits before
+	// checking; it defaults to DefaultDebounce, and negative checks at once
+	Debounce time.Duration
+}
+
+// Session checks text as it is edited, such as in an editor. Edits are
+// applied at once, and the text is checked when no edit came for the
+// debounce; only the blocks that changed since the last check are checked
+// again. A check still running when an edit comes is cancelled, so that
+// results are never those of outdated text.
+type Session struct {
+	service  *Service
+	options  Options
+	unit     string
+	debounce time.Duration
+	send     func(models.LiveGrammarEvent)
+
+	mu      sync.Mutex
+	text    string
+	version int
+	// checked is the text of the last check sent, and touched the ranges
+	// of the text edited since
+	checked string
+	touched [][2]int
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	closed  bool
+}
+
+// NewSession opens a session on text and checks it in full. Events are
+// passed to send, one at a time and in order.
+func (s *Service) NewSession(ctx context.Context, text string, options SessionOptions, send func(models.LiveGrammarEvent)) (*Session, error) {
+	switch options.Unit {
+	case "":
+		options.Unit = UnitByte
+	case UnitByte, UnitRune, UnitUTF16:
+	default:
+		return nil, fmt.Errorf("unsupported unit %q", options.Unit)
+	}
+	switch {
+	case options.Debounce == 0:
+		options.Debounce = DefaultDebounce
+	case options.Debounce < 0:
+		options.Debounce = 0
+	case options.Debounce > MaxDebounce:
+		return nil, fmt.Errorf("debounce longer than %s", MaxDebounce)
+	}
+	options.Previous = nil
+
+	result, err := s.CheckContext(ctx, text, options.Options)
+	if err != nil {
+		return nil, err
+	}
+	session := &Session{
+		service:  s,
+		options:  options.Options,
+		unit:     options.Unit,
+		debounce: options.Debounce,
+		send:     send,
+		text:     text,
+		checked:  text,
+	}
+	send(models.LiveGrammarEvent{Type: "result", Full: true, GrammarCheckResult: result})
+	return session, nil
+}
+
+// Text returns the current text of the session
+func (s *Session) Text() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.text
+}
+
+// Edit applies edits to the text, one after the other, and schedules a
+// check. When an edit is out of range, none are applied. It returns the
+// version of the text, the number of Edit calls that succeeded.
+func (s *Session) Edit(edits []models.TextEdit) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return s.version, fmt.Errorf("session closed")
+	}
+
+	text := s.text
+	touched := append([][2]int(nil), s.touched...)
+	for i, edit := range edits {
+		start, ok := byteOffset(text, 0, edit.Offset, s.unit)
+		if !ok || edit.Delete < 0 {
+			return s.version, fmt.Errorf("edit %d: offset %d out of range", i, edit.Offset)
+		}
+		end, ok := byteOffset(text, start, edit.Delete, s.unit)
+		if !ok {
+			return s.version, fmt.Errorf("edit %d: delete %d out of range", i, edit.Delete)
+		}
+		text = text[:start] + edit.Insert + text[end:]
+		touched = shiftRanges(touched, start, end, len(edit.Insert))
+		touched = append(touched, [2]int{start, start + len(edit.Insert)})
+	}
+	s.text = text
+	s.touched = touched
+	s.version++
+
+	// Results of the text before these edits are stale
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	version := s.version
+	s.timer = time.AfterFunc(s.debounce, func() { s.check(version) })
+	return s.version, nil
+}
+
+// check checks the text of a version, unless it was edited since
+func (s *Session) check(version int) {
+	s.mu.Lock()
+	if s.closed || version != s.version {
+		s.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	text, options := s.text, s.options
+	checked := s.checked
+	options.Previous = &checked
+	options.touched = s.touched
+	s.mu.Unlock()
+	defer cancel()
+
+	result, err := s.service.CheckContext(ctx, text, options)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || version != s.version || ctx.Err() != nil {
+		return
+	}
+	s.cancel = nil
+	if err != nil {
+		s.send(models.LiveGrammarEvent{Type: "error", Version: version, Error: err.Error()})
+		return
+	}
+	s.checked = text
+	s.touched = nil
+	s.send(models.LiveGrammarEvent{Type: "result", Version: version, GrammarCheckResult: result})
+}
+
+// Close stops the session, dropping pending checks
+func (s *Session) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// byteOffset returns the byte offset n units after from in text. It fails
+// when that is past the end of text or inside a character.
+func byteOffset(text string, from, n int, unit string) (int, bool) {
+	if n < 0 {
+		return 0, false
+	}
+	if unit == UnitByte {
+		offset := from + n
+		return offset, offset <= len(text) && (offset == len(text) || utf8.RuneStart(text[offset]))
+	}
+	offset := from
+	for n > 0 {
+		if offset >= len(text) {
+			return 0, false
+		}
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		units := 1
+		if unit == UnitUTF16 && r > maxBMP {
+			units = 2
+		}
+		if units > n {
+			// Half of a surrogate pair
+			return 0, false
+		}
+		n -= units
+		offset += size
+	}
+	return offset, true
+}
+
+// shiftRanges moves byte ranges after replacing text[start:end] with
+// inserted bytes: ranges after the replacement move with it, and ranges
+// overlapping it shrink to what is left of them
+func shiftRanges(ranges [][2]int, start, end, inserted int) [][2]int {
+	delta := inserted - (end - start)
+	shift := func(offset int) int {
+		switch {
+		case offset >= end:
+			return offset + delta
+		case offset > start:
+			return start
+		}
+		return offset
+	}
+	for i, r := range ranges {
+		ranges[i] = [2]int{shift(r[0]), shift(r[1])}
+	}
+	return ranges
+}
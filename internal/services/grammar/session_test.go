@@ -0,0 +1,198 @@
+package grammar
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSession opens a session whose events are sent to the returned
+// channel
+func newTestSession(t *testing.T, service *Service, text string, options SessionOptions) (*Session, chan models.LiveGrammarEvent) {
+	events := make(chan models.LiveGrammarEvent, 16)
+	session, err := service.NewSession(context.Background(), text, options, func(event models.LiveGrammarEvent) {
+		events <- event
+	})
+	require.NoError(t, err)
+	t.Cleanup(session.Close)
+	return session, events
+}
+
+func nextEvent(t *testing.T, events chan models.LiveGrammarEvent) models.LiveGrammarEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event")
+	}
+	return models.LiveGrammarEvent{}
+}
+
+func TestSession(t *testing.T) {
+	service := NewService()
+	const text = "The the first paragraph.\n\nA second paragraph.\n\nA third one.\n"
+	session, events := newTestSession(t, service, text, SessionOptions{Debounce: 20 * time.Millisecond})
+
+	event := nextEvent(t, events)
+	assert.Equal(t, "result", event.Type)
+	assert.True(t, event.Full)
+	assert.Equal(t, 0, event.Version)
+	require.Len(t, event.Issues, 1)
+	assert.Equal(t, "repeated-words", event.Issues[0].Rule)
+
+	// Edits typed in a row are checked once, and only their paragraph
+	for _, edit := range []models.TextEdit{
+		{Offset: 28, Delete: 6, Insert: "sec"},
+		{Offset: 31, Insert: "ond "},
+	} {
+		_, err := session.Edit([]models.TextEdit{edit})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "The the first paragraph.\n\nA second  paragraph.\n\nA third one.\n", session.Text())
+	event = nextEvent(t, events)
+	assert.Equal(t, "result", event.Type)
+	assert.False(t, event.Full)
+	assert.Equal(t, 2, event.Version)
+	require.Len(t, event.Checked, 1)
+	assert.Equal(t, models.TextRange{Offset: 26, Length: 20, RuneOffset: 26, RuneLength: 20, UTF16Offset: 26, UTF16Length: 20}, event.Checked[0])
+	require.Len(t, event.Issues, 1)
+	assert.Equal(t, "multiple-spaces", event.Issues[0].Rule)
+	assert.Equal(t, 34, event.Issues[0].Offset)
+
+	// A paragraph edited back to what it was is checked again
+	_, err := session.Edit([]models.TextEdit{{Offset: 34, Delete: 1}})
+	require.NoError(t, err)
+	event = nextEvent(t, events)
+	require.Len(t, event.Checked, 1)
+	assert.Empty(t, event.Issues)
+	_, err = session.Edit([]models.TextEdit{{Offset: 34, Insert: "x"}, {Offset: 34, Delete: 1}})
+	require.NoError(t, err)
+	event = nextEvent(t, events)
+	assert.Len(t, event.Checked, 1)
+
+	// Invalid edits are not applied
+	for _, edits := range [][]models.TextEdit{
+		{{Offset: 100}},
+		{{Offset: -1}},
+		{{Offset: 0, Delete: 100}},
+		{{Offset: 0, Insert: "x"}, {Offset: 0, Delete: -1}},
+	} {
+		version, err := session.Edit(edits)
+		assert.Error(t, err)
+		assert.Equal(t, 4, version)
+	}
+	assert.Equal(t, "The the first paragraph.\n\nA second paragraph.\n\nA third one.\n", session.Text())
+}
+
+func TestSession_Stale(t *testing.T) {
+	service := NewService()
+	session, events := newTestSession(t, service, "A cat.\n", SessionOptions{Debounce: 50 * time.Millisecond})
+	nextEvent(t, events)
+
+	// Edits before the debounce replace the pending check
+	for i := 0; i < 5; i++ {
+		_, err := session.Edit([]models.TextEdit{{Offset: 0, Insert: "the "}})
+		require.NoError(t, err)
+		time.Sleep(5 * time.Millisecond)
+	}
+	event := nextEvent(t, events)
+	assert.Equal(t, 5, event.Version)
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Closing drops the pending check
+	_, err := session.Edit([]models.TextEdit{{Offset: 0, Insert: "x"}})
+	require.NoError(t, err)
+	session.Close()
+	_, err = session.Edit([]models.TextEdit{{Offset: 0, Insert: "x"}})
+	assert.Error(t, err)
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// blockingRule blocks in Match until released, so that tests can edit
+// while a check runs
+type blockingRule struct {
+	ruleInfo
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingRule) Match(text string) []Match {
+	if strings.Contains(text, "block") {
+		r.started <- struct{}{}
+		<-r.release
+	}
+	return nil
+}
+
+func TestSession_CancelsRunningCheck(t *testing.T) {
+	service := NewService()
+	rule := &blockingRule{
+		ruleInfo: ruleInfo{id: "blocking", category: CategoryStyle, severity: SeverityInfo},
+		started:  make(chan struct{}),
+		release:  make(chan struct{}),
+	}
+	require.NoError(t, service.AddRule(LanguageEnglish, rule))
+	session, events := newTestSession(t, service, "A cat.\n", SessionOptions{Debounce: -1})
+	nextEvent(t, events)
+
+	_, err := session.Edit([]models.TextEdit{{Offset: 0, Insert: "block "}})
+	require.NoError(t, err)
+	<-rule.started
+	_, err = session.Edit([]models.TextEdit{{Offset: 0, Delete: 6, Insert: "The the "}})
+	require.NoError(t, err)
+	close(rule.release)
+
+	// Only the result of the newer edit arrives
+	event := nextEvent(t, events)
+	assert.Equal(t, 2, event.Version)
+	require.Len(t, event.Issues, 1)
+	assert.Equal(t, "repeated-words", event.Issues[0].Rule)
+}
+
+func TestSession_Units(t *testing.T) {
+	service := NewService()
+	const text = "I 😀 é cat.\n"
+	for _, tt := range []struct {
+		unit   string
+		offset int
+		delete int
+	}{
+		{UnitByte, 10, 3},
+		{UnitRune, 6, 3},
+		{UnitUTF16, 7, 3},
+	} {
+		t.Run(tt.unit, func(t *testing.T) {
+			session, _ := newTestSession(t, service, text, SessionOptions{Unit: tt.unit, Debounce: MaxDebounce})
+			_, err := session.Edit([]models.TextEdit{{Offset: tt.offset, Delete: tt.delete, Insert: "dog"}})
+			require.NoError(t, err)
+			assert.Equal(t, "I 😀 é dog.\n", session.Text())
+		})
+	}
+
+	// Offsets inside a character are rejected
+	for _, unit := range []string{UnitByte, UnitUTF16} {
+		session, _ := newTestSession(t, service, text, SessionOptions{Unit: unit, Debounce: MaxDebounce})
+		_, err := session.Edit([]models.TextEdit{{Offset: 3, Insert: "x"}})
+		assert.Error(t, err, unit)
+	}
+
+	_, err := service.NewSession(context.Background(), text, SessionOptions{Unit: "lines"}, func(models.LiveGrammarEvent) {})
+	assert.Error(t, err)
+	_, err = service.NewSession(context.Background(), text, SessionOptions{Debounce: time.Minute}, func(models.LiveGrammarEvent) {})
+	assert.Error(t, err)
+	_, err = service.NewSession(context.Background(), text, SessionOptions{Options: Options{Language: "xx"}}, func(models.LiveGrammarEvent) {})
+	assert.Error(t, err)
+}
@@ -0,0 +1,590 @@
+package grammar
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"gopkg.in/yaml.v3"
+)
+
+// Style rule types, set by the extends field of a rule file
+const (
+	StyleExistence    = "existence"
+	StyleSubstitution = "substitution"
+	StyleOccurrence   = "occurrence"
+	StyleRepetition   = "repetition"
+)
+
+// Scopes of style rules. Rules may be limited to kinds of prose block;
+// occurrence rules count tokens per sentence or per block.
+const (
+	ScopeText      = "text"
+	ScopeSentence  = "sentence"
+	ScopeParagraph = "paragraph"
+	ScopeHeading   = "heading"
+	ScopeList      = "list"
+	ScopeTable     = "table"
+)
+
+// scopeBlocks maps the scopes that limit a rule to kinds of prose block
+var scopeBlocks = map[string][]string{
+	ScopeParagraph: {markdown.ProseParagraph},
+	ScopeHeading:   {markdown.ProseHeading},
+	ScopeList:      {markdown.ProseListItem},
+	ScopeTable:     {markdown.ProseTableCell},
+}
+
+// styleLevels maps the levels of rule files to severities. Vale's
+// "suggestion" is an info issue.
+var styleLevels = map[string]string{
+	"":              SeverityWarning,
+	"suggestion":    SeverityInfo,
+	SeverityInfo:    SeverityInfo,
+	SeverityWarning: SeverityWarning,
+	SeverityError:   SeverityError,
+}
+
+// StyleFile is a style rule as written in a YAML file, in the format of
+// Vale rules
+type StyleFile struct {
+	// Extends is the type of the rule, one of the Style constants
+	Extends string `yaml:"extends"`
+	// Message is the message of issues; each %s is replaced by the text
+	// found or, for substitutions, by the replacement and the text found
+	Message string `yaml:"message"`
+	// Level is the severity of issues: suggestion, info, warning or error
+	Level       string `yaml:"level"`
+	Description string `yaml:"description"`
+	// Scope limits the rule to a kind of block, or is the sentence or
+	// paragraph occurrence rules count tokens in
+	Scope string `yaml:"scope"`
+	// IgnoreCase matches tokens regardless of case
+	IgnoreCase bool `yaml:"ignorecase"`
+	// NonWord also matches tokens that are part of a longer word
+	NonWord bool `yaml:"nonword"`
+	// Tokens are regular expressions; existence rules report each match
+	// and repetition rules report matches repeated in a row
+	Tokens []string `yaml:"tokens"`
+	// Raw is a regular expression written in parts, which are joined;
+	// it matches as written, inside words too
+	Raw []string `yaml:"raw"`
+	// Exceptions are matches that are not reported
+	Exceptions []string `yaml:"exceptions"`
+	// Swap maps regular expressions to their replacement; alternative
+	// replacements are separated by "|"
+	Swap map[string]string `yaml:"swap"`
+	// Token is the regular expression occurrence rules count
+	Token string `yaml:"token"`
+	// Max and Min bound the occurrences of Token in the scope; 0 means no
+	// bound
+	Max int `yaml:"max"`
+	Min int `yaml:"min"`
+	// Alpha only reports repeated tokens made of letters
+	Alpha bool `yaml:"alpha"`
+	// Languages limits the rule to some languages; empty means all
+	Languages []string `yaml:"languages"`
+}
+
+// ParseStyleRule parses a style rule from YAML. The ID is usually the name
+// of the pack and of the file, such as "Docs.Simplicity".
+func ParseStyleRule(id string, data []byte) (Rule, error) {
+	var file StyleFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid style rule %s: %w", id, err)
+	}
+	rule, err := NewStyleRule(id, file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid style rule %s: %w", id, err)
+	}
+	return rule, nil
+}
+
+// NewStyleRule creates a rule from a rule file. Style rules are in the
+// style category.
+func NewStyleRule(id string, file StyleFile) (Rule, error) {
+	if id == "" {
+		return nil, errors.New("rule without an ID")
+	}
+	if strings.TrimSpace(file.Message) == "" {
+		return nil, errors.New("missing message")
+	}
+	severity, ok := styleLevels[strings.ToLower(file.Level)]
+	if !ok {
+		return nil, fmt.Errorf("invalid level %q", file.Level)
+	}
+	description := file.Description
+	if description == "" {
+		description = formatMessage(file.Message)
+	}
+	info := ruleInfo{
+		id:          id,
+		category:    CategoryStyle,
+		severity:    severity,
+		description: description,
+	}
+	if len(file.Languages) > 0 {
+		info.languages = file.Languages
+	}
+
+	switch file.Scope {
+	case "", ScopeText, ScopeSentence:
+	default:
+		blocks, ok := scopeBlocks[file.Scope]
+		if !ok {
+			return nil, fmt.Errorf("unsupported scope %q", file.Scope)
+		}
+		if file.Extends != StyleOccurrence {
+			info.blocks = blocks
+		}
+	}
+	flags := ""
+	if file.IgnoreCase {
+		flags = "(?i)"
+	}
+
+	switch file.Extends {
+	case StyleExistence:
+		return newExistenceRule(info, file, flags)
+	case StyleSubstitution:
+		return newSubstitutionRule(info, file, flags)
+	case StyleOccurrence:
+		return newOccurrenceRule(info, file, flags)
+	case StyleRepetition:
+		return newRepetitionRule(info, file, flags)
+	case "":
+		return nil, errors.New("missing rule type")
+	}
+	return nil, fmt.Errorf("unsupported rule type %q", file.Extends)
+}
+
+// LoadStyles loads the style rules of the packs in a directory. Each
+// subdirectory is a pack and each .yml or .yaml file in it a rule, whose ID
+// is the pack and file name, such as "Docs.Simplicity" for
+// Docs/Simplicity.yml. Rules are returned sorted by ID.
+func LoadStyles(dir string) ([]Rule, error) {
+	packs, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var rules []Rule
+	for _, pack := range packs {
+		if !pack.IsDir() || strings.HasPrefix(pack.Name(), ".") {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(dir, pack.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			ext := filepath.Ext(file.Name())
+			if file.IsDir() || (ext != ".yml" && ext != ".yaml") {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dir, pack.Name(), file.Name()))
+			if err != nil {
+				return nil, err
+			}
+			rule, err := ParseStyleRule(pack.Name()+"."+strings.TrimSuffix(file.Name(), ext), data)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, rule)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID() < rules[j].ID() })
+	for i := 1; i < len(rules); i++ {
+		if rules[i].ID() == rules[i-1].ID() {
+			return nil, fmt.Errorf("style rule %s defined twice", rules[i].ID())
+		}
+	}
+	return rules, nil
+}
+
+// StylePack returns the pack of a style rule ID, the part before the
+// first period, or "" if the ID has none
+func StylePack(id string) string {
+	pack, _, ok := strings.Cut(id, ".")
+	if !ok {
+		return ""
+	}
+	return pack
+}
+
+// formatMessage replaces the %s verbs of a message with args in order, and
+// those left over with "…". Other verbs are kept as written.
+func formatMessage(message string, args ...string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(message, "%s")
+		if i < 0 {
+			b.WriteString(message)
+			return b.String()
+		}
+		b.WriteString(message[:i])
+		if len(args) > 0 {
+			b.WriteString(args[0])
+			args = args[1:]
+		} else {
+			b.WriteString("…")
+		}
+		message = message[i+2:]
+	}
+}
+
+// compileStyle compiles a pattern of a rule file, naming the field it is
+// from in errors
+func compileStyle(field, pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", field, err)
+	}
+	return re, nil
+}
+
+// exceptions reports whether text is one of a rule's exceptions
+type exceptions struct {
+	words      map[string]bool
+	ignoreCase bool
+}
+
+func newExceptions(words []string, ignoreCase bool) exceptions {
+	e := exceptions{words: map[string]bool{}, ignoreCase: ignoreCase}
+	for _, word := range words {
+		if ignoreCase {
+			word = strings.ToLower(word)
+		}
+		e.words[word] = true
+	}
+	return e
+}
+
+func (e exceptions) contains(text string) bool {
+	if e.ignoreCase {
+		text = strings.ToLower(text)
+	}
+	return e.words[text]
+}
+
+// existenceRule reports each match of its tokens or raw pattern
+type existenceRule struct {
+	ruleInfo
+	pattern *regexp.Regexp
+	// tokens is the index of the submatch of the tokens, which must be
+	// whole words unless nonWord is set
+	tokens     int
+	nonWord    bool
+	message    string
+	exceptions exceptions
+}
+
+func newExistenceRule(info ruleInfo, file StyleFile, flags string) (Rule, error) {
+	var alternatives []string
+	if len(file.Raw) > 0 {
+		alternatives = append(alternatives, "(?P<raw>"+strings.Join(file.Raw, "")+")")
+	}
+	if len(file.Tokens) > 0 {
+		alternatives = append(alternatives, "(?P<tokens>"+strings.Join(file.Tokens, "|")+")")
+	}
+	if len(alternatives) == 0 {
+		return nil, errors.New("existence rule without tokens or raw")
+	}
+	pattern, err := compileStyle("tokens", flags+strings.Join(alternatives, "|"))
+	if err != nil {
+		return nil, err
+	}
+	return &existenceRule{
+		ruleInfo:   info,
+		pattern:    pattern,
+		tokens:     pattern.SubexpIndex("tokens"),
+		nonWord:    file.NonWord,
+		message:    file.Message,
+		exceptions: newExceptions(file.Exceptions, file.IgnoreCase),
+	}, nil
+}
+
+func (r *existenceRule) Match(text string) []Match {
+	var matches []Match
+	for _, loc := range r.pattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if start == end {
+			continue
+		}
+		if r.tokens > 0 && loc[2*r.tokens] >= 0 && !r.nonWord && !wholeWord(text, start, end) {
+			continue
+		}
+		found := text[start:end]
+		if r.exceptions.contains(found) {
+			continue
+		}
+		matches = append(matches, Match{
+			Offset:  start,
+			Length:  end - start,
+			Message: formatMessage(r.message, found),
+		})
+	}
+	return matches
+}
+
+// substitutionRule reports text that has a preferred replacement
+type substitutionRule struct {
+	ruleInfo
+	pattern *regexp.Regexp
+	// replacements holds the replacements of the submatches of pattern,
+	// by submatch index
+	replacements map[int][]string
+	nonWord      bool
+	ignoreCase   bool
+	message      string
+	exceptions   exceptions
+}
+
+func newSubstitutionRule(info ruleInfo, file StyleFile, flags string) (Rule, error) {
+	if len(file.Swap) == 0 {
+		return nil, errors.New("substitution rule without swap")
+	}
+	keys := make([]string, 0, len(file.Swap))
+	for key := range file.Swap {
+		if _, err := compileStyle("swap", key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	// Longer patterns first, so they win over patterns they start with
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+
+	alternatives := make([]string, len(keys))
+	for i, key := range keys {
+		alternatives[i] = fmt.Sprintf("(?P<swap%d>%s)", i, key)
+	}
+	pattern, err := compileStyle("swap", flags+strings.Join(alternatives, "|"))
+	if err != nil {
+		return nil, err
+	}
+	replacements := map[int][]string{}
+	for i, key := range keys {
+		var options []string
+		for _, option := range strings.Split(file.Swap[key], "|") {
+			if option = strings.TrimSpace(option); option != "" {
+				options = append(options, option)
+			}
+		}
+		if len(options) == 0 {
+			return nil, fmt.Errorf("swap %q without a replacement", key)
+		}
+		replacements[pattern.SubexpIndex(fmt.Sprintf("swap%d", i))] = options
+	}
+	return &substitutionRule{
+		ruleInfo:     info,
+		pattern:      pattern,
+		replacements: replacements,
+		nonWord:      file.NonWord,
+		ignoreCase:   file.IgnoreCase,
+		message:      file.Message,
+		exceptions:   newExceptions(file.Exceptions, file.IgnoreCase),
+	}, nil
+}
+
+func (r *substitutionRule) Match(text string) []Match {
+	var matches []Match
+	for _, loc := range r.pattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if start == end || (!r.nonWord && !wholeWord(text, start, end)) {
+			continue
+		}
+		found := text[start:end]
+		if r.exceptions.contains(found) {
+			continue
+		}
+		var options []string
+		for index, replacements := range r.replacements {
+			if loc[2*index] >= 0 {
+				options = replacements
+				break
+			}
+		}
+		suggestions := make([]string, 0, len(options))
+		for _, option := range options {
+			if r.ignoreCase {
+				option = matchCase(found, option)
+			}
+			if option == found {
+				// The text is already written the preferred way
+				suggestions = nil
+				break
+			}
+			suggestions = append(suggestions, option)
+		}
+		if len(suggestions) == 0 {
+			continue
+		}
+		match := Match{
+			Offset:      start,
+			Length:      end - start,
+			Message:     formatMessage(r.message, strings.Join(suggestions, "' or '"), found),
+			Replacement: suggestions[0],
+		}
+		if len(suggestions) > 1 {
+			match.Suggestions = suggestions
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// occurrenceRule reports sentences or blocks with too many or too few
+// occurrences of a token
+type occurrenceRule struct {
+	ruleInfo
+	token    *regexp.Regexp
+	max, min int
+	// sentences counts per sentence instead of per block
+	sentences bool
+	message   string
+}
+
+func newOccurrenceRule(info ruleInfo, file StyleFile, flags string) (Rule, error) {
+	if file.Token == "" {
+		return nil, errors.New("occurrence rule without a token")
+	}
+	if file.Max < 0 || file.Min < 0 || (file.Max == 0 && file.Min == 0) {
+		return nil, errors.New("occurrence rule needs a positive max or min")
+	}
+	if file.Max > 0 && file.Min > file.Max {
+		return nil, errors.New("occurrence rule with min above max")
+	}
+	var sentences bool
+	switch file.Scope {
+	case ScopeSentence:
+		sentences = true
+	case "", ScopeText, ScopeParagraph:
+	default:
+		return nil, fmt.Errorf("unsupported scope %q for an occurrence rule", file.Scope)
+	}
+	token, err := compileStyle("token", flags+file.Token)
+	if err != nil {
+		return nil, err
+	}
+	return &occurrenceRule{
+		ruleInfo:  info,
+		token:     token,
+		max:       file.Max,
+		min:       file.Min,
+		sentences: sentences,
+		message:   file.Message,
+	}, nil
+}
+
+func (r *occurrenceRule) Match(text string) []Match {
+	scopes := []Sentence{{Start: 0, End: len(text), Text: text}}
+	if r.sentences {
+		scopes = Sentences(text)
+	}
+	var matches []Match
+	for _, scope := range scopes {
+		count := 0
+		for _, loc := range r.token.FindAllStringIndex(scope.Text, -1) {
+			if loc[0] < loc[1] {
+				count++
+			}
+		}
+		if (r.max > 0 && count > r.max) || (r.min > 0 && count < r.min) {
+			start, end := trimSentence(text, scope.Start, scope.End)
+			if start == end {
+				continue
+			}
+			matches = append(matches, Match{
+				Offset:  start,
+				Length:  end - start,
+				Message: formatMessage(r.message, fmt.Sprint(count)),
+			})
+		}
+	}
+	return matches
+}
+
+// repetitionRule reports tokens repeated in a row, such as "the the"
+type repetitionRule struct {
+	ruleInfo
+	tokens     *regexp.Regexp
+	alpha      bool
+	nonWord    bool
+	ignoreCase bool
+	message    string
+}
+
+func newRepetitionRule(info ruleInfo, file StyleFile, flags string) (Rule, error) {
+	if len(file.Tokens) == 0 {
+		return nil, errors.New("repetition rule without tokens")
+	}
+	tokens, err := compileStyle("tokens", flags+"(?:"+strings.Join(file.Tokens, "|")+")")
+	if err != nil {
+		return nil, err
+	}
+	return &repetitionRule{
+		ruleInfo:   info,
+		tokens:     tokens,
+		alpha:      file.Alpha,
+		nonWord:    file.NonWord,
+		ignoreCase: file.IgnoreCase,
+		message:    file.Message,
+	}, nil
+}
+
+func (r *repetitionRule) Match(text string) []Match {
+	var matches []Match
+	var prev []int
+	for _, loc := range r.tokens.FindAllStringIndex(text, -1) {
+		if loc[0] == loc[1] || (!r.nonWord && !wholeWord(text, loc[0], loc[1])) {
+			continue
+		}
+		if prev != nil && r.repeats(text, prev, loc) {
+			token := text[loc[0]:loc[1]]
+			matches = append(matches, Match{
+				Offset:      prev[0],
+				Length:      loc[1] - prev[0],
+				Message:     formatMessage(r.message, token),
+				Replacement: text[prev[0]:prev[1]],
+			})
+		}
+		prev = loc
+	}
+	return matches
+}
+
+// repeats reports whether the token at loc repeats the one at prev, with
+// only whitespace between them
+func (r *repetitionRule) repeats(text string, prev, loc []int) bool {
+	if strings.TrimSpace(text[prev[1]:loc[0]]) != "" {
+		return false
+	}
+	a, b := text[prev[0]:prev[1]], text[loc[0]:loc[1]]
+	if r.alpha && !isAlpha(b) {
+		return false
+	}
+	if r.ignoreCase {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// isAlpha reports whether text is made of letters only
+func isAlpha(text string) bool {
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return text != ""
+}
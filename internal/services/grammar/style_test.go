@@ -0,0 +1,157 @@
+package grammar
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStyleRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		text    string
+		want    []Match
+		wantErr string
+	}{
+		{
+			name: "existence",
+			yaml: "extends: existence\nmessage: \"Avoid '%s'.\"\nignorecase: true\ntokens: [simply, just]\n",
+			text: "Simply run it, just once. Justice is simplyfied.",
+			want: []Match{
+				{Offset: 0, Length: 6, Message: "Avoid 'Simply'."},
+				{Offset: 15, Length: 4, Message: "Avoid 'just'."},
+			},
+		},
+		{
+			name: "existence exceptions",
+			yaml: "extends: existence\nmessage: \"Avoid '%s'.\"\ntokens: ['[A-Z]{3,}']\nexceptions: [API]\n",
+			text: "The API and the CLI.",
+			want: []Match{{Offset: 16, Length: 3, Message: "Avoid 'CLI'."}},
+		},
+		{
+			name: "existence raw",
+			yaml: "extends: existence\nmessage: \"'%s' is passive.\"\nraw: ['\\b(?:is|was)\\s+', '\\w+ed\\b']\n",
+			text: "The file was saved.",
+			want: []Match{{Offset: 9, Length: 9, Message: "'was saved' is passive."}},
+		},
+		{
+			name: "substitution",
+			yaml: "extends: substitution\nmessage: \"Use '%s' instead of '%s'.\"\nignorecase: true\nswap:\n  log ?in: sign in\n  click on: click|select\n",
+			text: "Login, then click on Save. Sign in works.",
+			want: []Match{
+				{Offset: 0, Length: 5, Message: "Use 'Sign in' instead of 'Login'.", Replacement: "Sign in"},
+				{Offset: 12, Length: 8, Message: "Use 'click' or 'select' instead of 'click on'.", Replacement: "click", Suggestions: []string{"click", "select"}},
+			},
+		},
+		{
+			name: "occurrence",
+			yaml: "extends: occurrence\nmessage: \"Sentence has %s words.\"\nscope: sentence\nmax: 3\ntoken: '\\w+'\n",
+			text: "One two three. One two three four.",
+			want: []Match{{Offset: 15, Length: 19, Message: "Sentence has 4 words."}},
+		},
+		{
+			name: "occurrence min",
+			yaml: "extends: occurrence\nmessage: Too short.\nmin: 3\ntoken: '\\w+'\n",
+			text: "Two words",
+			want: []Match{{Offset: 0, Length: 9, Message: "Too short."}},
+		},
+		{
+			name: "repetition",
+			yaml: "extends: repetition\nmessage: \"'%s' is repeated.\"\nalpha: true\nignorecase: true\ntokens: ['[^\\s]+']\n",
+			text: "It is is fine, 1 1 too. The the end.",
+			want: []Match{
+				{Offset: 3, Length: 5, Message: "'is' is repeated.", Replacement: "is"},
+				{Offset: 24, Length: 7, Message: "'the' is repeated.", Replacement: "The"},
+			},
+		},
+		{name: "no message", yaml: "extends: existence\ntokens: [x]\n", wantErr: "missing message"},
+		{name: "no type", yaml: "message: x\n", wantErr: "missing rule type"},
+		{name: "unknown type", yaml: "extends: capitalization\nmessage: x\n", wantErr: `unsupported rule type "capitalization"`},
+		{name: "invalid level", yaml: "extends: existence\nmessage: x\nlevel: fatal\ntokens: [x]\n", wantErr: `invalid level "fatal"`},
+		{name: "invalid scope", yaml: "extends: existence\nmessage: x\nscope: footnote\ntokens: [x]\n", wantErr: `unsupported scope "footnote"`},
+		{name: "no tokens", yaml: "extends: existence\nmessage: x\n", wantErr: "without tokens"},
+		{name: "invalid pattern", yaml: "extends: existence\nmessage: x\ntokens: ['(']\n", wantErr: "invalid tokens"},
+		{name: "no swap", yaml: "extends: substitution\nmessage: x\n", wantErr: "without swap"},
+		{name: "no bound", yaml: "extends: occurrence\nmessage: x\ntoken: x\n", wantErr: "positive max or min"},
+		{name: "invalid YAML", yaml: "extends: [", wantErr: "invalid style rule"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseStyleRule("Test.Rule", []byte(tt.yaml))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, CategoryStyle, rule.Category())
+			assert.Equal(t, tt.want, rule.Match(tt.text))
+		})
+	}
+}
+
+func TestLoadStyles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "Docs"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Docs", "Simplicity.yml"),
+		[]byte("extends: existence\nmessage: x\nlevel: suggestion\nscope: heading\ntokens: [simply]\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Docs", "README.md"), []byte("# Docs"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ignored.yml"), []byte("extends: nothing"), 0644))
+
+	rules, err := LoadStyles(dir)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, "Docs.Simplicity", rules[0].ID())
+	assert.Equal(t, SeverityInfo, rules[0].Severity())
+	assert.True(t, appliesTo(rules[0], "heading"))
+	assert.False(t, appliesTo(rules[0], "paragraph"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Docs", "Simplicity.yaml"), []byte("extends: existence\nmessage: x\ntokens: [x]\n"), 0644))
+	_, err = LoadStyles(dir)
+	assert.ErrorContains(t, err, "defined twice")
+
+	// The packs shipped with the app load
+	rules, err = LoadStyles(filepath.Join("..", "..", "..", "styles"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, rules)
+}
+
+func TestGrammarService_SetStyles(t *testing.T) {
+	simplicity, err := ParseStyleRule("Docs.Simplicity", []byte("extends: existence\nmessage: \"Avoid '%s'.\"\nlanguages: [en]\ntokens: [simply]\n"))
+	require.NoError(t, err)
+	terms, err := ParseStyleRule("Docs.Terms", []byte("extends: substitution\nmessage: \"Use '%s' instead of '%s'.\"\nswap:\n  login: sign in\n"))
+	require.NoError(t, err)
+
+	service := NewService()
+	require.NoError(t, service.SetStyles([]Rule{simplicity, terms}))
+
+	ids := func(options Options) []string {
+		result, err := service.CheckWithOptions("You simply login.", options)
+		require.NoError(t, err)
+		var ids []string
+		for _, issue := range result.Issues {
+			ids = append(ids, issue.Rule)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"Docs.Simplicity", "Docs.Terms"}, ids(Options{}))
+	assert.Empty(t, ids(Options{Rules: map[string]bool{"Docs": false}}))
+	assert.Equal(t, []string{"Docs.Terms"}, ids(Options{Rules: map[string]bool{"Docs": false, "Docs.Terms": true}}))
+	assert.Empty(t, ids(Options{Rules: map[string]bool{CategoryStyle: false}}))
+	assert.Equal(t, []string{"Docs.Terms"}, ids(Options{Language: LanguageGerman}))
+
+	_, err = service.CheckWithOptions("Text.", Options{Rules: map[string]bool{"Vale": false}})
+	assert.ErrorContains(t, err, "unknown grammar rule")
+
+	duplicate, err := ParseStyleRule("repeated-words", []byte("extends: existence\nmessage: x\ntokens: [x]\n"))
+	require.NoError(t, err)
+	assert.Error(t, service.SetStyles([]Rule{duplicate}))
+
+	require.NoError(t, service.SetStyles(nil))
+	assert.Empty(t, ids(Options{}))
+}
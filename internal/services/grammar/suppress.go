@@ -0,0 +1,152 @@
+package grammar
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"gopkg.in/yaml.v3"
+)
+
+// Directives in HTML comments that suppress issues
+const (
+	DirectiveIgnoreNextLine = "grammar-ignore-next-line"
+	DirectiveDisable        = "grammar-disable"
+	DirectiveEnable         = "grammar-enable"
+)
+
+// directivePattern matches a directive comment, with the rules it names
+var directivePattern = regexp.MustCompile(`<!--\s*(grammar-ignore-next-line|grammar-disable|grammar-enable)((?:\s+[\w.-]+)*)\s*-->`)
+
+// suppression is a byte range of a note where issues of some rules, or of
+// all rules when rules is nil, are not reported
+type suppression struct {
+	start, end int
+	rules      map[string]bool
+}
+
+// suppresses reports whether the suppression covers an issue of a rule at
+// an offset. Like options, it names rules by ID, style pack or category.
+func (s suppression) suppresses(offset int, rule, category string) bool {
+	if offset < s.start || offset >= s.end {
+		return false
+	}
+	return s.rules == nil || s.rules[rule] || s.rules[category] || (StylePack(rule) != "" && s.rules[StylePack(rule)])
+}
+
+// suppressions finds the directives of a note in its HTML, so that
+// directives in code are text. grammar-ignore-next-line suppresses issues
+// on the line after it, and grammar-disable suppresses issues up to the
+// grammar-enable naming the same rules, or to the end of the note. A
+// grammar-enable without rules ends every grammar-disable before it.
+func suppressions(text string) []suppression {
+	var result []suppression
+	// open holds the offsets where disabled rules start, by the rules
+	// joined by spaces; "" disables every rule
+	open := map[string]int{}
+	var order []string
+	// lineEnd returns the offset after the line containing offset
+	lineEnd := func(offset int) int {
+		end := strings.IndexByte(text[offset:], '\n') + offset + 1
+		if end == offset {
+			end = len(text)
+		}
+		return end
+	}
+	for _, fragment := range parser.HTML(text) {
+		if !strings.Contains(fragment.Text, "<!--") {
+			continue
+		}
+		for _, m := range directivePattern.FindAllStringSubmatchIndex(fragment.Text, -1) {
+			directive := fragment.Text[m[2]:m[3]]
+			names := strings.Fields(fragment.Text[m[4]:m[5]])
+			key := strings.Join(names, " ")
+			var rules map[string]bool
+			if len(names) > 0 {
+				rules = map[string]bool{}
+				for _, name := range names {
+					rules[name] = true
+				}
+			}
+
+			switch directive {
+			case DirectiveIgnoreNextLine:
+				end := lineEnd(fragment.Offset + m[1])
+				if end < len(text) {
+					result = append(result, suppression{start: end, end: lineEnd(end), rules: rules})
+				}
+			case DirectiveDisable:
+				if _, ok := open[key]; !ok {
+					open[key] = fragment.Offset + m[1]
+					order = append(order, key)
+				}
+			case DirectiveEnable:
+				for _, disabled := range order {
+					start, ok := open[disabled]
+					if !ok || (key != "" && disabled != key) {
+						continue
+					}
+					result = append(result, suppression{start: start, end: fragment.Offset + m[0], rules: ruleSet(disabled)})
+					delete(open, disabled)
+				}
+			}
+		}
+	}
+	for _, disabled := range order {
+		if start, ok := open[disabled]; ok {
+			result = append(result, suppression{start: start, end: len(text), rules: ruleSet(disabled)})
+		}
+	}
+	return result
+}
+
+// ruleSet returns the rules of a key of suppressions, nil for all rules
+func ruleSet(key string) map[string]bool {
+	if key == "" {
+		return nil
+	}
+	rules := map[string]bool{}
+	for _, name := range strings.Fields(key) {
+		rules[name] = true
+	}
+	return rules
+}
+
+// checkingOff reports whether the front matter of a note turns grammar
+// checking off with grammar: off, or false
+func checkingOff(text string) bool {
+	front := markdown.FrontMatter(text)
+	if front == "" {
+		return false
+	}
+	var settings struct {
+		Grammar interface{} `yaml:"grammar"`
+	}
+	if err := yaml.Unmarshal([]byte(front), &settings); err != nil {
+		return false
+	}
+	switch value := settings.Grammar.(type) {
+	case bool:
+		return !value
+	case string:
+		switch strings.ToLower(value) {
+		case "off", "false", "no", "disabled":
+			return true
+		}
+	}
+	return false
+}
+
+// Fingerprint returns the fingerprint of an issue of a rule: a hash of the
+// rule, the text of the issue and the sentence it is in, ignoring case and
+// spacing. Unlike the ID of the issue, it does not change when text before
+// the issue does, so ignored issues stay ignored while the note is edited.
+func Fingerprint(rule, text, sentence string) string {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.Join(strings.Fields(s), " "))
+	}
+	sum := sha256.Sum256([]byte(rule + "\x00" + normalize(text) + "\x00" + normalize(sentence)))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,135 @@
+package grammar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rules returns the rules of the issues found in text
+func rules(t *testing.T, service *Service, text string, options Options) []string {
+	t.Helper()
+	result, err := service.CheckWithOptions(text, options)
+	require.NoError(t, err)
+	var rules []string
+	for _, issue := range result.Issues {
+		rules = append(rules, issue.Rule)
+	}
+	return rules
+}
+
+func TestGrammarService_Check_Directives(t *testing.T) {
+	service := &Service{}
+	for _, tt := range []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			"ignore next line",
+			"<!-- grammar-ignore-next-line -->\nThe the cat sat  on the mat.\nThe the dog sat.\n",
+			[]string{"repeated-words"},
+		},
+		{
+			"ignore next line of a rule",
+			"<!-- grammar-ignore-next-line repeated-words -->\nThe the cat sat  on the mat.\n",
+			[]string{"multiple-spaces"},
+		},
+		{
+			"ignore next line of a category",
+			"<!-- grammar-ignore-next-line spacing -->\nThe the cat sat  on the mat.\n",
+			[]string{"repeated-words"},
+		},
+		{
+			"disable and enable",
+			"The the cat.\n\n<!-- grammar-disable -->\nThe the dog.\n<!-- grammar-enable -->\n\nThe the bird.\n",
+			[]string{"repeated-words", "repeated-words"},
+		},
+		{
+			"disable a rule to the end",
+			"The the cat sat  on the mat.\n\n<!-- grammar-disable repeated-words -->\nThe the dog sat  on the mat.\n",
+			[]string{"repeated-words", "multiple-spaces", "multiple-spaces"},
+		},
+		{
+			"enable a rule closes its disable only",
+			"<!-- grammar-disable spacing -->\n<!-- grammar-disable repeated-words -->\n<!-- grammar-enable repeated-words -->\nThe the cat sat  on the mat.\n",
+			[]string{"repeated-words"},
+		},
+		{
+			"directives in code are text",
+			"```\n<!-- grammar-disable -->\n```\n\nThe the cat.\n",
+			[]string{"repeated-words"},
+		},
+		{
+			"directives in inline code are text",
+			"Text `<!-- grammar-disable -->` inline.\n\nThis is is on.\n",
+			[]string{"repeated-words"},
+		},
+		{
+			"directives in indented code are text",
+			"Some text.\n\n    <!-- grammar-disable -->\n\nThis is is on.\n",
+			[]string{"repeated-words"},
+		},
+		{
+			"escaped directives are text",
+			"Text \\<!-- grammar-disable --> here.\n\nThis is is on.\n",
+			[]string{"repeated-words"},
+		},
+		{
+			"inline directives",
+			"This is is off. <!-- grammar-disable -->Is is off.\n<!-- grammar-enable -->\n\nThis is is on.\n",
+			[]string{"repeated-words", "repeated-words"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rules(t, service, tt.text, Options{}))
+		})
+	}
+
+	result, err := service.Check("<!-- grammar-ignore-next-line -->\nThe the cat sat  on the mat.\n")
+	require.NoError(t, err)
+	assert.Empty(t, result.Issues)
+	assert.Equal(t, 2, result.Suppressed)
+	assert.Equal(t, 100.0, result.Score)
+}
+
+func TestGrammarService_Check_FrontMatterOff(t *testing.T) {
+	service := &Service{}
+	for _, value := range []string{"off", "false", "\"off\"", "no"} {
+		result, err := service.Check("---\ntitle: Cat\ngrammar: " + value + "\n---\nThe the cat.\n")
+		require.NoError(t, err)
+		assert.True(t, result.Disabled, value)
+		assert.Empty(t, result.Issues, value)
+	}
+
+	result, err := service.Check("---\ngrammar: on\n---\nThe the cat.\n")
+	require.NoError(t, err)
+	assert.False(t, result.Disabled)
+	assert.Len(t, result.Issues, 1)
+}
+
+func TestGrammarService_Check_Ignored(t *testing.T) {
+	service := &Service{}
+	result, err := service.Check("The the cat sat  on the mat.")
+	require.NoError(t, err)
+	require.Len(t, result.Issues, 2)
+	fingerprint := result.Issues[0].Fingerprint
+	require.NotEmpty(t, fingerprint)
+
+	// The fingerprint survives edits before and around the issue
+	options := Options{Ignored: []string{fingerprint}}
+	assert.Equal(t, []string{"multiple-spaces"}, rules(t, service, "The the cat sat  on the mat.", options))
+	assert.Equal(t, []string{"multiple-spaces"}, rules(t, service, "# Cats\n\nA note.  The the cat sat on the mat.", options))
+
+	// but not edits of the sentence it is in
+	assert.Equal(t, []string{"repeated-words"}, rules(t, service, "The the dog sat on the mat.", options))
+}
+
+func TestFingerprint(t *testing.T) {
+	fingerprint := Fingerprint("repeated-words", "The the", "The the cat sat.")
+	assert.Len(t, fingerprint, 16)
+	assert.Equal(t, fingerprint, Fingerprint("repeated-words", "the  THE", "The the\ncat sat."))
+	assert.NotEqual(t, fingerprint, Fingerprint("multiple-spaces", "The the", "The the cat sat."))
+	assert.NotEqual(t, fingerprint, Fingerprint("repeated-words", "The the", "The the dog sat."))
+}
@@ -0,0 +1,135 @@
+package grammar
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxBMP          = '\uffff'
+	zeroWidthJoiner = '\u200d'
+	// Regional indicators pair up into flags
+	regionalIndicatorFirst = '\U0001F1E6'
+	regionalIndicatorLast  = '\U0001F1FF'
+	// Skin tone modifiers follow the emoji they modify
+	emojiModifierFirst = '\U0001F3FB'
+	emojiModifierLast  = '\U0001F3FF'
+	// Tags follow flags of subdivisions such as England
+	tagFirst = '\U000E0020'
+	tagLast  = '\U000E007F'
+)
+
+// graphemeLength returns the length in bytes of the grapheme cluster s
+// starts with: a character with its combining marks, an emoji sequence or
+// a flag. It covers the clusters of UAX #29 found in prose, not every rule.
+func graphemeLength(s string) int {
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
+		return 0
+	}
+	if r == '\r' && len(s) > 1 && s[1] == '\n' {
+		return 2
+	}
+	if isRegionalIndicator(r) {
+		if next, n := utf8.DecodeRuneInString(s[size:]); isRegionalIndicator(next) {
+			size += n
+		}
+	}
+	for size < len(s) {
+		next, n := utf8.DecodeRuneInString(s[size:])
+		switch {
+		case next == zeroWidthJoiner:
+			// The joiner and the character it joins
+			size += n
+			if size < len(s) {
+				_, n = utf8.DecodeRuneInString(s[size:])
+				size += n
+			}
+		case unicode.In(next, unicode.Mn, unicode.Me, unicode.Mc),
+			next >= emojiModifierFirst && next <= emojiModifierLast,
+			next >= tagFirst && next <= tagLast:
+			size += n
+		default:
+			return size
+		}
+	}
+	return size
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= regionalIndicatorFirst && r <= regionalIndicatorLast
+}
+
+// graphemeBounds widens a byte range of text so that it starts and ends
+// at grapheme cluster boundaries
+func graphemeBounds(text string, start, end int) (int, int) {
+	if end <= start {
+		return start, end
+	}
+	for i := 0; i < len(text); {
+		n := graphemeLength(text[i:])
+		if start > i && start < i+n {
+			start = i
+		}
+		if end > i && end < i+n {
+			end = i + n
+		}
+		if i >= end {
+			break
+		}
+		i += n
+	}
+	return start, end
+}
+
+// isWordRune reports whether r can be part of a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r) || r == '_'
+}
+
+// wholeWord reports whether text[start:end] is not part of a longer word.
+// Unlike \b in regular expressions, it treats all letters as word
+// characters, not only ASCII ones.
+func wholeWord(text string, start, end int) bool {
+	if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(before) {
+		return false
+	}
+	if after, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(after) {
+		return false
+	}
+	return true
+}
+
+// unicodeOffset is a position in text counted in runes and in UTF-16 code
+// units
+type unicodeOffset struct {
+	runes int
+	utf16 int
+}
+
+// unicodeOffsets converts byte offsets in text to rune and UTF-16 offsets
+func unicodeOffsets(text string, offsets []int) map[int]unicodeOffset {
+	sorted := append([]int(nil), offsets...)
+	sort.Ints(sorted)
+
+	result := make(map[int]unicodeOffset, len(sorted))
+	var pos unicodeOffset
+	i := 0
+	for _, offset := range sorted {
+		for i < offset && i < len(text) {
+			r, size := utf8.DecodeRuneInString(text[i:])
+			pos.runes++
+			// Characters outside the Basic Multilingual Plane take a
+			// surrogate pair
+			if r > maxBMP {
+				pos.utf16 += 2
+			} else {
+				pos.utf16++
+			}
+			i += size
+		}
+		result[offset] = pos
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+package grammar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphemeLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"ascii", "ab", "a"},
+		{"precomposed", "éa", "é"},
+		{"combining marks", "ẹ́a", "ẹ́"},
+		{"flag", "🇩🇪🇫🇷", "🇩🇪"},
+		{"skin tone", "👍🏽!", "👍🏽"},
+		{"zero width joiner", "👩‍👩‍👧 family", "👩‍👩‍👧"},
+		{"variation selector", "❤️x", "❤️"},
+		{"crlf", "\r\nx", "\r\n"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.text[:graphemeLength(tt.text)])
+		})
+	}
+}
+
+func TestGraphemeBounds(t *testing.T) {
+	text := "a é b"
+	start, end := graphemeBounds(text, 2, 3)
+	assert.Equal(t, "é", text[start:end])
+
+	start, end = graphemeBounds(text, 3, 5)
+	assert.Equal(t, "é", text[start:end])
+
+	start, end = graphemeBounds(text, 0, 1)
+	assert.Equal(t, "a", text[start:end])
+}
+
+func TestUnicodeOffsets(t *testing.T) {
+	text := "aé😀b"
+	offsets := unicodeOffsets(text, []int{len(text), 0, 1, 3, 7})
+	assert.Equal(t, unicodeOffset{}, offsets[0])
+	assert.Equal(t, unicodeOffset{runes: 1, utf16: 1}, offsets[1])
+	assert.Equal(t, unicodeOffset{runes: 2, utf16: 2}, offsets[3])
+	assert.Equal(t, unicodeOffset{runes: 3, utf16: 4}, offsets[7])
+	assert.Equal(t, unicodeOffset{runes: 4, utf16: 5}, offsets[len(text)])
+}
+
+func TestWholeWord(t *testing.T) {
+	assert.True(t, wholeWord("a alot b", 2, 6))
+	assert.False(t, wholeWord("ßalot", len("ß"), len("ßalot")))
+	assert.False(t, wholeWord("alotë", 0, 4))
+	assert.True(t, wholeWord("alot", 0, 4))
+}
+
+func TestGrammarService_Check_Unicode(t *testing.T) {
+	service := NewService()
+
+	// Offsets count bytes, runes and UTF-16 code units
+	input := "Grüße 😀 from the the café."
+	result, err := service.Check(input)
+	require.NoError(t, err)
+	require.Len(t, result.Issues, 1)
+	issue := result.Issues[0]
+	assert.Equal(t, "the the", input[issue.Offset:issue.Offset+issue.Length])
+	assert.Equal(t, "the the", string([]rune(input)[issue.RuneOffset:issue.RuneOffset+issue.RuneLength]))
+	assert.Equal(t, 13, issue.RuneOffset)
+	assert.Equal(t, 14, issue.UTF16Offset)
+	assert.Equal(t, 7, issue.UTF16Length)
+
+	// Suggestions keep combining marks and use title case
+	input = "Done. étude and ǆungla follow. ǆungla. ü̈ber."
+	result, err = service.CheckWithOptions(input, Options{Rules: map[string]bool{CategoryCapitalization: true, CategoryGrammar: false, CategoryStyle: false, CategoryPunctuation: false, CategorySpacing: false}})
+	require.NoError(t, err)
+	var found, replacements []string
+	for _, issue := range result.Issues {
+		found = append(found, input[issue.Offset:issue.Offset+issue.Length])
+		replacements = append(replacements, issue.Replacement)
+	}
+	assert.Equal(t, []string{"é", "ǆ", "ü̈"}, found)
+	assert.Equal(t, []string{"É", "ǅ", "Ü̈"}, replacements)
+
+	// Words in other scripts are not split at non-ASCII letters
+	result, err = service.Check("Ça ira, ßalot.")
+	require.NoError(t, err)
+	assert.Empty(t, result.Issues)
+}
@@ -0,0 +1,233 @@
+// Package links finds links between notes, to heading anchors and to
+// attachments that no longer resolve.
+package links
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+)
+
+// Reasons a link is broken
+const (
+	ReasonMissingNote       = "missing-note"
+	ReasonMissingAnchor     = "missing-anchor"
+	ReasonMissingAttachment = "missing-attachment"
+)
+
+// notePath matches links to a note, or to its HTML view
+var notePath = regexp.MustCompile(`^/api/v1/notes/([^/]+)(?:/html)?/?$`)
+
+// attachmentStore is implemented by storages that keep attachments
+type attachmentStore interface {
+	HasAttachment(name string) (bool, error)
+}
+
+// Checker checks the links of every note and keeps the latest report
+type Checker struct {
+	storage  storage.Storage
+	markdown *markdown.Service
+
+	// checking serializes checks
+	checking sync.Mutex
+	mu       sync.RWMutex
+	report   *models.BrokenLinksReport
+}
+
+// NewChecker creates a new link checker
+func NewChecker(storage storage.Storage, markdown *markdown.Service) *Checker {
+	return &Checker{
+		storage:  storage,
+		markdown: markdown,
+	}
+}
+
+// Report returns the latest report, or nil when no check has run yet
+func (c *Checker) Report() *models.BrokenLinksReport {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.report
+}
+
+// Run checks links every interval until the context is cancelled, starting
+// with an immediate check
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := c.Check(); err != nil {
+			log.Printf("Link check failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// note is a parsed note with the anchors links to it may use. doc is nil
+// for notes that could not be parsed.
+type note struct {
+	meta    *models.NoteMetadata
+	doc     *markdown.Document
+	anchors map[string]bool
+}
+
+// parse parses the content of a note. A panic in the markdown parser is
+// returned as an error, so that one note cannot stop the check.
+func (c *Checker) parse(content string) (doc *markdown.Document, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to parse markdown: %v", r)
+		}
+	}()
+	return c.markdown.Parse(content), nil
+}
+
+// Check parses every note and reports links to missing notes, anchors and
+// attachments. The report is kept as the latest one.
+func (c *Checker) Check() (*models.BrokenLinksReport, error) {
+	c.checking.Lock()
+	defer c.checking.Unlock()
+
+	list, err := c.storage.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+
+	report := &models.BrokenLinksReport{
+		CheckedAt:        time.Now(),
+		BrokenLinks:      []models.BrokenLink{},
+		UnparseableNotes: []models.UnparseableNote{},
+	}
+	notes := map[string]*note{}
+	for _, meta := range list {
+		n, err := c.storage.Get(meta.ID)
+		if err != nil {
+			// The note was deleted while checking
+			if strings.Contains(err.Error(), "not found") {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get note %s: %w", meta.ID, err)
+		}
+		doc, err := c.parse(n.Content)
+		if err != nil {
+			// Links to the note are taken to resolve, as its anchors are
+			// unknown
+			notes[meta.ID] = &note{meta: meta}
+			report.UnparseableNotes = append(report.UnparseableNotes, models.UnparseableNote{
+				NoteID: meta.ID, NoteTitle: meta.Title, Error: err.Error(),
+			})
+			continue
+		}
+		notes[meta.ID] = &note{meta: meta, doc: doc, anchors: doc.Anchors()}
+		report.NotesChecked++
+	}
+
+	attachments, _ := c.storage.(attachmentStore)
+	for _, source := range notes {
+		if source.doc == nil {
+			continue
+		}
+		for _, link := range source.doc.Links() {
+			reason, checked, err := c.checkLink(link, source, notes, attachments)
+			if err != nil {
+				return nil, err
+			}
+			if !checked {
+				continue
+			}
+			report.LinksChecked++
+			if reason != "" {
+				report.BrokenLinks = append(report.BrokenLinks, models.BrokenLink{
+					NoteID:    source.meta.ID,
+					NoteTitle: source.meta.Title,
+					Line:      link.Line,
+					Target:    link.Destination,
+					Reason:    reason,
+				})
+			}
+		}
+	}
+	sort.Slice(report.BrokenLinks, func(i, j int) bool {
+		a, b := report.BrokenLinks[i], report.BrokenLinks[j]
+		if a.NoteTitle != b.NoteTitle {
+			return a.NoteTitle < b.NoteTitle
+		}
+		if a.NoteID != b.NoteID {
+			return a.NoteID < b.NoteID
+		}
+		return a.Line < b.Line
+	})
+	sort.Slice(report.UnparseableNotes, func(i, j int) bool {
+		a, b := report.UnparseableNotes[i], report.UnparseableNotes[j]
+		if a.NoteTitle != b.NoteTitle {
+			return a.NoteTitle < b.NoteTitle
+		}
+		return a.NoteID < b.NoteID
+	})
+
+	c.mu.Lock()
+	c.report = report
+	c.mu.Unlock()
+	return report, nil
+}
+
+// checkLink returns why a link is broken, or "" when it resolves. Links to
+// other sites and relative links that are neither notes nor attachments
+// are not checked.
+func (c *Checker) checkLink(link markdown.Link, source *note, notes map[string]*note, attachments attachmentStore) (reason string, checked bool, err error) {
+	u, err := url.Parse(link.Destination)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return "", false, nil
+	}
+
+	switch {
+	case u.Path == "" && u.Fragment != "":
+		if !source.anchors[u.Fragment] {
+			return ReasonMissingAnchor, true, nil
+		}
+		return "", true, nil
+
+	case notePath.MatchString(u.Path):
+		target, ok := notes[notePath.FindStringSubmatch(u.Path)[1]]
+		if !ok {
+			return ReasonMissingNote, true, nil
+		}
+		if u.Fragment != "" && target.doc != nil && !target.anchors[u.Fragment] {
+			return ReasonMissingAnchor, true, nil
+		}
+		return "", true, nil
+	}
+
+	// Images are always attachments, other links only inside the folder
+	name := strings.TrimPrefix(u.Path, "/")
+	if !link.Image && !strings.HasPrefix(name, "attachments/") {
+		return "", false, nil
+	}
+	if attachments == nil || name == "" {
+		return "", false, nil
+	}
+	exists, err := attachments.HasAttachment(strings.TrimPrefix(name, "attachments/"))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid attachment name") {
+			return ReasonMissingAttachment, true, nil
+		}
+		return "", false, err
+	}
+	if !exists {
+		return ReasonMissingAttachment, true, nil
+	}
+	return "", true, nil
+}
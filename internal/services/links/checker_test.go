@@ -0,0 +1,98 @@
+package links
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker_Check(t *testing.T) {
+	dir := t.TempDir()
+	storageService := storage.NewFileStorage(dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "attachments", "img"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "attachments", "img", "chart.png"), []byte("png"), 0644))
+
+	target := &models.Note{Title: "Target", Content: "# Setup\n\n## Usage\n\n## Usage\n"}
+	require.NoError(t, storageService.Save(target))
+
+	source := &models.Note{Title: "Source", Content: "# Links\n\n" +
+		"[ok](/api/v1/notes/" + target.ID + ") and [html](/api/v1/notes/" + target.ID + "/html#usage-1)\n" +
+		"[gone](/api/v1/notes/deleted-note)\n" +
+		"[renamed](/api/v1/notes/" + target.ID + "#install)\n" +
+		"[local](#links) [missing](#nowhere)\n\n" +
+		"![chart](attachments/img/chart.png) ![lost](lost.png) [file](/attachments/report.pdf)\n\n" +
+		"[external](https://example.com/api/v1/notes/x) [relative](other.md) `[code](#nope)`\n\n" +
+		"```\n[in code](#nope)\n```\n"}
+	require.NoError(t, storageService.Save(source))
+
+	checker := NewChecker(storageService, markdown.NewService())
+	assert.Nil(t, checker.Report())
+
+	report, err := checker.Check()
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.NotesChecked)
+	assert.Equal(t, 9, report.LinksChecked)
+	assert.Equal(t, []models.BrokenLink{
+		{NoteID: source.ID, NoteTitle: "Source", Line: 4, Target: "/api/v1/notes/deleted-note", Reason: ReasonMissingNote},
+		{NoteID: source.ID, NoteTitle: "Source", Line: 5, Target: "/api/v1/notes/" + target.ID + "#install", Reason: ReasonMissingAnchor},
+		{NoteID: source.ID, NoteTitle: "Source", Line: 6, Target: "#nowhere", Reason: ReasonMissingAnchor},
+		{NoteID: source.ID, NoteTitle: "Source", Line: 8, Target: "lost.png", Reason: ReasonMissingAttachment},
+		{NoteID: source.ID, NoteTitle: "Source", Line: 8, Target: "/attachments/report.pdf", Reason: ReasonMissingAttachment},
+	}, report.BrokenLinks)
+	assert.Same(t, report, checker.Report())
+
+	// Deleting the target breaks every link to it
+	require.NoError(t, storageService.Delete(target.ID))
+	report, err = checker.Check()
+	require.NoError(t, err)
+	assert.Len(t, report.BrokenLinks, 7)
+	assert.Equal(t, ReasonMissingNote, report.BrokenLinks[0].Reason)
+}
+
+func TestChecker_Run(t *testing.T) {
+	storageService := storage.NewFileStorage(t.TempDir())
+	require.NoError(t, storageService.Save(&models.Note{Title: "Note", Content: "[x](#missing)"}))
+	checker := NewChecker(storageService, markdown.NewService())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		checker.Run(ctx, time.Hour)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool { return checker.Report() != nil }, time.Second, 10*time.Millisecond)
+	assert.Len(t, checker.Report().BrokenLinks, 1)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not stop when its context was cancelled")
+	}
+}
+
+func TestChecker_Check_UnparseableNote(t *testing.T) {
+	storageService := storage.NewFileStorage(t.TempDir())
+	bad := &models.Note{Title: "Bad", Content: "Term\n\n:\nab"}
+	require.NoError(t, storageService.Save(bad))
+	good := &models.Note{Title: "Good", Content: "[bad](/api/v1/notes/" + bad.ID + "#term) [x](#missing)"}
+	require.NoError(t, storageService.Save(good))
+
+	report, err := NewChecker(storageService, markdown.NewService()).Check()
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.NotesChecked)
+	require.Len(t, report.UnparseableNotes, 1)
+	assert.Equal(t, bad.ID, report.UnparseableNotes[0].NoteID)
+	assert.Contains(t, report.UnparseableNotes[0].Error, "failed to parse markdown")
+	require.Len(t, report.BrokenLinks, 1, "links to the note are not reported")
+	assert.Equal(t, "#missing", report.BrokenLinks[0].Target)
+}
@@ -0,0 +1,143 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"sort"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+// Mark is a byte range of markdown source to highlight in HTML
+type Mark struct {
+	Start, End int
+	// ID is the id attribute of the first element of the mark
+	ID string
+	// Attributes are added to every element of the mark, such as class
+	// or data attributes
+	Attributes map[string]string
+}
+
+// ToAnnotatedHTML converts markdown content to HTML like ToHTML, wrapping
+// the text of marks in <mark> elements. Only prose copied as is from the
+// source is marked: code, math, URLs and image descriptions are not. A
+// mark spanning inline markup, such as emphasis or a link, gets an element
+// in each text it covers, and overlapping marks are nested, so the markup
+// stays well-formed.
+func (s *Service) ToAnnotatedHTML(markdown string, marks []Mark) string {
+	doc := s.Parse(markdown)
+	w := &proseWriter{doc: doc, source: markdown, spans: map[*blackfriday.Node][]textSpan{}}
+	w.walk()
+
+	sorted := make([]Mark, 0, len(marks))
+	for _, mark := range marks {
+		if mark.End > mark.Start {
+			sorted = append(sorted, mark)
+		}
+	}
+	// Outer marks first, so that they are opened before the marks in them
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Start != sorted[j].Start {
+			return sorted[i].Start < sorted[j].Start
+		}
+		return sorted[i].End > sorted[j].End
+	})
+
+	renderer := s.newHTMLRenderer()
+	renderer.callouts = doc.callouts
+	annotator := &annotator{htmlRenderer: renderer, spans: w.spans, marks: sorted, opened: map[int]bool{}}
+
+	var buf bytes.Buffer
+	renderer.RenderHeader(&buf, doc.Root)
+	doc.Root.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		return annotator.RenderNode(&buf, node, entering)
+	})
+	renderer.RenderFooter(&buf, doc.Root)
+
+	return restoreMath(buf.String(), doc.math)
+}
+
+// annotator renders text nodes with the marks in them
+type annotator struct {
+	*htmlRenderer
+	spans map[*blackfriday.Node][]textSpan
+	marks []Mark
+	// opened records the marks that have an element, which has their ID
+	opened map[int]bool
+}
+
+func (a *annotator) RenderNode(w io.Writer, node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+	if node.Type != blackfriday.Text || len(a.spans[node]) == 0 {
+		return a.htmlRenderer.RenderNode(w, node, entering)
+	}
+
+	// Cut the literal where marks start and end
+	literal := node.Literal
+	cuts := []int{0, len(literal)}
+	for _, span := range a.spans[node] {
+		for _, mark := range a.marks {
+			start, end, ok := span.literal(mark)
+			if ok {
+				cuts = append(cuts, start, end)
+			}
+		}
+	}
+	sort.Ints(cuts)
+
+	for i := 0; i+1 < len(cuts); i++ {
+		start, end := cuts[i], cuts[i+1]
+		if start == end {
+			continue
+		}
+		var covering []int
+		for _, span := range a.spans[node] {
+			if start < span.start || end > span.end {
+				continue
+			}
+			for j, mark := range a.marks {
+				if markStart, markEnd, ok := span.literal(mark); ok && markStart <= start && end <= markEnd {
+					covering = append(covering, j)
+				}
+			}
+		}
+		for _, j := range covering {
+			a.open(w, j)
+		}
+		a.htmlRenderer.RenderNode(w, &blackfriday.Node{Type: blackfriday.Text, Parent: node.Parent, Literal: literal[start:end]}, true)
+		for range covering {
+			io.WriteString(w, "</mark>")
+		}
+	}
+	return blackfriday.GoToNext
+}
+
+// open writes the start tag of an element of a mark
+func (a *annotator) open(w io.Writer, i int) {
+	mark := a.marks[i]
+	io.WriteString(w, "<mark")
+	if mark.ID != "" && !a.opened[i] {
+		io.WriteString(w, ` id="`+html.EscapeString(mark.ID)+`"`)
+	}
+	a.opened[i] = true
+	names := make([]string, 0, len(mark.Attributes))
+	for name := range mark.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		io.WriteString(w, " "+name+`="`+html.EscapeString(mark.Attributes[name])+`"`)
+	}
+	io.WriteString(w, ">")
+}
+
+// literal maps the part of a mark in the span to the literal of its text
+// node
+func (s textSpan) literal(mark Mark) (start, end int, ok bool) {
+	src, srcEnd := s.src, s.src+s.end-s.start
+	start, end = max(mark.Start, src), min(mark.End, srcEnd)
+	if start >= end {
+		return 0, 0, false
+	}
+	return s.start + start - src, s.start + end - src, true
+}
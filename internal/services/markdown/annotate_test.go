@@ -0,0 +1,68 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownService_ToAnnotatedHTML(t *testing.T) {
+	service := NewService()
+	const src = "# A title\n\nSome *the the* text with `the code` and [a link the](https://example.com).\n\n![alt the](img.png) $x$ the end.\n"
+	mark := func(text string, n int, attributes map[string]string) Mark {
+		start := strings.Index(src, text)
+		return Mark{Start: start, End: start + n, Attributes: attributes}
+	}
+
+	// Without marks the HTML is that of ToHTML
+	assert.Equal(t, service.ToHTML(src), service.ToAnnotatedHTML(src, nil))
+
+	for _, tt := range []struct {
+		name  string
+		marks []Mark
+		want  string
+	}{
+		{
+			"prose",
+			[]Mark{mark("the end", 7, nil)},
+			"</math> <mark>the end</mark>.</p>",
+		},
+		{
+			"attributes are escaped",
+			[]Mark{mark("title", 5, map[string]string{"data-message": `Use "a" & <b>`, "class": "x"})},
+			`<h1 id="a-title">A <mark class="x" data-message="Use &#34;a&#34; &amp; &lt;b&gt;">title</mark></h1>`,
+		},
+		{
+			"marks are split at inline markup",
+			[]Mark{mark("with `the", 19, nil)},
+			"text <mark>with </mark><code>the code</code><mark> and</mark> <a",
+		},
+		{
+			"marks in links",
+			[]Mark{mark("link the", 8, nil)},
+			`<a href="https://example.com">a <mark>link the</mark></a>`,
+		},
+		{
+			"overlapping marks are nested",
+			[]Mark{mark("the the", 7, map[string]string{"class": "outer"}), mark("the*", 3, nil)},
+			`<em><mark class="outer">the </mark><mark class="outer"><mark>the</mark></mark></em>`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := service.ToAnnotatedHTML(src, tt.marks)
+			assert.Contains(t, got, tt.want)
+			assert.Equal(t, strings.Count(got, "<mark"), strings.Count(got, "</mark>"))
+		})
+	}
+
+	// Code, image descriptions and math are not marked
+	got := service.ToAnnotatedHTML(src, []Mark{mark("the code", 8, nil), mark("alt the", 7, nil), mark("$x$", 3, nil)})
+	assert.Equal(t, service.ToHTML(src), got)
+
+	// The ID is only on the first element of a mark
+	first := mark("with `the", 19, nil)
+	first.ID = "issue"
+	got = service.ToAnnotatedHTML(src, []Mark{first})
+	assert.Contains(t, got, `<mark id="issue">with </mark><code>the code</code><mark> and</mark>`)
+}
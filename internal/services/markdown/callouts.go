@@ -0,0 +1,182 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+// Callout describes a GitHub/Obsidian-style admonition such as
+//
+//	> [!WARNING] Optional title
+//	> Body text
+//
+// A trailing "-" or "+" after the marker makes the callout foldable,
+// collapsed or expanded by default respectively.
+type Callout struct {
+	// Type is the canonical callout type used for styling, e.g. "warning"
+	Type string
+	// Title is the explicit title as plain text, or the type name when
+	// none was given
+	Title string
+	// Foldable callouts render as <details>; Open sets their initial state
+	Foldable bool
+	Open     bool
+
+	// title holds the inline nodes of an explicit title
+	title *blackfriday.Node
+}
+
+var calloutMarker = regexp.MustCompile(`^\[!([A-Za-z][\w-]*)\]([+-]?)[ \t]*([^\n]*)\n?`)
+
+// calloutAliases maps the Obsidian aliases onto the type used for styling.
+// GitHub's NOTE, TIP, IMPORTANT, WARNING and CAUTION are all included.
+var calloutAliases = map[string]string{
+	"note": "note", "info": "info", "todo": "todo",
+	"abstract": "abstract", "summary": "abstract", "tldr": "abstract",
+	"tip": "tip", "hint": "tip", "important": "important",
+	"success": "success", "check": "success", "done": "success",
+	"question": "question", "help": "question", "faq": "question",
+	"warning": "warning", "attention": "warning",
+	"caution": "caution", "danger": "caution", "error": "caution",
+	"failure": "failure", "fail": "failure", "missing": "failure",
+	"bug": "bug", "example": "example", "quote": "quote", "cite": "quote",
+}
+
+// findCallouts turns blockquotes that start with a callout marker into
+// callouts. The marker line is removed from the tree and the callout details
+// are returned keyed by blockquote node. The plain text of explicit titles
+// is left to the caller, which knows the math spans in them.
+func findCallouts(doc *blackfriday.Node) map[*blackfriday.Node]Callout {
+	callouts := map[*blackfriday.Node]Callout{}
+	doc.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering || node.Type != blackfriday.BlockQuote {
+			return blackfriday.GoToNext
+		}
+		para := node.FirstChild
+		if para == nil || para.Type != blackfriday.Paragraph || para.FirstChild == nil || para.FirstChild.Type != blackfriday.Text {
+			return blackfriday.GoToNext
+		}
+		text := para.FirstChild
+		m := calloutMarker.FindSubmatch(text.Literal)
+		if m == nil {
+			return blackfriday.GoToNext
+		}
+
+		name := strings.ToLower(string(m[1]))
+		callout := Callout{
+			Type:     "note",
+			Foldable: len(m[2]) > 0,
+			Open:     string(m[2]) == "+",
+		}
+		if canonical, ok := calloutAliases[name]; ok {
+			callout.Type = canonical
+		}
+
+		// The title is the rest of the marker line, with its inline markup
+		title := blackfriday.NewNode(blackfriday.Paragraph)
+		if len(m[3]) > 0 {
+			first := blackfriday.NewNode(blackfriday.Text)
+			first.Literal = append([]byte(nil), m[3]...)
+			title.AppendChild(first)
+		}
+		lineEnded := bytes.HasSuffix(m[0], []byte("\n"))
+		text.Literal = text.Literal[len(m[0]):]
+		if len(text.Literal) == 0 {
+			next := text.Next
+			text.Unlink()
+			for !lineEnded && next != nil {
+				if next.Type == blackfriday.Softbreak || next.Type == blackfriday.Hardbreak {
+					next.Unlink()
+					break
+				}
+				// Text runs on to the next line, which belongs to the body
+				if i := bytes.IndexByte(next.Literal, '\n'); next.Type == blackfriday.Text && i >= 0 {
+					if i > 0 {
+						part := blackfriday.NewNode(blackfriday.Text)
+						part.Literal = next.Literal[:i]
+						title.AppendChild(part)
+					}
+					next.Literal = next.Literal[i+1:]
+					if len(next.Literal) == 0 {
+						next.Unlink()
+					}
+					break
+				}
+				node := next
+				next = next.Next
+				title.AppendChild(node)
+			}
+		}
+		for last := title.LastChild; last != nil && last.Type == blackfriday.Text; last = title.LastChild {
+			last.Literal = bytes.TrimRight(last.Literal, " \t")
+			if len(last.Literal) > 0 {
+				break
+			}
+			last.Unlink()
+		}
+		if title.FirstChild != nil {
+			callout.title = title
+		} else {
+			callout.Title = strings.ToUpper(name[:1]) + name[1:]
+		}
+		callouts[node] = callout
+
+		// Drop the paragraph if nothing but the marker line was in it
+		if para.FirstChild == nil {
+			para.Unlink()
+		}
+		return blackfriday.GoToNext
+	})
+	return callouts
+}
+
+// renderCallout writes the opening or closing markup for a callout. An
+// explicit title is rendered with its inline markup. In XHTML the open
+// attribute needs a value.
+func (r *htmlRenderer) renderCallout(w io.Writer, callout Callout, entering bool) {
+	tag := "aside"
+	if callout.Foldable {
+		tag = "details"
+	}
+	if !entering {
+		io.WriteString(w, "</div>\n</"+tag+">\n")
+		return
+	}
+
+	attrs := ` class="callout callout-` + callout.Type + `" data-callout="` + callout.Type + `"`
+	if callout.Foldable && callout.Open {
+		if r.xhtml {
+			attrs += ` open="open"`
+		} else {
+			attrs += " open"
+		}
+	}
+	titleTag := "p"
+	if callout.Foldable {
+		titleTag = "summary"
+	}
+	io.WriteString(w, "<"+tag+attrs+">\n<"+titleTag+` class="callout-title">`)
+	if callout.title != nil {
+		// A renderer of its own keeps the title from changing how the
+		// blocks of the body are separated
+		title := &htmlRenderer{
+			HTMLRenderer: blackfriday.NewHTMLRenderer(r.HTMLRendererParameters),
+			service:      r.service,
+			xhtml:        r.xhtml,
+		}
+		callout.title.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+			if node == callout.title {
+				return blackfriday.GoToNext
+			}
+			return title.RenderNode(w, node, entering)
+		})
+	} else {
+		io.WriteString(w, html.EscapeString(callout.Title))
+	}
+	io.WriteString(w, "</"+titleTag+">\n<div class=\"callout-content\">\n")
+}
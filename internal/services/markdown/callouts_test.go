@@ -0,0 +1,90 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownService_ToHTML_Callouts(t *testing.T) {
+	service := NewService()
+
+	tests := []struct {
+		name     string
+		markdown string
+		expected string
+	}{
+		{
+			name:     "github note",
+			markdown: "> [!NOTE]\n> Useful information.",
+			expected: "<aside class=\"callout callout-note\" data-callout=\"note\">\n<p class=\"callout-title\">Note</p>\n<div class=\"callout-content\">\n<p>Useful information.</p>\n</div>\n</aside>\n",
+		},
+		{
+			name:     "warning with custom title",
+			markdown: "> [!WARNING] Back up first\n> This deletes **everything**.",
+			expected: "<aside class=\"callout callout-warning\" data-callout=\"warning\">\n<p class=\"callout-title\">Back up first</p>\n<div class=\"callout-content\">\n<p>This deletes <strong>everything</strong>.</p>\n</div>\n</aside>\n",
+		},
+		{
+			name:     "collapsed foldable",
+			markdown: "> [!TIP]-\n> Hidden until opened.",
+			expected: "<details class=\"callout callout-tip\" data-callout=\"tip\">\n<summary class=\"callout-title\">Tip</summary>\n<div class=\"callout-content\">\n<p>Hidden until opened.</p>\n</div>\n</details>\n",
+		},
+		{
+			name:     "expanded foldable with alias",
+			markdown: "> [!faq]+ Why?\n> Because.",
+			expected: "<details class=\"callout callout-question\" data-callout=\"question\" open>\n<summary class=\"callout-title\">Why?</summary>\n<div class=\"callout-content\">\n<p>Because.</p>\n</div>\n</details>\n",
+		},
+		{
+			name:     "title only",
+			markdown: "> [!IMPORTANT] Read this",
+			expected: "<aside class=\"callout callout-important\" data-callout=\"important\">\n<p class=\"callout-title\">Read this</p>\n<div class=\"callout-content\">\n</div>\n</aside>\n",
+		},
+		{
+			name:     "title with inline markup",
+			markdown: "> [!WARNING] Be **careful** now\n> body",
+			expected: "<aside class=\"callout callout-warning\" data-callout=\"warning\">\n<p class=\"callout-title\">Be <strong>careful</strong> now</p>\n<div class=\"callout-content\">\n<p>body</p>\n</div>\n</aside>\n",
+		},
+		{
+			name:     "foldable title with code",
+			markdown: "> [!TIP]- Run `make` *first*\n> Hidden.",
+			expected: "<details class=\"callout callout-tip\" data-callout=\"tip\">\n<summary class=\"callout-title\">Run <code>make</code> <em>first</em></summary>\n<div class=\"callout-content\">\n<p>Hidden.</p>\n</div>\n</details>\n",
+		},
+		{
+			name:     "plain blockquote is unchanged",
+			markdown: "> Just a quote",
+			expected: "<blockquote>\n<p>Just a quote</p>\n</blockquote>\n",
+		},
+		{
+			name:     "marker must start the quote",
+			markdown: "> Quote mentioning [!NOTE] later",
+			expected: "<blockquote>\n<p>Quote mentioning [!NOTE] later</p>\n</blockquote>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, service.ToHTML(tt.markdown))
+		})
+	}
+}
+
+func TestMarkdownService_ToHTML_CalloutUnknownType(t *testing.T) {
+	html := NewService().ToHTML("> [!Custom]\n> Body")
+
+	// Unknown types are styled as notes but keep their own title
+	assert.Contains(t, html, `class="callout callout-note"`)
+	assert.Contains(t, html, `<p class="callout-title">Custom</p>`)
+}
+
+func TestMarkdownService_CalloutTitleMarkup(t *testing.T) {
+	service := NewService()
+	markdown := "> [!WARNING] Be **careful** now\n> body\n"
+
+	doc := service.Parse(markdown)
+	callout, ok := doc.Callout(doc.Root.FirstChild)
+	assert.True(t, ok)
+	assert.Equal(t, "Be careful now", callout.Title)
+	assert.Equal(t, "Be careful now\n\nbody", service.ToPlainText(markdown))
+
+	assert.Equal(t, markdown, service.Format(markdown, FormatOptions{}))
+}
@@ -0,0 +1,73 @@
+package markdown
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"html"
+	"sync"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/diagram"
+)
+
+// maxCachedDiagrams bounds the diagram cache; rendering is cheap enough that
+// evicting the oldest entries is fine
+const maxCachedDiagrams = 256
+
+// diagramCache memoizes rendered diagrams by a hash of their language and
+// source, so re-rendering an unchanged note skips the layout work
+type diagramCache struct {
+	mu      sync.Mutex
+	entries map[string]string
+	order   []string
+}
+
+func (c *diagramCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out, ok := c.entries[key]
+	return out, ok
+}
+
+func (c *diagramCache) put(key, out string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]string)
+	}
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	if len(c.order) >= maxCachedDiagrams {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.entries[key] = out
+	c.order = append(c.order, key)
+}
+
+func isDiagramLanguage(lang string) bool {
+	return lang != "" && diagram.IsDiagram(lang)
+}
+
+// renderDiagram renders a diagram code block to inline SVG. Invalid or
+// unsupported diagrams render as an error box showing the problem and the
+// original source, so one bad block does not break the rest of the note.
+func (s *Service) renderDiagram(lang, src string) string {
+	sum := sha256.Sum256([]byte(lang + "\x00" + src))
+	key := hex.EncodeToString(sum[:])
+	if out, ok := s.diagrams.get(key); ok {
+		return out
+	}
+
+	var out string
+	svg, err := diagram.Render(lang, src)
+	if err != nil {
+		out = `<div class="diagram-error" role="alert"><p><strong>Could not render diagram:</strong> ` +
+			html.EscapeString(err.Error()) + "</p><pre><code>" + html.EscapeString(src) + "</code></pre></div>\n"
+	} else {
+		out = `<figure class="diagram">` + svg + "</figure>\n"
+	}
+
+	s.diagrams.put(key, out)
+	return out
+}
@@ -0,0 +1,127 @@
+package markdown
+
+import (
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+// Document is a parsed markdown note. It exposes the same AST that ToHTML
+// renders, so other output formats stay consistent with the HTML view.
+type Document struct {
+	// Root is the blackfriday syntax tree of the note
+	Root *blackfriday.Node
+
+	callouts map[*blackfriday.Node]Callout
+	math     []mathSpan
+	// source is the markdown the document was parsed from
+	source string
+}
+
+// Parse parses markdown into a Document. Math spans are protected from
+// markdown processing and callout markers are removed from the tree.
+func (s *Service) Parse(markdown string) *Document {
+	src, math := extractMath(markdown)
+	root := parse(src)
+	doc := &Document{
+		Root:     root,
+		callouts: findCallouts(root),
+		math:     math,
+		source:   markdown,
+	}
+	for node, callout := range doc.callouts {
+		if callout.title != nil {
+			callout.Title = doc.NodeText(callout.title)
+			doc.callouts[node] = callout
+		}
+	}
+	return doc
+}
+
+// parse builds the markdown AST using blackfriday with common extensions
+func parse(markdown string) *blackfriday.Node {
+	extensions := blackfriday.CommonExtensions | blackfriday.AutoHeadingIDs
+	return blackfriday.New(blackfriday.WithExtensions(extensions)).Parse([]byte(markdown))
+}
+
+// Callout returns the callout details if the blockquote node is a callout
+func (d *Document) Callout(node *blackfriday.Node) (Callout, bool) {
+	callout, ok := d.callouts[node]
+	return callout, ok
+}
+
+// Text returns a node literal as plain text, with any math placeholders
+// replaced by the original $TeX$ source and escaped dollar signs by
+// dollar signs
+func (d *Document) Text(literal []byte) string {
+	text := string(literal)
+	if len(d.math) == 0 || !strings.ContainsRune(text, mathTokenStart) {
+		return text
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(text); {
+		if n, width, ok := readMathToken(text[i:]); ok && n < len(d.math) {
+			if d.math[n].literal {
+				out.WriteString(d.math[n].text())
+			} else {
+				out.WriteString(d.math[n].source())
+			}
+			i += width
+			continue
+		}
+		out.WriteByte(text[i])
+		i++
+	}
+	return out.String()
+}
+
+// NodeText returns the text content of a node and its descendants with
+// runs of whitespace collapsed, e.g. the plain title of a heading
+func (d *Document) NodeText(node *blackfriday.Node) string {
+	var b strings.Builder
+	node.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering {
+			switch n.Type {
+			case blackfriday.Text, blackfriday.Code:
+				b.WriteString(d.Text(n.Literal))
+			case blackfriday.Softbreak, blackfriday.Hardbreak:
+				b.WriteByte(' ')
+			}
+		}
+		return blackfriday.GoToNext
+	})
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// Chapter is a run of top-level blocks starting at a heading
+type Chapter struct {
+	// Title is the heading text; it is empty for content before the first
+	// heading
+	Title string
+	// ID is the heading's anchor
+	ID    string
+	Nodes []*blackfriday.Node
+}
+
+// Chapters splits the document at its highest-ranking headings, so a note
+// using "##" sections throughout is split at those
+func (d *Document) Chapters() []Chapter {
+	level := 0
+	for node := d.Root.FirstChild; node != nil; node = node.Next {
+		if node.Type == blackfriday.Heading && (level == 0 || node.HeadingData.Level < level) {
+			level = node.HeadingData.Level
+		}
+	}
+
+	var chapters []Chapter
+	for node := d.Root.FirstChild; node != nil; node = node.Next {
+		if node.Type == blackfriday.Heading && node.HeadingData.Level == level {
+			chapters = append(chapters, Chapter{Title: d.NodeText(node), ID: node.HeadingData.HeadingID})
+		} else if len(chapters) == 0 {
+			chapters = append(chapters, Chapter{})
+		}
+		chapters[len(chapters)-1].Nodes = append(chapters[len(chapters)-1].Nodes, node)
+	}
+	return chapters
+}
@@ -0,0 +1,82 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_Chapters(t *testing.T) {
+	service := NewService()
+
+	tests := []struct {
+		name     string
+		markdown string
+		titles   []string
+	}{
+		{
+			name:     "top level headings",
+			markdown: "# One\n\ntext\n\n## Sub\n\n# Two\n\nmore",
+			titles:   []string{"One", "Two"},
+		},
+		{
+			name:     "content before first heading",
+			markdown: "intro\n\n## A\n\n### deep\n\n## B",
+			titles:   []string{"", "A", "B"},
+		},
+		{
+			name:     "no headings",
+			markdown: "just text",
+			titles:   []string{""},
+		},
+		{
+			name:     "empty",
+			markdown: "",
+			titles:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var titles []string
+			for _, chapter := range service.Parse(tt.markdown).Chapters() {
+				titles = append(titles, chapter.Title)
+			}
+			assert.Equal(t, tt.titles, titles)
+		})
+	}
+}
+
+func TestMarkdownService_ToXHTML(t *testing.T) {
+	service := NewService()
+	doc := service.Parse("# Title &copy; <b>bold</b>\n\nA&nbsp;B &amp; C &bogus; $x^2$\n\n<div>raw</div>")
+	chapters := doc.Chapters()
+	require.Len(t, chapters, 1)
+
+	xhtml := service.ToXHTML(doc, chapters[0].Nodes)
+	assert.Contains(t, xhtml, "Title © bold</h1>")
+	assert.Contains(t, xhtml, "A\u00a0B &amp; C &amp;bogus;")
+	assert.Contains(t, xhtml, "<math")
+	assert.NotContains(t, xhtml, "<b>")
+	assert.NotContains(t, xhtml, "<div>")
+}
+
+func TestDocument_Anchors(t *testing.T) {
+	doc := NewService().Parse("# Setup\n\n## Setup\n\n## Setup\n\n### Custom {#my-id}\n\n<div id=\"box\"></div>\n\nText <a name='here'></a>\n\n```\n<p id=\"code\"></p>\n```")
+	assert.Equal(t, map[string]bool{
+		"setup": true, "setup-1": true, "setup-2": true, "my-id": true, "box": true, "here": true,
+	}, doc.Anchors())
+}
+
+func TestDocument_Links(t *testing.T) {
+	doc := NewService().Parse("# Title\n\nSee [a](one.md) and\n[b](two.md), ![img](pic.png)\n\n- [a again](one.md)\n\n[ref][r] <https://example.com>\n\n[r]: three.md")
+	assert.Equal(t, []Link{
+		{Destination: "one.md", Line: 3},
+		{Destination: "two.md", Line: 4},
+		{Destination: "pic.png", Image: true, Line: 4},
+		{Destination: "one.md", Line: 6},
+		{Destination: "three.md", Line: 10},
+		{Destination: "https://example.com", Line: 8},
+	}, doc.Links())
+}
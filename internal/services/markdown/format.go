@@ -0,0 +1,727 @@
+package markdown
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+// DefaultFormatWidth is the column Format wraps paragraphs at unless
+// configured otherwise
+const DefaultFormatWidth = 80
+
+// minFormatWidth keeps deeply nested paragraphs from being wrapped one
+// word per line
+const minFormatWidth = 20
+
+// FormatOptions controls how Format lays out a note
+type FormatOptions struct {
+	// Width is the column paragraphs are wrapped at. Zero unwraps every
+	// paragraph onto a single line.
+	Width int `json:"width"`
+}
+
+// Validate checks the options are usable
+func (o FormatOptions) Validate() error {
+	if o.Width < 0 {
+		return errors.New("format width must not be negative")
+	}
+	if o.Width > 0 && o.Width < minFormatWidth {
+		return fmt.Errorf("format width must be 0 or at least %d", minFormatWidth)
+	}
+	return nil
+}
+
+// SetFormatOptions sets the options used when formatting without explicit
+// options
+func (s *Service) SetFormatOptions(options FormatOptions) error {
+	if err := options.Validate(); err != nil {
+		return err
+	}
+	s.format = options
+	return nil
+}
+
+// FormatOptions returns the configured format options
+func (s *Service) FormatOptions() FormatOptions {
+	return s.format
+}
+
+// Format rewrites markdown in a canonical style: "-" bullets, ATX headings,
+// "*" emphasis, "**" strong emphasis, padded tables and paragraphs wrapped
+// at the configured width. The result is rendered from the parsed tree, so
+// it converts to the same HTML as the input, and formatting it again
+// returns it unchanged. Code blocks and front matter are kept as written.
+func (s *Service) Format(markdown string, options FormatOptions) string {
+	front, body := splitFrontMatter(strings.ReplaceAll(markdown, "\r\n", "\n"))
+
+	doc := s.Parse(body)
+	f := &formatter{doc: doc}
+	out := strings.Join(f.blocks(doc.Root, options.Width, false), "\n")
+	if out != "" {
+		out += "\n"
+	}
+
+	switch {
+	case front == "":
+		return out
+	case out == "":
+		return front
+	default:
+		return front + "\n" + out
+	}
+}
+
+// FrontMatter returns the YAML front matter between --- lines at the top
+// of a note, without those lines, or "" if the note has none
+func FrontMatter(markdown string) string {
+	front, _ := splitFrontMatter(strings.ReplaceAll(markdown, "\r\n", "\n"))
+	if front == "" {
+		return ""
+	}
+	lines := strings.SplitAfter(front, "\n")
+	// The last element is empty, as the front matter ends with a newline
+	return strings.Join(lines[1:len(lines)-2], "")
+}
+
+// splitFrontMatter splits YAML front matter between --- lines at the top of
+// a note from the rest of it. The front matter keeps its final newline.
+func splitFrontMatter(markdown string) (front, body string) {
+	if !strings.HasPrefix(markdown, "---") {
+		return "", markdown
+	}
+	lines := strings.SplitAfter(markdown, "\n")
+	if strings.TrimRight(lines[0], " \t\n") != "---" {
+		return "", markdown
+	}
+	offset := len(lines[0])
+	for _, line := range lines[1:] {
+		offset += len(line)
+		if end := strings.TrimRight(line, " \t\n"); end == "---" || end == "..." {
+			front = markdown[:offset]
+			if !strings.HasSuffix(front, "\n") {
+				front += "\n"
+			}
+			return front, markdown[offset:]
+		}
+	}
+	return "", markdown
+}
+
+// formatter writes the markdown source for a parsed document
+type formatter struct {
+	doc *Document
+}
+
+// blocks formats the block children of parent as lines. Blocks are
+// separated by a blank line unless they belong to a tight list item.
+func (f *formatter) blocks(parent *blackfriday.Node, width int, tight bool) []string {
+	var lines []string
+	for node := parent.FirstChild; node != nil; node = node.Next {
+		block := f.block(node, width)
+		if len(block) == 0 {
+			continue
+		}
+		if len(lines) > 0 && !tight {
+			lines = append(lines, "")
+		}
+		lines = append(lines, block...)
+	}
+	return lines
+}
+
+func (f *formatter) block(node *blackfriday.Node, width int) []string {
+	switch node.Type {
+	case blackfriday.Paragraph:
+		return wrapWords(f.inlines(node, false), width)
+	case blackfriday.Heading:
+		return []string{f.heading(node)}
+	case blackfriday.HorizontalRule:
+		return []string{"---"}
+	case blackfriday.CodeBlock:
+		return f.codeBlock(node)
+	case blackfriday.HTMLBlock:
+		return strings.Split(strings.TrimRight(f.doc.Text(node.Literal), "\n"), "\n")
+	case blackfriday.BlockQuote:
+		return f.blockQuote(node, width)
+	case blackfriday.List:
+		if node.ListFlags&blackfriday.ListTypeDefinition != 0 {
+			return f.definitionList(node, width)
+		}
+		return f.list(node, width)
+	case blackfriday.Table:
+		return f.table(node)
+	}
+	return nil
+}
+
+// heading writes an ATX heading. An explicit {#id} is only added when the
+// heading's ID differs from the one generated from its text.
+func (f *formatter) heading(node *blackfriday.Node) string {
+	text := joinWords(f.inlines(node, false))
+	if strings.HasSuffix(text, "#") && !strings.HasSuffix(text, `\#`) {
+		// Closing #s are stripped from ATX headings
+		text = text[:len(text)-1] + `\#`
+	}
+
+	line := strings.Repeat("#", node.HeadingData.Level)
+	if text != "" {
+		line += " " + text
+	}
+	// Automatic IDs leave math out, as it is swapped for tokens first
+	auto, _ := extractMath(text)
+	if id := node.HeadingData.HeadingID; id != "" && id != blackfriday.SanitizedAnchorName(auto) {
+		line += " {#" + id + "}"
+	}
+	return line
+}
+
+// codeBlock writes a code block as a fenced block with its content
+// unchanged. Indented blocks are fenced too, which also keeps them code
+// inside list items.
+func (f *formatter) codeBlock(node *blackfriday.Node) []string {
+	code := f.doc.Text(node.Literal)
+	if !strings.HasSuffix(code, "\n") {
+		code += "\n"
+	}
+
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	lines := []string{fence + string(node.Info)}
+	lines = append(lines, strings.Split(strings.TrimSuffix(code, "\n"), "\n")...)
+	return append(lines, fence)
+}
+
+// blockQuote writes a blockquote, starting with the marker line for
+// callouts
+func (f *formatter) blockQuote(node *blackfriday.Node, width int) []string {
+	body := f.blocks(node, innerWidth(width, 2), false)
+
+	var lines []string
+	if callout, ok := f.doc.Callout(node); ok {
+		lines = append(lines, f.calloutMarkerLine(callout))
+		if node.FirstChild != nil && node.FirstChild.Type != blackfriday.Paragraph {
+			lines = append(lines, "")
+		}
+	}
+	lines = append(lines, body...)
+
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return lines
+}
+
+// calloutMarkerLine writes the [!TYPE] marker of a callout, with the title
+// and its markup only when it is not the default one
+func (f *formatter) calloutMarkerLine(callout Callout) string {
+	marker := "[!" + strings.ToUpper(callout.Type) + "]"
+	if callout.Foldable {
+		if callout.Open {
+			marker += "+"
+		} else {
+			marker += "-"
+		}
+	}
+	if callout.title != nil && !strings.EqualFold(callout.Title, callout.Type) {
+		var words []string
+		for _, word := range f.inlines(callout.title, false) {
+			words = append(words, word.text)
+		}
+		marker += " " + strings.Join(words, " ")
+	}
+	return marker
+}
+
+// list writes a bullet or ordered list
+func (f *formatter) list(node *blackfriday.Node, width int) []string {
+	ordered := node.ListFlags&blackfriday.ListTypeOrdered != 0
+
+	var lines []string
+	n := 0
+	for item := node.FirstChild; item != nil; item = item.Next {
+		n++
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(n) + ". "
+		}
+		if n > 1 && !node.Tight {
+			lines = append(lines, "")
+		}
+		lines = append(lines, f.listItem(item, marker, width, node.Tight)...)
+	}
+	return lines
+}
+
+// definitionList writes terms on their own lines followed by ": definition"
+func (f *formatter) definitionList(node *blackfriday.Node, width int) []string {
+	var lines []string
+	for item := node.FirstChild; item != nil; item = item.Next {
+		term := item.ListFlags&blackfriday.ListTypeTerm != 0
+		// A term directly after a definition would continue its text
+		if item != node.FirstChild && (term || !node.Tight) {
+			lines = append(lines, "")
+		}
+		if term {
+			lines = append(lines, f.blocks(item, 0, true)...)
+			continue
+		}
+		lines = append(lines, f.listItem(item, ": ", width, node.Tight)...)
+	}
+	return lines
+}
+
+// listIndent indents the content of list items after their first line.
+// Content after a blank line only stays in the item when indented by four
+// spaces, whatever the marker width.
+const listIndent = "    "
+
+func (f *formatter) listItem(item *blackfriday.Node, marker string, width int, tight bool) []string {
+	content := f.blocks(item, innerWidth(width, len(listIndent)), tight)
+	if len(content) == 0 {
+		return []string{strings.TrimSpace(marker)}
+	}
+
+	lines := []string{marker + content[0]}
+	for _, line := range content[1:] {
+		if line != "" {
+			line = listIndent + line
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// innerWidth is the wrap width left for content indented by prefix columns
+func innerWidth(width, prefix int) int {
+	if width == 0 {
+		return 0
+	}
+	if width-prefix < minFormatWidth {
+		return minFormatWidth
+	}
+	return width - prefix
+}
+
+// table writes a table with its columns padded to a common width
+func (f *formatter) table(node *blackfriday.Node) []string {
+	var rows [][]string
+	var align []blackfriday.CellAlignFlags
+	node.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering || n.Type != blackfriday.TableRow {
+			return blackfriday.GoToNext
+		}
+		var row []string
+		for cell := n.FirstChild; cell != nil; cell = cell.Next {
+			row = append(row, joinWords(f.inlines(cell, true)))
+			if len(rows) == 0 {
+				align = append(align, cell.Align)
+			}
+		}
+		rows = append(rows, row)
+		return blackfriday.SkipChildren
+	})
+	if len(rows) == 0 {
+		return nil
+	}
+
+	widths := make([]int, len(align))
+	for i := range widths {
+		widths[i] = 3
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i < len(widths) && utf8.RuneCountInString(cell) > widths[i] {
+				widths[i] = utf8.RuneCountInString(cell)
+			}
+		}
+	}
+
+	line := func(row []string) string {
+		cells := make([]string, len(widths))
+		for i := range widths {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			cells[i] = padCell(cell, widths[i], align[i])
+		}
+		return "| " + strings.Join(cells, " | ") + " |"
+	}
+
+	lines := []string{line(rows[0])}
+	delimiters := make([]string, len(widths))
+	for i, w := range widths {
+		switch align[i] {
+		case blackfriday.TableAlignmentLeft:
+			delimiters[i] = ":" + strings.Repeat("-", w-1)
+		case blackfriday.TableAlignmentRight:
+			delimiters[i] = strings.Repeat("-", w-1) + ":"
+		case blackfriday.TableAlignmentCenter:
+			delimiters[i] = ":" + strings.Repeat("-", w-2) + ":"
+		default:
+			delimiters[i] = strings.Repeat("-", w)
+		}
+	}
+	lines = append(lines, "| "+strings.Join(delimiters, " | ")+" |")
+	for _, row := range rows[1:] {
+		lines = append(lines, line(row))
+	}
+	return lines
+}
+
+func padCell(cell string, width int, align blackfriday.CellAlignFlags) string {
+	pad := width - utf8.RuneCountInString(cell)
+	switch align {
+	case blackfriday.TableAlignmentRight:
+		return strings.Repeat(" ", pad) + cell
+	case blackfriday.TableAlignmentCenter:
+		return strings.Repeat(" ", pad/2) + cell + strings.Repeat(" ", pad-pad/2)
+	}
+	return cell + strings.Repeat(" ", pad)
+}
+
+// formatWord is a run of markdown between breakable spaces. Words are kept
+// on one line when wrapping.
+type formatWord struct {
+	text string
+	// plain is set when the word starts with text rather than markup
+	plain bool
+	// hardBreak ends the line after the word
+	hardBreak bool
+}
+
+// inlineWriter collects the words of a block's inline content
+type inlineWriter struct {
+	doc   *Document
+	cell  bool
+	words []formatWord
+	// open is set while the last word can still be extended
+	open bool
+}
+
+// inlines formats the inline children of node as words. Pipes are escaped
+// in table cells.
+func (f *formatter) inlines(node *blackfriday.Node, cell bool) []formatWord {
+	w := &inlineWriter{doc: f.doc, cell: cell}
+	w.children(node)
+	return w.words
+}
+
+// write appends markup to the current word
+func (w *inlineWriter) write(s string, plain bool) {
+	if s == "" {
+		return
+	}
+	if !w.open {
+		w.words = append(w.words, formatWord{plain: plain})
+		w.open = true
+	}
+	w.words[len(w.words)-1].text += s
+}
+
+// space ends the current word
+func (w *inlineWriter) space() {
+	w.open = false
+}
+
+func (w *inlineWriter) hardBreak() {
+	if len(w.words) == 0 {
+		return
+	}
+	w.words[len(w.words)-1].text += `\`
+	w.words[len(w.words)-1].hardBreak = true
+	w.open = false
+}
+
+func (w *inlineWriter) children(node *blackfriday.Node) {
+	for child := node.FirstChild; child != nil; child = child.Next {
+		if child.Type != blackfriday.Text {
+			w.inline(child)
+			continue
+		}
+		// Escapes split text into several nodes; escaping needs the
+		// characters around each one
+		literal := string(child.Literal)
+		for child.Next != nil && child.Next.Type == blackfriday.Text {
+			child = child.Next
+			literal += string(child.Literal)
+		}
+		w.text(literal, child.Next)
+	}
+}
+
+func (w *inlineWriter) inline(node *blackfriday.Node) {
+	switch node.Type {
+	case blackfriday.Softbreak:
+		w.space()
+	case blackfriday.Hardbreak:
+		w.hardBreak()
+	case blackfriday.Emph:
+		delimiter := "*"
+		if isStrong(node.Parent) || isStrong(node.FirstChild) || isStrong(node.LastChild) {
+			delimiter = "_"
+		}
+		w.write(delimiter, false)
+		w.children(node)
+		w.write(delimiter, false)
+	case blackfriday.Strong:
+		w.write("**", false)
+		w.children(node)
+		w.write("**", false)
+	case blackfriday.Del:
+		w.write("~~", false)
+		w.children(node)
+		w.write("~~", false)
+	case blackfriday.Code:
+		w.write(codeSpan(strings.ReplaceAll(w.doc.Text(node.Literal), "\n", " ")), false)
+	case blackfriday.HTMLSpan:
+		w.write(w.doc.Text(node.Literal), false)
+	case blackfriday.Link:
+		dest := string(node.LinkData.Destination)
+		text := w.doc.NodeText(node)
+		if len(node.LinkData.Title) == 0 && node.FirstChild == node.LastChild && (text == dest || "mailto:"+text == dest) {
+			w.write("<"+dest+">", false)
+			return
+		}
+		w.write("[", false)
+		w.children(node)
+		w.write("]"+linkTarget(node.LinkData), false)
+	case blackfriday.Image:
+		// Alt text is not parsed as markdown
+		var alt strings.Builder
+		for child := node.FirstChild; child != nil; child = child.Next {
+			alt.WriteString(w.doc.Text(child.Literal))
+		}
+		w.write("!["+alt.String()+"]"+linkTarget(node.LinkData), false)
+	default:
+		w.children(node)
+	}
+}
+
+func isStrong(node *blackfriday.Node) bool {
+	return node != nil && node.Type == blackfriday.Strong
+}
+
+// codeSpan fences code with more backticks than it contains in a row
+func codeSpan(code string) string {
+	longest, run := 0, 0
+	for _, r := range code {
+		if r == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		return fence + " " + code + " " + fence
+	}
+	return fence + code + fence
+}
+
+// linkTarget writes the (destination "title") part of a link or image
+func linkTarget(link blackfriday.LinkData) string {
+	dest := string(link.Destination)
+	if dest == "" || strings.ContainsAny(dest, " <>") || strings.Count(dest, "(") != strings.Count(dest, ")") {
+		dest = "<" + dest + ">"
+	}
+	if len(link.Title) > 0 {
+		title := string(link.Title)
+		if strings.Contains(title, `"`) {
+			dest += " '" + title + "'"
+		} else {
+			dest += ` "` + title + `"`
+		}
+	}
+	return "(" + dest + ")"
+}
+
+// text writes text as words, escaping characters that would otherwise be
+// read as markup. Math is written as its source.
+func (w *inlineWriter) text(literal string, next *blackfriday.Node) {
+	nextIsLink := next != nil && next.Type == blackfriday.Link
+
+	for literal != "" {
+		i := strings.IndexRune(literal, mathTokenStart)
+		if i < 0 {
+			i = len(literal)
+		}
+		w.plainText(literal[:i], nextIsLink && i == len(literal))
+		if i == len(literal) {
+			return
+		}
+		n, width, ok := readMathToken(literal[i:])
+		if !ok || n >= len(w.doc.math) {
+			w.write(literal[i:i+len(string(mathTokenStart))], false)
+			literal = literal[i+len(string(mathTokenStart)):]
+			continue
+		}
+		w.write(w.doc.math[n].source(), false)
+		literal = literal[i+width:]
+	}
+}
+
+func (w *inlineWriter) plainText(text string, beforeLink bool) {
+	escaped := escapeText(text, beforeLink, w.cell)
+	start := 0
+	for i, r := range escaped {
+		if isBreakableSpace(r) {
+			w.write(escaped[start:i], !w.open)
+			w.space()
+			start = i + 1
+		}
+	}
+	w.write(escaped[start:], !w.open)
+}
+
+// isBreakableSpace reports whether a line may be broken at r. Other
+// spaces, such as no-break spaces, are kept.
+func isBreakableSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n'
+}
+
+// escapeText escapes the characters of plain text that markdown would read
+// as markup in its position
+func escapeText(text string, beforeLink, cell bool) string {
+	runes := []rune(text)
+	at := func(i int) rune {
+		if i < 0 || i >= len(runes) {
+			return 0
+		}
+		return runes[i]
+	}
+	alnum := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+
+	var b strings.Builder
+	for i, r := range runes {
+		prev, next := at(i-1), at(i+1)
+		escape := false
+		switch r {
+		case '\\':
+			// A backslash that may end a line would be a hard break
+			escape = next == 0 || isBreakableSpace(next) || strings.ContainsRune(escapeChars, next)
+		case '`', '*', '$':
+			escape = true
+		case '_':
+			escape = !alnum(prev) || !alnum(next)
+		case '<':
+			escape = next == 0 || unicode.IsLetter(next) || strings.ContainsRune("/!?", next)
+		case '~':
+			escape = prev == 0 || next == 0 || prev == '~' || next == '~'
+		case ']':
+			escape = next == 0 || strings.ContainsRune("([:", next)
+		case '!':
+			escape = next == 0 && beforeLink
+		case '{':
+			escape = next == '#'
+		case '|':
+			escape = cell
+		}
+		if escape {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// escapeChars are the characters markdown allows to be backslash escaped
+const escapeChars = "\\`*_{}[]()#+-.!:|&<>~"
+
+// lineStart matches plain text that starts a block when it begins a line,
+// including a bracket that may open a reference definition
+var lineStart = regexp.MustCompile(`^(?:[#>|]|[+=-]+$|:$|\d+[.)]$|\[(?:[^\]]*$|.*\]:))`)
+
+// wrapWords joins words into lines no longer than width where possible.
+// Lines are never broken before a word that would start a block, and such
+// a word is escaped when it has to begin a line.
+func wrapWords(words []formatWord, width int) []string {
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+	startOfLine := true
+
+	for _, word := range words {
+		text := word.text
+		fits := width == 0 || lineWidth+1+utf8.RuneCountInString(text) <= width
+		if !startOfLine && (fits || (word.plain && lineStart.MatchString(text))) {
+			line.WriteByte(' ')
+			lineWidth++
+		} else if !startOfLine {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineWidth = 0
+			startOfLine = true
+		}
+		if startOfLine && word.plain {
+			text = escapeLineStart(text)
+		}
+		line.WriteString(text)
+		lineWidth += utf8.RuneCountInString(text)
+		startOfLine = false
+
+		if word.hardBreak {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineWidth = 0
+			startOfLine = true
+		}
+	}
+	if !startOfLine {
+		lines = append(lines, line.String())
+	}
+
+	// Display math may span lines
+	return strings.Split(strings.Join(lines, "\n"), "\n")
+}
+
+// joinWords joins words onto a single line
+func joinWords(words []formatWord) string {
+	var texts []string
+	for i, word := range words {
+		text := word.text
+		if i == 0 && word.plain {
+			text = escapeLineStart(text)
+		}
+		if word.hardBreak {
+			text = strings.TrimSuffix(text, `\`)
+		}
+		texts = append(texts, text)
+	}
+	return strings.Join(texts, " ")
+}
+
+// escapeLineStart escapes a word that would start a block at the beginning
+// of a line
+func escapeLineStart(word string) string {
+	m := lineStart.FindString(word)
+	switch {
+	case m == "":
+		return word
+	case m[0] >= '0' && m[0] <= '9':
+		return m[:len(m)-1] + `\` + word[len(m)-1:]
+	case m[0] == '=':
+		// = cannot be escaped; it only starts a heading underline on a line
+		// of its own
+		return word
+	}
+	return `\` + word
+}
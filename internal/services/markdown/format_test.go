@@ -0,0 +1,176 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// formatCorpus covers the markdown constructs Format rewrites
+var formatCorpus = map[string]string{
+	"paragraphs":   "A first paragraph that is long enough to need wrapping when the width is small, with several words.\n\nSecond\nparagraph   on\nthree lines.\n",
+	"headings":     "Title\n=====\n\nSub title\n---------\n\n### Closed ###\n\n#### C#\n\n## Custom {#custom-id}\n\n# Hello *world*\n",
+	"emphasis":     "Some _emphasis_, __strong__, ***both***, ~~gone~~ and **_mixed_** text.\n",
+	"lists":        "* one\n* two\n    * nested\n    * nested two\n* three\n\n1) first\n2) second\n\n10. ten\n11. eleven\n",
+	"loose list":   "+ one\n\n+ two\n\n  with a second paragraph\n\n+ three\n",
+	"tasks":        "- [ ] open task\n- [x] done task\n",
+	"code":         "Text\n\n```go\nfunc main() {\n\tfmt.Println(\"*not emphasis*\")\n}\n```\n\n    indented code\n    keeps   spacing\n\n~~~~\n```\nnested\n```\n~~~~\n\nInline `code` and ``a ` tick``.\n",
+	"code in list": "- item\n\n  ```\n  code\n  ```\n\n- other\n",
+	"links":        "A [link](https://example.com \"Title\"), <https://example.com>, https://example.org and ![image](img.png).\n\nA [reference][ref] link.\n\n[ref]: https://example.com/ref\n",
+	"blockquote":   "> Quoted text\n> over lines\n>\n> > nested quote\n\n> - list in quote\n",
+	"callouts":     "> [!WARNING]\n> Careful now.\n\n<!-- -->\n\n> [!tip]- Folded title\n> Hidden.\n\n<!-- -->\n\n> [!NOTE]\n> - a list\n",
+	"table":        "| Name | Value | Center | Plain |\n|:---|---:|:---:|---|\n| a | 1 | x | y |\n| longer name | 12345 | c | a \\| pipe |\n",
+	"rules":        "Text\n\n***\n\n___\n\nMore\n",
+	"html":         "<div class=\"box\">\n  <p>raw</p>\n</div>\n\nText with <span>inline</span> html.\n",
+	"escapes":      "Not \\*emphasis\\*, not \\_this\\_, a \\# hash, \\[brackets\\](here) and a back\\\\slash.\n\n\\# Not a heading\n\n1\\. Not a list\n\n\\- Not a bullet\n\n\\> Not a quote\n",
+	"line starts":  "These words wrap so that - a dash, # hash, > angle, + plus and 1. number could begin a line.\n",
+	"hard breaks":  "Line one\\\nline two  \nline three\n",
+	"math":         "Inline $a + b = c$ math and $$x^2$$ display, with prices $5 and $10.\n\n$$\n\\frac{1}{2}\n$$\n",
+	"definitions":  "Term\n: Definition of the term\n\nOther\n: Another one\n",
+	"entities":     "Caf&eacute; &amp; bar &copy; 2024, a&nbsp;b and 5 < 6 > 4.\n",
+	"unicode":      "Größe → ünïcödé text, with a no break space and 日本語の文章も含まれています。\n",
+	"tricky":       "Wow! [link](https://example.com/a_(b)) and ![alt *text*](a.png 'A \"title\"')\n\n## A [linked](https://example.com) heading\n\nBreak\\\n\\# not heading\\\n\\- not item\n\n- item with `code | pipe`\n  1. nested ordered\n\n     ```sh\n     echo $HOME\n     ```\n  2. second\n",
+	"special":      "Snake_case_name, under_ score, 2*3*4, a ~ b, C++ and [brackets] and {#curly}.\n",
+	"dollars":      "I paid $20 for\nlunch and $x$ is math, \\$y\\$ is not.\n\n# Costs $5 and $6\n",
+	"backslashes":  "Use a path like C:\\ and\nthen more words here to wrap around the limit of width okay.\n",
+	"footnotes":    "Footnote[^1].\n\n[^1]: The note.\n\nText that is wrapped [a]: b and [c d]: e at narrow widths.\n",
+}
+
+// normalizeHTML collapses whitespace outside <pre> blocks, which does not
+// change how the HTML is displayed
+func normalizeHTML(html string) string {
+	pre := regexp.MustCompile(`(?s)<pre>.*?</pre>`)
+	space := regexp.MustCompile(`\s+`)
+	var out strings.Builder
+	last := 0
+	for _, loc := range pre.FindAllStringIndex(html, -1) {
+		out.WriteString(space.ReplaceAllString(html[last:loc[0]], " "))
+		out.WriteString(html[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	out.WriteString(space.ReplaceAllString(html[last:], " "))
+	return strings.TrimSpace(strings.ReplaceAll(strings.ReplaceAll(out.String(), "> <", "><"), " <br>", "<br>"))
+}
+
+func TestMarkdownService_Format_Corpus(t *testing.T) {
+	service := NewService()
+
+	for name, input := range formatCorpus {
+		for _, width := range []int{0, 10, 20, 40, 80} {
+			t.Run(name, func(t *testing.T) {
+				options := FormatOptions{Width: width}
+				formatted := service.Format(input, options)
+
+				assert.Equal(t, normalizeHTML(service.ToHTML(input)), normalizeHTML(service.ToHTML(formatted)), "formatted:\n%s", formatted)
+				assert.Equal(t, formatted, service.Format(formatted, options), "formatting is not idempotent")
+			})
+		}
+	}
+}
+
+func TestMarkdownService_Format(t *testing.T) {
+	service := NewService()
+
+	tests := []struct {
+		name     string
+		markdown string
+		width    int
+		expected string
+	}{
+		{
+			name:     "normalizes markers and headings",
+			markdown: "Title\n===\n\n* a\n* b\n\n__bold__ and _em_",
+			expected: "# Title\n\n- a\n- b\n\n**bold** and *em*\n",
+		},
+		{
+			name:     "wraps paragraphs",
+			markdown: "one two three four five six seven eight nine ten",
+			width:    20,
+			expected: "one two three four\nfive six seven eight\nnine ten\n",
+		},
+		{
+			name:     "unwraps paragraphs",
+			markdown: "one two\nthree\n\nfour\nfive",
+			expected: "one two three\n\nfour five\n",
+		},
+		{
+			name:     "wraps inside lists and quotes",
+			markdown: "- one two three four five six seven\n\n> one two three four five six seven",
+			width:    20,
+			expected: "- one two three four\n    five six seven\n\n> one two three four\n> five six seven\n",
+		},
+		{
+			name:     "does not break before block markers",
+			markdown: "aaaa bbbb - cccc",
+			width:    10,
+			expected: "aaaa bbbb -\ncccc\n",
+		},
+		{
+			name:     "renumbers ordered lists",
+			markdown: "3. a\n7. b\n1. c",
+			expected: "1. a\n2. b\n3. c\n",
+		},
+		{
+			name:     "pads tables",
+			markdown: "a|bb\n--:|:--:\nccc|d",
+			expected: "|   a | bb  |\n| --: | :-: |\n| ccc |  d  |\n",
+		},
+		{
+			name:     "keeps explicit heading ids",
+			markdown: "# Intro {#start}\n\n## Setup ##",
+			expected: "# Intro {#start}\n\n## Setup\n",
+		},
+		{
+			name:     "callouts",
+			markdown: "> [!warning]\n> Mind the gap\n\n<!-- -->\n\n> [!faq]+ Why?\n> Because.",
+			expected: "> [!WARNING]\n> Mind the gap\n\n<!-- -->\n\n> [!QUESTION]+ Why?\n> Because.\n",
+		},
+		{
+			name:     "empty",
+			markdown: "",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, service.Format(tt.markdown, FormatOptions{Width: tt.width}))
+		})
+	}
+}
+
+func TestMarkdownService_Format_KeepsCodeAndFrontMatter(t *testing.T) {
+	service := NewService()
+
+	frontMatter := "---\ntitle:   My  Note\ntags: [a,  b]\n---\n"
+	code := "```python   \ndef f( x ):\n\n    return   x  # *not* emphasis\n\n\n```\n"
+	input := frontMatter + "Some   text\n\n" + "*  item\n\n" + code
+
+	formatted := service.Format(input, FormatOptions{Width: 80})
+	require.True(t, strings.HasPrefix(formatted, frontMatter), formatted)
+	assert.Contains(t, formatted, "def f( x ):\n\n    return   x  # *not* emphasis\n\n\n```\n")
+	assert.Equal(t, frontMatter+"\nSome text\n\n- item\n\n```python\ndef f( x ):\n\n    return   x  # *not* emphasis\n\n\n```\n", formatted)
+	assert.Equal(t, formatted, service.Format(formatted, FormatOptions{Width: 80}))
+
+	assert.Equal(t, frontMatter, service.Format(frontMatter, FormatOptions{}))
+}
+
+func TestMarkdownService_SetFormatOptions(t *testing.T) {
+	service := NewService()
+	assert.Equal(t, DefaultFormatWidth, service.FormatOptions().Width)
+
+	require.NoError(t, service.SetFormatOptions(FormatOptions{Width: 100}))
+	assert.Equal(t, 100, service.FormatOptions().Width)
+
+	assert.Error(t, service.SetFormatOptions(FormatOptions{Width: -1}))
+	assert.EqualError(t, service.SetFormatOptions(FormatOptions{Width: 10}), "format width must be 0 or at least 20")
+}
+
+func TestFrontMatter(t *testing.T) {
+	assert.Equal(t, "title: Cat\ngrammar: off\n", FrontMatter("---\ntitle: Cat\ngrammar: off\n---\n# Cat\n"))
+	assert.Equal(t, "", FrontMatter("# Cat\n\n---\ntitle: Cat\n---\n"))
+	assert.Equal(t, "", FrontMatter("---\ntitle: Cat\n"))
+}
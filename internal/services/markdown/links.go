@@ -0,0 +1,92 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+var htmlIDPattern = regexp.MustCompile(`\b(?:id|name)\s*=\s*["']([^"']+)["']`)
+
+// Anchors returns the IDs #fragment links to the note may point at: the
+// heading IDs as the HTML view renders them, with repeated headings
+// numbered, and the id and name attributes of raw HTML
+func (d *Document) Anchors() map[string]bool {
+	anchors := map[string]bool{}
+	headingIDs := map[string]int{}
+	d.Root.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering {
+			return blackfriday.GoToNext
+		}
+		switch node.Type {
+		case blackfriday.Heading:
+			if node.HeadingData.HeadingID != "" {
+				anchors[uniqueHeadingID(headingIDs, node.HeadingData.HeadingID)] = true
+			}
+		case blackfriday.HTMLBlock, blackfriday.HTMLSpan:
+			for _, m := range htmlIDPattern.FindAllSubmatch(node.Literal, -1) {
+				anchors[string(m[1])] = true
+			}
+		}
+		return blackfriday.GoToNext
+	})
+	return anchors
+}
+
+// uniqueHeadingID numbers repeated heading IDs the way the HTML renderer
+// does, e.g. "setup", "setup-1", "setup-2"
+func uniqueHeadingID(seen map[string]int, id string) string {
+	for count, found := seen[id]; found; count, found = seen[id] {
+		next := fmt.Sprintf("%s-%d", id, count+1)
+		if _, taken := seen[next]; !taken {
+			seen[id] = count + 1
+			id = next
+		} else {
+			id += "-1"
+		}
+	}
+	if _, found := seen[id]; !found {
+		seen[id] = 0
+	}
+	return id
+}
+
+// Link is a link or image in a note
+type Link struct {
+	// Destination is the URL as written in the note
+	Destination string
+	Image       bool
+	// Line is the 1-based line the destination is written on
+	Line int
+}
+
+// Links returns the links and images of the document in order. Lines are
+// found by locating each destination in the source, so a reference link
+// is reported on the line of its definition.
+func (d *Document) Links() []Link {
+	var links []Link
+	// next is where the search for the following destination starts
+	offset, next := 0, 0
+	d.Root.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering || (node.Type != blackfriday.Link && node.Type != blackfriday.Image) {
+			return blackfriday.GoToNext
+		}
+		dest := string(node.LinkData.Destination)
+		if at := strings.Index(d.source[next:], dest); at >= 0 {
+			offset = next + at
+			next = offset + len(dest)
+		} else if at = strings.Index(d.source, dest); at >= 0 {
+			// Definitions of reference links usually come last
+			offset = at
+		}
+		links = append(links, Link{
+			Destination: dest,
+			Image:       node.Type == blackfriday.Image,
+			Line:        strings.Count(d.source[:offset], "\n") + 1,
+		})
+		return blackfriday.GoToNext
+	})
+	return links
+}
@@ -0,0 +1,454 @@
+package markdown
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+)
+
+// Lint finding severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// LintRule describes a lint rule. Rule IDs follow the markdownlint rule
+// aliases where one exists.
+type LintRule struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+
+	check func(ctx *lintContext, report reportFunc)
+}
+
+// reportFunc records a finding at a 1-based line and byte offset in it
+type reportFunc func(line, offset int, message string)
+
+// lintRules lists all rules in the order findings are reported for the
+// same position
+var lintRules = []LintRule{
+	{ID: "heading-increment", Description: "Heading levels should only increment by one level at a time", Severity: SeverityWarning, check: checkHeadingIncrement},
+	{ID: "no-duplicate-heading", Description: "Multiple headings should not have the same content", Severity: SeverityWarning, check: checkDuplicateHeadings},
+	{ID: "no-trailing-spaces", Description: "Lines should not end in whitespace, except two spaces for a line break", Severity: SeverityWarning, check: checkTrailingSpaces},
+	{ID: "fenced-code-closed", Description: "Fenced code blocks must be closed", Severity: SeverityError, check: checkUnclosedFences},
+	{ID: "link-fragments", Description: "Links to #fragments must point at a heading in the note", Severity: SeverityError, check: checkLinkFragments},
+	{ID: "ul-style", Description: "Unordered lists should use the same marker throughout the note", Severity: SeverityWarning, check: checkListMarkers},
+	{ID: "no-bare-urls", Description: "URLs should be written as links or wrapped in angle brackets", Severity: SeverityWarning, check: checkBareURLs},
+}
+
+// LintRules returns the available lint rules
+func LintRules() []LintRule {
+	rules := make([]LintRule, len(lintRules))
+	copy(rules, lintRules)
+	return rules
+}
+
+// LintConfig selects the lint rules a deployment runs
+type LintConfig struct {
+	// Default sets whether rules not listed in Rules run; nil means true
+	Default *bool `json:"default,omitempty"`
+	// Rules enables or disables rules by ID
+	Rules map[string]bool `json:"rules,omitempty"`
+	// Severity overrides the severity of rules by ID
+	Severity map[string]string `json:"severity,omitempty"`
+	// OnSave lints notes when they are saved, rejecting notes with errors
+	OnSave bool `json:"on_save"`
+}
+
+// LoadLintConfig reads a lint configuration from a JSON file
+func LoadLintConfig(path string) (LintConfig, error) {
+	var config LintConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read lint config: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse lint config: %w", err)
+	}
+	return config, config.Validate()
+}
+
+// Validate checks that the configuration only refers to known rules and
+// severities
+func (c LintConfig) Validate() error {
+	known := map[string]bool{}
+	for _, rule := range lintRules {
+		known[rule.ID] = true
+	}
+	for id := range c.Rules {
+		if !known[id] {
+			return fmt.Errorf("unknown lint rule %q", id)
+		}
+	}
+	for id, severity := range c.Severity {
+		if !known[id] {
+			return fmt.Errorf("unknown lint rule %q", id)
+		}
+		if severity != SeverityError && severity != SeverityWarning {
+			return fmt.Errorf("invalid severity %q for lint rule %q", severity, id)
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether a rule runs under the configuration
+func (c LintConfig) Enabled(id string) bool {
+	if enabled, ok := c.Rules[id]; ok {
+		return enabled
+	}
+	return c.Default == nil || *c.Default
+}
+
+// SetLintConfig sets the lint rules the service runs
+func (s *Service) SetLintConfig(config LintConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	s.lint = config
+	return nil
+}
+
+// LintConfig returns the lint configuration of the service
+func (s *Service) LintConfig() LintConfig {
+	return s.lint
+}
+
+// Lint checks markdown against the enabled lint rules. Findings are sorted
+// by position.
+func (s *Service) Lint(markdown string) *models.LintResult {
+	ctx := newLintContext(markdown, s.Parse(markdown))
+	result := &models.LintResult{Issues: []models.LintIssue{}}
+
+	for _, rule := range lintRules {
+		if !s.lint.Enabled(rule.ID) {
+			continue
+		}
+		severity := rule.Severity
+		if override, ok := s.lint.Severity[rule.ID]; ok {
+			severity = override
+		}
+		rule.check(ctx, func(line, offset int, message string) {
+			result.Issues = append(result.Issues, models.LintIssue{
+				Rule:     rule.ID,
+				Severity: severity,
+				Message:  message,
+				Line:     line,
+				Column:   utf8.RuneCountInString(ctx.lines[line-1][:offset]) + 1,
+			})
+		})
+	}
+
+	sort.SliceStable(result.Issues, func(i, j int) bool {
+		a, b := result.Issues[i], result.Issues[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	for _, issue := range result.Issues {
+		if issue.Severity == SeverityError {
+			result.Errors++
+		} else {
+			result.Warnings++
+		}
+	}
+	return result
+}
+
+// Validate checks if the markdown is valid. It returns the first
+// error-level lint finding; warnings do not make markdown invalid.
+func (s *Service) Validate(markdown string) error {
+	for _, issue := range s.Lint(markdown).Issues {
+		if issue.Severity == SeverityError {
+			return fmt.Errorf("line %d, column %d: %s (%s)", issue.Line, issue.Column, issue.Message, issue.Rule)
+		}
+	}
+	return nil
+}
+
+// lintHeading is an ATX or setext heading found in the source
+type lintHeading struct {
+	line   int
+	offset int
+	level  int
+	text   string
+}
+
+// lintFence is a fenced code block opening
+type lintFence struct {
+	line   int
+	offset int
+	marker string
+	closed bool
+}
+
+// lintContext is the source of a note split into lines, with the lines
+// rules should skip and the structure several rules share
+type lintContext struct {
+	lines []string
+	// skip marks lines inside fenced code or front matter
+	skip     []bool
+	fences   []lintFence
+	headings []lintHeading
+	// anchors holds the IDs links to #fragments may point at
+	anchors map[string]bool
+}
+
+var (
+	fencePattern  = regexp.MustCompile("^((?:\\s*>)*\\s*)(`{3,}|~{3,})(.*)$")
+	atxPattern    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextPattern = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	blockStart    = regexp.MustCompile(`^\s*(?:[#>|]|[*+-]\s|\d+[.)]\s)`)
+)
+
+func newLintContext(markdown string, doc *Document) *lintContext {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	ctx := &lintContext{
+		lines: lines,
+		skip:  make([]bool, len(lines)),
+	}
+
+	// YAML front matter between --- lines at the top
+	if len(lines) > 1 && strings.TrimRight(lines[0], " \t") == "---" {
+		for i := 1; i < len(lines); i++ {
+			if end := strings.TrimRight(lines[i], " \t"); end == "---" || end == "..." {
+				for j := 0; j <= i; j++ {
+					ctx.skip[j] = true
+				}
+				break
+			}
+		}
+	}
+
+	var open *lintFence
+	for i, line := range lines {
+		if ctx.skip[i] && open == nil {
+			continue
+		}
+		if m := fencePattern.FindStringSubmatch(line); m != nil {
+			if open == nil {
+				// Backtick fences cannot have backticks in their info string
+				if m[2][0] == '`' && strings.Contains(m[3], "`") {
+					continue
+				}
+				ctx.fences = append(ctx.fences, lintFence{line: i + 1, offset: len(m[1]), marker: m[2]})
+				open = &ctx.fences[len(ctx.fences)-1]
+				ctx.skip[i] = true
+				continue
+			}
+			if m[2][0] == open.marker[0] && len(m[2]) >= len(open.marker) && strings.TrimSpace(m[3]) == "" {
+				open.closed = true
+				open = nil
+				ctx.skip[i] = true
+				continue
+			}
+		}
+		if open != nil {
+			ctx.skip[i] = true
+		}
+	}
+
+	for i, line := range lines {
+		if ctx.skip[i] {
+			continue
+		}
+		if m := atxPattern.FindStringSubmatchIndex(line); m != nil {
+			text := ""
+			if m[4] >= 0 {
+				text = line[m[4]:m[5]]
+			}
+			ctx.headings = append(ctx.headings, lintHeading{line: i + 1, offset: m[2], level: m[3] - m[2], text: text})
+			continue
+		}
+		if i > 0 && !ctx.skip[i-1] && setextPattern.MatchString(line) {
+			prev := lines[i-1]
+			if strings.TrimSpace(prev) != "" && !blockStart.MatchString(prev) && !setextPattern.MatchString(prev) {
+				level := 1
+				if strings.TrimSpace(line)[0] == '-' {
+					level = 2
+				}
+				offset := len(prev) - len(strings.TrimLeft(prev, " \t"))
+				ctx.headings = append(ctx.headings, lintHeading{line: i, offset: offset, level: level, text: strings.TrimSpace(prev)})
+			}
+		}
+	}
+	// Setext headings are found on their underline, after the line holding
+	// their text
+	sort.SliceStable(ctx.headings, func(i, j int) bool { return ctx.headings[i].line < ctx.headings[j].line })
+
+	ctx.anchors = doc.Anchors()
+	return ctx
+}
+
+// proseLines calls fn for each line outside code and front matter, with
+// inline code spans blanked out so rules do not match inside them
+func (ctx *lintContext) proseLines(fn func(line int, text string)) {
+	for i, text := range ctx.lines {
+		if ctx.skip[i] {
+			continue
+		}
+		fn(i+1, blankCodeSpans(text))
+	}
+}
+
+// blankCodeSpans replaces inline code spans with spaces, keeping offsets.
+// A span ends at the next run of exactly as many backticks as opened it.
+func blankCodeSpans(text string) string {
+	b := []byte(text)
+	for i := 0; i < len(b); {
+		if b[i] != '`' {
+			i++
+			continue
+		}
+		n := 0
+		for i+n < len(b) && b[i+n] == '`' {
+			n++
+		}
+		end := -1
+		for j := i + n; j < len(b); {
+			if b[j] != '`' {
+				j++
+				continue
+			}
+			m := 0
+			for j+m < len(b) && b[j+m] == '`' {
+				m++
+			}
+			if m == n {
+				end = j + m
+				break
+			}
+			j += m
+		}
+		if end < 0 {
+			i += n
+			continue
+		}
+		for k := i; k < end; k++ {
+			b[k] = ' '
+		}
+		i = end
+	}
+	return string(b)
+}
+
+func checkHeadingIncrement(ctx *lintContext, report reportFunc) {
+	previous := 0
+	for _, h := range ctx.headings {
+		if previous > 0 && h.level > previous+1 {
+			report(h.line, h.offset, fmt.Sprintf("Heading level jumps from h%d to h%d", previous, h.level))
+		}
+		previous = h.level
+	}
+}
+
+func checkDuplicateHeadings(ctx *lintContext, report reportFunc) {
+	seen := map[string]int{}
+	for _, h := range ctx.headings {
+		key := strings.ToLower(strings.Join(strings.Fields(h.text), " "))
+		if key == "" {
+			continue
+		}
+		if first, ok := seen[key]; ok {
+			report(h.line, h.offset, fmt.Sprintf("Duplicate heading %q, first used on line %d", h.text, first))
+			continue
+		}
+		seen[key] = h.line
+	}
+}
+
+func checkTrailingSpaces(ctx *lintContext, report reportFunc) {
+	for i, line := range ctx.lines {
+		if ctx.skip[i] {
+			continue
+		}
+		trimmed := strings.TrimRight(line, " \t")
+		if trimmed == line {
+			continue
+		}
+		// Exactly two spaces after text, followed by more text, is a hard
+		// line break
+		next := ""
+		if i+1 < len(ctx.lines) {
+			next = strings.TrimSpace(ctx.lines[i+1])
+		}
+		if line[len(trimmed):] == "  " && strings.TrimSpace(trimmed) != "" && next != "" {
+			continue
+		}
+		report(i+1, len(trimmed), "Trailing whitespace")
+	}
+}
+
+func checkUnclosedFences(ctx *lintContext, report reportFunc) {
+	for _, fence := range ctx.fences {
+		if !fence.closed {
+			report(fence.line, fence.offset, fmt.Sprintf("Code fence %s is never closed", fence.marker))
+		}
+	}
+}
+
+var fragmentLink = regexp.MustCompile(`\]\(\s*<?#([^)\s>"]*)|^\s*\[[^\]]+\]:\s*<?#([^\s>]*)`)
+
+func checkLinkFragments(ctx *lintContext, report reportFunc) {
+	ctx.proseLines(func(line int, text string) {
+		for _, m := range fragmentLink.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[2], m[3]
+			if start < 0 {
+				start, end = m[4], m[5]
+			}
+			fragment := text[start:end]
+			if fragment == "" || ctx.anchors[fragment] {
+				continue
+			}
+			report(line, start-1, fmt.Sprintf("Link fragment #%s does not match any heading", fragment))
+		}
+	})
+}
+
+var (
+	bulletPattern = regexp.MustCompile(`^((?:\s*>)*\s*)([*+-])[ \t]+\S`)
+	rulePattern   = regexp.MustCompile(`^(?:\s*>)*\s*([-*_])(?:[ \t]*([-*_]))*[ \t]*$`)
+)
+
+func checkListMarkers(ctx *lintContext, report reportFunc) {
+	expected := ""
+	ctx.proseLines(func(line int, text string) {
+		m := bulletPattern.FindStringSubmatchIndex(text)
+		if m == nil || rulePattern.MatchString(text) {
+			return
+		}
+		marker := text[m[4]:m[5]]
+		if expected == "" {
+			expected = marker
+			return
+		}
+		if marker != expected {
+			report(line, m[4], fmt.Sprintf("List marker %q differs from %q used earlier in the note", marker, expected))
+		}
+	})
+}
+
+var bareURL = regexp.MustCompile(`(?i)\b(?:https?|ftp)://[^\s<>()\[\]]+`)
+
+func checkBareURLs(ctx *lintContext, report reportFunc) {
+	ctx.proseLines(func(line int, text string) {
+		for _, m := range bareURL.FindAllStringIndex(text, -1) {
+			before := text[:m[0]]
+			if before != "" && strings.ContainsAny(before[len(before)-1:], "(<\"'=[") {
+				continue
+			}
+			// Reference definitions such as [name]: https://example.com
+			if strings.HasSuffix(strings.TrimRight(before, " \t"), "]:") {
+				continue
+			}
+			report(line, m[0], fmt.Sprintf("Bare URL %s should be a link or wrapped in <>", text[m[0]:m[1]]))
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package markdown
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownService_Lint(t *testing.T) {
+	service := NewService()
+
+	tests := []struct {
+		name     string
+		markdown string
+		expected []models.LintIssue
+	}{
+		{
+			name:     "clean note",
+			markdown: "# Title\n\n## Section\n\nText with a [link](#section) and <https://example.com>.\n\n- one\n- two\n",
+			expected: []models.LintIssue{},
+		},
+		{
+			name:     "heading level jump",
+			markdown: "# Title\n\n### Too deep\n\n## Fine\n\n### Fine too",
+			expected: []models.LintIssue{
+				{Rule: "heading-increment", Severity: SeverityWarning, Message: "Heading level jumps from h1 to h3", Line: 3, Column: 1},
+			},
+		},
+		{
+			name:     "duplicate headings",
+			markdown: "# Notes\n\n## Setup\n\nSetup\n-----\n\n## setup ##",
+			expected: []models.LintIssue{
+				{Rule: "no-duplicate-heading", Severity: SeverityWarning, Message: "Duplicate heading \"Setup\", first used on line 3", Line: 5, Column: 1},
+				{Rule: "no-duplicate-heading", Severity: SeverityWarning, Message: "Duplicate heading \"setup\", first used on line 3", Line: 8, Column: 1},
+			},
+		},
+		{
+			name:     "trailing whitespace",
+			markdown: "Hard break  \nnext line \nTabbed\t\nLast two  ",
+			expected: []models.LintIssue{
+				{Rule: "no-trailing-spaces", Severity: SeverityWarning, Message: "Trailing whitespace", Line: 2, Column: 10},
+				{Rule: "no-trailing-spaces", Severity: SeverityWarning, Message: "Trailing whitespace", Line: 3, Column: 7},
+				{Rule: "no-trailing-spaces", Severity: SeverityWarning, Message: "Trailing whitespace", Line: 4, Column: 9},
+			},
+		},
+		{
+			name:     "unclosed fence hides the rest of the note",
+			markdown: "Text\n\n  ~~~~python\nprint() \n~~~\n# ### Not a heading",
+			expected: []models.LintIssue{
+				{Rule: "fenced-code-closed", Severity: SeverityError, Message: "Code fence ~~~~ is never closed", Line: 3, Column: 3},
+			},
+		},
+		{
+			name:     "broken anchors",
+			markdown: "# Getting Started\n\nSee [start](#getting-started), [gone](#missing) and [ref][r].\n\n<a id=\"custom\"></a> [custom](#custom)\n\n[r]: #nowhere",
+			expected: []models.LintIssue{
+				{Rule: "link-fragments", Severity: SeverityError, Message: "Link fragment #missing does not match any heading", Line: 3, Column: 39},
+				{Rule: "link-fragments", Severity: SeverityError, Message: "Link fragment #nowhere does not match any heading", Line: 7, Column: 6},
+			},
+		},
+		{
+			name:     "inconsistent list markers",
+			markdown: "- one\n- two\n\n* three\n  + nested\n\n***\n\n- four",
+			expected: []models.LintIssue{
+				{Rule: "ul-style", Severity: SeverityWarning, Message: "List marker \"*\" differs from \"-\" used earlier in the note", Line: 4, Column: 1},
+				{Rule: "ul-style", Severity: SeverityWarning, Message: "List marker \"+\" differs from \"-\" used earlier in the note", Line: 5, Column: 3},
+			},
+		},
+		{
+			name:     "bare urls",
+			markdown: "Visit https://example.com/a today.\n\n[ok](https://example.com) <https://example.com> `https://code.example`\n\n[ref]: https://example.com\n\n```\nhttps://in-code.example\n```",
+			expected: []models.LintIssue{
+				{Rule: "no-bare-urls", Severity: SeverityWarning, Message: "Bare URL https://example.com/a should be a link or wrapped in <>", Line: 1, Column: 7},
+			},
+		},
+		{
+			name:     "front matter is skipped",
+			markdown: "---\ntitle: Note \nurl: https://example.com\n---\n\n# Note",
+			expected: []models.LintIssue{},
+		},
+		{
+			name:     "columns count characters",
+			markdown: "Größe → https://example.com",
+			expected: []models.LintIssue{
+				{Rule: "no-bare-urls", Severity: SeverityWarning, Message: "Bare URL https://example.com should be a link or wrapped in <>", Line: 1, Column: 9},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, service.Lint(tt.markdown).Issues)
+		})
+	}
+}
+
+func TestMarkdownService_Lint_Counts(t *testing.T) {
+	result := NewService().Lint("# A\n\n### B\n\n```\ncode")
+	assert.Equal(t, 1, result.Errors)
+	assert.Equal(t, 1, result.Warnings)
+}
+
+func TestMarkdownService_LintConfig(t *testing.T) {
+	markdown := "# A\n\n### B \n\nhttps://example.com"
+
+	disabled := false
+	tests := []struct {
+		name   string
+		config LintConfig
+		rules  []string
+	}{
+		{
+			name:  "defaults",
+			rules: []string{"heading-increment", "no-trailing-spaces", "no-bare-urls"},
+		},
+		{
+			name:   "rule disabled",
+			config: LintConfig{Rules: map[string]bool{"no-bare-urls": false}},
+			rules:  []string{"heading-increment", "no-trailing-spaces"},
+		},
+		{
+			name:   "only listed rules",
+			config: LintConfig{Default: &disabled, Rules: map[string]bool{"no-trailing-spaces": true}},
+			rules:  []string{"no-trailing-spaces"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService()
+			require.NoError(t, service.SetLintConfig(tt.config))
+
+			var rules []string
+			for _, issue := range service.Lint(markdown).Issues {
+				rules = append(rules, issue.Rule)
+			}
+			assert.Equal(t, tt.rules, rules)
+		})
+	}
+
+	service := NewService()
+	require.NoError(t, service.SetLintConfig(LintConfig{Severity: map[string]string{"no-bare-urls": SeverityError}}))
+	assert.EqualError(t, service.Validate(markdown), "line 5, column 1: Bare URL https://example.com should be a link or wrapped in <> (no-bare-urls)")
+
+	assert.EqualError(t, service.SetLintConfig(LintConfig{Rules: map[string]bool{"MD999": true}}), `unknown lint rule "MD999"`)
+	assert.Error(t, service.SetLintConfig(LintConfig{Severity: map[string]string{"ul-style": "fatal"}}))
+}
+
+func TestLoadLintConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lint.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"on_save": true, "rules": {"ul-style": false}}`), 0644))
+
+	config, err := LoadLintConfig(path)
+	require.NoError(t, err)
+	assert.True(t, config.OnSave)
+	assert.False(t, config.Enabled("ul-style"))
+	assert.True(t, config.Enabled("no-bare-urls"))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules": {"nope": false}}`), 0644))
+	_, err = LoadLintConfig(path)
+	assert.Error(t, err)
+
+	_, err = LoadLintConfig(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestMarkdownService_Validate_Errors(t *testing.T) {
+	service := NewService()
+	assert.NoError(t, service.Validate("# Title\n\n#### Skipped levels are only a warning"))
+	assert.EqualError(t, service.Validate("```go\nfunc main() {}"), "line 1, column 1: Code fence ``` is never closed (fenced-code-closed)")
+}
@@ -1,36 +1,39 @@
 package markdown
 
 import (
+	"bytes"
+
 	"github.com/russross/blackfriday/v2"
 )
 
 // Service provides markdown processing functionality
 type Service struct {
-	// Add any configuration here if needed
+	// diagrams caches rendered diagram blocks by content hash
+	diagrams diagramCache
+	// lint selects the rules Lint runs
+	lint LintConfig
+	// format holds the default options for Format
+	format FormatOptions
 }
 
 // NewService creates a new markdown service
 func NewService() *Service {
-	return &Service{}
+	return &Service{format: FormatOptions{Width: DefaultFormatWidth}}
 }
 
 // ToHTML converts markdown content to HTML
 func (s *Service) ToHTML(markdown string) string {
-	// Use blackfriday with common extensions
-	extensions := blackfriday.CommonExtensions | blackfriday.AutoHeadingIDs
-	renderer := blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{
-		Flags: blackfriday.CommonHTMLFlags,
+	doc := s.Parse(markdown)
+	renderer := s.newHTMLRenderer()
+	renderer.callouts = doc.callouts
+
+	var buf bytes.Buffer
+	renderer.RenderHeader(&buf, doc.Root)
+	doc.Root.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		return renderer.RenderNode(&buf, node, entering)
 	})
-	
-	return string(blackfriday.Run([]byte(markdown), blackfriday.WithExtensions(extensions), blackfriday.WithRenderer(renderer)))
-}
+	renderer.RenderFooter(&buf, doc.Root)
 
-// Validate checks if the markdown is valid
-func (s *Service) Validate(markdown string) error {
-	// Basic validation - for now just check if it's not empty
-	// You can add more sophisticated validation here
-	if markdown == "" {
-		return nil
-	}
-	return nil
+	// Render the protected math server-side as MathML
+	return restoreMath(buf.String(), doc.math)
 }
@@ -72,3 +72,34 @@ func TestMarkdownService_Validate(t *testing.T) {
 	err = service.Validate("")
 	assert.NoError(t, err)
 }
+
+func TestMarkdownService_ToHTML_Diagrams(t *testing.T) {
+	service := NewService()
+
+	markdown := "# Architecture\n\n```dot\ndigraph { api -> storage }\n```\n\n```sequence\nA->B: hello\n```\n\n```go\nfunc main() {}\n```\n"
+	html := service.ToHTML(markdown)
+
+	assert.Contains(t, html, `<figure class="diagram"><svg xmlns="http://www.w3.org/2000/svg" class="diagram diagram-dot"`)
+	assert.Contains(t, html, `class="diagram diagram-sequence"`)
+	assert.Contains(t, html, `<code class="language-go">`)
+	assert.NotContains(t, html, "digraph")
+
+	// Rendered diagrams are cached by content hash
+	assert.Len(t, service.diagrams.entries, 2)
+	assert.Equal(t, html, service.ToHTML(markdown))
+	assert.Len(t, service.diagrams.entries, 2)
+}
+
+func TestMarkdownService_ToHTML_DiagramErrors(t *testing.T) {
+	service := NewService()
+
+	html := service.ToHTML("Before\n\n```dot\ndigraph { a -> }\n```\n\n```mermaid\ngraph TD\n```\n\nAfter")
+
+	// Each broken block gets its own error box and the rest still renders
+	assert.Equal(t, 2, strings.Count(html, `<div class="diagram-error" role="alert">`))
+	assert.Contains(t, html, "dot diagram, line 1: expected node name")
+	assert.Contains(t, html, "<pre><code>digraph { a -&gt; }")
+	assert.Contains(t, html, "mermaid diagram: diagram language is not supported on the server")
+	assert.Contains(t, html, "<p>Before</p>")
+	assert.Contains(t, html, "<p>After</p>")
+}
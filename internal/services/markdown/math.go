@@ -0,0 +1,390 @@
+package markdown
+
+import (
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+// Math spans are swapped out for placeholder tokens before the markdown is
+// parsed so that blackfriday never sees the underscores and asterisks inside
+// them. The tokens use private-use runes, which markdown treats as plain text.
+// The span number is written with private-use digits too, so that it is
+// left out of heading IDs like the rest of the token.
+const (
+	mathTokenStart = '\uE000'
+	mathTokenEnd   = '\uE001'
+	mathTokenDigit = '\uE002'
+)
+
+// mathSpan is a single $inline$ or $$display$$ expression found in the
+// source. Token characters written in the note and escaped dollar signs
+// are literal spans, which are restored as text.
+type mathSpan struct {
+	tex     string
+	display bool
+	literal bool
+}
+
+// extractMath replaces every math span outside of code with a placeholder
+// token and returns the rewritten source together with the extracted spans
+func extractMath(src string) (string, []mathSpan) {
+	var spans []mathSpan
+	src = escapeMathTokens(src, &spans)
+	if !strings.Contains(src, "$") {
+		return src, spans
+	}
+
+	var (
+		out   strings.Builder
+		fence string
+	)
+
+	lines := strings.SplitAfter(src, "\n")
+	raw := rawBlockLines(src, lines)
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimLeft(line, " \t")
+
+		// Indented code and HTML blocks are copied through untouched
+		if raw[i] {
+			out.WriteString(line)
+			continue
+		}
+
+		// Fenced code blocks are copied through untouched
+		if fence != "" {
+			out.WriteString(line)
+			if strings.HasPrefix(strings.TrimRight(trimmed, " \t\r\n"), fence) {
+				fence = ""
+			}
+			continue
+		}
+		if marker := fenceMarker(trimmed); marker != "" {
+			fence = marker
+			out.WriteString(line)
+			continue
+		}
+
+		// Display math may span several lines, so join the rest of the
+		// paragraph when a line opens a $$ block without closing it
+		if strings.Count(line, "$$")%2 == 1 {
+			for j := i + 1; j < len(lines); j++ {
+				if raw[j] || strings.TrimSpace(lines[j]) == "" || fenceMarker(strings.TrimLeft(lines[j], " \t")) != "" {
+					break
+				}
+				if strings.Contains(lines[j], "$$") {
+					line = strings.Join(lines[i:j+1], "")
+					i = j
+					break
+				}
+			}
+		}
+
+		out.WriteString(replaceMathInLine(line, &spans))
+	}
+
+	return out.String(), spans
+}
+
+// escapeMathTokens replaces the token start characters written in src with
+// literal spans, so that text that looks like a token is not read as one
+func escapeMathTokens(src string, spans *[]mathSpan) string {
+	if !strings.ContainsRune(src, mathTokenStart) {
+		return src
+	}
+	var out strings.Builder
+	for {
+		i := strings.IndexRune(src, mathTokenStart)
+		if i < 0 {
+			out.WriteString(src)
+			return out.String()
+		}
+		out.WriteString(src[:i])
+		out.WriteString(mathToken(len(*spans)))
+		*spans = append(*spans, mathSpan{tex: string(mathTokenStart), literal: true})
+		src = src[i+len(string(mathTokenStart)):]
+	}
+}
+
+// rawBlockLines marks the lines of src in indented code blocks and HTML
+// blocks, which are found by parsing it. A block is matched to the first
+// run of lines after the previous block that end in its lines, preceded
+// only by indentation and blockquote markers.
+func rawBlockLines(src string, lines []string) []bool {
+	raw := make([]bool, len(lines))
+	next := 0
+	parse(src).Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering || !(node.Type == blackfriday.CodeBlock && !node.IsFenced) && node.Type != blackfriday.HTMLBlock {
+			return blackfriday.GoToNext
+		}
+		block := strings.Split(strings.TrimRight(string(node.Literal), "\n"), "\n")
+		indent := 0
+		if node.Type == blackfriday.CodeBlock {
+			indent = 4
+		}
+		for start := next; start+len(block) <= len(lines); start++ {
+			if blockAt(lines[start:start+len(block)], block, indent) {
+				for i := start; i < start+len(block); i++ {
+					raw[i] = true
+				}
+				next = start + len(block)
+				break
+			}
+		}
+		return blackfriday.SkipChildren
+	})
+	return raw
+}
+
+// blockAt reports whether lines end in the lines of a block, each preceded
+// by blockquote markers and at least indent columns of whitespace
+func blockAt(lines, block []string, indent int) bool {
+	for i, want := range block {
+		line := strings.TrimRight(lines[i], "\r\n")
+		if !strings.HasSuffix(line, want) {
+			return false
+		}
+		prefix := line[:len(line)-len(want)]
+		if strings.Trim(prefix, " \t>") != "" {
+			return false
+		}
+		if strings.TrimSpace(want) == "" {
+			continue
+		}
+		columns := 0
+		for _, r := range prefix[strings.LastIndex(prefix, ">")+1:] {
+			if r == '\t' {
+				columns += 4
+			} else {
+				columns++
+			}
+		}
+		if columns < indent {
+			return false
+		}
+	}
+	return true
+}
+
+// fenceMarker returns the fence characters that open a fenced code block on
+// the given (left-trimmed) line, or an empty string if the line is not a fence
+func fenceMarker(line string) string {
+	for _, ch := range []string{"`", "~"} {
+		n := 0
+		for n < len(line) && line[n:n+1] == ch {
+			n++
+		}
+		if n >= 3 {
+			return strings.Repeat(ch, n)
+		}
+	}
+	return ""
+}
+
+// replaceMathInLine swaps math spans in a chunk of paragraph text for tokens,
+// skipping inline code spans and backslash escapes
+func replaceMathInLine(text string, spans *[]mathSpan) string {
+	var out strings.Builder
+	for i := 0; i < len(text); {
+		switch text[i] {
+		case '\\':
+			// An escaped dollar sign is a literal one, as markdown does
+			// not escape it itself
+			if strings.HasPrefix(text[i:], `\$`) {
+				out.WriteString(mathToken(len(*spans)))
+				*spans = append(*spans, mathSpan{tex: `\$`, literal: true})
+				i += 2
+				continue
+			}
+			end := i + 2
+			if end > len(text) {
+				end = len(text)
+			}
+			out.WriteString(text[i:end])
+			i = end
+			continue
+		case '`':
+			run := 0
+			for i+run < len(text) && text[i+run] == '`' {
+				run++
+			}
+			delim := text[i : i+run]
+			if closeAt := strings.Index(text[i+run:], delim); closeAt >= 0 {
+				end := i + run + closeAt + run
+				out.WriteString(text[i:end])
+				i = end
+				continue
+			}
+			out.WriteString(delim)
+			i += run
+			continue
+		case '$':
+			if tex, end, display, ok := scanMath(text, i); ok {
+				out.WriteString(mathToken(len(*spans)))
+				*spans = append(*spans, mathSpan{tex: tex, display: display})
+				i = end
+				continue
+			}
+		}
+		out.WriteByte(text[i])
+		i++
+	}
+	return out.String()
+}
+
+// scanMath tries to read a math span starting at the dollar sign at text[start].
+// It follows the pandoc rules for inline math: the opening $ must be followed
+// by a non-space character and the closing $ must be preceded by one and not
+// followed by a digit, so that prices like "$5 and $10" stay plain text.
+func scanMath(text string, start int) (tex string, end int, display bool, ok bool) {
+	if strings.HasPrefix(text[start:], "$$") {
+		closeAt := strings.Index(text[start+2:], "$$")
+		if closeAt < 0 {
+			return "", 0, false, false
+		}
+		tex = strings.TrimSpace(text[start+2 : start+2+closeAt])
+		if tex == "" {
+			return "", 0, false, false
+		}
+		return tex, start + 2 + closeAt + 2, true, true
+	}
+
+	if start+1 >= len(text) || isMathSpace(text[start+1]) {
+		return "", 0, false, false
+	}
+	for i := start + 1; i < len(text); i++ {
+		switch text[i] {
+		case '\n':
+			return "", 0, false, false
+		case '\\':
+			i++
+		case '$':
+			if isMathSpace(text[i-1]) {
+				continue
+			}
+			if i+1 < len(text) && text[i+1] >= '0' && text[i+1] <= '9' {
+				continue
+			}
+			return text[start+1 : i], i + 1, false, true
+		}
+	}
+	return "", 0, false, false
+}
+
+func isMathSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+// mathToken returns the placeholder used for the n-th math span
+func mathToken(n int) string {
+	var b strings.Builder
+	b.WriteRune(mathTokenStart)
+	for _, d := range strconv.Itoa(n) {
+		b.WriteRune(mathTokenDigit + d - '0')
+	}
+	b.WriteRune(mathTokenEnd)
+	return b.String()
+}
+
+// restoreMath replaces placeholder tokens in rendered HTML with MathML.
+// Tokens that ended up inside a tag (for example in an image alt attribute)
+// are restored as escaped TeX instead, so the markup stays well formed.
+func restoreMath(rendered string, spans []mathSpan) string {
+	if len(spans) == 0 {
+		return rendered
+	}
+
+	// A display block on its own line is wrapped in a paragraph by the
+	// markdown renderer; unwrap it so the <math> element stands alone
+	for n, span := range spans {
+		if span.display {
+			rendered = strings.Replace(rendered, "<p>"+mathToken(n)+"</p>", mathToken(n), 1)
+		}
+	}
+
+	var out strings.Builder
+	inTag := false
+	for i := 0; i < len(rendered); {
+		if n, width, ok := readMathToken(rendered[i:]); ok && n < len(spans) {
+			if spans[n].literal {
+				out.WriteString(html.EscapeString(spans[n].text()))
+			} else if inTag {
+				out.WriteString(html.EscapeString(spans[n].source()))
+			} else {
+				out.WriteString(renderMath(spans[n]))
+			}
+			i += width
+			continue
+		}
+		switch rendered[i] {
+		case '<':
+			inTag = true
+		case '>':
+			inTag = false
+		}
+		out.WriteByte(rendered[i])
+		i++
+	}
+	return out.String()
+}
+
+// readMathToken parses a placeholder token at the start of s, returning the
+// span index and the token's width in bytes
+func readMathToken(s string) (n int, width int, ok bool) {
+	if !strings.HasPrefix(s, string(mathTokenStart)) {
+		return 0, 0, false
+	}
+	end := strings.IndexRune(s, mathTokenEnd)
+	if end < 0 {
+		return 0, 0, false
+	}
+	digits := s[len(string(mathTokenStart)):end]
+	if digits == "" {
+		return 0, 0, false
+	}
+	for _, d := range digits {
+		if d < mathTokenDigit || d > mathTokenDigit+9 {
+			return 0, 0, false
+		}
+		n = n*10 + int(d-mathTokenDigit)
+	}
+	return n, end + len(string(mathTokenEnd)), true
+}
+
+// source returns the span as it was written in the markdown
+func (m mathSpan) source() string {
+	if m.literal {
+		return m.tex
+	}
+	if m.display {
+		return "$$" + m.tex + "$$"
+	}
+	return "$" + m.tex + "$"
+}
+
+// text returns a literal span as it reads, without the backslash of an
+// escaped dollar sign
+func (m mathSpan) text() string {
+	return strings.TrimPrefix(m.tex, `\`)
+}
+
+// renderMath converts a span to MathML, falling back to the raw TeX wrapped
+// in a styled element when the expression uses unsupported commands
+func renderMath(span mathSpan) string {
+	mathml, err := TeXToMathML(span.tex, span.display)
+	if err == nil {
+		return mathml
+	}
+
+	class := "math math-inline math-fallback"
+	tag := "span"
+	if span.display {
+		class = "math math-display math-fallback"
+		tag = "div"
+	}
+	return "<" + tag + ` class="` + class + `" title="` + html.EscapeString(err.Error()) + `"><code>` +
+		html.EscapeString(span.source()) + "</code></" + tag + ">"
+}
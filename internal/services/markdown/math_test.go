@@ -0,0 +1,189 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownService_ToHTML_Math(t *testing.T) {
+	service := NewService()
+
+	tests := []struct {
+		name        string
+		markdown    string
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:     "inline math keeps underscores and asterisks",
+			markdown: "Product $a_1*b_2*c$ here",
+			contains: []string{
+				`<math xmlns="http://www.w3.org/1998/Math/MathML" display="inline"`,
+				"<msub><mi>a</mi><mn>1</mn></msub><mo>*</mo><msub><mi>b</mi><mn>2</mn></msub>",
+				`<annotation encoding="application/x-tex">a_1*b_2*c</annotation>`,
+			},
+			notContains: []string{"<em>"},
+		},
+		{
+			name:     "display math is not wrapped in a paragraph",
+			markdown: "$$\n\\sum_{i=1}^{n} x_i\n$$",
+			contains: []string{
+				`display="block"`,
+				"<munderover><mo largeop=\"true\" movablelimits=\"true\">∑</mo>",
+			},
+			notContains: []string{"<p><math"},
+		},
+		{
+			name:        "prices are not math",
+			markdown:    "It costs $5 and $10.",
+			contains:    []string{"<p>It costs $5 and $10.</p>"},
+			notContains: []string{"<math"},
+		},
+		{
+			name:        "code spans and blocks are left alone",
+			markdown:    "`$x_1$`\n\n```\n$a_b$\n```",
+			contains:    []string{"<code>$x_1$</code>", "<pre><code>$a_b$\n</code></pre>"},
+			notContains: []string{"<math"},
+		},
+		{
+			name:        "indented code is left alone",
+			markdown:    "Text\n\n    code $x_1$ here\n\n- item\n\n        list code $y$\n\n> quote\n>\n>     quoted code $z$\n",
+			contains:    []string{"<pre><code>code $x_1$ here\n</code></pre>", "<pre><code>list code $y$\n</code></pre>", "<pre><code>quoted code $z$\n</code></pre>"},
+			notContains: []string{"<math"},
+		},
+		{
+			name:        "html blocks are left alone",
+			markdown:    "<div>$a$\n  <p>$b_1$</p>\n</div>\n\nAfter $c$ math",
+			contains:    []string{"<div>$a$\n  <p>$b_1$</p>\n</div>", "After <math"},
+			notContains: []string{"<annotation encoding=\"application/x-tex\">a</annotation>"},
+		},
+		{
+			name:     "indented lines in paragraphs are still math",
+			markdown: "Text\n    continued $x$",
+			contains: []string{`<annotation encoding="application/x-tex">x</annotation>`},
+		},
+		{
+			name:        "token characters written in the note are kept",
+			markdown:    "Math $a$ and \uE0000\uE001 typed, also in `\uE0000\uE001` code",
+			contains:    []string{"and \uE0000\uE001 typed", "<code>\uE0000\uE001</code>"},
+			notContains: []string{"and <math", "<code><math"},
+		},
+		{
+			name:     "unsupported commands fall back to raw TeX",
+			markdown: "See $\\unknown{x}_1$",
+			contains: []string{`<span class="math math-inline math-fallback"`, `<code>$\unknown{x}_1$</code>`},
+		},
+		{
+			name:        "math in attributes stays escaped TeX",
+			markdown:    "![area $\\pi r^2$](circle.png)",
+			contains:    []string{`alt="area $\pi r^2$"`},
+			notContains: []string{"<math"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html := service.ToHTML(tt.markdown)
+			for _, want := range tt.contains {
+				assert.Contains(t, html, want)
+			}
+			for _, unwanted := range tt.notContains {
+				assert.NotContains(t, html, unwanted)
+			}
+		})
+	}
+}
+
+func TestTeXToMathML(t *testing.T) {
+	tests := []struct {
+		name     string
+		tex      string
+		display  bool
+		expected string
+	}{
+		{
+			name:     "fraction",
+			tex:      `\frac{1}{2}`,
+			expected: "<mfrac><mn>1</mn><mn>2</mn></mfrac>",
+		},
+		{
+			name:     "root with index",
+			tex:      `\sqrt[3]{x}`,
+			expected: "<mroot><mi>x</mi><mn>3</mn></mroot>",
+		},
+		{
+			name:     "greek and operators",
+			tex:      `\alpha \leq \beta`,
+			expected: "<mi>α</mi><mo>≤</mo><mi>β</mi>",
+		},
+		{
+			name:     "limits are scripts inline",
+			tex:      `\lim_{x \to 0}`,
+			expected: `<msub><mi mathvariant="normal">lim</mi><mrow><mi>x</mi><mo>→</mo><mn>0</mn></mrow></msub>`,
+		},
+		{
+			name:     "limits are under and over in display mode",
+			tex:      `\lim_{x \to 0}`,
+			display:  true,
+			expected: `<munder><mi mathvariant="normal">lim</mi><mrow><mi>x</mi><mo>→</mo><mn>0</mn></mrow></munder>`,
+		},
+		{
+			name:     "text keeps spaces",
+			tex:      `x \text{ if } y`,
+			expected: "<mtext>if </mtext>",
+		},
+		{
+			name:     "cases environment",
+			tex:      `\begin{cases} 1 & x > 0 \\ 0 & \text{otherwise} \end{cases}`,
+			expected: `<mo fence="true" stretchy="true">{</mo><mtable columnalign="left">`,
+		},
+		{
+			name:     "font variants",
+			tex:      `\mathbb{R}`,
+			expected: `<mi mathvariant="double-struck">R</mi>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mathml, err := TeXToMathML(tt.tex, tt.display)
+			require.NoError(t, err)
+			assert.Contains(t, mathml, tt.expected)
+		})
+	}
+}
+
+func TestTeXToMathML_Errors(t *testing.T) {
+	for _, tex := range []string{`\frac{1}`, `x^`, `{x`, `\left( x`, `\begin{foo}x\end{foo}`, `\notacommand`} {
+		_, err := TeXToMathML(tex, false)
+		assert.Error(t, err, tex)
+	}
+
+	// Errors never panic and the message names the offending command
+	_, err := TeXToMathML(`\unknown`, false)
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), `\unknown`))
+}
+
+func TestMarkdownService_Math_TokenCharacters(t *testing.T) {
+	service := NewService()
+	markdown := "A \uE0000\uE001 and $x$\n"
+
+	assert.Equal(t, "A \uE0000\uE001 and $x$", service.ToPlainText(markdown))
+	assert.Equal(t, markdown, service.Format(markdown, FormatOptions{}))
+}
+
+func TestMarkdownService_Math_EscapedDollars(t *testing.T) {
+	service := NewService()
+	markdown := "# Costs \\$5 and $x$\n\nPaid \\$20 for\nlunch and $y$ too.\n"
+
+	html := service.ToHTML(markdown)
+	assert.Contains(t, html, `<h1 id="costs-5-and">Costs $5 and <math`)
+	assert.Contains(t, html, "Paid $20 for")
+	assert.Equal(t, 2, strings.Count(html, "<math"))
+	assert.Equal(t, "Costs $5 and $x$\n\nPaid $20 for lunch and $y$ too.", service.ToPlainText(markdown))
+	assert.Equal(t, "# Costs \\$5 and $x$\n\nPaid \\$20 for lunch and $y$ too.\n", service.Format(markdown, FormatOptions{}))
+}
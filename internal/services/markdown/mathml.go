@@ -0,0 +1,705 @@
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// TeXToMathML converts a LaTeX math expression to presentation MathML.
+// It supports the subset of TeX commonly used in notes: scripts, fractions,
+// roots, greek letters and symbols, accents, font styles, \left/\right
+// delimiters and matrix-like environments. Unsupported commands return an
+// error so callers can fall back to showing the raw TeX.
+func TeXToMathML(tex string, display bool) (string, error) {
+	p := &texParser{tokens: tokenizeTeX(tex), display: display}
+	body, err := p.parseExpr()
+	if err != nil {
+		return "", err
+	}
+	if !p.done() {
+		return "", fmt.Errorf("unexpected %q", p.peek().text)
+	}
+
+	mode := "inline"
+	if display {
+		mode = "block"
+	}
+	return `<math xmlns="http://www.w3.org/1998/Math/MathML" display="` + mode + `" class="math math-` + inlineOrDisplay(display) + `">` +
+		"<semantics><mrow>" + body + "</mrow>" +
+		`<annotation encoding="application/x-tex">` + html.EscapeString(tex) + "</annotation></semantics></math>", nil
+}
+
+func inlineOrDisplay(display bool) string {
+	if display {
+		return "display"
+	}
+	return "inline"
+}
+
+type texTokenKind int
+
+const (
+	texCommand texTokenKind = iota
+	texOpen
+	texClose
+	texSup
+	texSub
+	texAlign
+	texNumber
+	texLetter
+	texSymbol
+	texPrime
+)
+
+type texToken struct {
+	kind texTokenKind
+	text string
+	// spaced records whitespace before the token, which matters inside \text
+	spaced bool
+}
+
+// tokenizeTeX splits an expression into commands, groups, scripts, numbers,
+// letters and single-character symbols. Whitespace is insignificant in math
+// mode and is dropped.
+func tokenizeTeX(tex string) []texToken {
+	var tokens []texToken
+	runes := []rune(tex)
+	spaced := false
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if unicode.IsSpace(r) {
+			spaced = true
+			continue
+		}
+		start := len(tokens)
+		switch {
+		case r == '\\':
+			j := i + 1
+			for j < len(runes) && unicode.IsLetter(runes[j]) && runes[j] < unicode.MaxASCII {
+				j++
+			}
+			if j == i+1 && j < len(runes) {
+				j++
+			}
+			tokens = append(tokens, texToken{kind: texCommand, text: string(runes[i+1 : j])})
+			i = j - 1
+		case r == '{':
+			tokens = append(tokens, texToken{kind: texOpen, text: "{"})
+		case r == '}':
+			tokens = append(tokens, texToken{kind: texClose, text: "}"})
+		case r == '^':
+			tokens = append(tokens, texToken{kind: texSup, text: "^"})
+		case r == '_':
+			tokens = append(tokens, texToken{kind: texSub, text: "_"})
+		case r == '&':
+			tokens = append(tokens, texToken{kind: texAlign, text: "&"})
+		case r == '\'':
+			tokens = append(tokens, texToken{kind: texPrime, text: "'"})
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || (runes[j] == '.' && j+1 < len(runes) && unicode.IsDigit(runes[j+1]))) {
+				j++
+			}
+			tokens = append(tokens, texToken{kind: texNumber, text: string(runes[i:j])})
+			i = j - 1
+		case unicode.IsLetter(r):
+			tokens = append(tokens, texToken{kind: texLetter, text: string(r)})
+		default:
+			tokens = append(tokens, texToken{kind: texSymbol, text: string(r)})
+		}
+		tokens[start].spaced = spaced
+		spaced = false
+	}
+	return tokens
+}
+
+// texParser is a small recursive-descent parser that emits MathML directly
+type texParser struct {
+	tokens  []texToken
+	pos     int
+	display bool
+}
+
+// mathNode is a rendered fragment plus what the script handling needs to
+// know about it
+type mathNode struct {
+	markup string
+	// limits is set for operators such as \sum and \lim whose scripts are
+	// placed above and below in display mode
+	limits bool
+}
+
+func (p *texParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *texParser) peek() texToken {
+	if p.done() {
+		return texToken{kind: -1}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *texParser) next() texToken {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// atStop reports whether the parser reached a token that ends the current
+// expression: a closing brace, a table separator or the end of a \left group
+func (p *texParser) atStop() bool {
+	if p.done() {
+		return true
+	}
+	t := p.peek()
+	switch t.kind {
+	case texClose, texAlign:
+		return true
+	case texCommand:
+		return t.text == "\\" || t.text == "right" || t.text == "end" || t.text == "cr"
+	}
+	return false
+}
+
+// parseExpr parses a sequence of atoms up to the next stop token
+func (p *texParser) parseExpr() (string, error) {
+	var b strings.Builder
+	for !p.atStop() {
+		node, err := p.parseScripted()
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(node.markup)
+	}
+	return b.String(), nil
+}
+
+// parseScripted parses an atom followed by any ^, _ or prime scripts
+func (p *texParser) parseScripted() (mathNode, error) {
+	base, err := p.parseAtom()
+	if err != nil {
+		return mathNode{}, err
+	}
+
+	var sup, sub string
+	hasSup, hasSub := false, false
+	for !p.done() {
+		switch p.peek().kind {
+		case texSup:
+			if hasSup {
+				return mathNode{}, fmt.Errorf("double superscript")
+			}
+			p.next()
+			arg, err := p.parseArgument()
+			if err != nil {
+				return mathNode{}, err
+			}
+			sup, hasSup = sup+arg, true
+			continue
+		case texSub:
+			if hasSub {
+				return mathNode{}, fmt.Errorf("double subscript")
+			}
+			p.next()
+			arg, err := p.parseArgument()
+			if err != nil {
+				return mathNode{}, err
+			}
+			sub, hasSub = arg, true
+			continue
+		case texPrime:
+			p.next()
+			sup, hasSup = "<mo>′</mo>"+sup, true
+			continue
+		}
+		break
+	}
+
+	if !hasSup && !hasSub {
+		return base, nil
+	}
+	under, over := "msub", "msup"
+	both := "msubsup"
+	if base.limits && p.display {
+		under, over, both = "munder", "mover", "munderover"
+	}
+	switch {
+	case hasSup && hasSub:
+		return mathNode{markup: "<" + both + ">" + base.markup + wrapRow(sub) + wrapRow(sup) + "</" + both + ">"}, nil
+	case hasSub:
+		return mathNode{markup: "<" + under + ">" + base.markup + wrapRow(sub) + "</" + under + ">"}, nil
+	default:
+		return mathNode{markup: "<" + over + ">" + base.markup + wrapRow(sup) + "</" + over + ">"}, nil
+	}
+}
+
+// parseArgument parses a single-token argument or a braced group
+func (p *texParser) parseArgument() (string, error) {
+	if p.done() {
+		return "", fmt.Errorf("missing argument")
+	}
+	if p.peek().kind == texOpen {
+		return p.parseGroup()
+	}
+	// A bare digit run only contributes its first digit, as in TeX: x^23
+	if t := p.peek(); t.kind == texNumber && len(t.text) > 1 {
+		p.tokens[p.pos].text = t.text[1:]
+		return "<mn>" + t.text[:1] + "</mn>", nil
+	}
+	node, err := p.parseAtom()
+	return node.markup, err
+}
+
+// parseGroup parses a {...} group and returns its content
+func (p *texParser) parseGroup() (string, error) {
+	if p.next().kind != texOpen {
+		return "", fmt.Errorf("expected {")
+	}
+	body, err := p.parseExpr()
+	if err != nil {
+		return "", err
+	}
+	if p.next().kind != texClose {
+		return "", fmt.Errorf("unbalanced braces")
+	}
+	return wrapRow(body), nil
+}
+
+// rawGroup returns the literal text inside a {...} group, used for \text
+// and environment names where the content is not math
+func (p *texParser) rawGroup() (string, error) {
+	if p.next().kind != texOpen {
+		return "", fmt.Errorf("expected {")
+	}
+	var b strings.Builder
+	depth := 0
+	for !p.done() {
+		t := p.next()
+		if t.spaced && b.Len() > 0 {
+			b.WriteString(" ")
+		}
+		switch t.kind {
+		case texOpen:
+			depth++
+		case texClose:
+			if depth == 0 {
+				return b.String(), nil
+			}
+			depth--
+		case texCommand:
+			if t.text == " " {
+				b.WriteString(" ")
+				continue
+			}
+			b.WriteString("\\" + t.text)
+			continue
+		}
+		b.WriteString(t.text)
+	}
+	return "", fmt.Errorf("unbalanced braces")
+}
+
+// optionalArgument parses a [...] argument if present
+func (p *texParser) optionalArgument() (string, bool, error) {
+	if p.peek().kind != texSymbol || p.peek().text != "[" {
+		return "", false, nil
+	}
+	p.next()
+	var b strings.Builder
+	for !p.done() && !(p.peek().kind == texSymbol && p.peek().text == "]") {
+		node, err := p.parseScripted()
+		if err != nil {
+			return "", false, err
+		}
+		b.WriteString(node.markup)
+	}
+	if p.done() {
+		return "", false, fmt.Errorf("unclosed [")
+	}
+	p.next()
+	return wrapRow(b.String()), true, nil
+}
+
+// parseAtom parses a single symbol, group or command
+func (p *texParser) parseAtom() (mathNode, error) {
+	t := p.next()
+	switch t.kind {
+	case texOpen:
+		p.pos--
+		g, err := p.parseGroup()
+		return mathNode{markup: g}, err
+	case texNumber:
+		return mathNode{markup: "<mn>" + t.text + "</mn>"}, nil
+	case texLetter:
+		return mathNode{markup: "<mi>" + t.text + "</mi>"}, nil
+	case texSymbol:
+		return mathNode{markup: operator(t.text)}, nil
+	case texCommand:
+		return p.parseCommand(t.text)
+	case texSup, texSub:
+		return mathNode{}, fmt.Errorf("script without base")
+	}
+	return mathNode{}, fmt.Errorf("unexpected end of expression")
+}
+
+func operator(sym string) string {
+	switch sym {
+	case "(", ")", "[", "]", "|":
+		return `<mo stretchy="false">` + html.EscapeString(sym) + "</mo>"
+	}
+	return "<mo>" + html.EscapeString(sym) + "</mo>"
+}
+
+// wrapRow groups several MathML children so they act as a single argument
+func wrapRow(markup string) string {
+	if isSingleElement(markup) {
+		return markup
+	}
+	return "<mrow>" + markup + "</mrow>"
+}
+
+// isSingleElement reports whether markup consists of exactly one top-level
+// element
+func isSingleElement(markup string) bool {
+	if markup == "" {
+		return false
+	}
+	depth, roots := 0, 0
+	for i := 0; i < len(markup); i++ {
+		if markup[i] != '<' {
+			continue
+		}
+		end := strings.IndexByte(markup[i:], '>')
+		if end < 0 {
+			return false
+		}
+		tag := markup[i : i+end+1]
+		switch {
+		case strings.HasPrefix(tag, "</"):
+			depth--
+		case strings.HasSuffix(tag, "/>"):
+			if depth == 0 {
+				roots++
+			}
+		default:
+			if depth == 0 {
+				roots++
+			}
+			depth++
+		}
+		i += end
+	}
+	return roots == 1
+}
+
+func (p *texParser) parseCommand(name string) (mathNode, error) {
+	if sym, ok := texIdentifiers[name]; ok {
+		return mathNode{markup: "<mi>" + sym + "</mi>"}, nil
+	}
+	if sym, ok := texOperators[name]; ok {
+		return mathNode{markup: "<mo>" + html.EscapeString(sym) + "</mo>"}, nil
+	}
+	if sym, ok := texLargeOperators[name]; ok {
+		integral := strings.Contains(name, "int")
+		return mathNode{markup: `<mo largeop="true" movablelimits="true">` + sym + "</mo>", limits: !integral}, nil
+	}
+	if texFunctions[name] {
+		_, limits := texLimitFunctions[name]
+		return mathNode{markup: `<mi mathvariant="normal">` + name + "</mi>", limits: limits}, nil
+	}
+	if width, ok := texSpaces[name]; ok {
+		return mathNode{markup: `<mspace width="` + width + `"/>`}, nil
+	}
+	if accent, ok := texAccents[name]; ok {
+		arg, err := p.parseArgument()
+		if err != nil {
+			return mathNode{}, err
+		}
+		return mathNode{markup: `<mover accent="true">` + wrapRow(arg) + `<mo stretchy="true">` + accent + "</mo></mover>"}, nil
+	}
+	if variant, ok := texFontVariants[name]; ok {
+		return p.parseFontVariant(variant)
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac":
+		num, err := p.parseArgument()
+		if err != nil {
+			return mathNode{}, err
+		}
+		den, err := p.parseArgument()
+		if err != nil {
+			return mathNode{}, err
+		}
+		return mathNode{markup: "<mfrac>" + wrapRow(num) + wrapRow(den) + "</mfrac>"}, nil
+	case "binom":
+		top, err := p.parseArgument()
+		if err != nil {
+			return mathNode{}, err
+		}
+		bottom, err := p.parseArgument()
+		if err != nil {
+			return mathNode{}, err
+		}
+		return mathNode{markup: `<mrow><mo>(</mo><mfrac linethickness="0">` + wrapRow(top) + wrapRow(bottom) + "</mfrac><mo>)</mo></mrow>"}, nil
+	case "sqrt":
+		index, hasIndex, err := p.optionalArgument()
+		if err != nil {
+			return mathNode{}, err
+		}
+		radicand, err := p.parseArgument()
+		if err != nil {
+			return mathNode{}, err
+		}
+		if hasIndex {
+			return mathNode{markup: "<mroot>" + wrapRow(radicand) + index + "</mroot>"}, nil
+		}
+		return mathNode{markup: "<msqrt>" + radicand + "</msqrt>"}, nil
+	case "text", "textrm", "textit", "textbf", "mbox":
+		text, err := p.rawGroup()
+		if err != nil {
+			return mathNode{}, err
+		}
+		return mathNode{markup: "<mtext>" + html.EscapeString(text) + "</mtext>"}, nil
+	case "operatorname":
+		text, err := p.rawGroup()
+		if err != nil {
+			return mathNode{}, err
+		}
+		return mathNode{markup: `<mi mathvariant="normal">` + html.EscapeString(text) + "</mi>"}, nil
+	case "overline", "underline", "overbrace", "underbrace":
+		arg, err := p.parseArgument()
+		if err != nil {
+			return mathNode{}, err
+		}
+		switch name {
+		case "overline":
+			return mathNode{markup: `<mover accent="true">` + wrapRow(arg) + `<mo stretchy="true">‾</mo></mover>`}, nil
+		case "underline":
+			return mathNode{markup: `<munder accentunder="true">` + wrapRow(arg) + `<mo stretchy="true">_</mo></munder>`}, nil
+		case "overbrace":
+			return mathNode{markup: "<mover>" + wrapRow(arg) + `<mo stretchy="true">⏞</mo></mover>`, limits: true}, nil
+		default:
+			return mathNode{markup: "<munder>" + wrapRow(arg) + `<mo stretchy="true">⏟</mo></munder>`, limits: true}, nil
+		}
+	case "left":
+		return p.parseLeftRight()
+	case "begin":
+		return p.parseEnvironment()
+	}
+
+	return mathNode{}, fmt.Errorf("unsupported command \\%s", name)
+}
+
+// parseFontVariant handles \mathbf{...} and friends by applying a mathvariant
+// to the identifiers inside the argument
+func (p *texParser) parseFontVariant(variant string) (mathNode, error) {
+	arg, err := p.parseArgument()
+	if err != nil {
+		return mathNode{}, err
+	}
+	arg = strings.ReplaceAll(arg, "<mi>", `<mi mathvariant="`+variant+`">`)
+	arg = strings.ReplaceAll(arg, "<mn>", `<mn mathvariant="`+variant+`">`)
+	return mathNode{markup: arg}, nil
+}
+
+// delimiter reads the delimiter following \left or \right
+func (p *texParser) delimiter() (string, error) {
+	t := p.next()
+	switch t.kind {
+	case texSymbol:
+		if t.text == "." {
+			return "", nil
+		}
+		return html.EscapeString(t.text), nil
+	case texCommand:
+		if sym, ok := texDelimiters[t.text]; ok {
+			return sym, nil
+		}
+		return "", fmt.Errorf("unsupported delimiter \\%s", t.text)
+	}
+	return "", fmt.Errorf("missing delimiter")
+}
+
+func (p *texParser) parseLeftRight() (mathNode, error) {
+	open, err := p.delimiter()
+	if err != nil {
+		return mathNode{}, err
+	}
+	body, err := p.parseExpr()
+	if err != nil {
+		return mathNode{}, err
+	}
+	if t := p.next(); t.kind != texCommand || t.text != "right" {
+		return mathNode{}, fmt.Errorf("\\left without matching \\right")
+	}
+	closing, err := p.delimiter()
+	if err != nil {
+		return mathNode{}, err
+	}
+
+	var b strings.Builder
+	b.WriteString("<mrow>")
+	if open != "" {
+		b.WriteString(`<mo fence="true" stretchy="true">` + open + "</mo>")
+	}
+	b.WriteString(body)
+	if closing != "" {
+		b.WriteString(`<mo fence="true" stretchy="true">` + closing + "</mo>")
+	}
+	b.WriteString("</mrow>")
+	return mathNode{markup: b.String()}, nil
+}
+
+// parseEnvironment renders \begin{name}...\end{name} as an mtable, wrapped
+// in the delimiters the environment implies
+func (p *texParser) parseEnvironment() (mathNode, error) {
+	name, err := p.rawGroup()
+	if err != nil {
+		return mathNode{}, err
+	}
+	delims, ok := texEnvironments[name]
+	if !ok {
+		return mathNode{}, fmt.Errorf("unsupported environment %s", name)
+	}
+	if name == "array" {
+		// The column specification does not affect the rendered output
+		if _, err := p.rawGroup(); err != nil {
+			return mathNode{}, err
+		}
+	}
+
+	var rows []string
+	var cells []string
+	for {
+		cell, err := p.parseExpr()
+		if err != nil {
+			return mathNode{}, err
+		}
+		cells = append(cells, "<mtd>"+cell+"</mtd>")
+
+		t := p.next()
+		switch {
+		case t.kind == texAlign:
+			continue
+		case t.kind == texCommand && (t.text == "\\" || t.text == "cr"):
+			rows = append(rows, "<mtr>"+strings.Join(cells, "")+"</mtr>")
+			cells = nil
+			continue
+		case t.kind == texCommand && t.text == "end":
+			end, err := p.rawGroup()
+			if err != nil {
+				return mathNode{}, err
+			}
+			if end != name {
+				return mathNode{}, fmt.Errorf("\\begin{%s} ended by \\end{%s}", name, end)
+			}
+		default:
+			return mathNode{}, fmt.Errorf("unterminated environment %s", name)
+		}
+		break
+	}
+	if len(cells) > 1 || cells[0] != "<mtd></mtd>" {
+		rows = append(rows, "<mtr>"+strings.Join(cells, "")+"</mtr>")
+	}
+
+	table := "<mtable"
+	if name == "cases" || strings.HasPrefix(name, "align") {
+		table += ` columnalign="left"`
+	}
+	table += ">" + strings.Join(rows, "") + "</mtable>"
+
+	if delims[0] == "" && delims[1] == "" {
+		return mathNode{markup: table}, nil
+	}
+	markup := "<mrow>"
+	if delims[0] != "" {
+		markup += `<mo fence="true" stretchy="true">` + delims[0] + "</mo>"
+	}
+	markup += table
+	if delims[1] != "" {
+		markup += `<mo fence="true" stretchy="true">` + delims[1] + "</mo>"
+	}
+	return mathNode{markup: markup + "</mrow>"}, nil
+}
+
+var texIdentifiers = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ", "varepsilon": "ε",
+	"zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ",
+	"lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π", "varpi": "ϖ", "rho": "ρ",
+	"varrho": "ϱ", "sigma": "σ", "varsigma": "ς", "tau": "τ", "upsilon": "υ", "phi": "ϕ",
+	"varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π",
+	"Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+	"infty": "∞", "partial": "∂", "nabla": "∇", "emptyset": "∅", "varnothing": "∅",
+	"hbar": "ℏ", "ell": "ℓ", "Re": "ℜ", "Im": "ℑ", "aleph": "ℵ", "wp": "℘",
+}
+
+var texOperators = map[string]string{
+	"cdot": "⋅", "times": "×", "div": "÷", "pm": "±", "mp": "∓", "ast": "∗", "star": "⋆",
+	"circ": "∘", "bullet": "∙", "oplus": "⊕", "otimes": "⊗", "odot": "⊙",
+	"leq": "≤", "le": "≤", "geq": "≥", "ge": "≥", "neq": "≠", "ne": "≠", "approx": "≈",
+	"equiv": "≡", "sim": "∼", "simeq": "≃", "cong": "≅", "propto": "∝", "ll": "≪", "gg": "≫",
+	"in": "∈", "notin": "∉", "ni": "∋", "subset": "⊂", "subseteq": "⊆", "supset": "⊃",
+	"supseteq": "⊇", "cup": "∪", "cap": "∩", "setminus": "∖", "perp": "⊥", "parallel": "∥",
+	"mid": "∣", "to": "→", "rightarrow": "→", "leftarrow": "←", "gets": "←",
+	"Rightarrow": "⇒", "Leftarrow": "⇐", "Leftrightarrow": "⇔", "leftrightarrow": "↔",
+	"mapsto": "↦", "implies": "⟹", "iff": "⟺", "uparrow": "↑", "downarrow": "↓",
+	"forall": "∀", "exists": "∃", "nexists": "∄", "neg": "¬", "lnot": "¬", "land": "∧",
+	"wedge": "∧", "lor": "∨", "vee": "∨", "ldots": "…", "dots": "…", "cdots": "⋯",
+	"vdots": "⋮", "ddots": "⋱", "langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋",
+	"lceil": "⌈", "rceil": "⌉", "{": "{", "}": "}", "|": "‖", "vert": "|", "Vert": "‖",
+	"colon": ":", "prime": "′", "%": "%", "$": "$", "#": "#", "&": "&", "_": "_",
+	"angle": "∠", "triangle": "△", "therefore": "∴", "because": "∵",
+}
+
+var texLargeOperators = map[string]string{
+	"sum": "∑", "prod": "∏", "coprod": "∐", "int": "∫", "iint": "∬", "iiint": "∭",
+	"oint": "∮", "bigcup": "⋃", "bigcap": "⋂", "bigoplus": "⨁", "bigotimes": "⨂",
+	"bigvee": "⋁", "bigwedge": "⋀",
+}
+
+var texFunctions = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "cot": true, "sec": true, "csc": true,
+	"arcsin": true, "arccos": true, "arctan": true, "sinh": true, "cosh": true, "tanh": true,
+	"log": true, "ln": true, "lg": true, "exp": true, "det": true, "dim": true, "ker": true,
+	"deg": true, "gcd": true, "hom": true, "arg": true, "min": true, "max": true,
+	"sup": true, "inf": true, "lim": true, "liminf": true, "limsup": true, "Pr": true,
+	"argmin": true, "argmax": true,
+}
+
+var texLimitFunctions = map[string]struct{}{
+	"det": {}, "gcd": {}, "min": {}, "max": {}, "sup": {}, "inf": {}, "lim": {},
+	"liminf": {}, "limsup": {}, "Pr": {}, "argmin": {}, "argmax": {},
+}
+
+var texSpaces = map[string]string{
+	",": "0.1667em", ":": "0.2222em", ">": "0.2222em", ";": "0.2778em",
+	"!": "-0.1667em", " ": "0.2778em", "quad": "1em", "qquad": "2em",
+}
+
+var texAccents = map[string]string{
+	"hat": "^", "widehat": "^", "bar": "¯", "vec": "→", "dot": "˙", "ddot": "¨",
+	"tilde": "~", "widetilde": "~", "check": "ˇ", "breve": "˘", "acute": "´", "grave": "`",
+}
+
+var texFontVariants = map[string]string{
+	"mathbf": "bold", "mathit": "italic", "mathrm": "normal", "mathbb": "double-struck",
+	"mathcal": "script", "mathscr": "script", "mathfrak": "fraktur", "mathsf": "sans-serif",
+	"mathtt": "monospace", "boldsymbol": "bold-italic", "bm": "bold-italic",
+}
+
+var texDelimiters = map[string]string{
+	"{": "{", "}": "}", "|": "‖", "langle": "⟨", "rangle": "⟩", "lfloor": "⌊",
+	"rfloor": "⌋", "lceil": "⌈", "rceil": "⌉", "vert": "|", "Vert": "‖",
+}
+
+var texEnvironments = map[string][2]string{
+	"matrix": {"", ""}, "smallmatrix": {"", ""}, "pmatrix": {"(", ")"}, "bmatrix": {"[", "]"},
+	"Bmatrix": {"{", "}"}, "vmatrix": {"|", "|"}, "Vmatrix": {"‖", "‖"}, "cases": {"{", ""},
+	"aligned": {"", ""}, "align": {"", ""}, "align*": {"", ""}, "gathered": {"", ""},
+	"array": {"", ""}, "split": {"", ""},
+}
@@ -0,0 +1,155 @@
+package markdown
+
+import (
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+// ToPlainText converts markdown content to plain text. Markup is removed
+// while paragraph structure is kept: blocks are separated by blank lines,
+// list items are written one per line with simple markers and table cells
+// are separated by tabs. Raw HTML is dropped, links keep their text and
+// images are replaced by their alt text.
+func (s *Service) ToPlainText(markdown string) string {
+	doc := s.Parse(markdown)
+	return strings.Join(doc.plainBlocks(true), "\n\n")
+}
+
+// plainBlocks returns the plain text of each top-level block of the
+// document. Code blocks are left out unless code is set.
+func (d *Document) plainBlocks(code bool) []string {
+	w := &plainTextWriter{doc: d, code: code}
+	for node := d.Root.FirstChild; node != nil; node = node.Next {
+		w.block(node)
+	}
+	return w.blocks
+}
+
+// plainTextWriter collects the plain text blocks of a document
+type plainTextWriter struct {
+	doc    *Document
+	code   bool
+	blocks []string
+}
+
+func (w *plainTextWriter) add(text string) {
+	if strings.TrimSpace(text) != "" {
+		w.blocks = append(w.blocks, text)
+	}
+}
+
+func (w *plainTextWriter) block(node *blackfriday.Node) {
+	switch node.Type {
+	case blackfriday.Paragraph, blackfriday.Heading:
+		w.add(w.inline(node))
+	case blackfriday.List:
+		w.add(strings.Join(w.listLines(node, ""), "\n"))
+	case blackfriday.BlockQuote:
+		if callout, ok := w.doc.Callout(node); ok {
+			w.add(callout.Title)
+		}
+		for child := node.FirstChild; child != nil; child = child.Next {
+			w.block(child)
+		}
+	case blackfriday.CodeBlock:
+		if w.code {
+			w.add(strings.TrimRight(w.doc.Text(node.Literal), "\n"))
+		}
+	case blackfriday.Table:
+		w.add(strings.Join(w.tableLines(node), "\n"))
+	case blackfriday.HorizontalRule, blackfriday.HTMLBlock:
+		// Nothing to write; the surrounding blocks stay separate
+	default:
+		for child := node.FirstChild; child != nil; child = child.Next {
+			w.block(child)
+		}
+	}
+}
+
+// listLines writes each list item on its own line, with nested content
+// indented under the item
+func (w *plainTextWriter) listLines(list *blackfriday.Node, indent string) []string {
+	var lines []string
+	number := 1
+	for item := list.FirstChild; item != nil; item = item.Next {
+		marker := "- "
+		if list.ListData.ListFlags&blackfriday.ListTypeOrdered != 0 {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		first := true
+		for child := item.FirstChild; child != nil; child = child.Next {
+			if child.Type == blackfriday.List {
+				lines = append(lines, w.listLines(child, indent+"  ")...)
+				continue
+			}
+			sub := &plainTextWriter{doc: w.doc, code: w.code}
+			sub.block(child)
+			for _, block := range sub.blocks {
+				for _, line := range strings.Split(block, "\n") {
+					if first {
+						lines = append(lines, indent+marker+line)
+						first = false
+					} else {
+						lines = append(lines, indent+strings.Repeat(" ", len(marker))+line)
+					}
+				}
+			}
+		}
+		if first {
+			lines = append(lines, indent+strings.TrimSpace(marker))
+		}
+	}
+	return lines
+}
+
+// tableLines writes each table row on its own line with tab-separated cells
+func (w *plainTextWriter) tableLines(table *blackfriday.Node) []string {
+	var lines []string
+	table.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering || node.Type != blackfriday.TableRow {
+			return blackfriday.GoToNext
+		}
+		var cells []string
+		for cell := node.FirstChild; cell != nil; cell = cell.Next {
+			cells = append(cells, w.inline(cell))
+		}
+		lines = append(lines, strings.Join(cells, "\t"))
+		return blackfriday.SkipChildren
+	})
+	return lines
+}
+
+// inline returns the text of the inline children of a block. Line breaks
+// inside a paragraph become spaces unless they are hard breaks.
+func (w *plainTextWriter) inline(parent *blackfriday.Node) string {
+	var b strings.Builder
+	var walk func(parent *blackfriday.Node)
+	walk = func(parent *blackfriday.Node) {
+		for node := parent.FirstChild; node != nil; node = node.Next {
+			switch node.Type {
+			case blackfriday.Text:
+				b.WriteString(strings.ReplaceAll(html.UnescapeString(w.doc.Text(node.Literal)), "\n", " "))
+			case blackfriday.Code:
+				b.WriteString(w.doc.Text(node.Literal))
+			case blackfriday.Softbreak:
+				b.WriteByte(' ')
+			case blackfriday.Hardbreak:
+				b.WriteByte('\n')
+			case blackfriday.HTMLSpan:
+			default:
+				walk(node)
+			}
+		}
+	}
+	walk(parent)
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,69 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownService_ToPlainText(t *testing.T) {
+	service := NewService()
+
+	tests := []struct {
+		name     string
+		markdown string
+		expected string
+	}{
+		{
+			name:     "inline markup",
+			markdown: "Some **bold**, *italic*, ~~struck~~ and `code` with a [link](https://example.com).",
+			expected: "Some bold, italic, struck and code with a link.",
+		},
+		{
+			name:     "paragraphs and headings",
+			markdown: "# Title\n\nFirst paragraph\nwrapped over lines.\n\nSecond paragraph.",
+			expected: "Title\n\nFirst paragraph wrapped over lines.\n\nSecond paragraph.",
+		},
+		{
+			name:     "hard break",
+			markdown: "Roses are red  \nViolets are blue",
+			expected: "Roses are red\nViolets are blue",
+		},
+		{
+			name:     "lists",
+			markdown: "- one\n- two\n  1. nested\n  2. items\n\nAfter.",
+			expected: "- one\n- two\n  1. nested\n  2. items\n\nAfter.",
+		},
+		{
+			name:     "code block",
+			markdown: "Before.\n\n```go\nfunc main() {\n\tprintln()\n}\n```\n\nAfter.",
+			expected: "Before.\n\nfunc main() {\n\tprintln()\n}\n\nAfter.",
+		},
+		{
+			name:     "table",
+			markdown: "| Name | Value |\n|------|-------|\n| a    | 1     |",
+			expected: "Name\tValue\na\t1",
+		},
+		{
+			name:     "images, html and entities",
+			markdown: "![a chart](chart.png) shows <b>growth</b> &amp; more.\n\n<div>dropped</div>\n\n---\n\nEnd.",
+			expected: "a chart shows growth & more.\n\nEnd.",
+		},
+		{
+			name:     "callout",
+			markdown: "> [!WARNING] Careful\n> Hot surface.",
+			expected: "Careful\n\nHot surface.",
+		},
+		{
+			name:     "math keeps its source",
+			markdown: "Energy is $E = mc^2$.",
+			expected: "Energy is $E = mc^2$.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, service.ToPlainText(tt.markdown))
+		})
+	}
+}
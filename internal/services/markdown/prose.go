@@ -0,0 +1,336 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+// Kinds of prose block
+const (
+	ProseParagraph = "paragraph"
+	ProseHeading   = "heading"
+	ProseListItem  = "list-item"
+	ProseTableCell = "table-cell"
+)
+
+// ProsePlaceholder stands in the prose for inline code, math, images and
+// URLs. It is neither a letter, a digit, a space nor punctuation.
+const ProsePlaceholder = "\ufffc"
+
+// ProseBlock is the prose of a paragraph, heading, list item or table
+// cell. Markup is removed; inline code, math, images and URLs are replaced
+// by ProsePlaceholder, and inline HTML tags are dropped.
+type ProseBlock struct {
+	Kind string
+	Text string
+
+	fragments []proseFragment
+}
+
+// proseFragment maps a range of prose text to the source it came from.
+// Exact fragments are a copy of the source, so every byte maps to the
+// same byte in it; other fragments map as a whole.
+type proseFragment struct {
+	start, end       int
+	srcStart, srcEnd int
+	exact            bool
+}
+
+// Source maps a byte range of the prose text to the range of markdown
+// source it came from
+func (b ProseBlock) Source(start, end int) (srcStart, srcEnd int) {
+	srcStart = b.sourceStart(start)
+	if end <= start {
+		return srcStart, srcStart
+	}
+	return srcStart, b.sourceEnd(end)
+}
+
+// sourceStart maps the offset of the first byte of a range to the source
+func (b ProseBlock) sourceStart(offset int) int {
+	end := 0
+	for _, f := range b.fragments {
+		if offset >= f.start && offset < f.end {
+			if f.exact {
+				return f.srcStart + offset - f.start
+			}
+			return f.srcStart
+		}
+		if f.end <= offset {
+			end = f.srcEnd
+		}
+	}
+	return end
+}
+
+// sourceEnd maps the offset just past the last byte of a range to the
+// source
+func (b ProseBlock) sourceEnd(offset int) int {
+	for _, f := range b.fragments {
+		if offset > f.start && offset <= f.end {
+			if f.exact {
+				return f.srcStart + offset - f.start
+			}
+			return f.srcEnd
+		}
+	}
+	return b.sourceStart(offset)
+}
+
+// Prose returns the prose blocks of a note in order. Code blocks, HTML
+// blocks and front matter have no prose.
+func (s *Service) Prose(markdown string) []ProseBlock {
+	front, body := splitFrontMatter(markdown)
+	w := &proseWriter{doc: s.Parse(body), source: body, offset: len(front)}
+	w.walk()
+	return w.blocks
+}
+
+// HTMLFragment is a line of an HTML block, or an inline HTML tag or
+// comment, with its byte offset in the note
+type HTMLFragment struct {
+	Text   string
+	Offset int
+}
+
+// HTML returns the raw HTML of a note in order: the lines of HTML blocks
+// and the inline tags and comments of its text. HTML written in code or
+// escaped is text, and is not returned.
+func (s *Service) HTML(markdown string) []HTMLFragment {
+	front, body := splitFrontMatter(markdown)
+	w := &proseWriter{doc: s.Parse(body), source: body, offset: len(front)}
+	w.walk()
+	return w.html
+}
+
+// walk collects the prose blocks of the document
+func (w *proseWriter) walk() {
+	w.doc.Root.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering {
+			return blackfriday.GoToNext
+		}
+		switch node.Type {
+		case blackfriday.Paragraph, blackfriday.Heading, blackfriday.TableCell:
+			w.block(node)
+			return blackfriday.SkipChildren
+		case blackfriday.CodeBlock:
+			w.skip(node.Literal, false)
+			return blackfriday.SkipChildren
+		case blackfriday.HTMLBlock:
+			w.skip(node.Literal, true)
+			return blackfriday.SkipChildren
+		}
+		return blackfriday.GoToNext
+	})
+}
+
+// proseWriter collects prose blocks while moving through the source in
+// step with the syntax tree
+type proseWriter struct {
+	doc    *Document
+	source string
+	// offset is the position of source in the note
+	offset int
+	// cursor is where the next literal is searched for in the source
+	cursor int
+	blocks []ProseBlock
+	html   []HTMLFragment
+
+	text      strings.Builder
+	fragments []proseFragment
+
+	// spans, when not nil, collects the parts of the literals of text
+	// nodes that are copied from the source as is
+	spans map[*blackfriday.Node][]textSpan
+	// starts, when not nil, collects where the literals of text nodes
+	// start in the source, when they are found
+	starts map[*blackfriday.Node]int
+	// node is the text node whose literal is added, and at the offset in
+	// it of the next piece
+	node *blackfriday.Node
+	at   int
+}
+
+// textSpan maps a range of the literal of a text node to the source,
+// byte for byte
+type textSpan struct {
+	start, end int
+	src        int
+}
+
+// find returns the position of s in the source after the cursor, or -1
+func (w *proseWriter) find(s string) int {
+	if s == "" {
+		return -1
+	}
+	i := strings.Index(w.source[w.cursor:], s)
+	if i < 0 {
+		return -1
+	}
+	return w.cursor + i
+}
+
+// skip moves the cursor past a literal that has no prose, collecting its
+// lines when it is HTML
+func (w *proseWriter) skip(literal []byte, html bool) {
+	lines := strings.Split(strings.TrimRight(string(literal), "\n"), "\n")
+	for _, line := range lines {
+		if i := w.find(line); i >= 0 {
+			w.cursor = i + len(line)
+			if html {
+				w.html = append(w.html, HTMLFragment{Text: line, Offset: w.offset + i})
+			}
+		}
+	}
+}
+
+func (w *proseWriter) block(node *blackfriday.Node) {
+	kind := ProseParagraph
+	switch {
+	case node.Type == blackfriday.Heading:
+		kind = ProseHeading
+	case node.Type == blackfriday.TableCell:
+		kind = ProseTableCell
+	case node.Parent != nil && node.Parent.Type == blackfriday.Item:
+		kind = ProseListItem
+	}
+
+	w.text.Reset()
+	w.fragments = nil
+	w.inline(node)
+	if strings.TrimSpace(w.text.String()) == "" {
+		return
+	}
+	w.blocks = append(w.blocks, ProseBlock{Kind: kind, Text: w.text.String(), fragments: w.fragments})
+}
+
+// inlineLink matches the source between the text and the destination of
+// an inline link
+var inlineLink = regexp.MustCompile(`^\]\s*\(\s*<?$`)
+
+func (w *proseWriter) inline(parent *blackfriday.Node) {
+	for node := parent.FirstChild; node != nil; node = node.Next {
+		switch node.Type {
+		case blackfriday.Text:
+			w.node = node
+			w.literal(string(node.Literal))
+			w.node = nil
+		case blackfriday.Code:
+			w.placeholder(string(node.Literal))
+		case blackfriday.HTMLSpan:
+			if i := w.find(string(node.Literal)); i >= 0 {
+				w.cursor = i + len(node.Literal)
+				w.html = append(w.html, HTMLFragment{Text: string(node.Literal), Offset: w.offset + i})
+			}
+		case blackfriday.Hardbreak, blackfriday.Softbreak:
+			w.add("\n", w.cursor, w.cursor, false)
+		case blackfriday.Image:
+			w.placeholder(string(node.LinkData.Destination))
+		case blackfriday.Link:
+			if isURLText(w.doc.NodeText(node), string(node.LinkData.Destination)) {
+				w.placeholder(w.doc.NodeText(node))
+				continue
+			}
+			w.inline(node)
+			// Move past an inline destination, so later text is not
+			// found in it
+			dest := string(node.LinkData.Destination)
+			if i := w.find(dest); i >= 0 && inlineLink.MatchString(w.source[w.cursor:i]) {
+				w.cursor = i + len(dest)
+			}
+		default:
+			w.inline(node)
+		}
+	}
+}
+
+// isURLText reports whether link text is a URL, as in autolinks
+func isURLText(text, destination string) bool {
+	if text == destination || "mailto:"+text == destination {
+		return true
+	}
+	lower := strings.ToLower(text)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") ||
+		strings.HasPrefix(lower, "ftp://") || strings.HasPrefix(lower, "www.")
+}
+
+// literal adds text from the tree, split into the lines and math spans
+// that are found in the source separately
+func (w *proseWriter) literal(text string) {
+	w.at = 0
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			w.add("\n", w.cursor, w.cursor, false)
+			w.at++
+		}
+		for line != "" {
+			next := strings.IndexRune(line, mathTokenStart)
+			if next < 0 {
+				next = len(line)
+			}
+			if next == 0 {
+				n, width, ok := readMathToken(line)
+				if !ok || n >= len(w.doc.math) {
+					width = len(string(mathTokenStart))
+					w.piece(line[:width])
+				} else {
+					w.placeholder(w.doc.math[n].source())
+				}
+				line = line[width:]
+				w.at += width
+				continue
+			}
+			w.piece(line[:next])
+			line = line[next:]
+			w.at += next
+		}
+	}
+}
+
+// piece adds text found in the source. Entities the parser kept are
+// decoded; text that is not found maps to the cursor.
+func (w *proseWriter) piece(piece string) {
+	i := w.find(piece)
+	if i < 0 {
+		w.add(html.UnescapeString(piece), w.cursor, w.cursor, false)
+		return
+	}
+	w.cursor = i + len(piece)
+	if w.starts != nil && w.node != nil && w.at == 0 {
+		w.starts[w.node] = w.offset + i
+	}
+	if decoded := html.UnescapeString(piece); decoded != piece {
+		w.add(decoded, i, w.cursor, false)
+		return
+	}
+	w.add(piece, i, w.cursor, true)
+	if w.spans != nil && w.node != nil {
+		w.spans[w.node] = append(w.spans[w.node], textSpan{start: w.at, end: w.at + len(piece), src: w.offset + i})
+	}
+}
+
+// placeholder adds a placeholder for source text that is not prose
+func (w *proseWriter) placeholder(source string) {
+	i := w.find(source)
+	if i < 0 {
+		w.add(ProsePlaceholder, w.cursor, w.cursor, false)
+		return
+	}
+	w.cursor = i + len(source)
+	w.add(ProsePlaceholder, i, w.cursor, false)
+}
+
+func (w *proseWriter) add(text string, srcStart, srcEnd int, exact bool) {
+	start := w.text.Len()
+	w.text.WriteString(text)
+	w.fragments = append(w.fragments, proseFragment{
+		start:    start,
+		end:      w.text.Len(),
+		srcStart: w.offset + srcStart,
+		srcEnd:   w.offset + srcEnd,
+		exact:    exact,
+	})
+}
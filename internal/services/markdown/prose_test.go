@@ -0,0 +1,66 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownService_Prose(t *testing.T) {
+	service := NewService()
+
+	input := "---\ntitle: Front matter is skipped\n---\n" +
+		"# A *heading*\n\n" +
+		"Some **bold** text with `code`, $x+1$ math,\nhttps://example.com and a [link](https://example.com/x) here.\n\n" +
+		"```go\nfmt.Println(\"code block\")\n```\n\n" +
+		"<div>\nhtml block\n</div>\n\n" +
+		"- item *one*\n- item &amp; two\n\n" +
+		"> quoted ![image](a.png) text\n\n" +
+		"| cell | other |\n|---|---|\n| x | y  z |\n"
+
+	blocks := service.Prose(input)
+	var kinds, texts []string
+	for _, block := range blocks {
+		kinds = append(kinds, block.Kind)
+		texts = append(texts, block.Text)
+	}
+	assert.Equal(t, []string{ProseHeading, ProseParagraph, ProseListItem, ProseListItem, ProseParagraph, ProseTableCell, ProseTableCell, ProseTableCell, ProseTableCell}, kinds)
+	assert.Equal(t, []string{
+		"A heading",
+		"Some bold text with " + ProsePlaceholder + ", " + ProsePlaceholder + " math,\n" + ProsePlaceholder + " and a link here.",
+		"item one",
+		"item & two",
+		"quoted " + ProsePlaceholder + " text",
+		"cell", "other", "x", "y  z",
+	}, texts)
+
+	// Every word of prose maps back to the same word in the source
+	for _, block := range blocks {
+		for _, loc := range regexp.MustCompile(`\pL+`).FindAllStringIndex(block.Text, -1) {
+			start, end := block.Source(loc[0], loc[1])
+			assert.Equal(t, block.Text[loc[0]:loc[1]], input[start:end])
+		}
+	}
+
+	// Placeholders and entities map to their whole source
+	paragraph := blocks[1]
+	code := strings.Index(paragraph.Text, ProsePlaceholder)
+	start, end := paragraph.Source(code, code+len(ProsePlaceholder))
+	assert.Equal(t, "code", input[start:end])
+	math := strings.Index(paragraph.Text, ProsePlaceholder+" math")
+	start, end = paragraph.Source(math, math+len(ProsePlaceholder))
+	assert.Equal(t, "$x+1$", input[start:end])
+	amp := strings.Index(blocks[3].Text, "&")
+	start, end = blocks[3].Source(amp, amp+1)
+	assert.Equal(t, "&", input[start:end])
+
+	// The end of a block maps to the end of its last text
+	start, end = paragraph.Source(len(paragraph.Text), len(paragraph.Text))
+	assert.Equal(t, start, end)
+	assert.True(t, strings.HasSuffix(input[:start], "here."))
+
+	require.Empty(t, service.Prose("```\ncode\n```\n"))
+}
@@ -0,0 +1,80 @@
+package markdown
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+// htmlRenderer extends blackfriday's HTML renderer with the server-side
+// extensions this service supports. Nodes it does not handle itself are
+// passed through to the embedded renderer.
+type htmlRenderer struct {
+	*blackfriday.HTMLRenderer
+	service  *Service
+	callouts map[*blackfriday.Node]Callout
+	xhtml    bool
+}
+
+func (s *Service) newHTMLRenderer() *htmlRenderer {
+	return &htmlRenderer{
+		HTMLRenderer: blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{
+			Flags: blackfriday.CommonHTMLFlags,
+		}),
+		service: s,
+	}
+}
+
+// newXHTMLRenderer returns a renderer whose output is well-formed XML. Raw
+// HTML from the note is skipped as it cannot be trusted to be well-formed.
+func (s *Service) newXHTMLRenderer() *htmlRenderer {
+	return &htmlRenderer{
+		HTMLRenderer: blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{
+			Flags: blackfriday.CommonHTMLFlags | blackfriday.SkipHTML,
+		}),
+		service: s,
+		xhtml:   true,
+	}
+}
+
+// RenderNode renders a single node, intercepting diagram code blocks and
+// callout blockquotes
+func (r *htmlRenderer) RenderNode(w io.Writer, node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+	if callout, ok := r.callouts[node]; ok {
+		if entering {
+			newline(w)
+		}
+		r.renderCallout(w, callout, entering)
+		return blackfriday.GoToNext
+	}
+	if node.Type == blackfriday.CodeBlock {
+		if lang := codeBlockLanguage(node); isDiagramLanguage(lang) {
+			io.WriteString(w, r.service.renderDiagram(lang, string(node.Literal)))
+			return blackfriday.GoToNext
+		}
+	}
+	return r.HTMLRenderer.RenderNode(w, node, entering)
+}
+
+// codeBlockLanguage returns the first word of a fenced code block's info
+// string
+func codeBlockLanguage(node *blackfriday.Node) string {
+	info := node.CodeBlockData.Info
+	for i, b := range info {
+		if b == ' ' || b == '\t' || b == '{' {
+			return string(info[:i])
+		}
+	}
+	return string(info)
+}
+
+// newline starts a new line unless the output is empty or already ends with
+// one, mirroring how blackfriday separates block elements
+func newline(w io.Writer) {
+	if buf, ok := w.(*bytes.Buffer); ok {
+		if b := buf.Bytes(); len(b) > 0 && b[len(b)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+}
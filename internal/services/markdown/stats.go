@@ -0,0 +1,150 @@
+package markdown
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/russross/blackfriday/v2"
+)
+
+// wordsPerMinute is the average silent reading speed of adults for
+// non-fiction text
+const wordsPerMinute = 238
+
+// Stats returns reading statistics for markdown content. Word, character
+// and sentence counts and the readability scores are computed from the
+// plain text of the note without its code blocks.
+func (s *Service) Stats(markdown string) models.NoteStats {
+	doc := s.Parse(markdown)
+
+	var stats models.NoteStats
+	doc.Root.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering {
+			return blackfriday.GoToNext
+		}
+		switch node.Type {
+		case blackfriday.Heading:
+			stats.Headings++
+		case blackfriday.Link:
+			stats.Links++
+		case blackfriday.Image:
+			stats.Images++
+		case blackfriday.CodeBlock:
+			stats.CodeBlocks++
+		}
+		return blackfriday.GoToNext
+	})
+
+	syllables := 0
+	blocks := doc.plainBlocks(false)
+	for _, block := range blocks {
+		stats.Characters += utf8.RuneCountInString(block)
+		for _, r := range block {
+			if !unicode.IsSpace(r) {
+				stats.CharactersNoSpaces++
+			}
+		}
+		// Every line of a block, such as a heading or a list item, ends a
+		// sentence even without closing punctuation
+		for _, line := range strings.Split(block, "\n") {
+			stats.Sentences += countSentences(line)
+		}
+		for _, word := range strings.Fields(block) {
+			if !isWord(word) {
+				continue
+			}
+			stats.Words++
+			syllables += countSyllables(word)
+		}
+	}
+	// Blocks are separated by a blank line in the plain text
+	if len(blocks) > 1 {
+		stats.Characters += 2 * (len(blocks) - 1)
+	}
+
+	stats.ReadingTimeSeconds = int(math.Round(float64(stats.Words) * 60 / wordsPerMinute))
+	stats.ReadingTimeMinutes = (stats.ReadingTimeSeconds + 59) / 60
+
+	if stats.Words > 0 && stats.Sentences > 0 {
+		wordsPerSentence := float64(stats.Words) / float64(stats.Sentences)
+		syllablesPerWord := float64(syllables) / float64(stats.Words)
+		stats.FleschReadingEase = round1(206.835 - 1.015*wordsPerSentence - 84.6*syllablesPerWord)
+		stats.FleschKincaidGrade = round1(0.39*wordsPerSentence + 11.8*syllablesPerWord - 15.59)
+	}
+	return stats
+}
+
+// isWord reports whether a whitespace-separated token contains a letter or
+// digit, so stray punctuation such as dashes is not counted
+func isWord(token string) bool {
+	return strings.IndexFunc(token, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsNumber(r)
+	}) >= 0
+}
+
+// countSentences counts the sentences in a line of text. A sentence ends
+// at a run of ., ! or ? followed by whitespace or the end of the line;
+// text after the last terminator counts as one more sentence.
+func countSentences(line string) int {
+	count := 0
+	pending := false
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			pending = true
+			continue
+		}
+		if !pending || (r != '.' && r != '!' && r != '?') {
+			continue
+		}
+		j := i
+		for j < len(runes) && strings.ContainsRune(".!?\"')’”", runes[j]) {
+			j++
+		}
+		if j == len(runes) || unicode.IsSpace(runes[j]) {
+			count++
+			pending = false
+		}
+		i = j - 1
+	}
+	if pending {
+		count++
+	}
+	return count
+}
+
+// countSyllables estimates the syllables in an English word by counting
+// groups of vowels, ignoring a silent final e
+func countSyllables(word string) int {
+	word = strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}))
+	if word == "" {
+		return 1
+	}
+
+	count := 0
+	previousVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !previousVowel {
+			count++
+		}
+		previousVowel = vowel
+	}
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && count > 1 {
+		count--
+	}
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,76 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownService_Stats(t *testing.T) {
+	service := NewService()
+
+	content := "# Getting Started\n\n" +
+		"The cat sat on the mat. It was happy! Was it warm?\n\n" +
+		"See [the docs](https://example.com) and ![a diagram](d.png).\n\n" +
+		"```sh\nmake build && make test\n```\n\n" +
+		"- Version 1.5 is out\n- Upgrade soon\n"
+	stats := service.Stats(content)
+
+	assert.Equal(t, 1, stats.Headings)
+	assert.Equal(t, 1, stats.Links)
+	assert.Equal(t, 1, stats.Images)
+	assert.Equal(t, 1, stats.CodeBlocks)
+	// Heading, three sentences, the link paragraph and two list items
+	assert.Equal(t, 7, stats.Sentences)
+	// Code is not counted; "-" list markers are not words
+	assert.Equal(t, 26, stats.Words)
+	assert.Equal(t, 1, stats.ReadingTimeMinutes)
+	assert.Equal(t, 7, stats.ReadingTimeSeconds)
+	assert.Greater(t, stats.Characters, stats.CharactersNoSpaces)
+	assert.Greater(t, stats.FleschReadingEase, 60.0, "short simple sentences are easy to read")
+	assert.Less(t, stats.FleschKincaidGrade, 8.0)
+}
+
+func TestMarkdownService_Stats_Empty(t *testing.T) {
+	stats := NewService().Stats("")
+	assert.Zero(t, stats.Words)
+	assert.Zero(t, stats.Sentences)
+	assert.Zero(t, stats.ReadingTimeMinutes)
+	assert.Zero(t, stats.FleschReadingEase)
+}
+
+func TestCountSentences(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{"One. Two! Three?", 3},
+		{"No terminator", 1},
+		{"Pi is 3.14 exactly.", 1},
+		{"Wait... what?!", 2},
+		{"He said \"stop.\" Then left.", 2},
+		{"...", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.expected, countSentences(tt.text))
+		})
+	}
+}
+
+func TestCountSyllables(t *testing.T) {
+	tests := map[string]int{
+		"cat":         1,
+		"table":       2,
+		"make":        1,
+		"readability": 5,
+		"Why?":        1,
+		"42":          1,
+	}
+
+	for word, expected := range tests {
+		assert.Equal(t, expected, countSyllables(word), word)
+	}
+}
@@ -0,0 +1,113 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+// Task is a GFM task list item, such as
+// "- [ ] Write the report @due(2026-11-01) @alice"
+type Task struct {
+	// Line is the 1-based line of the item in the note
+	Line int
+	// Text is the item text without its annotations
+	Text string
+	Done bool
+	// Due is the date of a valid @due(YYYY-MM-DD) annotation
+	Due *time.Time
+	// Owner is the first @name annotation, without the @
+	Owner string
+
+	// checkbox is the offset of the character between the brackets
+	checkbox int
+}
+
+var (
+	taskMarker   = regexp.MustCompile(`^\[([ xX])\][ \t]+`)
+	duePattern   = regexp.MustCompile(`(?:^|\s)@due\(([^)]*)\)`)
+	ownerPattern = regexp.MustCompile(`(?:^|\s)@([\pL\pN_][\pL\pN_.-]*)`)
+)
+
+// dueLayout is the date format of @due annotations
+const dueLayout = "2006-01-02"
+
+// Tasks returns the task list items of a note in order: list items whose
+// text starts with a [ ] or [x] checkbox. Items are taken from the syntax
+// tree, so text in code and HTML blocks or front matter is not a task.
+func (s *Service) Tasks(markdown string) []Task {
+	front, body := splitFrontMatter(markdown)
+	w := &proseWriter{doc: s.Parse(body), source: body, offset: len(front), starts: map[*blackfriday.Node]int{}}
+	w.walk()
+
+	var tasks []Task
+	w.doc.Root.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering || node.Type != blackfriday.Item {
+			return blackfriday.GoToNext
+		}
+		para := node.FirstChild
+		if para == nil || para.Type != blackfriday.Paragraph || para.FirstChild == nil || para.FirstChild.Type != blackfriday.Text {
+			return blackfriday.GoToNext
+		}
+		m := taskMarker.FindSubmatch(para.FirstChild.Literal)
+		start, ok := w.starts[para.FirstChild]
+		if m == nil || !ok || markdown[start] != '[' {
+			return blackfriday.GoToNext
+		}
+
+		task := Task{
+			Line:     strings.Count(markdown[:start], "\n") + 1,
+			Done:     string(m[1]) != " ",
+			checkbox: start + 1,
+		}
+		// The plain text starts with the checkbox, as whitespace is
+		// collapsed
+		text := strings.TrimPrefix(w.doc.NodeText(para), "["+string(m[1])+"]")
+
+		if due := duePattern.FindStringSubmatch(text); due != nil {
+			if date, err := time.Parse(dueLayout, strings.TrimSpace(due[1])); err == nil {
+				task.Due = &date
+			}
+		}
+		text = duePattern.ReplaceAllString(text, " ")
+		if owner := ownerPattern.FindStringSubmatch(text); owner != nil {
+			task.Owner = strings.TrimRight(owner[1], ".")
+		}
+		text = ownerPattern.ReplaceAllString(text, " ")
+
+		task.Text = strings.Join(strings.Fields(text), " ")
+		tasks = append(tasks, task)
+		return blackfriday.GoToNext
+	})
+	return tasks
+}
+
+// TaskAt returns the task on a line of a note
+func (s *Service) TaskAt(markdown string, line int) (Task, bool) {
+	for _, task := range s.Tasks(markdown) {
+		if task.Line == line {
+			return task, true
+		}
+	}
+	return Task{}, false
+}
+
+// SetTaskDone checks or unchecks the task on a line of a note, returning
+// the updated note. Only its checkbox changes.
+func (s *Service) SetTaskDone(markdown string, line int, done bool) (string, error) {
+	task, ok := s.TaskAt(markdown, line)
+	if !ok {
+		return "", fmt.Errorf("no task on line %d", line)
+	}
+	if task.Done == done {
+		return markdown, nil
+	}
+	mark := " "
+	if done {
+		mark = "x"
+	}
+	return markdown[:task.checkbox] + mark + markdown[task.checkbox+1:], nil
+}
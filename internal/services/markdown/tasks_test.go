@@ -0,0 +1,72 @@
+package markdown
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownService_Tasks(t *testing.T) {
+	service := NewService()
+
+	input := "---\ntitle: Plan\n---\n" +
+		"# Plan\n\n" +
+		"- [ ] Write the report @due(2026-11-01) @alice\n" +
+		"- [x] Book the room @bob.\n" +
+		"- plain item\n" +
+		"1. [X] Numbered @due(not-a-date)\n\n" +
+		"> - [ ] Quoted task\n\n" +
+		"```\n- [ ] in code\n```\n\n" +
+		"Example:\n\n    - [ ] indented code\n\n" +
+		"<div>\n- [ ] in html\n</div>\n\n" +
+		"- [ ] Read *the* `docs`\n\n" +
+		"Mail me at me@example.com\n"
+
+	tasks := service.Tasks(input)
+	for i := range tasks {
+		tasks[i].checkbox = 0
+	}
+	due := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, []Task{
+		{Line: 6, Text: "Write the report", Due: &due, Owner: "alice"},
+		{Line: 7, Text: "Book the room", Done: true, Owner: "bob"},
+		{Line: 9, Text: "Numbered", Done: true},
+		{Line: 11, Text: "Quoted task"},
+		{Line: 25, Text: "Read the docs"},
+	}, tasks)
+
+	assert.Empty(t, service.Tasks("No tasks here"))
+}
+
+func TestMarkdownService_SetTaskDone(t *testing.T) {
+	service := NewService()
+	input := "# Plan\n\n- [ ] First\n- [x] Second\n\n```\n- [ ] code\n```\n"
+
+	updated, err := service.SetTaskDone(input, 3, true)
+	require.NoError(t, err)
+	assert.Equal(t, "# Plan\n\n- [x] First\n- [x] Second\n\n```\n- [ ] code\n```\n", updated)
+
+	updated, err = service.SetTaskDone(input, 4, false)
+	require.NoError(t, err)
+	assert.Equal(t, "# Plan\n\n- [ ] First\n- [ ] Second\n\n```\n- [ ] code\n```\n", updated)
+
+	// Setting the current state changes nothing
+	updated, err = service.SetTaskDone(input, 4, true)
+	require.NoError(t, err)
+	assert.Equal(t, input, updated)
+
+	for _, line := range []int{0, 1, 7, 100} {
+		_, err = service.SetTaskDone(input, line, true)
+		assert.Error(t, err, "line %d", line)
+	}
+
+	// Task-like lines in indented code and HTML blocks are not rewritten
+	input = "Example:\n\n    - [ ] code\n\n<div>\n- [ ] html\n</div>\n"
+	assert.Empty(t, service.Tasks(input))
+	for _, line := range []int{3, 6} {
+		_, err = service.SetTaskDone(input, line, true)
+		assert.Error(t, err, "line %d", line)
+	}
+}
@@ -0,0 +1,40 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+// namedEntity matches character references; XML only predefines five names
+var namedEntity = regexp.MustCompile(`&[A-Za-z][A-Za-z0-9]*;`)
+
+// ToXHTML renders top-level nodes of a parsed document as well-formed XHTML,
+// e.g. one chapter of an e-book. Raw HTML in the note is left out.
+func (s *Service) ToXHTML(doc *Document, nodes []*blackfriday.Node) string {
+	renderer := s.newXHTMLRenderer()
+	renderer.callouts = doc.callouts
+
+	var buf bytes.Buffer
+	for _, node := range nodes {
+		node.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+			return renderer.RenderNode(&buf, n, entering)
+		})
+	}
+
+	// Smartypants emits HTML entities such as &ldquo; that XML parsers
+	// reject, so replace them with the characters themselves
+	out := namedEntity.ReplaceAllStringFunc(buf.String(), func(entity string) string {
+		switch entity {
+		case "&amp;", "&lt;", "&gt;", "&quot;", "&apos;":
+			return entity
+		}
+		if text := html.UnescapeString(entity); text != entity {
+			return text
+		}
+		return "&amp;" + entity[1:]
+	})
+	return restoreMath(out, doc.math)
+}
@@ -0,0 +1,99 @@
+package spelling
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Cases of a word
+const (
+	caseLower = iota
+	caseTitle
+	caseUpper
+	caseMixed
+)
+
+// wordCase classifies the letters of a word as all lowercase, capitalized,
+// all uppercase or mixed, as in "iPhone"
+func wordCase(word string) int {
+	upper, lower, first := 0, 0, false
+	for i, r := range word {
+		switch {
+		case unicode.IsUpper(r) || unicode.IsTitle(r):
+			upper++
+			if i == 0 {
+				first = true
+			}
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	switch {
+	case upper == 0:
+		return caseLower
+	case lower == 0 && upper > 1:
+		return caseUpper
+	case upper == 1 && first:
+		return caseTitle
+	}
+	return caseMixed
+}
+
+// capitalize uppercases the first letter of a word
+func capitalize(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	if size == 0 {
+		return word
+	}
+	return string(unicode.ToTitle(r)) + word[size:]
+}
+
+// caseVariants returns the forms a word may have in a dictionary other
+// than as written: "Apple" may be "apple", and "APPLE" may be "apple" or
+// "Apple". Lowercase and mixed case words must match exactly.
+func caseVariants(word string) []string {
+	switch wordCase(word) {
+	case caseTitle:
+		return []string{strings.ToLower(word)}
+	case caseUpper:
+		lower := strings.ToLower(word)
+		return []string{lower, capitalize(lower)}
+	}
+	return nil
+}
+
+// applyCase gives a suggestion the case of the misspelled word
+func applyCase(suggestion, word string) string {
+	switch wordCase(word) {
+	case caseTitle:
+		return capitalize(suggestion)
+	case caseUpper:
+		return strings.ToUpper(suggestion)
+	}
+	return suggestion
+}
+
+// Check reports whether a word is spelled correctly. Typographic
+// apostrophes match straight ones, and capitalized and uppercase words
+// match lowercase stems, unless the stem has the KEEPCASE flag.
+func (d *Dictionary) Check(word string) bool {
+	if word == "" {
+		return true
+	}
+	return d.check(word, lookup{}) ||
+		(strings.ContainsRune(word, '’') && d.check(strings.ReplaceAll(word, "’", "'"), lookup{}))
+}
+
+func (d *Dictionary) check(word string, lk lookup) bool {
+	if d.valid(word, lk) {
+		return true
+	}
+	lk.folded = true
+	for _, variant := range caseVariants(word) {
+		if d.valid(variant, lk) {
+			return true
+		}
+	}
+	return false
+}
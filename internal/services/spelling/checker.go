@@ -0,0 +1,62 @@
+package spelling
+
+import "strings"
+
+// Checker checks words against a Hunspell dictionary and a custom
+// dictionary
+type Checker struct {
+	dictionary *Dictionary
+	custom     *CustomDictionary
+}
+
+// NewChecker creates a checker. The custom dictionary may be nil.
+func NewChecker(dictionary *Dictionary, custom *CustomDictionary) *Checker {
+	return &Checker{
+		dictionary: dictionary,
+		custom:     custom,
+	}
+}
+
+// Check reports whether a word is in either dictionary
+func (c *Checker) Check(word string) bool {
+	if c.custom != nil && c.custom.Check(word) {
+		return true
+	}
+	return c.dictionary == nil || c.dictionary.Check(word)
+}
+
+// Suggest returns up to MaxSuggestions corrections of a misspelled word
+// from both dictionaries, best first. Custom words rank before dictionary
+// words that are further from the misspelling.
+func (c *Checker) Suggest(word string) []string {
+	var suggestions []string
+	if c.dictionary != nil {
+		suggestions = c.dictionary.Suggest(word)
+	}
+	if c.custom == nil {
+		return suggestions
+	}
+
+	lower := strings.ToLower(word)
+	for _, custom := range c.custom.Suggest(word) {
+		d := distance(lower, strings.ToLower(custom))
+		at := len(suggestions)
+		for i, s := range suggestions {
+			if s == custom {
+				at = -1
+				break
+			}
+			if distance(lower, strings.ToLower(s)) > d && at == len(suggestions) {
+				at = i
+			}
+		}
+		if at < 0 {
+			continue
+		}
+		suggestions = append(suggestions[:at], append([]string{custom}, suggestions[at:]...)...)
+	}
+	if len(suggestions) > MaxSuggestions {
+		suggestions = suggestions[:MaxSuggestions]
+	}
+	return suggestions
+}
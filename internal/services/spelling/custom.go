@@ -0,0 +1,204 @@
+package spelling
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxWordLength is the longest word, in characters, a custom dictionary
+// accepts
+const maxWordLength = 100
+
+// CustomDictionary is a list of extra correct words, such as product
+// names, kept in a text file with one word per line. Words match like
+// dictionary stems: "Acme" also matches "ACME" but not "acme".
+type CustomDictionary struct {
+	// path is the file the words are saved to; empty keeps them in memory
+	path string
+
+	mu    sync.RWMutex
+	words map[string]bool
+	// version counts the changes to the words
+	version int
+}
+
+// NewCustomDictionary creates an empty custom dictionary kept in memory
+func NewCustomDictionary() *CustomDictionary {
+	return &CustomDictionary{words: map[string]bool{}}
+}
+
+// LoadCustomDictionary loads a custom dictionary from a file, which is
+// created when words are added if it does not exist
+func LoadCustomDictionary(path string) (*CustomDictionary, error) {
+	c := &CustomDictionary{path: path, words: map[string]bool{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if word := strings.TrimSpace(line); word != "" && !strings.HasPrefix(word, "#") {
+			c.words[word] = true
+		}
+	}
+	return c, nil
+}
+
+// ValidateWord checks that a word can be added to a custom dictionary: it
+// is not empty, has no spaces and contains a letter
+func ValidateWord(word string) error {
+	if word == "" {
+		return fmt.Errorf("invalid word: empty")
+	}
+	if utf8.RuneCountInString(word) > maxWordLength {
+		return fmt.Errorf("invalid word %q: longer than %d characters", word, maxWordLength)
+	}
+	if strings.IndexFunc(word, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("invalid word %q: contains a space", word)
+	}
+	if strings.IndexFunc(word, unicode.IsLetter) < 0 {
+		return fmt.Errorf("invalid word %q: contains no letter", word)
+	}
+	return nil
+}
+
+// Words returns the words in alphabetical order
+func (c *CustomDictionary) Words() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	words := make([]string, 0, len(c.words))
+	for word := range c.words {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
+// Add adds words and saves the dictionary. Nothing is added if any word is
+// invalid.
+func (c *CustomDictionary) Add(words ...string) error {
+	for _, word := range words {
+		if err := ValidateWord(word); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	added := []string{}
+	for _, word := range words {
+		if !c.words[word] {
+			c.words[word] = true
+			added = append(added, word)
+		}
+	}
+	if err := c.save(); err != nil {
+		for _, word := range added {
+			delete(c.words, word)
+		}
+		return err
+	}
+	if len(added) > 0 {
+		c.version++
+	}
+	return nil
+}
+
+// Remove removes a word and saves the dictionary. It returns an error
+// containing "not found" if the word is not in the dictionary.
+func (c *CustomDictionary) Remove(word string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.words[word] {
+		return fmt.Errorf("word %q not found", word)
+	}
+	delete(c.words, word)
+	if err := c.save(); err != nil {
+		c.words[word] = true
+		return err
+	}
+	c.version++
+	return nil
+}
+
+// Version returns a number that changes whenever words are added or
+// removed, so that results of checks can be cached until then
+func (c *CustomDictionary) Version() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.version
+}
+
+// Check reports whether a word is in the dictionary, as written or with
+// the case changed as for dictionary stems
+func (c *CustomDictionary) Check(word string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.words[word] || c.words[strings.ReplaceAll(word, "’", "'")] {
+		return true
+	}
+	for _, variant := range caseVariants(word) {
+		if c.words[variant] {
+			return true
+		}
+	}
+	return false
+}
+
+// Suggest returns the words within maxDistance edits of a misspelled word,
+// nearest first
+func (c *CustomDictionary) Suggest(word string) []string {
+	lower := strings.ToLower(word)
+	type scored struct {
+		word     string
+		distance int
+	}
+	var found []scored
+	for _, w := range c.Words() {
+		if d := distance(lower, strings.ToLower(w)); d <= maxDistance && w != word {
+			found = append(found, scored{w, d})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].distance < found[j].distance })
+
+	suggestions := make([]string, len(found))
+	for i, s := range found {
+		suggestions[i] = s.word
+	}
+	return suggestions
+}
+
+// save writes the words to the file through a temporary file, so that a
+// failed write keeps the old list. The caller holds the lock.
+func (c *CustomDictionary) save() error {
+	if c.path == "" {
+		return nil
+	}
+	words := make([]string, 0, len(c.words))
+	for word := range c.words {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	data := strings.Join(words, "\n")
+	if len(words) > 0 {
+		data += "\n"
+	}
+	if err := os.WriteFile(tmp, []byte(data), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
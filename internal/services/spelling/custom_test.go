@@ -0,0 +1,65 @@
+package spelling
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomDictionary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words", "dictionary.txt")
+	custom, err := LoadCustomDictionary(path)
+	require.NoError(t, err)
+	assert.Empty(t, custom.Words())
+
+	require.NoError(t, custom.Add("Zentrix", "kubectl", "Zentrix"))
+	assert.Equal(t, []string{"Zentrix", "kubectl"}, custom.Words())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Zentrix\nkubectl\n", string(data))
+
+	// Words match like dictionary stems
+	assert.True(t, custom.Check("Zentrix"))
+	assert.True(t, custom.Check("ZENTRIX"))
+	assert.False(t, custom.Check("zentrix"))
+	assert.True(t, custom.Check("Kubectl"))
+
+	reloaded, err := LoadCustomDictionary(path)
+	require.NoError(t, err)
+	assert.Equal(t, custom.Words(), reloaded.Words())
+
+	require.NoError(t, custom.Remove("kubectl"))
+	assert.Equal(t, []string{"Zentrix"}, custom.Words())
+	err = custom.Remove("kubectl")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+
+	for _, word := range []string{"", "two words", "1234"} {
+		err := custom.Add("Valid", word)
+		require.Error(t, err, word)
+		assert.Contains(t, err.Error(), "invalid word")
+	}
+	assert.Equal(t, []string{"Zentrix"}, custom.Words())
+}
+
+func TestChecker(t *testing.T) {
+	custom := NewCustomDictionary()
+	require.NoError(t, custom.Add("Zentrix"))
+	checker := NewChecker(loadTestDictionary(t), custom)
+
+	assert.True(t, checker.Check("cats"))
+	assert.True(t, checker.Check("Zentrix"))
+	assert.False(t, checker.Check("Zentrex"))
+	assert.Equal(t, []string{"Zentrix"}, checker.Suggest("Zentrex"))
+
+	// Custom words rank by their distance among dictionary words
+	require.NoError(t, custom.Add("cax"))
+	suggestions := checker.Suggest("xat")
+	require.NotEmpty(t, suggestions)
+	assert.Equal(t, "cat", suggestions[0])
+	assert.Contains(t, suggestions, "cax")
+}
@@ -0,0 +1,537 @@
+// Package spelling checks words against Hunspell dictionaries and a custom
+// word list.
+package spelling
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Flag types of the FLAG directive
+const (
+	flagChar = "char"
+	flagLong = "long"
+	flagNum  = "num"
+)
+
+// encodings maps the SET names of 8-bit encodings to their charmaps
+var encodings = map[string]*charmap.Charmap{
+	"ISO8859-1":  charmap.ISO8859_1,
+	"ISO8859-2":  charmap.ISO8859_2,
+	"ISO8859-3":  charmap.ISO8859_3,
+	"ISO8859-4":  charmap.ISO8859_4,
+	"ISO8859-5":  charmap.ISO8859_5,
+	"ISO8859-6":  charmap.ISO8859_6,
+	"ISO8859-7":  charmap.ISO8859_7,
+	"ISO8859-8":  charmap.ISO8859_8,
+	"ISO8859-9":  charmap.ISO8859_9,
+	"ISO8859-10": charmap.ISO8859_10,
+	"ISO8859-13": charmap.ISO8859_13,
+	"ISO8859-14": charmap.ISO8859_14,
+	"ISO8859-15": charmap.ISO8859_15,
+	"KOI8-R":     charmap.KOI8R,
+	"KOI8-U":     charmap.KOI8U,
+	"CP1251":     charmap.Windows1251,
+	"CP1252":     charmap.Windows1252,
+}
+
+// Dictionary is a Hunspell dictionary: the stems of a .dic file and the
+// affix rules of its .aff file. Words are checked by stripping at most one
+// prefix and one suffix; compounding and twofold suffixes are not
+// supported.
+type Dictionary struct {
+	words    map[string][]entry
+	prefixes []affix
+	suffixes []affix
+
+	// try lists the characters suggestions insert and replace, most
+	// frequent first
+	try          string
+	replacements []replacement
+	keyboard     []string
+
+	forbidden    string
+	noSuggest    string
+	keepCase     string
+	needAffix    string
+	onlyCompound string
+}
+
+// entry is one homonym of a stem with its flags
+type entry struct {
+	flags map[string]bool
+}
+
+func (e entry) has(flag string) bool {
+	return flag != "" && e.flags[flag]
+}
+
+// affix is one rule of a PFX or SFX class
+type affix struct {
+	flag      string
+	prefix    bool
+	cross     bool
+	strip     string
+	add       string
+	condition *regexp.Regexp
+}
+
+// replacement is an entry of the REP table, a common misspelling
+type replacement struct {
+	from, to string
+}
+
+// Load reads the dictionary name.aff and name.dic from a directory, such as
+// en_US from "/usr/share/hunspell". The error wraps os.ErrNotExist when
+// either file is missing.
+func Load(dir, name string) (*Dictionary, error) {
+	aff, err := os.ReadFile(filepath.Join(dir, name+".aff"))
+	if err != nil {
+		return nil, err
+	}
+	dic, err := os.ReadFile(filepath.Join(dir, name+".dic"))
+	if err != nil {
+		return nil, err
+	}
+	d, err := Parse(bytes.NewReader(aff), bytes.NewReader(dic))
+	if err != nil {
+		return nil, fmt.Errorf("dictionary %s: %w", name, err)
+	}
+	return d, nil
+}
+
+// Parse reads a dictionary from the contents of its .aff and .dic files
+func Parse(aff, dic io.Reader) (*Dictionary, error) {
+	d := &Dictionary{words: map[string][]entry{}}
+	p := &affParser{flagType: flagChar}
+
+	affData, err := io.ReadAll(aff)
+	if err != nil {
+		return nil, err
+	}
+	// The encoding is declared in the file itself, so find it first
+	if m := regexp.MustCompile(`(?m)^SET\s+(\S+)`).FindSubmatch(affData); m != nil {
+		p.encoding = strings.ToUpper(string(m[1]))
+	}
+	if affData, err = p.decode(affData); err != nil {
+		return nil, err
+	}
+	if err := p.parseAff(d, string(affData)); err != nil {
+		return nil, err
+	}
+
+	dicData, err := io.ReadAll(dic)
+	if err != nil {
+		return nil, err
+	}
+	if dicData, err = p.decode(dicData); err != nil {
+		return nil, err
+	}
+	if err := p.parseDic(d, string(dicData)); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// affParser holds the settings of an .aff file that the .dic file needs
+type affParser struct {
+	encoding string
+	flagType string
+	aliases  []string
+}
+
+// decode converts the contents of a file to UTF-8
+func (p *affParser) decode(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	switch p.encoding {
+	case "", "UTF-8":
+		if !utf8.Valid(data) {
+			return nil, fmt.Errorf("invalid UTF-8")
+		}
+		return data, nil
+	}
+	cm, ok := encodings[strings.ReplaceAll(p.encoding, "MICROSOFT-", "")]
+	if !ok {
+		return nil, fmt.Errorf("unsupported encoding %q", p.encoding)
+	}
+	return cm.NewDecoder().Bytes(data)
+}
+
+func (p *affParser) parseAff(d *Dictionary, data string) error {
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		fields := strings.Fields(lines[i])
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		arg := ""
+		if len(fields) > 1 {
+			arg = fields[1]
+		}
+
+		switch fields[0] {
+		case "FLAG":
+			switch arg {
+			case "long", "num":
+				p.flagType = arg
+			case "UTF-8":
+				p.flagType = flagChar
+			default:
+				return fmt.Errorf("line %d: unknown flag type %q", i+1, arg)
+			}
+		case "TRY":
+			d.try = arg
+		case "KEY":
+			d.keyboard = strings.Split(arg, "|")
+		case "FORBIDDENWORD":
+			d.forbidden = arg
+		case "NOSUGGEST":
+			d.noSuggest = arg
+		case "KEEPCASE":
+			d.keepCase = arg
+		case "NEEDAFFIX", "PSEUDOROOT":
+			d.needAffix = arg
+		case "ONLYINCOMPOUND":
+			d.onlyCompound = arg
+		case "AF":
+			n, body, err := p.table(lines, i, fields)
+			if err != nil {
+				return err
+			}
+			for _, f := range body {
+				if len(f) < 2 {
+					return fmt.Errorf("line %d: missing flags", i+1)
+				}
+				p.aliases = append(p.aliases, f[1])
+			}
+			i += n
+		case "REP":
+			n, body, err := p.table(lines, i, fields)
+			if err != nil {
+				return err
+			}
+			for _, f := range body {
+				if len(f) < 3 {
+					return fmt.Errorf("line %d: REP needs two patterns", i+1)
+				}
+				d.replacements = append(d.replacements, replacement{
+					from: strings.ReplaceAll(f[1], "_", " "),
+					to:   strings.ReplaceAll(f[2], "_", " "),
+				})
+			}
+			i += n
+		case "PFX", "SFX":
+			n, err := p.affixClass(d, lines, i, fields)
+			if err != nil {
+				return err
+			}
+			i += n
+		}
+	}
+	return nil
+}
+
+// table reads the lines of a directive that starts with a count, such as
+// "REP 2", returning how many lines it read and their fields
+func (p *affParser) table(lines []string, i int, header []string) (int, [][]string, error) {
+	if len(header) < 2 {
+		return 0, nil, fmt.Errorf("line %d: missing count", i+1)
+	}
+	count, err := strconv.Atoi(header[1])
+	if err != nil {
+		return 0, nil, fmt.Errorf("line %d: invalid count %q", i+1, header[1])
+	}
+	var body [][]string
+	n := 0
+	for len(body) < count {
+		n++
+		if i+n >= len(lines) {
+			return 0, nil, fmt.Errorf("line %d: expected %d %s entries, found %d", i+1, count, header[0], len(body))
+		}
+		fields := strings.Fields(lines[i+n])
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if fields[0] != header[0] {
+			return 0, nil, fmt.Errorf("line %d: expected %s entry", i+n+1, header[0])
+		}
+		body = append(body, fields)
+	}
+	return n, body, nil
+}
+
+// affixClass reads a PFX or SFX class such as "SFX D Y 4" and its rules
+// such as "SFX D y ied [^aeiou]y"
+func (p *affParser) affixClass(d *Dictionary, lines []string, i int, header []string) (int, error) {
+	if len(header) < 4 {
+		return 0, fmt.Errorf("line %d: invalid %s header", i+1, header[0])
+	}
+	flag, cross := header[1], header[2] == "Y"
+	n, body, err := p.table(lines, i, []string{header[0], header[3]})
+	if err != nil {
+		return 0, err
+	}
+
+	prefix := header[0] == "PFX"
+	for _, f := range body {
+		if len(f) < 4 || f[1] != flag {
+			return 0, fmt.Errorf("line %d: invalid %s rule", i+1, header[0])
+		}
+		a := affix{flag: flag, prefix: prefix, cross: cross, strip: f[2], add: f[3]}
+		if a.strip == "0" {
+			a.strip = ""
+		}
+		// Continuation classes after a slash are ignored
+		if slash := strings.IndexByte(a.add, '/'); slash >= 0 {
+			a.add = a.add[:slash]
+		}
+		if a.add == "0" {
+			a.add = ""
+		}
+		condition := "."
+		if len(f) > 4 {
+			condition = f[4]
+		}
+		if a.condition, err = compileCondition(condition, prefix); err != nil {
+			return 0, fmt.Errorf("line %d: invalid condition %q", i+1, condition)
+		}
+		if prefix {
+			d.prefixes = append(d.prefixes, a)
+		} else {
+			d.suffixes = append(d.suffixes, a)
+		}
+	}
+	return n, nil
+}
+
+// compileCondition converts an affix condition, a pattern of characters,
+// "." and bracket classes, to a regular expression matching the start or
+// end of a stem
+func compileCondition(condition string, prefix bool) (*regexp.Regexp, error) {
+	if condition == "." {
+		return nil, nil
+	}
+	var b strings.Builder
+	inClass := false
+	for _, r := range condition {
+		switch {
+		case r == '[' && !inClass:
+			inClass = true
+			b.WriteRune(r)
+		case r == ']' && inClass:
+			inClass = false
+			b.WriteRune(r)
+		case r == '^' && inClass:
+			b.WriteRune(r)
+		case r == '.' && !inClass:
+			b.WriteRune(r)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	if prefix {
+		return regexp.Compile("^(?:" + b.String() + ")")
+	}
+	return regexp.Compile("(?:" + b.String() + ")$")
+}
+
+// parseDic reads the stems of a .dic file: a count, then one stem per line
+// with optional flags after a slash, as in "cat/S"
+func (p *affParser) parseDic(d *Dictionary, data string) error {
+	lines := strings.Split(data, "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if i == 0 {
+			if _, err := strconv.Atoi(strings.TrimSpace(line)); err == nil {
+				continue
+			}
+		}
+		// Morphological fields follow a tab or a space
+		if end := strings.IndexAny(line, "\t "); end >= 0 {
+			line = line[:end]
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		word, flags := line, ""
+		for j := 0; j < len(line); j++ {
+			if line[j] == '\\' {
+				j++
+				continue
+			}
+			if line[j] == '/' && j > 0 {
+				word, flags = line[:j], line[j+1:]
+				break
+			}
+		}
+		word = strings.ReplaceAll(word, `\/`, "/")
+
+		e, err := p.entry(flags)
+		if err != nil {
+			return fmt.Errorf("dic line %d: %w", i+1, err)
+		}
+		d.words[word] = append(d.words[word], e)
+	}
+	return nil
+}
+
+// entry parses the flags of a stem, which may be an AF alias number
+func (p *affParser) entry(flags string) (entry, error) {
+	if flags == "" {
+		return entry{}, nil
+	}
+	if len(p.aliases) > 0 {
+		n, err := strconv.Atoi(flags)
+		if err != nil || n < 1 || n > len(p.aliases) {
+			return entry{}, fmt.Errorf("invalid flag alias %q", flags)
+		}
+		flags = p.aliases[n-1]
+	}
+	parsed, err := p.flags(flags)
+	if err != nil {
+		return entry{}, err
+	}
+	e := entry{flags: map[string]bool{}}
+	for _, flag := range parsed {
+		e.flags[flag] = true
+	}
+	return e, nil
+}
+
+// flags splits a string of flags according to the FLAG type
+func (p *affParser) flags(s string) ([]string, error) {
+	var flags []string
+	switch p.flagType {
+	case flagLong:
+		if len([]rune(s))%2 != 0 {
+			return nil, fmt.Errorf("odd number of characters in long flags %q", s)
+		}
+		runes := []rune(s)
+		for i := 0; i < len(runes); i += 2 {
+			flags = append(flags, string(runes[i:i+2]))
+		}
+	case flagNum:
+		for _, flag := range strings.Split(s, ",") {
+			if _, err := strconv.Atoi(flag); err != nil {
+				return nil, fmt.Errorf("invalid numeric flag %q", flag)
+			}
+			flags = append(flags, flag)
+		}
+	default:
+		for _, r := range s {
+			flags = append(flags, string(r))
+		}
+	}
+	return flags, nil
+}
+
+// Size returns the number of stems in the dictionary
+func (d *Dictionary) Size() int {
+	return len(d.words)
+}
+
+// lookup selects the stems a lookup accepts
+type lookup struct {
+	// folded is set when the case of the word was changed, which stems
+	// with the KEEPCASE flag do not allow
+	folded bool
+	// suggest is set for suggestions, which skip NOSUGGEST stems
+	suggest bool
+}
+
+// usable reports whether a lookup accepts an entry
+func (d *Dictionary) usable(e entry, lk lookup) bool {
+	return !e.has(d.forbidden) && !e.has(d.onlyCompound) &&
+		!(lk.folded && e.has(d.keepCase)) && !(lk.suggest && e.has(d.noSuggest))
+}
+
+// valid reports whether a word is a stem or a stem with affixes, exactly
+// as written
+func (d *Dictionary) valid(word string, lk lookup) bool {
+	entries := d.words[word]
+	for _, e := range entries {
+		if e.has(d.forbidden) {
+			return false
+		}
+	}
+	for _, e := range entries {
+		if d.usable(e, lk) && !e.has(d.needAffix) {
+			return true
+		}
+	}
+
+	for _, sfx := range d.suffixes {
+		if stem, ok := sfx.stem(word); ok && d.hasStem(stem, lk, sfx.flag) {
+			return true
+		}
+	}
+	for _, pfx := range d.prefixes {
+		stem, ok := pfx.stem(word)
+		if !ok {
+			continue
+		}
+		if d.hasStem(stem, lk, pfx.flag) {
+			return true
+		}
+		if !pfx.cross {
+			continue
+		}
+		for _, sfx := range d.suffixes {
+			if !sfx.cross {
+				continue
+			}
+			if root, ok := sfx.stem(stem); ok && d.hasStem(root, lk, pfx.flag, sfx.flag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasStem reports whether a stem has a usable entry with all the flags
+func (d *Dictionary) hasStem(stem string, lk lookup, flags ...string) bool {
+	for _, e := range d.words[stem] {
+		if !d.usable(e, lk) {
+			continue
+		}
+		all := true
+		for _, flag := range flags {
+			all = all && e.has(flag)
+		}
+		if all {
+			return true
+		}
+	}
+	return false
+}
+
+// stem removes the affix from a word and restores what it stripped,
+// reporting whether the affix could have produced the word
+func (a affix) stem(word string) (string, bool) {
+	var stem string
+	if a.prefix {
+		if !strings.HasPrefix(word, a.add) || len(word) == len(a.add) {
+			return "", false
+		}
+		stem = a.strip + word[len(a.add):]
+	} else {
+		if !strings.HasSuffix(word, a.add) || len(word) == len(a.add) {
+			return "", false
+		}
+		stem = word[:len(word)-len(a.add)] + a.strip
+	}
+	if a.condition != nil && !a.condition.MatchString(stem) {
+		return "", false
+	}
+	return stem, true
+}
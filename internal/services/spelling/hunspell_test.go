@@ -0,0 +1,163 @@
+package spelling
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadTestDictionary(t *testing.T) *Dictionary {
+	t.Helper()
+	d, err := Load("testdata", "en_test")
+	require.NoError(t, err)
+	return d
+}
+
+func TestLoad(t *testing.T) {
+	d := loadTestDictionary(t)
+	assert.Equal(t, 24, d.Size())
+	assert.Len(t, d.prefixes, 1)
+	assert.Len(t, d.suffixes, 9)
+	assert.Len(t, d.replacements, 2)
+
+	_, err := Load("testdata", "missing")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestDictionary_Check(t *testing.T) {
+	d := loadTestDictionary(t)
+
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{"cat", true},
+		{"cats", true},
+		{"catz", false},
+		{"cities", true},
+		{"citys", false},
+		{"days", true},
+		{"boxes", true},
+		{"boxs", false},
+		{"baked", true},
+		{"baking", true},
+		{"bakeing", false},
+		{"walked", true},
+		{"unhappy", true},
+		{"happy", true},
+		{"unkind", false},
+		{"kind", true},
+		// A stem that needs an affix
+		{"foot", false},
+		{"foots", true},
+		// Case
+		{"Cat", true},
+		{"CATS", true},
+		{"cAt", false},
+		{"Paris", true},
+		{"PARIS", true},
+		{"paris", false},
+		{"McDonald", true},
+		{"MCDONALD", false},
+		// Apostrophes
+		{"don't", true},
+		{"don’t", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			assert.Equal(t, tt.want, d.Check(tt.word))
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		aff     string
+		dic     string
+		valid   []string
+		invalid []string
+		wantErr string
+	}{
+		{
+			name:    "long flags",
+			aff:     "FLAG long\nSFX Aa Y 1\nSFX Aa 0 s .\n",
+			dic:     "1\nword/AaBb\n",
+			valid:   []string{"word", "words"},
+			invalid: []string{"wordz"},
+		},
+		{
+			name:  "numeric flags",
+			aff:   "FLAG num\nSFX 101 Y 1\nSFX 101 0 s .\n",
+			dic:   "1\nword/7,101\n",
+			valid: []string{"word", "words"},
+		},
+		{
+			name:  "flag aliases",
+			aff:   "AF 1\nAF S\nSFX S Y 1\nSFX S 0 s .\n",
+			dic:   "1\nword/1\n",
+			valid: []string{"word", "words"},
+		},
+		{
+			name:    "cross product",
+			aff:     "PFX R Y 1\nPFX R 0 re .\nSFX D Y 1\nSFX D 0 ed .\nSFX S N 1\nSFX S 0 s .\n",
+			dic:     "1\nwork/RDS\n",
+			valid:   []string{"rework", "reworked", "works"},
+			invalid: []string{"reworks"},
+		},
+		{
+			name:  "Latin-1",
+			aff:   "SET ISO8859-1\n",
+			dic:   "1\ncaf\xe9\n",
+			valid: []string{"café"},
+		},
+		{
+			name:  "morphological fields and escaped slash",
+			aff:   "",
+			dic:   "2\nword po:noun\nand\\/or\n",
+			valid: []string{"word", "and/or"},
+		},
+		{
+			name:    "unsupported encoding",
+			aff:     "SET EBCDIC\n",
+			dic:     "0\n",
+			wantErr: "unsupported encoding",
+		},
+		{
+			name:    "short affix class",
+			aff:     "SFX S Y 2\nSFX S 0 s .\n",
+			dic:     "0\n",
+			wantErr: "expected 2 SFX entries",
+		},
+		{
+			name:    "invalid alias",
+			aff:     "AF 1\nAF S\n",
+			dic:     "1\nword/2\n",
+			wantErr: "invalid flag alias",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Parse(strings.NewReader(tt.aff), strings.NewReader(tt.dic))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			for _, word := range tt.valid {
+				assert.True(t, d.Check(word), word)
+			}
+			for _, word := range tt.invalid {
+				assert.False(t, d.Check(word), word)
+			}
+		})
+	}
+}
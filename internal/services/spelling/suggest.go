@@ -0,0 +1,277 @@
+package spelling
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxSuggestions is the most suggestions returned for a word
+const MaxSuggestions = 5
+
+// maxDistance is the largest edit distance of suggestions found by
+// scanning the stems
+const maxDistance = 2
+
+// defaultTry is used for dictionaries without a TRY directive
+const defaultTry = "esianrtolcdugmphbyfvkwzxjq'"
+
+// candidate is a possible suggestion and how it was found
+type candidate struct {
+	word string
+	// common is set for known misspellings from the REP table and for
+	// case errors, which rank first
+	common bool
+}
+
+// Suggest returns up to MaxSuggestions corrections of a misspelled word,
+// best first. Known misspellings and case errors rank first, then
+// suggestions by edit distance, preferring typos of neighbouring keys and
+// a longer common prefix.
+func (d *Dictionary) Suggest(word string) []string {
+	if word == "" {
+		return nil
+	}
+	lk := lookup{suggest: true}
+	found := map[string]candidate{}
+	add := func(c candidate) {
+		if c.word == "" || c.word == word {
+			return
+		}
+		if old, ok := found[c.word]; ok && (old.common || !c.common) {
+			return
+		}
+		found[c.word] = c
+	}
+	// Candidates are checked exactly, so that edits inserting uppercase
+	// letters from TRY only suggest stems written that way
+	valid := func(s string) bool {
+		for _, part := range strings.Split(s, " ") {
+			if part == "" || !d.valid(part, lk) {
+				return false
+			}
+		}
+		return true
+	}
+
+	lower := strings.ToLower(word)
+	if wordCase(word) != caseTitle {
+		if title := capitalize(lower); d.valid(title, lk) {
+			add(candidate{word: title, common: true})
+		}
+	}
+	for _, rep := range d.replacements {
+		for _, s := range d.replace(lower, rep) {
+			if valid(s) {
+				add(candidate{word: s, common: true})
+			}
+		}
+	}
+	for _, s := range d.edits(lower) {
+		if valid(s) {
+			add(candidate{word: s})
+		}
+	}
+	if len(found) < MaxSuggestions {
+		d.scan(lower, lk, add)
+	}
+
+	candidates := make([]candidate, 0, len(found))
+	for _, c := range found {
+		candidates = append(candidates, c)
+	}
+	ranked := d.rank(lower, candidates)
+
+	var suggestions []string
+	seen := map[string]bool{}
+	for _, c := range ranked {
+		s := c.word
+		if wordCase(s) == caseLower {
+			s = applyCase(s, word)
+		}
+		if s == word || seen[s] {
+			continue
+		}
+		seen[s] = true
+		suggestions = append(suggestions, s)
+		if len(suggestions) == MaxSuggestions {
+			break
+		}
+	}
+	return suggestions
+}
+
+// replace applies a REP entry at each place it matches. "^" and "$"
+// anchor a pattern to the start or end of the word.
+func (d *Dictionary) replace(word string, rep replacement) []string {
+	from, to := rep.from, strings.TrimSuffix(strings.TrimPrefix(rep.to, "^"), "$")
+	atStart, atEnd := strings.HasPrefix(from, "^"), strings.HasSuffix(from, "$")
+	from = strings.TrimSuffix(strings.TrimPrefix(from, "^"), "$")
+	if from == "" {
+		return nil
+	}
+
+	var results []string
+	for i := 0; i+len(from) <= len(word); i++ {
+		if word[i:i+len(from)] != from || (atStart && i != 0) || (atEnd && i+len(from) != len(word)) {
+			continue
+		}
+		results = append(results, word[:i]+to+word[i+len(from):])
+	}
+	return results
+}
+
+// edits returns the strings one edit away from a word: a deleted,
+// swapped, replaced or inserted character, or a space splitting it in two
+func (d *Dictionary) edits(word string) []string {
+	try := d.try
+	if try == "" {
+		try = defaultTry
+	}
+	runes := []rune(word)
+	var edits []string
+	for i := range runes {
+		edits = append(edits, string(runes[:i])+string(runes[i+1:]))
+		if i+1 < len(runes) {
+			swapped := append([]rune(nil), runes...)
+			swapped[i], swapped[i+1] = swapped[i+1], swapped[i]
+			edits = append(edits, string(swapped))
+		}
+		if i > 0 {
+			edits = append(edits, string(runes[:i])+" "+string(runes[i:]))
+		}
+		for _, r := range try {
+			if r != runes[i] {
+				edits = append(edits, string(runes[:i])+string(r)+string(runes[i+1:]))
+			}
+		}
+	}
+	for i := 0; i <= len(runes); i++ {
+		for _, r := range try {
+			edits = append(edits, string(runes[:i])+string(r)+string(runes[i:]))
+		}
+	}
+	return edits
+}
+
+// scan adds the stems within maxDistance edits of a word, for misspellings
+// that are more than one edit away
+func (d *Dictionary) scan(word string, lk lookup, add func(candidate)) {
+	length := utf8.RuneCountInString(word)
+	for stem := range d.words {
+		n := utf8.RuneCountInString(stem)
+		if n < length-maxDistance || n > length+maxDistance {
+			continue
+		}
+		if distance(word, strings.ToLower(stem)) > maxDistance {
+			continue
+		}
+		if d.valid(stem, lk) {
+			add(candidate{word: stem})
+		}
+	}
+}
+
+// rank sorts candidates from best to worst
+func (d *Dictionary) rank(word string, candidates []candidate) []candidate {
+	type scored struct {
+		candidate
+		distance, keys, prefix int
+	}
+	list := make([]scored, len(candidates))
+	for i, c := range candidates {
+		lower := strings.ToLower(c.word)
+		list[i] = scored{
+			candidate: c,
+			distance:  distance(word, lower),
+			keys:      d.neighbourTypos(word, lower),
+			prefix:    commonPrefix(word, lower),
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		switch {
+		case a.common != b.common:
+			return a.common
+		case a.distance != b.distance:
+			return a.distance < b.distance
+		case a.keys != b.keys:
+			return a.keys > b.keys
+		case a.prefix != b.prefix:
+			return a.prefix > b.prefix
+		}
+		return a.word < b.word
+	})
+
+	ranked := make([]candidate, len(list))
+	for i, s := range list {
+		ranked[i] = s.candidate
+	}
+	return ranked
+}
+
+// neighbourTypos counts the characters of a word that differ from a
+// suggestion of the same length by a key next to them on the KEY rows
+func (d *Dictionary) neighbourTypos(word, suggestion string) int {
+	a, b := []rune(word), []rune(suggestion)
+	if len(a) != len(b) || len(d.keyboard) == 0 {
+		return 0
+	}
+	count := 0
+	for i := range a {
+		if a[i] != b[i] && d.neighbours(a[i], b[i]) {
+			count++
+		}
+	}
+	return count
+}
+
+func (d *Dictionary) neighbours(a, b rune) bool {
+	for _, row := range d.keyboard {
+		keys := []rune(row)
+		for i := 0; i+1 < len(keys); i++ {
+			if (keys[i] == a && keys[i+1] == b) || (keys[i] == b && keys[i+1] == a) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// commonPrefix returns the number of leading characters two words share
+func commonPrefix(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	n := 0
+	for n < len(ra) && n < len(rb) && ra[n] == rb[n] {
+		n++
+	}
+	return n
+}
+
+// distance returns the Damerau-Levenshtein distance between two strings:
+// the fewest insertions, deletions, replacements and swaps of adjacent
+// characters that turn one into the other
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
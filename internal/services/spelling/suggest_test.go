@@ -0,0 +1,52 @@
+package spelling
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDictionary_Suggest(t *testing.T) {
+	d := loadTestDictionary(t)
+
+	tests := []struct {
+		word string
+		// want are the first suggestions, in order
+		want []string
+	}{
+		{"teh", []string{"the"}},
+		{"alot", []string{"a lot"}},
+		{"paris", []string{"Paris"}},
+		{"ctas", []string{"cats"}},
+		{"kitchn", []string{"kitchen"}},
+		{"Kitchn", []string{"Kitchen"}},
+		{"KITCHN", []string{"KITCHEN"}},
+		{"xat", []string{"cat"}},
+		{"kitvhens", []string{"kitchens"}},
+		// Two edits away
+		{"ktichn", []string{"kitchen"}},
+		{"dann", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			got := d.Suggest(tt.word)
+			assert.LessOrEqual(t, len(got), MaxSuggestions)
+			if tt.want == nil {
+				assert.NotContains(t, got, "damn")
+				return
+			}
+			if assert.GreaterOrEqual(t, len(got), len(tt.want)) {
+				assert.Equal(t, tt.want, got[:len(tt.want)])
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, distance("cat", "cat"))
+	assert.Equal(t, 1, distance("cat", "cats"))
+	assert.Equal(t, 1, distance("cat", "act"))
+	assert.Equal(t, 3, distance("kitten", "sitting"))
+	assert.Equal(t, 1, distance("café", "cafe"))
+}
@@ -6,6 +6,9 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
@@ -20,9 +23,19 @@ type Storage interface {
 	Delete(id string) error
 }
 
-// FileStorage implements file-based storage for notes
+// MaxRevisions is the number of revisions of each note FileStorage keeps
+const MaxRevisions = 50
+
+// FileStorage implements file-based storage for notes. Each save is a new
+// revision of the note, and the content of the last MaxRevisions revisions
+// is kept in <id>.revisions.
 type FileStorage struct {
 	baseDir string
+
+	mu        sync.RWMutex
+	listeners []func(id string)
+	// metadataMu serializes updates of metadata files
+	metadataMu sync.Mutex
 }
 
 // NewFileStorage creates a new file storage instance
@@ -34,30 +47,71 @@ func NewFileStorage(baseDir string) *FileStorage {
 	}
 }
 
-// Save saves a note to the file system
+// OnChange registers a function called with the ID of each note saved or
+// deleted, after the change
+func (fs *FileStorage) OnChange(listener func(id string)) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.listeners = append(fs.listeners, listener)
+}
+
+// changed calls the change listeners
+func (fs *FileStorage) changed(id string) {
+	fs.mu.RLock()
+	listeners := fs.listeners
+	fs.mu.RUnlock()
+	for _, listener := range listeners {
+		listener(id)
+	}
+}
+
+// Save saves a note to the file system as its next revision. A note saved
+// before must have the revision it was read at: saving it fails with a
+// "changed" error when it was saved again since.
 func (fs *FileStorage) Save(note *models.Note) error {
 	if note.ID == "" {
 		note.ID = uuid.New().String()
 		note.CreatedAt = time.Now()
 	}
-	note.UpdatedAt = time.Now()
 
-	// Create metadata file
-	metadataPath := filepath.Join(fs.baseDir, note.ID+".json")
-	metadata := map[string]interface{}{
-		"id":         note.ID,
-		"title":      note.Title,
-		"created_at": note.CreatedAt,
-		"updated_at": note.UpdatedAt,
+	// Create metadata file, keeping the extra metadata of the note. The
+	// note must be saved over the revision it was read at, so that saves
+	// made since are not overwritten.
+	fs.metadataMu.Lock()
+	metadata, err := fs.readMetadata(note.ID)
+	if err != nil && !os.IsNotExist(err) {
+		fs.metadataMu.Unlock()
+		return err
 	}
-
-	metadataJSON, err := json.MarshalIndent(metadata, "", "  ")
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	revision, _ := metadata["revision"].(float64)
+	if note.Revision != int(revision) {
+		fs.metadataMu.Unlock()
+		return fmt.Errorf("note changed since revision %d was read", note.Revision)
+	}
+	note.UpdatedAt = time.Now()
+	note.Revision++
+	metadata["id"] = note.ID
+	metadata["title"] = note.Title
+	metadata["created_at"] = note.CreatedAt
+	metadata["updated_at"] = note.UpdatedAt
+	metadata["revision"] = note.Revision
+	err = fs.write(note, metadata)
+	fs.metadataMu.Unlock()
 	if err != nil {
-		return fmt.Errorf("failed to marshal metadata: %w", err)
+		return err
 	}
 
-	if err := os.WriteFile(metadataPath, metadataJSON, 0644); err != nil {
-		return fmt.Errorf("failed to write metadata: %w", err)
+	fs.changed(note.ID)
+	return nil
+}
+
+// write writes the metadata, the markdown and the revision of a note
+func (fs *FileStorage) write(note *models.Note, metadata map[string]interface{}) error {
+	if err := fs.writeMetadata(note.ID, metadata); err != nil {
+		return err
 	}
 
 	// Create markdown file
@@ -66,12 +120,158 @@ func (fs *FileStorage) Save(note *models.Note) error {
 		return fmt.Errorf("failed to write markdown: %w", err)
 	}
 
+	return fs.saveRevision(note)
+}
+
+// readMetadata reads the metadata file of a note
+func (fs *FileStorage) readMetadata(id string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(filepath.Join(fs.baseDir, id+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+// writeMetadata writes the metadata file of a note
+func (fs *FileStorage) writeMetadata(id string, metadata map[string]interface{}) error {
+	metadataJSON, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(fs.baseDir, id+".json"), metadataJSON, 0644); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
 	return nil
 }
 
+// reservedMetadata are the metadata keys of the note itself
+var reservedMetadata = map[string]bool{
+	"id": true, "title": true, "created_at": true, "updated_at": true, "revision": true,
+}
+
+// Metadata decodes the extra metadata of a note stored under key into
+// value, such as data other services keep about the note. It reports
+// whether the note has metadata under key.
+func (fs *FileStorage) Metadata(id, key string, value interface{}) (bool, error) {
+	fs.metadataMu.Lock()
+	metadata, err := fs.readMetadata(id)
+	fs.metadataMu.Unlock()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, fmt.Errorf("note not found")
+		}
+		return false, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	raw, ok := metadata[key]
+	if !ok {
+		return false, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return false, fmt.Errorf("failed to unmarshal metadata %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// UpdateMetadata changes the extra metadata of a note stored under key:
+// update is called with the current value decoded into value, and the
+// value is stored unless update fails. Updates are not revisions of the
+// note, and listeners are not called.
+func (fs *FileStorage) UpdateMetadata(id, key string, value interface{}, update func() error) error {
+	if reservedMetadata[key] {
+		return fmt.Errorf("metadata %s is reserved", key)
+	}
+	fs.metadataMu.Lock()
+	defer fs.metadataMu.Unlock()
+	metadata, err := fs.readMetadata(id)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("note not found")
+		}
+		return fmt.Errorf("failed to read metadata: %w", err)
+	}
+	if raw, ok := metadata[key]; ok {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		if err := json.Unmarshal(data, value); err != nil {
+			return fmt.Errorf("failed to unmarshal metadata %s: %w", key, err)
+		}
+	}
+	if err := update(); err != nil {
+		return err
+	}
+	metadata[key] = value
+	return fs.writeMetadata(id, metadata)
+}
+
+// revisionsDir returns the directory of the revisions of a note
+func (fs *FileStorage) revisionsDir(id string) string {
+	return filepath.Join(fs.baseDir, id+".revisions")
+}
+
+// saveRevision keeps the content of a revision and removes the revisions
+// older than the last MaxRevisions
+func (fs *FileStorage) saveRevision(note *models.Note) error {
+	dir := fs.revisionsDir(note.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create revisions directory: %w", err)
+	}
+	path := filepath.Join(dir, strconv.Itoa(note.Revision)+".md")
+	if err := os.WriteFile(path, []byte(note.Content), 0644); err != nil {
+		return fmt.Errorf("failed to write revision: %w", err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read revisions: %w", err)
+	}
+	for _, file := range files {
+		revision, err := strconv.Atoi(strings.TrimSuffix(file.Name(), ".md"))
+		if err == nil && revision <= note.Revision-MaxRevisions {
+			os.Remove(filepath.Join(dir, file.Name()))
+		}
+	}
+	return nil
+}
+
+// GetRevision retrieves a note with the content it had at a revision. The
+// other fields are those of the current revision.
+func (fs *FileStorage) GetRevision(id string, revision int) (*models.Note, error) {
+	note, err := fs.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if revision == note.Revision {
+		return note, nil
+	}
+	content, err := os.ReadFile(filepath.Join(fs.revisionsDir(id), strconv.Itoa(revision)+".md"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("revision not found")
+		}
+		return nil, fmt.Errorf("failed to read revision: %w", err)
+	}
+	note.Content = string(content)
+	note.Revision = revision
+	return note, nil
+}
+
 // Get retrieves a note by ID
 func (fs *FileStorage) Get(id string) (*models.Note, error) {
 	// Read metadata
+	// Metadata and content are read together, so that they are of the
+	// same revision
+	fs.metadataMu.Lock()
+	defer fs.metadataMu.Unlock()
 	metadataPath := filepath.Join(fs.baseDir, id+".json")
 	metadataData, err := os.ReadFile(metadataPath)
 	if err != nil {
@@ -97,12 +297,16 @@ func (fs *FileStorage) Get(id string) (*models.Note, error) {
 	createdAt, _ := time.Parse(time.RFC3339, metadata["created_at"].(string))
 	updatedAt, _ := time.Parse(time.RFC3339, metadata["updated_at"].(string))
 
+	// Notes saved before revisions were kept have none
+	revision, _ := metadata["revision"].(float64)
+
 	return &models.Note{
 		ID:        metadata["id"].(string),
 		Title:     metadata["title"].(string),
 		Content:   string(content),
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
+		Revision:  int(revision),
 	}, nil
 }
 
@@ -129,12 +333,14 @@ func (fs *FileStorage) List() ([]*models.NoteMetadata, error) {
 
 			createdAt, _ := time.Parse(time.RFC3339, metadata["created_at"].(string))
 			updatedAt, _ := time.Parse(time.RFC3339, metadata["updated_at"].(string))
+			revision, _ := metadata["revision"].(float64)
 
 			notes = append(notes, &models.NoteMetadata{
 				ID:        metadata["id"].(string),
 				Title:     metadata["title"].(string),
 				CreatedAt: createdAt,
 				UpdatedAt: updatedAt,
+				Revision:  int(revision),
 			})
 		}
 	}
@@ -155,6 +361,11 @@ func (fs *FileStorage) Delete(id string) error {
 		return fmt.Errorf("failed to remove markdown: %w", err)
 	}
 
+	if err := os.RemoveAll(fs.revisionsDir(id)); err != nil {
+		return fmt.Errorf("failed to remove revisions: %w", err)
+	}
+
+	fs.changed(id)
 	return nil
 }
 
@@ -182,3 +393,51 @@ func (fs *FileStorage) SaveUploadedFile(reader io.Reader, filename string) (*mod
 
 	return note, nil
 }
+
+// attachmentsDir is the folder under the notes directory holding images and
+// other files referenced by notes
+const attachmentsDir = "attachments"
+
+// ReadAttachment reads a file from the attachments folder. Names may include
+// subfolders but cannot point outside the folder.
+func (fs *FileStorage) ReadAttachment(name string) ([]byte, error) {
+	path, err := fs.attachmentPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	return data, nil
+}
+
+// HasAttachment reports whether a file exists in the attachments folder
+func (fs *FileStorage) HasAttachment(name string) (bool, error) {
+	path, err := fs.attachmentPath(name)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to stat attachment: %w", err)
+	}
+	return !info.IsDir(), nil
+}
+
+// attachmentPath resolves an attachment name inside the attachments folder
+func (fs *FileStorage) attachmentPath(name string) (string, error) {
+	clean := filepath.Clean("/" + filepath.FromSlash(name))
+	if clean == string(filepath.Separator) || strings.Contains(name, "\x00") {
+		return "", fmt.Errorf("invalid attachment name")
+	}
+	return filepath.Join(fs.baseDir, attachmentsDir, clean), nil
+}
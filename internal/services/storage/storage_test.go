@@ -138,3 +138,128 @@ func TestFileStorage_Delete(t *testing.T) {
 	err = storage.Delete("non-existent-id")
 	assert.NoError(t, err)
 }
+
+func TestFileStorage_Revisions(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := NewFileStorage(tempDir)
+	var changed []string
+	storage.OnChange(func(id string) { changed = append(changed, id) })
+
+	note := &models.Note{Title: "Revised", Content: "first"}
+	require.NoError(t, storage.Save(note))
+	assert.Equal(t, 1, note.Revision)
+	note.Content = "second"
+	require.NoError(t, storage.Save(note))
+	assert.Equal(t, 2, note.Revision)
+
+	current, err := storage.Get(note.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, current.Revision)
+	list, err := storage.List()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, 2, list[0].Revision)
+
+	first, err := storage.GetRevision(note.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "first", first.Content)
+	assert.Equal(t, 1, first.Revision)
+	_, err = storage.GetRevision(note.ID, 3)
+	assert.ErrorContains(t, err, "revision not found")
+
+	// Only the last MaxRevisions revisions are kept
+	for i := 0; i < MaxRevisions; i++ {
+		require.NoError(t, storage.Save(note))
+	}
+	_, err = storage.GetRevision(note.ID, 2)
+	assert.ErrorContains(t, err, "revision not found")
+	_, err = storage.GetRevision(note.ID, 3)
+	assert.NoError(t, err)
+
+	require.NoError(t, storage.Delete(note.ID))
+	assert.NoDirExists(t, filepath.Join(tempDir, note.ID+".revisions"))
+	assert.Len(t, changed, MaxRevisions+3)
+	assert.Equal(t, note.ID, changed[len(changed)-1])
+}
+
+func TestFileStorage_Save_Conflict(t *testing.T) {
+	storage := NewFileStorage(t.TempDir())
+
+	note := &models.Note{Title: "Shared", Content: "first"}
+	require.NoError(t, storage.Save(note))
+	read, err := storage.Get(note.ID)
+	require.NoError(t, err)
+	stale, err := storage.Get(note.ID)
+	require.NoError(t, err)
+
+	read.Content = "second"
+	require.NoError(t, storage.Save(read))
+
+	// A save over the revision read before is rejected and keeps the
+	// saved content
+	stale.Content = "other"
+	assert.EqualError(t, storage.Save(stale), "note changed since revision 1 was read")
+	assert.Equal(t, 1, stale.Revision)
+	current, err := storage.Get(note.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "second", current.Content)
+	assert.Equal(t, 2, current.Revision)
+}
+
+func TestFileStorage_Metadata(t *testing.T) {
+	storage := NewFileStorage(t.TempDir())
+	note := &models.Note{Title: "Cat", Content: "The cat."}
+	require.NoError(t, storage.Save(note))
+
+	var tags []string
+	found, err := storage.Metadata(note.ID, "tags", &tags)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, storage.UpdateMetadata(note.ID, "tags", &tags, func() error {
+		tags = append(tags, "pets")
+		return nil
+	}))
+	require.NoError(t, storage.UpdateMetadata(note.ID, "tags", &tags, func() error {
+		assert.Equal(t, []string{"pets"}, tags)
+		tags = append(tags, "cats")
+		return nil
+	}))
+
+	// Saving the note keeps its metadata, and updates are not revisions
+	note.Content = "The cat sat."
+	require.NoError(t, storage.Save(note))
+	assert.Equal(t, 2, note.Revision)
+	var stored []string
+	found, err = storage.Metadata(note.ID, "tags", &stored)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []string{"pets", "cats"}, stored)
+
+	assert.Error(t, storage.UpdateMetadata(note.ID, "title", &tags, func() error { return nil }))
+	_, err = storage.Metadata("missing", "tags", &tags)
+	assert.ErrorContains(t, err, "not found")
+	assert.ErrorContains(t, storage.UpdateMetadata("missing", "tags", &tags, func() error { return nil }), "not found")
+}
+
+func TestFileStorage_HasAttachment(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := NewFileStorage(tempDir)
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "attachments", "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "attachments", "sub", "file.txt"), []byte("data"), 0644))
+
+	exists, err := storage.HasAttachment("sub/file.txt")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = storage.HasAttachment("sub")
+	require.NoError(t, err)
+	assert.False(t, exists, "folders are not attachments")
+
+	exists, err = storage.HasAttachment("missing.txt")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = storage.HasAttachment("")
+	assert.Error(t, err)
+}
@@ -0,0 +1,302 @@
+// Package tasks indexes the task list items of all notes.
+package tasks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+)
+
+// Task statuses
+const (
+	StatusOpen = "open"
+	StatusDone = "done"
+)
+
+// DateLayout is the format of due dates
+const DateLayout = "2006-01-02"
+
+// Filter selects tasks. Zero fields match every task.
+type Filter struct {
+	// Status is StatusOpen or StatusDone
+	Status string
+	Owner  string
+	NoteID string
+	// DueBefore and DueAfter are inclusive; tasks without a due date do
+	// not match either
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	// Overdue matches open tasks due before today
+	Overdue bool
+	// Query matches tasks whose text contains it, ignoring case
+	Query string
+}
+
+// changeNotifier is implemented by storages that report changed notes
+type changeNotifier interface {
+	OnChange(listener func(id string))
+}
+
+// Service keeps an index of the tasks of the stored notes
+type Service struct {
+	storage  storage.Storage
+	markdown *markdown.Service
+	// now returns the current time, replaced in tests
+	now func() time.Time
+
+	// mu guards the index, which holds the tasks of each note by ID. It
+	// is built on first use, then only changed notes are read again.
+	// Storages that do not report changes are read in full every time.
+	mu      sync.Mutex
+	index   map[string]*indexedNote
+	notify  bool
+	changed changedNotes
+}
+
+// indexedNote is a note in the index, without its content
+type indexedNote struct {
+	note  *models.Note
+	tasks []markdown.Task
+}
+
+// changedNotes is the set of notes changed since the index was updated
+type changedNotes struct {
+	mu  sync.Mutex
+	ids map[string]bool
+}
+
+// NewService creates a new task service, which keeps its index up to date
+// when the storage reports changed notes
+func NewService(storage storage.Storage, markdown *markdown.Service) *Service {
+	s := &Service{
+		storage:  storage,
+		markdown: markdown,
+		now:      time.Now,
+		changed:  changedNotes{ids: map[string]bool{}},
+	}
+	if notifier, ok := storage.(changeNotifier); ok {
+		notifier.OnChange(s.Invalidate)
+		s.notify = true
+	}
+	return s
+}
+
+// Invalidate marks a note as changed, so that the index reads it again.
+// It is called when the note is saved or deleted.
+func (s *Service) Invalidate(id string) {
+	s.changed.mu.Lock()
+	defer s.changed.mu.Unlock()
+	s.changed.ids[id] = true
+}
+
+// update brings the index up to date, reading every note the first time
+// and the changed notes after
+func (s *Service) update() error {
+	s.changed.mu.Lock()
+	changed := s.changed.ids
+	s.changed.ids = map[string]bool{}
+	s.changed.mu.Unlock()
+
+	if s.index != nil && s.notify {
+		for id := range changed {
+			if err := s.indexNote(id); err != nil {
+				s.Invalidate(id)
+				return err
+			}
+		}
+		return nil
+	}
+
+	notes, err := s.storage.List()
+	if err != nil {
+		return fmt.Errorf("failed to list notes: %w", err)
+	}
+	s.index = map[string]*indexedNote{}
+	for _, meta := range notes {
+		if err := s.indexNote(meta.ID); err != nil {
+			s.index = nil
+			return err
+		}
+	}
+	return nil
+}
+
+// indexNote reads the tasks of a note into the index, removing the note
+// when it was deleted
+func (s *Service) indexNote(id string) error {
+	note, err := s.storage.Get(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			delete(s.index, id)
+			return nil
+		}
+		return fmt.Errorf("failed to get note %s: %w", id, err)
+	}
+	s.index[id] = &indexedNote{
+		note:  &models.Note{ID: note.ID, Title: note.Title},
+		tasks: s.markdown.Tasks(note.Content),
+	}
+	return nil
+}
+
+// List returns the tasks of all notes matching the filter. Tasks are sorted
+// by due date, with undated tasks last, then by note and line.
+func (s *Service) List(filter Filter) ([]models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.update(); err != nil {
+		return nil, err
+	}
+
+	y, m, d := s.now().Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	tasks := []models.Task{}
+	for _, indexed := range s.index {
+		for _, task := range indexed.tasks {
+			if filter.matches(indexed.note, task, today) {
+				tasks = append(tasks, newTask(indexed.note, task))
+			}
+		}
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if a.Due != b.Due {
+			return b.Due == "" || (a.Due != "" && a.Due < b.Due)
+		}
+		if a.NoteTitle != b.NoteTitle {
+			return a.NoteTitle < b.NoteTitle
+		}
+		if a.NoteID != b.NoteID {
+			return a.NoteID < b.NoteID
+		}
+		return a.Line < b.Line
+	})
+	return tasks, nil
+}
+
+func (f Filter) matches(note *models.Note, task markdown.Task, today time.Time) bool {
+	switch {
+	case f.Status == StatusOpen && task.Done, f.Status == StatusDone && !task.Done:
+		return false
+	case f.Owner != "" && !strings.EqualFold(f.Owner, task.Owner):
+		return false
+	case f.NoteID != "" && f.NoteID != note.ID:
+		return false
+	case f.Query != "" && !strings.Contains(strings.ToLower(task.Text), strings.ToLower(f.Query)):
+		return false
+	}
+	if f.DueBefore != nil || f.DueAfter != nil || f.Overdue {
+		if task.Due == nil {
+			return false
+		}
+		if f.DueBefore != nil && task.Due.After(*f.DueBefore) {
+			return false
+		}
+		if f.DueAfter != nil && task.Due.Before(*f.DueAfter) {
+			return false
+		}
+		if f.Overdue && (task.Done || !task.Due.Before(today)) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetDone checks or unchecks a task, rewriting its checkbox in the note.
+// IDs of a task whose text has changed since are rejected, so that an ID
+// kept while lines were added or removed does not check another task.
+func (s *Service) SetDone(id string, done bool) (*models.Task, error) {
+	noteID, line, fingerprint, err := ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	note, err := s.storage.Get(noteID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, fmt.Errorf("task not found")
+		}
+		return nil, err
+	}
+
+	task, ok := s.markdown.TaskAt(note.Content, line)
+	if !ok {
+		return nil, fmt.Errorf("task not found")
+	}
+	if textFingerprint(task.Text) != fingerprint {
+		return nil, fmt.Errorf("task on line %d changed since it was listed", line)
+	}
+
+	content, err := s.markdown.SetTaskDone(note.Content, line, done)
+	if err != nil {
+		return nil, fmt.Errorf("task not found")
+	}
+	if content != note.Content {
+		note.Content = content
+		if err := s.storage.Save(note); err != nil {
+			return nil, err
+		}
+	}
+
+	task.Done = done
+	result := newTask(note, task)
+	return &result, nil
+}
+
+// ID identifies the task on a line of a note, with a fingerprint of its
+// text
+func ID(noteID string, line int, text string) string {
+	return noteID + ":" + strconv.Itoa(line) + ":" + textFingerprint(text)
+}
+
+// textFingerprint returns a short hash of the text of a task
+func textFingerprint(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:4])
+}
+
+// ParseID splits a task ID into its note ID, line and text fingerprint
+func ParseID(id string) (noteID string, line int, fingerprint string, err error) {
+	invalid := fmt.Errorf("invalid task id %q", id)
+	i := strings.LastIndex(id, ":")
+	if i <= 0 || i == len(id)-1 {
+		return "", 0, "", invalid
+	}
+	fingerprint = id[i+1:]
+	j := strings.LastIndex(id[:i], ":")
+	if j <= 0 {
+		return "", 0, "", invalid
+	}
+	line, err = strconv.Atoi(id[j+1 : i])
+	if err != nil || line < 1 {
+		return "", 0, "", invalid
+	}
+	return id[:j], line, fingerprint, nil
+}
+
+func newTask(note *models.Note, task markdown.Task) models.Task {
+	result := models.Task{
+		ID:        ID(note.ID, task.Line, task.Text),
+		NoteID:    note.ID,
+		NoteTitle: note.Title,
+		Line:      task.Line,
+		Text:      task.Text,
+		Done:      task.Done,
+		Owner:     task.Owner,
+	}
+	if task.Due != nil {
+		result.Due = task.Due.Format(DateLayout)
+	}
+	return result
+}
@@ -0,0 +1,160 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(s string) *time.Time {
+	t, _ := time.Parse(DateLayout, s)
+	return &t
+}
+
+func TestService_List(t *testing.T) {
+	storageService := storage.NewFileStorage(t.TempDir())
+	service := NewService(storageService, markdown.NewService())
+	service.now = func() time.Time { return time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local) }
+
+	work := &models.Note{Title: "Work", Content: "# Work\n\n- [ ] Ship release @due(2026-10-25) @alice\n- [x] Old report @due(2026-10-01) @bob\n- [ ] Late review @due(2026-10-10) @Bob\n"}
+	home := &models.Note{Title: "Home", Content: "- [ ] Buy milk\n- [ ] Pay rent @due(2026-11-01)\n"}
+	require.NoError(t, storageService.Save(work))
+	require.NoError(t, storageService.Save(home))
+
+	ids := func(tasks []models.Task) []string {
+		result := []string{}
+		for _, task := range tasks {
+			result = append(result, task.Text)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected []string
+	}{
+		{"all", Filter{}, []string{"Old report", "Late review", "Ship release", "Pay rent", "Buy milk"}},
+		{"open", Filter{Status: StatusOpen}, []string{"Late review", "Ship release", "Pay rent", "Buy milk"}},
+		{"done", Filter{Status: StatusDone}, []string{"Old report"}},
+		{"owner", Filter{Owner: "bob"}, []string{"Old report", "Late review"}},
+		{"note", Filter{NoteID: home.ID}, []string{"Pay rent", "Buy milk"}},
+		{"due before", Filter{DueBefore: date("2026-10-25")}, []string{"Old report", "Late review", "Ship release"}},
+		{"due after", Filter{DueAfter: date("2026-10-25")}, []string{"Ship release", "Pay rent"}},
+		{"overdue", Filter{Overdue: true}, []string{"Late review"}},
+		{"query", Filter{Query: "REP"}, []string{"Old report"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := service.List(tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ids(tasks))
+		})
+	}
+
+	tasks, err := service.List(Filter{NoteID: work.ID, Owner: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Task{{
+		ID: ID(work.ID, 3, "Ship release"), NoteID: work.ID, NoteTitle: "Work", Line: 3,
+		Text: "Ship release", Due: "2026-10-25", Owner: "alice",
+	}}, tasks)
+}
+
+func TestService_SetDone(t *testing.T) {
+	storageService := storage.NewFileStorage(t.TempDir())
+	service := NewService(storageService, markdown.NewService())
+
+	note := &models.Note{Title: "Note", Content: "Intro\n\n- [ ] First\n- [ ] Second\n"}
+	require.NoError(t, storageService.Save(note))
+
+	task, err := service.SetDone(ID(note.ID, 4, "Second"), true)
+	require.NoError(t, err)
+	assert.True(t, task.Done)
+	assert.Equal(t, "Second", task.Text)
+
+	saved, err := storageService.Get(note.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Intro\n\n- [ ] First\n- [x] Second\n", saved.Content)
+
+	// An ID listed before a line was added above the task points at
+	// another task now
+	saved.Content = "Intro\n\n- [ ] Zeroth\n- [ ] First\n- [x] Second\n"
+	require.NoError(t, storageService.Save(saved))
+	_, err = service.SetDone(ID(note.ID, 4, "Second"), false)
+	assert.ErrorContains(t, err, "changed")
+	saved, err = storageService.Get(note.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Intro\n\n- [ ] Zeroth\n- [ ] First\n- [x] Second\n", saved.Content)
+
+	_, err = service.SetDone(ID(note.ID, 1, "Intro"), true)
+	assert.ErrorContains(t, err, "not found")
+	_, err = service.SetDone(ID("missing", 3, "First"), true)
+	assert.ErrorContains(t, err, "not found")
+	_, err = service.SetDone("no-line", true)
+	assert.ErrorContains(t, err, "invalid task id")
+}
+
+// countingStorage counts the notes read from a file storage
+type countingStorage struct {
+	*storage.FileStorage
+	gets int
+}
+
+func (s *countingStorage) Get(id string) (*models.Note, error) {
+	s.gets++
+	return s.FileStorage.Get(id)
+}
+
+func TestService_List_Index(t *testing.T) {
+	storageService := &countingStorage{FileStorage: storage.NewFileStorage(t.TempDir())}
+	service := NewService(storageService, markdown.NewService())
+
+	a := &models.Note{Title: "A", Content: "- [ ] One\n"}
+	b := &models.Note{Title: "B", Content: "- [ ] Two\n"}
+	require.NoError(t, storageService.Save(a))
+	require.NoError(t, storageService.Save(b))
+	texts := func() []string {
+		tasks, err := service.List(Filter{})
+		require.NoError(t, err)
+		result := []string{}
+		for _, task := range tasks {
+			result = append(result, task.Text)
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"One", "Two"}, texts())
+	reads := storageService.gets
+	assert.Equal(t, []string{"One", "Two"}, texts())
+	assert.Equal(t, reads, storageService.gets, "unchanged notes are not read again")
+
+	a.Content = "- [x] One\n- [ ] Three\n"
+	require.NoError(t, storageService.Save(a))
+	assert.Equal(t, []string{"One", "Three", "Two"}, texts())
+	assert.Equal(t, reads+1, storageService.gets, "only the changed note is read")
+
+	require.NoError(t, storageService.Delete(b.ID))
+	assert.Equal(t, []string{"One", "Three"}, texts())
+	c := &models.Note{Title: "C", Content: "- [ ] Four\n"}
+	require.NoError(t, storageService.Save(c))
+	assert.Equal(t, []string{"One", "Three", "Four"}, texts())
+}
+
+func TestParseID(t *testing.T) {
+	noteID, line, fingerprint, err := ParseID(ID("a:b", 12, "Task"))
+	require.NoError(t, err)
+	assert.Equal(t, "a:b", noteID)
+	assert.Equal(t, 12, line)
+	assert.Equal(t, textFingerprint("Task"), fingerprint)
+
+	for _, id := range []string{"", ":3:ab", "note", "note:3", "note:3:", "note:0:ab", "note:x:ab"} {
+		_, _, _, err := ParseID(id)
+		assert.Error(t, err, id)
+	}
+}
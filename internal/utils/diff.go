@@ -0,0 +1,253 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around changes
+const diffContext = 3
+
+// diffOp is a line kept, deleted or inserted by a diff
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// UnifiedDiff returns the changes from a to b in unified diff format, with
+// three lines of context, or "" when they are equal. The names label the
+// old and new text in the header.
+func UnifiedDiff(oldName, newName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+	for start := 0; start < len(ops); {
+		// Find the next change and the end of its hunk, which spans
+		// changes less than two contexts apart
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				last = i
+			} else if i-last > 2*diffContext {
+				break
+			}
+		}
+		from := max(first-diffContext, start)
+		to := min(last+diffContext+1, len(ops))
+
+		oldLine, newLine := 1, 1
+		for _, op := range ops[:from] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, op := range ops[from:to] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = to
+	}
+	return out.String()
+}
+
+// hunkRange formats the start and length of a hunk. An empty range starts
+// at the line before it.
+func hunkRange(line, count int) string {
+	if count == 0 {
+		line--
+	}
+	if count == 1 {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+// splitLines splits text after each newline
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script from a to b, found with the
+// linear space variant of Myers' algorithm, with deletions before
+// insertions in each change
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	diffRange(a, b, &ops)
+
+	// Within a run of changes, move the deletions first
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			start++
+			continue
+		}
+		end := start
+		for end < len(ops) && ops[end].kind != ' ' {
+			end++
+		}
+		sort.SliceStable(ops[start:end], func(i, j int) bool {
+			return ops[start+i].kind == '-' && ops[start+j].kind == '+'
+		})
+		start = end
+	}
+	return ops
+}
+
+// diffRange appends the edit script from a to b to ops. Common lines at
+// both ends are kept; what is left is split at the middle of a shortest
+// edit script and each half diffed in turn.
+func diffRange(a, b []string, ops *[]diffOp) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	for _, line := range a[:prefix] {
+		*ops = append(*ops, diffOp{' ', line})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	switch {
+	case len(midA) == 0:
+		for _, line := range midB {
+			*ops = append(*ops, diffOp{'+', line})
+		}
+	case len(midB) == 0:
+		for _, line := range midA {
+			*ops = append(*ops, diffOp{'-', line})
+		}
+	default:
+		if x, y, ok := middleSnake(midA, midB); ok {
+			diffRange(midA[:x], midB[:y], ops)
+			diffRange(midA[x:], midB[y:], ops)
+			break
+		}
+		for _, line := range midA {
+			*ops = append(*ops, diffOp{'-', line})
+		}
+		for _, line := range midB {
+			*ops = append(*ops, diffOp{'+', line})
+		}
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		*ops = append(*ops, diffOp{' ', line})
+	}
+}
+
+// middleSnake searches for a shortest edit script from both ends at once
+// and returns the point where the two searches meet. Only the furthest
+// points of the current step are kept, so memory grows with the length of
+// the texts. a and b are not empty and differ at both ends, so the point
+// is strictly inside the script. It is not found when a and b have no line
+// in common.
+func middleSnake(a, b []string) (x, y int, ok bool) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	size := 2*maxD + 2
+	forward, backward := make([]int, size), make([]int, size)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+	delta := n - m
+	// With an odd delta the forward search reaches the overlap first
+	odd := delta%2 != 0
+
+	// Diagonals that ran off the edges are skipped in later steps
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && forward[i-1] < forward[i+1]) {
+				x = forward[i+1]
+			} else {
+				x = forward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[i] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				j := offset + delta - k
+				if j >= 0 && j < size && backward[j] != -1 && x >= n-backward[j] {
+					return x, y, true
+				}
+			}
+		}
+
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && backward[i-1] < backward[i+1]) {
+				x = backward[i+1]
+			} else {
+				x = backward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			backward[i] = x
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !odd:
+				j := offset + delta - k
+				if j >= 0 && j < size && forward[j] != -1 {
+					fx := forward[j]
+					if fx >= n-x {
+						return fx, fx - (j - offset), true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
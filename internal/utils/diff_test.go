@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{name: "equal", a: "same\n", b: "same\n", want: ""},
+		{
+			name: "changed line",
+			a:    "one\ntwo\nthree\n",
+			b:    "one\n2\nthree\n",
+			want: "--- a\n+++ b\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n",
+		},
+		{
+			name: "insertion into empty text",
+			a:    "",
+			b:    "new\n",
+			want: "--- a\n+++ b\n@@ -0,0 +1 @@\n+new\n",
+		},
+		{
+			name: "missing final newline",
+			a:    "end",
+			b:    "end.",
+			want: "--- a\n+++ b\n@@ -1 +1 @@\n-end\n\\ No newline at end of file\n+end.\n\\ No newline at end of file\n",
+		},
+		{
+			name: "separate hunks",
+			a:    "a\n" + strings.Repeat("x\n", 10) + "b\n",
+			b:    "A\n" + strings.Repeat("x\n", 10) + "B\n",
+			want: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-a\n+A\n x\n x\n x\n@@ -9,4 +9,4 @@\n x\n x\n x\n-b\n+B\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, UnifiedDiff("a", "b", tt.a, tt.b))
+		})
+	}
+}
+
+func TestUnifiedDiff_LargeChange(t *testing.T) {
+	var a, b strings.Builder
+	for i := 0; i < 4000; i++ {
+		fmt.Fprintf(&a, "old %d\n", i)
+		fmt.Fprintf(&b, "new %d\n", i)
+	}
+
+	// The search keeps only the furthest points of a step, so a diff of
+	// thousands of changed lines needs little memory
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	diff := UnifiedDiff("a", "b", a.String(), b.String())
+	runtime.ReadMemStats(&after)
+
+	assert.True(t, strings.HasPrefix(diff, "--- a\n+++ b\n@@ -1,4000 +1,4000 @@\n-old 0\n"))
+	assert.Equal(t, 4000, strings.Count(diff, "\n-old "))
+	assert.Equal(t, 4000, strings.Count(diff, "\n+new "))
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(64<<20))
+}
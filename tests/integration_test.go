@@ -12,7 +12,9 @@ import (
 
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/api/routes"
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/models"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/audit"
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/grammar"
+	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/links"
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/markdown"
 	"github.com/JumpingMonkey/go-markdown-note-taking-app/internal/services/storage"
 	"github.com/gin-gonic/gin"
@@ -29,11 +31,13 @@ func setupTestServer(t *testing.T) (*gin.Engine, string, func()) {
 	storageService := storage.NewFileStorage(tempDir)
 	markdownService := markdown.NewService()
 	grammarService := grammar.NewService()
+	linkChecker := links.NewChecker(storageService, markdownService)
+	audits := audit.NewService(storageService, grammarService, audit.DefaultConcurrency)
 
 	// Setup router
 	gin.SetMode(gin.TestMode)
 	router := gin.Default()
-	routes.Setup(router, storageService, markdownService, grammarService)
+	routes.Setup(router, storageService, markdownService, grammarService, linkChecker, audits)
 
 	return router, tempDir, func() {
 		os.RemoveAll(tempDir)